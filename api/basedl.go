@@ -6,6 +6,7 @@ import (
 	"github.com/KJHJason/Cultured-Downloader-Logic/configs"
 	"github.com/KJHJason/Cultured-Downloader-Logic/filters"
 	"github.com/KJHJason/Cultured-Downloader-Logic/gdrive"
+	"github.com/KJHJason/Cultured-Downloader-Logic/iofuncs"
 	"github.com/KJHJason/Cultured-Downloader-Logic/notify"
 	"github.com/KJHJason/Cultured-Downloader-Logic/progress"
 )
@@ -13,6 +14,10 @@ import (
 type BaseDl struct {
 	Notifier notify.Notifier
 
+	// Session owns the database, logger, captcha caches, and HTTP transports used by the download.
+	// Leave nil to use the default session, see NewSession to run multiple sessions side by side.
+	Session *Session
+
 	DlThumbnails       bool // Fantia, PixivFanbox
 	DlImages           bool // Fantia, PixivFanbox
 	OrganiseImages     bool // Fantia
@@ -20,14 +25,33 @@ type BaseDl struct {
 	DlGdrive           bool // Fantia, PixivFanbox, Kemono
 	DetectOtherDlLinks bool // Fantia
 	UseCacheDb         bool
+	IncrementalSync    bool // Stops paginating a creator's posts at the newest post from the previous sync, requires UseCacheDb
 	SetMetadata        bool // Fantia, PixivFanbox, Kemono, Pixiv
+	IndexMetadata      bool // Adds the metadata to the search index, requires SetMetadata and UseCacheDb
 	DownloadDirPath    string
 
+	// Path templates like "{creator_name}/[{post_id}] {title}" and "{subfolder}/{index:03}.{ext}".
+	// Leave empty to use the default folder layout and the original file names.
+	// Validated and parsed by ValidatePathTemplates.
+	PostFolderTemplate  string
+	FileNameTemplate    string
+	Sanitizer           *iofuncs.PathSanitizer // defaults to iofuncs.DefaultPathSanitizer if nil
+	site                string
+	postFolderTmpl      *iofuncs.PathTemplate
+	noCreatorFolderTmpl *iofuncs.PathTemplate // for posts without a creator name, nil if the user has set a template
+	fileNameTmpl        *iofuncs.PathTemplate
+	pendingWatermarks   pendingWatermarks
+
 	PasswordRegex []string
 	Filters       *filters.Filters
 	Configs       *configs.Config
 	GdriveClient  *gdrive.GDrive // Fantia, PixivFanbox, Kemono
 
+	// Filter profiles assigned to the site or creators which take precedence over Filters.
+	// Leave nil to use Filters for every creator, see ValidateFilterProfiles and GetFilters.
+	FilterProfiles *filters.FilterProfiles
+	creatorFilters creatorFilters
+
 	SessionCookieId string
 	SessionCookies  []*http.Cookie
 
@@ -35,6 +59,15 @@ type BaseDl struct {
 	ProgressBarInfo *progress.ProgressBarInfo
 }
 
+// SetGdriveSession makes the GDrive client use the database and logger of the session.
+func (b *BaseDl) SetGdriveSession() {
+	if b.GdriveClient == nil || b.Session == nil {
+		return
+	}
+	b.GdriveClient.SetDb(b.Session.Db)
+	b.GdriveClient.SetLogger(b.Session.Logger)
+}
+
 func (b *BaseDl) MainProgBar() progress.ProgressBar {
 	if b.ProgressBarInfo == nil {
 		return nil
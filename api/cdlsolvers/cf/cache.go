@@ -10,6 +10,7 @@ import (
 	"github.com/KJHJason/Cultured-Downloader-Logic/api/cdlsolvers/cdldocker"
 	"github.com/KJHJason/Cultured-Downloader-Logic/cdlerrors"
 	"github.com/KJHJason/Cultured-Downloader-Logic/httpfuncs"
+	"github.com/KJHJason/Cultured-Downloader-Logic/logger"
 	"github.com/KJHJason/Cultured-Downloader-Logic/notify"
 )
 
@@ -31,18 +32,46 @@ type CacheArgs struct {
 	UserAgent string
 	Timeout   time.Duration
 	Notifier  notify.Notifier
+	Cache     *Cache         // defaults to DefaultCache if nil
+	Logger    *logger.Logger // defaults to logger.MainLogger if nil
 }
 
-var (
-	cfCacheMu     sync.RWMutex
-	cachedCookies = make(map[CacheKey]*cacheValues)
-	failedKeys    = make(map[CacheKey]struct{})
-)
+func (args CacheArgs) getCache() *Cache {
+	if args.Cache == nil {
+		return DefaultCache
+	}
+	return args.Cache
+}
+
+func (args CacheArgs) getLogger() *logger.Logger {
+	if args.Logger == nil {
+		return &logger.MainLogger
+	}
+	return args.Logger
+}
+
+// Cache holds the solved CF cookies so that
+// the captcha does not have to be solved for every request.
+type Cache struct {
+	mu            sync.RWMutex
+	cachedCookies map[CacheKey]*cacheValues
+	failedKeys    map[CacheKey]struct{}
+}
+
+func NewCache() *Cache {
+	return &Cache{
+		cachedCookies: make(map[CacheKey]*cacheValues),
+		failedKeys:    make(map[CacheKey]struct{}),
+	}
+}
+
+// DefaultCache is shared by the requests that do not specify a cache in their CacheArgs.
+var DefaultCache = NewCache()
 
-func getFilteredCachedCookiesUnsafe(key CacheKey) []*http.Cookie {
+func (c *Cache) getFilteredCachedCookiesUnsafe(key CacheKey) []*http.Cookie {
 	var ok bool
 	var cachedValues *cacheValues
-	if cachedValues, ok = cachedCookies[key]; !ok {
+	if cachedValues, ok = c.cachedCookies[key]; !ok {
 		return nil
 	}
 
@@ -55,16 +84,16 @@ func getFilteredCachedCookiesUnsafe(key CacheKey) []*http.Cookie {
 	return cfCookies
 }
 
-func getCachedCfCookiesUnsafe(key CacheKey, timeout time.Duration) []*http.Cookie {
+func (c *Cache) getCachedCfCookiesUnsafe(key CacheKey, timeout time.Duration) []*http.Cookie {
 	var ok bool
 	var cachedValues *cacheValues
-	if cachedValues, ok = cachedCookies[key]; !ok {
+	if cachedValues, ok = c.cachedCookies[key]; !ok {
 		return nil
 	}
 
 	solvedTime := cachedValues.solved
 	if !solvedTime.IsZero() && time.Since(solvedTime) < timeout {
-		return getFilteredCachedCookiesUnsafe(key)
+		return c.getFilteredCachedCookiesUnsafe(key)
 	}
 	return nil
 }
@@ -78,7 +107,7 @@ func checkHasCfCookies(req *http.Request) bool {
 	return false
 }
 
-func addCacheCookiesToReq(req *http.Request, key CacheKey) {
+func (c *Cache) addCacheCookiesToReq(req *http.Request, key CacheKey) {
 	if checkHasCfCookies(req) {
 		cookiesCopy := make([]*http.Cookie, len(req.Cookies()))
 		copy(cookiesCopy, req.Cookies())
@@ -90,7 +119,7 @@ func addCacheCookiesToReq(req *http.Request, key CacheKey) {
 		}
 	}
 
-	for _, cookie := range getFilteredCachedCookiesUnsafe(key) {
+	for _, cookie := range c.getFilteredCachedCookiesUnsafe(key) {
 		req.AddCookie(cookie)
 	}
 }
@@ -101,15 +130,15 @@ func alert(notifier notify.Notifier, msg string) {
 	}
 }
 
-func callMainLogicUnsafe(ctx context.Context, cacheArgs CacheArgs) error {
-	if _, ok := failedKeys[cacheArgs.Key]; ok {
+func (c *Cache) callMainLogicUnsafe(ctx context.Context, cacheArgs CacheArgs) error {
+	if _, ok := c.failedKeys[cacheArgs.Key]; ok {
 		return cdlerrors.ErrCaptchaPrevFailed
 	}
 
-	if cookies, err := sendReqAndGetCfCookies(cacheArgs.Url); err != nil {
+	if cookies, err := sendReqAndGetCfCookies(ctx, cacheArgs); err != nil {
 		return err
 	} else if len(cookies) > 0 {
-		cachedCookies[cacheArgs.Key] = &cacheValues{
+		c.cachedCookies[cacheArgs.Key] = &cacheValues{
 			cookies: cookies,
 			solved:  time.Now(),
 		}
@@ -131,7 +160,7 @@ func callMainLogicUnsafe(ctx context.Context, cacheArgs CacheArgs) error {
 	}
 
 	alert(cacheArgs.Notifier, "Successfully solved CF Captcha automatically!")
-	cachedCookies[cacheArgs.Key] = &cacheValues{
+	c.cachedCookies[cacheArgs.Key] = &cacheValues{
 		cookies: cdldocker.ConvertDevToolsCookies(cfCookies),
 		solved:  time.Now(),
 	}
@@ -147,29 +176,31 @@ func CaptchaChecker(res *httpfuncs.ResponseWrapper) (bool, error) {
 
 // Note: This function does not check for cached cookies.
 func Call(ctx context.Context, req *http.Request, cacheArgs CacheArgs) error {
-	cfCacheMu.Lock()
-	defer cfCacheMu.Unlock()
+	c := cacheArgs.getCache()
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	if err := callMainLogicUnsafe(ctx, cacheArgs); err != nil {
+	if err := c.callMainLogicUnsafe(ctx, cacheArgs); err != nil {
 		return err
 	}
-	addCacheCookiesToReq(req, cacheArgs.Key)
+	c.addCacheCookiesToReq(req, cacheArgs.Key)
 	return nil
 }
 
 // Similar to Call, but checks for cached cookies.
 func CallIfReq(ctx context.Context, req *http.Request, cacheArgs CacheArgs) error {
-	cfCacheMu.Lock()
-	defer cfCacheMu.Unlock()
+	c := cacheArgs.getCache()
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	if getCachedCfCookiesUnsafe(cacheArgs.Key, cacheArgs.Timeout) != nil {
-		addCacheCookiesToReq(req, cacheArgs.Key)
+	if c.getCachedCfCookiesUnsafe(cacheArgs.Key, cacheArgs.Timeout) != nil {
+		c.addCacheCookiesToReq(req, cacheArgs.Key)
 		return nil
 	}
 
-	if err := callMainLogicUnsafe(ctx, cacheArgs); err != nil {
+	if err := c.callMainLogicUnsafe(ctx, cacheArgs); err != nil {
 		return err
 	}
-	addCacheCookiesToReq(req, cacheArgs.Key)
+	c.addCacheCookiesToReq(req, cacheArgs.Key)
 	return nil
 }
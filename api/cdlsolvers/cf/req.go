@@ -1,6 +1,7 @@
 package cf
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
@@ -8,19 +9,21 @@ import (
 	"github.com/KJHJason/Cultured-Downloader-Logic/api/cdlsolvers/cdldocker"
 	"github.com/KJHJason/Cultured-Downloader-Logic/cdlerrors"
 	"github.com/KJHJason/Cultured-Downloader-Logic/httpfuncs"
-	"github.com/KJHJason/Cultured-Downloader-Logic/logger"
 )
 
 func IsCfCookies(name string) bool {
 	return name == cdldocker.BOT_COOKIE || name == cdldocker.CLEARANCE_COOKIE
 }
 
-func sendReqAndGetCfCookies(url string) ([]*http.Cookie, error) {
-	logger.MainLogger.Info("Sending request to get cf cookies")
+func sendReqAndGetCfCookies(ctx context.Context, cacheArgs CacheArgs) ([]*http.Cookie, error) {
+	l := cacheArgs.getLogger()
+	l.Info("Sending request to get cf cookies")
 	reqArgs := httpfuncs.RequestArgs{
 		Method:      "GET",
-		Url:         url,
+		Url:         cacheArgs.Url,
 		CheckStatus: false,
+		Context:     ctx,
+		Logger:      l,
 	}
 	res, err := httpfuncs.CallRequest(&reqArgs)
 	if err != nil {
@@ -28,7 +31,7 @@ func sendReqAndGetCfCookies(url string) ([]*http.Cookie, error) {
 			"error %d: failed to send request to get cf cookies => %v",
 			cdlerrors.CONNECTION_ERROR, err,
 		)
-		logger.MainLogger.Error(fmtErr)
+		l.Error(fmtErr)
 		return nil, errors.New(fmtErr)
 	}
 	defer res.Close()
@@ -42,7 +45,7 @@ func sendReqAndGetCfCookies(url string) ([]*http.Cookie, error) {
 	}
 
 	if len(cfCookies) == 0 {
-		logger.MainLogger.Errorf("failed to get cf cookies from %s", url)
+		l.Errorf("failed to get cf cookies from %s", cacheArgs.Url)
 	}
 	return cfCookies, nil
 }
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"strconv"
 	"sync"
+	"time"
 
 	"github.com/KJHJason/Cultured-Downloader-Logic/cdlerrors"
 	"github.com/KJHJason/Cultured-Downloader-Logic/constants"
@@ -73,6 +74,7 @@ func getFanclubContent(fanclubId, pageNum string, dlOptions *FantiaDlOptions, co
 		url = fmt.Sprintf("%s/fanclubs/%s/posts", constants.FANTIA_URL, fanclubId)
 	}
 	useHttp3 := httpfuncs.IsHttp3Supported(constants.FANTIA, false)
+	watermark := dlOptions.Base.NewSyncWatermark(contentType + "/" + fanclubId)
 	curPage := minPage
 	for {
 		var params map[string]string
@@ -104,6 +106,8 @@ func getFanclubContent(fanclubId, pageNum string, dlOptions *FantiaDlOptions, co
 				UserAgent:      dlOptions.Base.Configs.UserAgent,
 				Context:        dlOptions.GetContext(),
 				CaptchaHandler: newHttpCaptchaHandler(dlOptions),
+				Transports:     dlOptions.Base.Session.GetTransports(),
+				Logger:         dlOptions.Base.Session.GetLogger(),
 			},
 		)
 		if err != nil {
@@ -128,14 +132,20 @@ func getFanclubContent(fanclubId, pageNum string, dlOptions *FantiaDlOptions, co
 		if err != nil {
 			return nil, err
 		}
-		contentIds = append(contentIds, fanclubContentIds...)
+		for _, contentId := range fanclubContentIds {
+			// Fantia does not show the post dates in the listing but the IDs are in ascending order
+			if !watermark.IsSeen(contentId, time.Time{}) {
+				contentIds = append(contentIds, contentId)
+			}
+		}
 
-		// if there are no more posts, break
-		if len(fanclubContentIds) == 0 || (hasMax && curPage >= maxPage) {
+		// if there are no more posts or the posts from the previous sync has been reached, break
+		if len(fanclubContentIds) == 0 || (hasMax && curPage >= maxPage) || watermark.ReachedSeen() {
 			break
 		}
 		curPage++
 	}
+	watermark.Done()
 	return contentIds, nil
 }
 
@@ -235,7 +245,7 @@ func (f *FantiaDl) GetFanclubsContents(fanclubIds []string, pageNums []string, c
 	hasErr, hasCancelled := false, false
 	if errTsSlice.LenUnsafe() > 0 {
 		hasErr = true
-		hasCancelled, errorSlice = logger.LogSliceErrors(logger.ERROR, errTsSlice)
+		hasCancelled, errorSlice = dlOptions.Base.Session.GetLogger().LogSliceErrors(logger.ERROR, errTsSlice)
 	}
 	if hasCancelled {
 		dlOptions.CancelCtx()
@@ -64,6 +64,8 @@ func getFantiaPostDetails(postArg *fantiaPostArgs, dlOptions *FantiaDlOptions) (
 			UserAgent:      dlOptions.Base.Configs.UserAgent,
 			Context:        dlOptions.GetContext(),
 			CaptchaHandler: newHttpCaptchaHandler(dlOptions),
+			Transports:     dlOptions.Base.Session.GetTransports(),
+			Logger:         dlOptions.Base.Session.GetLogger(),
 		},
 	)
 	if err != nil || res.Resp.StatusCode != 200 {
@@ -105,7 +107,7 @@ func DlFantiaPost(count, maxCount int, postId string, dlOptions *FantiaDlOptions
 	var cacheKey string
 	if dlOptions.Base.UseCacheDb {
 		url := constants.FANTIA_POST_API_URL + postId
-		if database.PostCacheExists(url, constants.FANTIA) {
+		if dlOptions.Base.Session.GetDb().PostCacheExists(url, constants.FANTIA) {
 			return false, nil, nil
 		}
 		cacheKey = database.ParsePostKey(url, constants.FANTIA)
@@ -149,7 +151,10 @@ func DlFantiaPost(count, maxCount int, postId string, dlOptions *FantiaDlOptions
 			HeadReqTimeout:  constants.DEFAULT_HEAD_REQ_TIMEOUT,
 			SupportRange:    constants.FANTIA_RANGE_SUPPORTED,
 			Filters:         dlOptions.Base.Filters,
+			Sanitizer:       dlOptions.Base.Sanitizer,
 			ProgressBarInfo: dlOptions.Base.ProgressBarInfo,
+			Db:              dlOptions.Base.Session.GetDb(),
+			Logger:          dlOptions.Base.Session.GetLogger(),
 		},
 		dlOptions.Base.Configs,
 	)
@@ -160,7 +165,7 @@ func DlFantiaPost(count, maxCount int, postId string, dlOptions *FantiaDlOptions
 	}
 	if dlOptions.Base.UseCacheDb {
 		// No need to use batch since posts are downloaded sequentially
-		database.CachePost(cacheKey)
+		dlOptions.Base.Session.GetDb().CachePost(cacheKey)
 	}
 	return false, postGdriveUrls, nil
 }
@@ -191,7 +196,7 @@ func (f *FantiaDl) DlFantiaPosts(dlOptions *FantiaDlOptions) ([]*httpfuncs.ToDow
 	}
 
 	if len(errSlice) > 0 {
-		logger.LogErrors(logger.ERROR, errSlice...)
+		dlOptions.Base.Session.GetLogger().LogErrors(logger.ERROR, errSlice...)
 	}
 	return gdriveLinks, errSlice
 }
@@ -27,6 +27,8 @@ func getFantiaProductPaidContent(purchaseRelativeUrl, productId string, dlOption
 			UserAgent:      dlOptions.Base.Configs.UserAgent,
 			Context:        dlOptions.GetContext(),
 			CaptchaHandler: newHttpCaptchaHandler(dlOptions),
+			Transports:     dlOptions.Base.Session.GetTransports(),
+			Logger:         dlOptions.Base.Session.GetLogger(),
 		},
 	)
 	if err != nil {
@@ -48,7 +50,7 @@ func getProduct(productId string, dlOptions *FantiaDlOptions) ([]*httpfuncs.ToDo
 	var cacheKey string
 	productUrl := constants.FANTIA_PRODUCT_URL + productId
 	if dlOptions.Base.UseCacheDb {
-		if database.PostCacheExists(productUrl, constants.FANTIA) {
+		if dlOptions.Base.Session.GetDb().PostCacheExists(productUrl, constants.FANTIA) {
 			return nil, nil
 		}
 		cacheKey = database.ParsePostKey(productUrl, constants.FANTIA)
@@ -65,6 +67,8 @@ func getProduct(productId string, dlOptions *FantiaDlOptions) ([]*httpfuncs.ToDo
 			UserAgent:      dlOptions.Base.Configs.UserAgent,
 			Context:        dlOptions.GetContext(),
 			CaptchaHandler: newHttpCaptchaHandler(dlOptions),
+			Transports:     dlOptions.Base.Session.GetTransports(),
+			Logger:         dlOptions.Base.Session.GetLogger(),
 		},
 	)
 	if err != nil {
@@ -145,7 +149,7 @@ func (f *FantiaDl) GetProducts(dlOptions *FantiaDlOptions) ([]*httpfuncs.ToDownl
 	hasErr := errTsSlice.LenUnsafe() > 0
 	if hasErr {
 		var hasCancelled bool
-		if hasCancelled, errorSlice = logger.LogSliceErrors(logger.ERROR, errTsSlice); hasCancelled {
+		if hasCancelled, errorSlice = dlOptions.Base.Session.GetLogger().LogSliceErrors(logger.ERROR, errTsSlice); hasCancelled {
 			dlOptions.CancelCtx()
 			progress.StopInterrupt(
 				fmt.Sprintf("Stopped getting %d product content from Fantia...", productIdsLen),
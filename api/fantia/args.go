@@ -150,6 +150,8 @@ func (f *FantiaDlOptions) GetCsrfToken(userAgent string, captchaHandler httpfunc
 			CheckStatus:    true,
 			UserAgent:      userAgent,
 			CaptchaHandler: captchaHandler,
+			Transports:     f.Base.Session.GetTransports(),
+			Logger:         f.Base.Session.GetLogger(),
 		},
 	)
 	if err != nil {
@@ -246,6 +248,13 @@ func (f *FantiaDlOptions) ValidateArgs(userAgent string) error {
 		f.Base.DownloadDirPath = dlDirPath
 	}
 
+	if err := f.Base.ValidatePathTemplates(constants.FANTIA); err != nil {
+		return err
+	}
+	if err := f.Base.ValidateFilterProfiles(); err != nil {
+		return err
+	}
+
 	if f.Base.SessionCookieId != "" {
 		f.Base.SessionCookies = []*http.Cookie{
 			api.GetCookie(f.Base.SessionCookieId, constants.FANTIA),
@@ -270,6 +279,7 @@ func (f *FantiaDlOptions) ValidateArgs(userAgent string) error {
 	} else if !f.Base.DlGdrive && f.Base.GdriveClient != nil {
 		f.Base.GdriveClient = nil
 	}
+	f.Base.SetGdriveSession()
 
 	return f.GetCsrfToken(userAgent, ch)
 }
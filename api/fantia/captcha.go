@@ -5,9 +5,8 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
-	"sync"
-	"time"
 
+	"github.com/KJHJason/Cultured-Downloader-Logic/api"
 	"github.com/KJHJason/Cultured-Downloader-Logic/api/cdlsolvers/cdldocker"
 	"github.com/KJHJason/Cultured-Downloader-Logic/httpfuncs"
 
@@ -18,16 +17,27 @@ import (
 	"github.com/KJHJason/Cultured-Downloader-Logic/notify"
 )
 
-var (
-	captchaMu  sync.Mutex
-	solvedTime time.Time
-)
-
 type CaptchaOptions struct {
 	Ctx            context.Context
 	UserAgent      string
 	SessionCookies []*http.Cookie
 	Notifier       notify.Notifier
+	Cache          *api.CaptchaCache // defaults to the cache of the default session if nil
+	Logger         *logger.Logger    // defaults to logger.MainLogger if nil
+}
+
+func (options CaptchaOptions) getCache() *api.CaptchaCache {
+	if options.Cache == nil {
+		return (*api.Session)(nil).GetFantiaCaptcha()
+	}
+	return options.Cache
+}
+
+func (options CaptchaOptions) getLogger() *logger.Logger {
+	if options.Logger == nil {
+		return &logger.MainLogger
+	}
+	return options.Logger
 }
 
 // Automatically try to solve the reCAPTCHA for Fantia.
@@ -44,7 +54,7 @@ func autoSolveCaptcha(captchaOptions CaptchaOptions) error {
 		captchaOptions.SessionCookies,
 	)
 	if err != nil {
-		logger.MainLogger.Errorf(
+		captchaOptions.getLogger().Errorf(
 			"fantia error %d: failed to solve reCAPTCHA for %s, more info => %v",
 			cdlerrors.CAPTCHA_ERROR,
 			readableSite,
@@ -55,7 +65,6 @@ func autoSolveCaptcha(captchaOptions CaptchaOptions) error {
 	}
 
 	notifier.Alert("Successfully solved reCAPTCHA automatically!")
-	solvedTime = time.Now()
 	return nil
 }
 
@@ -66,6 +75,8 @@ func newHttpCaptchaHandler(dlOptions *FantiaDlOptions) httpfuncs.CaptchaHandler
 			UserAgent:      dlOptions.Base.Configs.UserAgent,
 			SessionCookies: dlOptions.Base.SessionCookies,
 			Notifier:       dlOptions.Base.Notifier,
+			Cache:          dlOptions.Base.Session.GetFantiaCaptcha(),
+			Logger:         dlOptions.Base.Session.GetLogger(),
 		},
 	}
 	return httpfuncs.CaptchaHandler{
@@ -83,6 +94,8 @@ func NewHttpCaptchaHandler(options CaptchaOptions) httpfuncs.CaptchaHandler {
 			UserAgent:      options.UserAgent,
 			SessionCookies: options.SessionCookies,
 			Notifier:       options.Notifier,
+			Cache:          options.Cache,
+			Logger:         options.Logger,
 		},
 	}
 	return httpfuncs.CaptchaHandler{
@@ -104,25 +117,20 @@ func NewCaptchaHandlerWithOptions(options CaptchaOptions) CaptchaHandler {
 }
 
 func (ch CaptchaHandler) Call(*http.Request) error {
-	captchaMu.Lock()
-	defer captchaMu.Unlock()
-
-	if !solvedTime.IsZero() && time.Since(solvedTime) < constants.FANTIA_CAPTCHA_CACHE_TIMEOUT {
-		// if the reCAPTCHA was solved within the last few seconds,
-		// then skip solving it to avoid solving it multiple times
-		return nil
-	}
-
-	if len(ch.options.SessionCookies) == 0 {
-		// Since reCAPTCHA is per session for Fantia, the program shall avoid
-		// trying to solve it and alert the user to login or create a Fantia account.
-		// It is possible that the reCAPTCHA is per IP address for guests, but I'm not sure.
-		return fmt.Errorf(
-			"fantia error %d: reCAPTCHA detected but you are not logged in. Please login to Fantia and try again",
-			cdlerrors.CAPTCHA_ERROR,
-		)
-	}
-	return autoSolveCaptcha(ch.options)
+	// if the reCAPTCHA was solved within the last few seconds,
+	// then skip solving it to avoid solving it multiple times
+	return ch.options.getCache().SolveIfExpired(constants.FANTIA_CAPTCHA_CACHE_TIMEOUT, func() error {
+		if len(ch.options.SessionCookies) == 0 {
+			// Since reCAPTCHA is per session for Fantia, the program shall avoid
+			// trying to solve it and alert the user to login or create a Fantia account.
+			// It is possible that the reCAPTCHA is per IP address for guests, but I'm not sure.
+			return fmt.Errorf(
+				"fantia error %d: reCAPTCHA detected but you are not logged in. Please login to Fantia and try again",
+				cdlerrors.CAPTCHA_ERROR,
+			)
+		}
+		return autoSolveCaptcha(ch.options)
+	})
 }
 
 func CaptchaChecker(res *httpfuncs.ResponseWrapper) (bool, error) {
@@ -6,6 +6,14 @@ type FantiaContent struct {
 
 	Category string `json:"category"`
 
+	// "visible" if the user has access to the content
+	VisibleStatus string `json:"visible_status"`
+
+	// The plan required to view the content, null if the content is free
+	Plan *struct {
+		Price int `json:"price"` // in JPY
+	} `json:"plan"`
+
 	// For images that are uploaded to their own section
 	PostContentPhotos []struct {
 		ID  int `json:"id"`
@@ -32,16 +40,21 @@ type FantiaPost struct {
 		ID       int    `json:"id"`
 		Comment  string `json:"comment"` // the main post content
 		Title    string `json:"title"`
+		Rating   string `json:"rating"`    // "general" or "adult"
 		PostedAt string `json:"posted_at"` // Wed, 14 Feb 2024 20:00:00 +0900
 		Thumb    struct {
 			Original string `json:"original"`
 		} `json:"thumb"`
 		Fanclub struct {
+			ID   int `json:"id"`
 			User struct {
 				Name string `json:"name"`
 			} `json:"user"`
 			FanclubNameWithCreatorName string `json:"fanclub_name_with_creator_name"`
 		} `json:"fanclub"`
+		Tags []struct {
+			Name string `json:"name"`
+		} `json:"tags"`
 		Status       string          `json:"status"`
 		PostContents []FantiaContent `json:"post_contents"`
 	} `json:"post"`
@@ -12,7 +12,7 @@ import (
 
 	"github.com/KJHJason/Cultured-Downloader-Logic/cdlerrors"
 	"github.com/KJHJason/Cultured-Downloader-Logic/constants"
-	"github.com/KJHJason/Cultured-Downloader-Logic/database"
+	"github.com/KJHJason/Cultured-Downloader-Logic/filters"
 	"github.com/KJHJason/Cultured-Downloader-Logic/gdrive"
 	"github.com/KJHJason/Cultured-Downloader-Logic/httpfuncs"
 	"github.com/KJHJason/Cultured-Downloader-Logic/iofuncs"
@@ -24,9 +24,15 @@ import (
 type postContentId struct {
 	postId    int
 	commentId int
+
+	// running count of the images in the post
+	// used for file name templates when images are not organised
+	postImgCount    int
+	commentImgCount int
 }
 
-func dlImagesFromPost(content *FantiaContent, postFolderPath string, organise bool, id *postContentId) []*httpfuncs.ToDownload {
+func dlImagesFromPost(content *FantiaContent, postFolderPath string, pathInfo *iofuncs.PathTemplateInfo, dlOptions *FantiaDlOptions, id *postContentId) []*httpfuncs.ToDownload {
+	organise := dlOptions.Base.OrganiseImages
 	postContentPhotos := content.PostContentPhotos
 	matchedUrlInComments := constants.FANTIA_COMMENT_IMAGE_URL_REGEX.FindAllStringSubmatch(content.Comment, -1)
 	commentsLen := len(matchedUrlInComments)
@@ -46,11 +52,26 @@ func dlImagesFromPost(content *FantiaContent, postFolderPath string, organise bo
 		// Since the API returns the URL with escaped & characters, we need to replace them so that the URL is valid.
 		imageUrl = strings.Replace(imageUrl, "\\u0026", "&", 2)
 
-		filePath := filepath.Join(postFolderPath, constants.FANTIA_POST_BLOG_DIR_NAME)
+		commentCount++
+		var filePath string
 		if organise {
-			commentCount++
 			fileExt := matched[constants.FANTIA_COMMENT_REGEX_EXT_IDX]
-			filePath = filepath.Join(filePath, commentFolderId, fmt.Sprintf("%d.%s", commentCount, fileExt))
+			filePath = dlOptions.Base.GetFilePath(
+				postFolderPath,
+				filepath.Join(constants.FANTIA_POST_BLOG_DIR_NAME, commentFolderId),
+				fmt.Sprintf("%d.%s", commentCount, fileExt),
+				commentCount,
+				pathInfo,
+			)
+		} else {
+			id.commentImgCount++
+			filePath = dlOptions.Base.GetFilePathFromUrl(
+				postFolderPath,
+				constants.FANTIA_POST_BLOG_DIR_NAME,
+				imageUrl,
+				id.commentImgCount,
+				pathInfo,
+			)
 		}
 
 		urlsSlice = append(urlsSlice, &httpfuncs.ToDownload{
@@ -69,19 +90,28 @@ func dlImagesFromPost(content *FantiaContent, postFolderPath string, organise bo
 		imageUrl := image.URL.Original
 		filePath := filepath.Join(postFolderPath, constants.IMAGES_FOLDER)
 
-		if organise {
-			postCount++
+		postCount++
+		if !organise {
+			id.postImgCount++
+			filePath = dlOptions.Base.GetFilePathFromUrl(postFolderPath, constants.IMAGES_FOLDER, imageUrl, id.postImgCount, pathInfo)
+		} else {
 			matched := constants.FANTIA_IMAGE_URL_REGEX.FindStringSubmatch(imageUrl)
 			if len(matched) > 0 {
 				fileExt := matched[constants.FANTIA_IMAGE_URL_REGEX_EXT_IDX]
-				filePath = filepath.Join(filePath, postFolderId, fmt.Sprintf("%d.%s", postCount, fileExt))
+				filePath = dlOptions.Base.GetFilePath(
+					postFolderPath,
+					filepath.Join(constants.IMAGES_FOLDER, postFolderId),
+					fmt.Sprintf("%d.%s", postCount, fileExt),
+					postCount,
+					pathInfo,
+				)
 			} else {
 				err := fmt.Errorf(
 					"fantia error %d: failed to match image url %q when trying to organise images",
 					cdlerrors.UNEXPECTED_ERROR,
 					imageUrl,
 				)
-				logger.LogError(err, logger.ERROR)
+				dlOptions.Base.Session.GetLogger().LogError(err, logger.ERROR)
 			}
 		}
 
@@ -96,7 +126,7 @@ func dlImagesFromPost(content *FantiaContent, postFolderPath string, organise bo
 	return urlsSlice
 }
 
-func dlAttachmentsFromPost(content *FantiaContent, postFolderPath string) []*httpfuncs.ToDownload {
+func dlAttachmentsFromPost(content *FantiaContent, postFolderPath string, pathInfo *iofuncs.PathTemplateInfo, dlOptions *FantiaDlOptions, idx int) []*httpfuncs.ToDownload {
 	var urlsSlice []*httpfuncs.ToDownload
 
 	// get the attachment url string if it exists
@@ -113,15 +143,21 @@ func dlAttachmentsFromPost(content *FantiaContent, postFolderPath string) []*htt
 		downloadUrl := constants.FANTIA_URL + content.DownloadUri
 		filename := content.Filename
 		urlsSlice = append(urlsSlice, &httpfuncs.ToDownload{
-			Url:      downloadUrl,
-			FilePath: filepath.Join(postFolderPath, constants.ATTACHMENT_FOLDER, filename),
+			Url: downloadUrl,
+			FilePath: dlOptions.Base.GetFilePath(
+				postFolderPath,
+				constants.ATTACHMENT_FOLDER,
+				filename,
+				idx,
+				pathInfo,
+			),
 		})
 	}
 	return urlsSlice
 }
 
 // Convert string value like "Wed, 14 Feb 2024 20:00:00 +0900" to time.Time
-func parseDateStrToDateTime(dateStr string) time.Time {
+func parseDateStrToDateTime(dateStr string, l *logger.Logger) time.Time {
 	dateTime, err := time.Parse(time.RFC1123Z, dateStr)
 	if err != nil {
 		errMsg := fmt.Errorf(
@@ -130,12 +166,23 @@ func parseDateStrToDateTime(dateStr string) time.Time {
 			dateStr,
 			err,
 		)
-		logger.LogError(errMsg, logger.ERROR)
+		l.LogError(errMsg, logger.ERROR)
 		return time.Time{}
 	}
 	return dateTime
 }
 
+// Checks the restriction and plan fee filters of a post content
+// as each content of a Fantia post can require a different plan
+func isFantiaContentValid(content *FantiaContent, contentFilters *filters.Filters) bool {
+	fee := 0
+	if content.Plan != nil {
+		fee = content.Plan.Price
+	}
+	isRestricted := content.VisibleStatus != "" && content.VisibleStatus != "visible"
+	return contentFilters.IsRestrictedPostValid(isRestricted) && contentFilters.IsPostFeeValid(fee)
+}
+
 // Process the JSON response from Fantia's API and
 // returns a slice of urls and a slice of gdrive urls to download from
 func processFantiaPost(res *httpfuncs.ResponseWrapper, dlOptions *FantiaDlOptions) ([]*httpfuncs.ToDownload, []*httpfuncs.ToDownload, error) {
@@ -153,8 +200,21 @@ func processFantiaPost(res *httpfuncs.ResponseWrapper, dlOptions *FantiaDlOption
 	}
 
 	post := postJson.Post
-	postDate := parseDateStrToDateTime(post.PostedAt)
-	if !dlOptions.Base.Filters.IsPostDateValid(postDate) {
+	postDate := parseDateStrToDateTime(post.PostedAt, dlOptions.Base.Session.GetLogger())
+	filterInfo := &filters.PostInfo{
+		Title: post.Title,
+		Tags:  make([]string, 0, len(post.Tags)),
+		Fee:   filters.UNKNOWN_FEE,
+		Date:  postDate,
+		Type:  "post",
+	}
+	for _, tag := range post.Tags {
+		filterInfo.Tags = append(filterInfo.Tags, tag.Name)
+	}
+	postFilters := dlOptions.Base.GetFilters(strconv.Itoa(post.Fanclub.ID))
+	if !postFilters.IsAdultContentValid(post.Rating == "adult") ||
+		!postFilters.IsPostDateValid(postDate) ||
+		!postFilters.IsPostExprValid(filterInfo) {
 		return nil, nil, nil
 	}
 	postId := strconv.Itoa(post.ID)
@@ -163,7 +223,14 @@ func processFantiaPost(res *httpfuncs.ResponseWrapper, dlOptions *FantiaDlOption
 	if fanclubName == "" { // just in case but shouldn't happen
 		fanclubName = post.Fanclub.User.Name
 	}
-	postFolderPath := iofuncs.GetPostFolder(dlOptions.Base.DownloadDirPath, fanclubName, postId, postTitle)
+	pathInfo := &iofuncs.PathTemplateInfo{
+		CreatorName: fanclubName,
+		CreatorId:   strconv.Itoa(post.Fanclub.ID),
+		PostId:      postId,
+		Title:       postTitle,
+		Date:        postDate,
+	}
+	postFolderPath := dlOptions.Base.GetPostFolder(pathInfo)
 
 	var urlsSlice []*httpfuncs.ToDownload
 	thumbnail := post.Thumb.Original
@@ -181,8 +248,15 @@ func processFantiaPost(res *httpfuncs.ResponseWrapper, dlOptions *FantiaDlOption
 		dlOptions.Base.Configs.LogUrls,
 	)
 
+	source := dlOptions.Base.NewFileSource(pathInfo, constants.FANTIA_POST_API_URL+postId)
 	postContent := post.PostContents
 	if postContent == nil {
+		httpfuncs.SetPostInfo(urlsSlice, filterInfo)
+		httpfuncs.SetPostInfo(gdriveLinks, filterInfo)
+		httpfuncs.SetFilters(urlsSlice, postFilters)
+		httpfuncs.SetFilters(gdriveLinks, postFilters)
+		httpfuncs.SetSource(urlsSlice, source)
+		httpfuncs.SetSource(gdriveLinks, source)
 		return urlsSlice, gdriveLinks, nil
 	}
 
@@ -197,8 +271,11 @@ func processFantiaPost(res *httpfuncs.ResponseWrapper, dlOptions *FantiaDlOption
 			Title:                postTitle,
 			PostComment:          post.Comment,
 			EmbeddedPostComments: comments,
+			Creator:              fanclubName,
+			CreatorId:            pathInfo.CreatorId,
+			Tags:                 filterInfo.Tags,
 		}
-		if err := metadata.WriteMetadata(postMetadata, postFolderPath); err != nil {
+		if err := metadata.WriteMetadataWithOptions(postMetadata, postFolderPath, dlOptions.Base.MetadataOptions()); err != nil {
 			return nil, nil, err
 		}
 	}
@@ -207,7 +284,10 @@ func processFantiaPost(res *httpfuncs.ResponseWrapper, dlOptions *FantiaDlOption
 		commentId: 1,
 		postId:    1,
 	}
-	for _, content := range postContent {
+	for idx, content := range postContent {
+		if !isFantiaContentValid(&content, postFilters) {
+			continue
+		}
 		commentGdriveLinks := gdrive.ProcessPostText(
 			content.Comment,
 			postFolderPath,
@@ -218,12 +298,18 @@ func processFantiaPost(res *httpfuncs.ResponseWrapper, dlOptions *FantiaDlOption
 			gdriveLinks = append(gdriveLinks, commentGdriveLinks...)
 		}
 		if dlOptions.Base.DlImages {
-			urlsSlice = append(urlsSlice, dlImagesFromPost(&content, postFolderPath, dlOptions.Base.OrganiseImages, contentIds)...)
+			urlsSlice = append(urlsSlice, dlImagesFromPost(&content, postFolderPath, pathInfo, dlOptions, contentIds)...)
 		}
 		if dlOptions.Base.DlAttachments {
-			urlsSlice = append(urlsSlice, dlAttachmentsFromPost(&content, postFolderPath)...)
+			urlsSlice = append(urlsSlice, dlAttachmentsFromPost(&content, postFolderPath, pathInfo, dlOptions, idx+1)...)
 		}
 	}
+	httpfuncs.SetPostInfo(urlsSlice, filterInfo)
+	httpfuncs.SetPostInfo(gdriveLinks, filterInfo)
+	httpfuncs.SetFilters(urlsSlice, postFilters)
+	httpfuncs.SetFilters(gdriveLinks, postFilters)
+	httpfuncs.SetSource(urlsSlice, source)
+	httpfuncs.SetSource(gdriveLinks, source)
 	return urlsSlice, gdriveLinks, nil
 }
 
@@ -294,7 +380,7 @@ func getAndProcessProductPaidContent(purchaseRelativeUrl, productId string, dlOp
 			cdlerrors.HTML_ERROR,
 			err,
 		)
-		logger.LogError(err, logger.ERROR)
+		dlOptions.Base.Session.GetLogger().LogError(err, logger.ERROR)
 		return nil, nil
 	}
 
@@ -324,7 +410,7 @@ func getAndProcessProductPaidContent(purchaseRelativeUrl, productId string, dlOp
 	return paidContentUrls, nil
 }
 
-func getFanclubNameFromProductPage(productId string, doc *goquery.Document) string {
+func getFanclubNameFromProductPage(productId string, doc *goquery.Document, l *logger.Logger) string {
 	fanclubName := doc.Find(".fanclub-show-header h1.fanclub-name a").Text()
 	if fanclubName == "" {
 		htmlContent, err := doc.Html()
@@ -338,7 +424,7 @@ func getFanclubNameFromProductPage(productId string, doc *goquery.Document) stri
 			productId,
 			htmlContent,
 		)
-		logger.LogError(errMsg, logger.ERROR)
+		l.LogError(errMsg, logger.ERROR)
 		fanclubName = constants.FANTIA_UNKNOWN_CREATOR
 	}
 	return fanclubName
@@ -373,11 +459,11 @@ type productDetails struct {
 	previewContentUrls []string
 }
 
-func getProductDetails(productId string, doc *goquery.Document) productDetails {
+func getProductDetails(productId string, doc *goquery.Document, l *logger.Logger) productDetails {
 	var pd productDetails
 	jsonContent := doc.Find("head script[type='application/ld+json']").Text()
 	if jsonContent == "" {
-		logger.LogError(
+		l.LogError(
 			fmt.Errorf(
 				"fantia error %d: failed to get product details from product id %q",
 				cdlerrors.HTML_ERROR,
@@ -391,7 +477,7 @@ func getProductDetails(productId string, doc *goquery.Document) productDetails {
 	// get the product details from the JSON content
 	var productInfoSlice []ProductInfo
 	if err := json.Unmarshal([]byte(jsonContent), &productInfoSlice); err != nil {
-		logger.LogError(
+		l.LogError(
 			//lint:ignore ST1005 Since the json content is long, it's better to have it on a new line for readability
 			fmt.Errorf(
 				"fantia error %d: failed to unmarshal product details from product id %q. More info => %w\nJSON content: %s\n",
@@ -406,7 +492,7 @@ func getProductDetails(productId string, doc *goquery.Document) productDetails {
 	}
 
 	if len(productInfoSlice) == 0 {
-		logger.LogError(
+		l.LogError(
 			fmt.Errorf(
 				"fantia error %d: although unmarshalled successfully, there is no element in the product info slice from product id %q",
 				cdlerrors.HTML_ERROR,
@@ -459,8 +545,19 @@ func processProductPage(cacheKey, productId string, dlOptions *FantiaDlOptions,
 		)
 	}
 
-	pd := getProductDetails(productId, doc)
-	fanclubName := getFanclubNameFromProductPage(productId, doc)
+	pd := getProductDetails(productId, doc, dlOptions.Base.Session.GetLogger())
+	filterInfo := &filters.PostInfo{
+		Title: pd.productName,
+		Fee:   pd.productInfo.Offers.Price,
+		Type:  "product",
+	}
+	if pd.productName == "" {
+		filterInfo.Fee = filters.UNKNOWN_FEE // failed to get the product details
+	}
+	if !dlOptions.Base.Filters.IsPostExprValid(filterInfo) {
+		return nil, nil
+	}
+	fanclubName := getFanclubNameFromProductPage(productId, doc, dlOptions.Base.Session.GetLogger())
 
 	// Check if the user has purchased the product so that we can get and download the paid content as well.
 	paidContent, paidContentErr := getProductPaidContent(productId, doc, dlOptions)
@@ -469,7 +566,7 @@ func processProductPage(cacheKey, productId string, dlOptions *FantiaDlOptions,
 		if errors.Is(paidContentErr, context.Canceled) {
 			return nil, paidContentErr
 		}
-		logger.LogError(paidContentErr, logger.ERROR)
+		dlOptions.Base.Session.GetLogger().LogError(paidContentErr, logger.ERROR)
 	}
 
 	numOfEl := len(pd.previewContentUrls) + len(paidContent)
@@ -478,12 +575,12 @@ func processProductPage(cacheKey, productId string, dlOptions *FantiaDlOptions,
 	}
 
 	toDownload := make([]*httpfuncs.ToDownload, 0, numOfEl)
-	dirPath := iofuncs.GetPostFolder(dlOptions.Base.DownloadDirPath, fanclubName, productId, pd.productName)
-	dirPath = filepath.Join(
-		filepath.Dir(dirPath), // go up one directory
-		constants.FANTIA_PRODUCT_DIR_NAME,
-		filepath.Base(dirPath), // go back to the original directory
-	)
+	pathInfo := &iofuncs.PathTemplateInfo{
+		CreatorName: fanclubName,
+		PostId:      productId,
+		Title:       pd.productName,
+	}
+	dirPath := dlOptions.Base.GetPostFolderInGroup(pathInfo, constants.FANTIA_PRODUCT_DIR_NAME)
 
 	if dlOptions.Base.SetMetadata {
 		productMetadata := metadata.FantiaProduct{
@@ -495,8 +592,9 @@ func processProductPage(cacheKey, productId string, dlOptions *FantiaDlOptions,
 				Price:    pd.productInfo.Offers.Price,
 				Currency: pd.productInfo.Offers.PriceCurrency,
 			},
+			Creator: fanclubName,
 		}
-		if err := metadata.WriteMetadata(productMetadata, dirPath); err != nil {
+		if err := metadata.WriteMetadataWithOptions(productMetadata, dirPath, dlOptions.Base.MetadataOptions()); err != nil {
 			return nil, err
 		}
 	}
@@ -506,29 +604,39 @@ func processProductPage(cacheKey, productId string, dlOptions *FantiaDlOptions,
 			Url:      pd.thumbnailUrl,
 			FilePath: dirPath,
 			CacheKey: cacheKey,
-			CacheFn:  database.CachePost,
+			CacheFn:  dlOptions.Base.Session.GetDb().CachePost,
 		})
 	}
 	for i, url := range pd.previewContentUrls {
-		dlFilePath := filepath.Join(dirPath, constants.FANTIA_PRODUCT_PREVIEW_DIR_NAME)
+		var dlFilePath string
 		if dlOptions.Base.OrganiseImages {
 			fileExt := filepath.Ext(url)
-			dlFilePath = filepath.Join(dlFilePath, fmt.Sprintf("%d%s", i+1, fileExt))
+			dlFilePath = dlOptions.Base.GetFilePath(
+				dirPath,
+				constants.FANTIA_PRODUCT_PREVIEW_DIR_NAME,
+				fmt.Sprintf("%d%s", i+1, fileExt),
+				i+1,
+				pathInfo,
+			)
+		} else {
+			dlFilePath = dlOptions.Base.GetFilePathFromUrl(dirPath, constants.FANTIA_PRODUCT_PREVIEW_DIR_NAME, url, i+1, pathInfo)
 		}
 		toDownload = append(toDownload, &httpfuncs.ToDownload{
 			Url:      url,
 			FilePath: dlFilePath,
 			CacheKey: cacheKey,
-			CacheFn:  database.CachePost,
+			CacheFn:  dlOptions.Base.Session.GetDb().CachePost,
 		})
 	}
-	for _, url := range paidContent {
+	for i, url := range paidContent {
 		toDownload = append(toDownload, &httpfuncs.ToDownload{
 			Url:      url,
-			FilePath: filepath.Join(dirPath, constants.FANTIA_PRODUCT_PAID_DIR_NAME),
+			FilePath: dlOptions.Base.GetFilePathFromUrl(dirPath, constants.FANTIA_PRODUCT_PAID_DIR_NAME, url, i+1, pathInfo),
 			CacheKey: cacheKey,
-			CacheFn:  database.CachePost,
+			CacheFn:  dlOptions.Base.Session.GetDb().CachePost,
 		})
 	}
+	httpfuncs.SetPostInfo(toDownload, filterInfo)
+	httpfuncs.SetSource(toDownload, dlOptions.Base.NewFileSource(pathInfo, constants.FANTIA_PRODUCT_URL+productId))
 	return toDownload, nil
 }
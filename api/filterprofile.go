@@ -0,0 +1,101 @@
+package api
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/KJHJason/Cultured-Downloader-Logic/cdlerrors"
+	"github.com/KJHJason/Cultured-Downloader-Logic/constants"
+	"github.com/KJHJason/Cultured-Downloader-Logic/filters"
+	"github.com/KJHJason/Cultured-Downloader-Logic/logger"
+)
+
+type creatorFilters struct {
+	mu      sync.Mutex
+	filters map[string]*filters.Filters // creator ID -> filters of the creator's profile
+}
+
+// Returns the site name used in the assignments of the filter profiles
+// where Pixiv's web and mobile downloads share the same assignments.
+func (b *BaseDl) filterProfileSite() string {
+	if b.site == constants.PIXIV_MOBILE {
+		return constants.PIXIV
+	}
+	return b.site
+}
+
+// ValidateFilterProfiles validates the FilterProfiles and replaces Filters with
+// the filters of the profile assigned to the site or the default profile if there is one.
+//
+// Must be called after ValidatePathTemplates as it uses the site of the download.
+func (b *BaseDl) ValidateFilterProfiles() error {
+	if b.FilterProfiles == nil {
+		return nil
+	}
+
+	if err := b.FilterProfiles.ValidateArgs(); err != nil {
+		return fmt.Errorf(
+			"%s error %d: invalid filter profiles, more info => %w",
+			b.site,
+			cdlerrors.INPUT_ERROR,
+			err,
+		)
+	}
+	siteFilters, err := b.FilterProfiles.GetFilters(b.filterProfileSite(), "")
+	if err != nil {
+		return fmt.Errorf(
+			"%s error %d: failed to get the filters of the site, more info => %w",
+			b.site,
+			cdlerrors.INPUT_ERROR,
+			err,
+		)
+	}
+	if siteFilters != nil {
+		b.Filters = siteFilters
+	}
+
+	b.creatorFilters.mu.Lock()
+	b.creatorFilters.filters = make(map[string]*filters.Filters)
+	b.creatorFilters.mu.Unlock()
+	return nil
+}
+
+// GetFilters returns the filters of the profile assigned to the creator
+// or Filters if there are no filter profiles or the creator has no assigned profile.
+//
+// For Kemono, creatorId should be in the form of "<service>/<creator ID>".
+func (b *BaseDl) GetFilters(creatorId string) *filters.Filters {
+	if b.FilterProfiles == nil || creatorId == "" {
+		return b.Filters
+	}
+	if _, ok := b.FilterProfiles.Assignments[b.filterProfileSite()+"/"+creatorId]; !ok {
+		return b.Filters
+	}
+
+	b.creatorFilters.mu.Lock()
+	defer b.creatorFilters.mu.Unlock()
+	if creatorFilters, ok := b.creatorFilters.filters[creatorId]; ok {
+		return creatorFilters
+	}
+
+	creatorFilters, err := b.FilterProfiles.GetFilters(b.filterProfileSite(), creatorId)
+	if err != nil {
+		// fallback to the site's filters instead of stopping the download
+		b.Session.GetLogger().LogError(
+			fmt.Errorf(
+				"%s error %d: failed to get the filters of the creator %s, more info => %w",
+				b.site,
+				cdlerrors.INPUT_ERROR,
+				creatorId,
+				err,
+			),
+			logger.ERROR,
+		)
+		creatorFilters = b.Filters
+	}
+	if b.creatorFilters.filters == nil {
+		b.creatorFilters.filters = make(map[string]*filters.Filters)
+	}
+	b.creatorFilters.filters[creatorId] = creatorFilters
+	return creatorFilters
+}
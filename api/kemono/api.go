@@ -58,9 +58,6 @@ func parseCreatorHtmlAndGetName(res *http.Response, url string) (string, error)
 	return creatorName, nil
 }
 
-var creatorNameCacheLock sync.Mutex
-var creatorNameCache = make(map[string]string)
-
 func getCreatorName(service, userId string, dlOptions *KemonoDlOptions) (string, error) {
 	url := fmt.Sprintf(
 		"%s/%s/user/%s",
@@ -69,20 +66,26 @@ func getCreatorName(service, userId string, dlOptions *KemonoDlOptions) (string,
 		userId,
 	)
 
-	var cacheKey string
-	if dlOptions.Base.UseCacheDb {
-		if name := database.GetKemonoCreatorCache(url); name != "" {
-			return name, nil
-		}
-	} else {
-		creatorNameCacheLock.Lock()
-		defer creatorNameCacheLock.Unlock()
-		cacheKey = fmt.Sprintf("%s/%s", service, userId)
-		if name, ok := creatorNameCache[cacheKey]; ok {
-			return name, nil
-		}
+	if !dlOptions.Base.UseCacheDb {
+		cacheKey := fmt.Sprintf("%s/%s", service, userId)
+		return dlOptions.Base.Session.GetKemonoCreatorNames().GetOrFetch(cacheKey, func() (string, error) {
+			return fetchCreatorName(url, userId, dlOptions)
+		})
+	}
+
+	db := dlOptions.Base.Session.GetDb()
+	if name := db.GetKemonoCreatorCache(url); name != "" {
+		return name, nil
 	}
+	creatorName, err := fetchCreatorName(url, userId, dlOptions)
+	if err != nil {
+		return creatorName, err
+	}
+	db.CacheKemonoCreatorName(url, creatorName)
+	return creatorName, nil
+}
 
+func fetchCreatorName(url, userId string, dlOptions *KemonoDlOptions) (string, error) {
 	useHttp3 := httpfuncs.IsHttp3Supported(constants.KEMONO, true)
 	res, err := httpfuncs.CallRequest(
 		&httpfuncs.RequestArgs{
@@ -95,6 +98,8 @@ func getCreatorName(service, userId string, dlOptions *KemonoDlOptions) (string,
 			Http3:       useHttp3,
 			CheckStatus: true,
 			Context:     dlOptions.GetContext(),
+			Transports:  dlOptions.Base.Session.GetTransports(),
+			Logger:      dlOptions.Base.Session.GetLogger(),
 		},
 	)
 	if err != nil {
@@ -105,26 +110,19 @@ func getCreatorName(service, userId string, dlOptions *KemonoDlOptions) (string,
 	if err != nil {
 		return userId, err
 	}
-
-	if dlOptions.Base.UseCacheDb {
-		database.CacheKemonoCreatorName(url, creatorName)
-	} else {
-		creatorNameCache[cacheKey] = creatorName
-	}
 	return creatorName, nil
 }
 
+// Returns the API URL of the post which is also used as the cache key of the post
+func getPostApiUrl(service, creatorId, postId string) string {
+	return fmt.Sprintf("%s/%s/user/%s/post/%s", constants.KEMONO_API_URL, service, creatorId, postId)
+}
+
 func getPostDetails(post *KemonoPostToDl, dlOptions *KemonoDlOptions) ([]*httpfuncs.ToDownload, []*httpfuncs.ToDownload, error) {
-	url := fmt.Sprintf(
-		"%s/%s/user/%s/post/%s",
-		constants.KEMONO_API_URL,
-		post.Service,
-		post.CreatorId,
-		post.PostId,
-	)
+	url := getPostApiUrl(post.Service, post.CreatorId, post.PostId)
 	var cacheKey string
 	if dlOptions.Base.UseCacheDb {
-		if database.PostCacheExists(url, constants.KEMONO) {
+		if dlOptions.Base.Session.GetDb().PostCacheExists(url, constants.KEMONO) {
 			return nil, nil, nil
 		}
 		cacheKey = database.ParsePostKey(url, constants.KEMONO)
@@ -142,6 +140,8 @@ func getPostDetails(post *KemonoPostToDl, dlOptions *KemonoDlOptions) ([]*httpfu
 			Http3:       useHttp3,
 			CheckStatus: true,
 			Context:     dlOptions.GetContext(),
+			Transports:  dlOptions.Base.Session.GetTransports(),
+			Logger:      dlOptions.Base.Session.GetLogger(),
 		},
 	)
 	if err != nil {
@@ -158,7 +158,7 @@ func getPostDetails(post *KemonoPostToDl, dlOptions *KemonoDlOptions) ([]*httpfu
 	if dlOptions.Base.UseCacheDb {
 		for _, post := range postsToDl {
 			post.CacheKey = cacheKey
-			post.CacheFn = database.CachePost
+			post.CacheFn = dlOptions.Base.Session.GetDb().CachePost
 		}
 	}
 	return postsToDl, gdriveLinks, nil
@@ -238,7 +238,7 @@ func GetMultiplePosts(posts []*KemonoPostToDl, dlOptions *KemonoDlOptions) (urls
 		if !hasError {
 			hasError = true
 		}
-		logger.LogError(res.err, logger.ERROR)
+		dlOptions.Base.Session.GetLogger().LogError(res.err, logger.ERROR)
 		errSlice = append(errSlice, res.err)
 	}
 
@@ -261,6 +261,7 @@ func getCreatorPosts(creator *KemonoCreatorToDl, dlOptions *KemonoDlOptions) ([]
 
 	var postsToDl, gdriveLinksToDl []*httpfuncs.ToDownload
 	params := make(map[string]string)
+	watermark := dlOptions.Base.NewSyncWatermark(creator.Service + "/" + creator.CreatorId)
 	curOffset := minOffset
 	for {
 		params["o"] = strconv.Itoa(curOffset)
@@ -281,6 +282,8 @@ func getCreatorPosts(creator *KemonoCreatorToDl, dlOptions *KemonoDlOptions) ([]
 				Http3:       useHttp3,
 				CheckStatus: true,
 				Context:     dlOptions.GetContext(),
+				Transports:  dlOptions.Base.Session.GetTransports(),
+				Logger:      dlOptions.Base.Session.GetLogger(),
 			},
 		)
 		if err != nil {
@@ -296,15 +299,26 @@ func getCreatorPosts(creator *KemonoCreatorToDl, dlOptions *KemonoDlOptions) ([]
 			break
 		}
 
+		if watermark != nil {
+			unseenPosts := make(KemonoJson, 0, len(resJson))
+			for _, post := range resJson {
+				if !watermark.IsSeen(post.Id, parsePublishedDate(post.Published, dlOptions.Base.Session.GetLogger())) {
+					unseenPosts = append(unseenPosts, post)
+				}
+			}
+			resJson = unseenPosts
+		}
+
 		posts, gdriveLinks := processMultipleJson(resJson, dlOptions)
 		postsToDl = append(postsToDl, posts...)
 		gdriveLinksToDl = append(gdriveLinksToDl, gdriveLinks...)
 
-		if hasMax && curOffset >= maxOffset {
+		if (hasMax && curOffset >= maxOffset) || watermark.ReachedSeen() {
 			break
 		}
 		curOffset += constants.KEMONO_PER_PAGE
 	}
+	watermark.Done()
 	return postsToDl, gdriveLinksToDl, nil
 }
 
@@ -361,7 +375,7 @@ func GetMultipleCreators(creators []*KemonoCreatorToDl, dlOptions *KemonoDlOptio
 	hasErr := false
 	if len(errSlice) > 0 {
 		hasErr = true
-		logger.LogErrors(logger.ERROR, errSlice...)
+		dlOptions.Base.Session.GetLogger().LogErrors(logger.ERROR, errSlice...)
 	}
 	if hasCancelled {
 		dlOptions.CancelCtx()
@@ -399,6 +413,8 @@ func GetFavourites(dlOptions *KemonoDlOptions) ([]*httpfuncs.ToDownload, []*http
 		Http3:       useHttp3,
 		CheckStatus: true,
 		Context:     dlOptions.GetContext(),
+		Transports:  dlOptions.Base.Session.GetTransports(),
+		Logger:      dlOptions.Base.Session.GetLogger(),
 	}
 	res, err := httpfuncs.CallRequest(reqArgs)
 	if err != nil {
@@ -197,6 +197,13 @@ func (k *KemonoDlOptions) ValidateArgs(userAgent string) error {
 		k.Base.DownloadDirPath = dlDirPath
 	}
 
+	if err := k.Base.ValidatePathTemplates(constants.KEMONO); err != nil {
+		return err
+	}
+	if err := k.Base.ValidateFilterProfiles(); err != nil {
+		return err
+	}
+
 	if k.Base.SessionCookieId != "" {
 		k.Base.SessionCookies = []*http.Cookie{
 			api.GetCookie(k.Base.SessionCookieId, constants.KEMONO),
@@ -221,5 +228,6 @@ func (k *KemonoDlOptions) ValidateArgs(userAgent string) error {
 	} else if !k.Base.DlGdrive && k.Base.GdriveClient != nil {
 		k.Base.GdriveClient = nil
 	}
+	k.Base.SetGdriveSession()
 	return nil
 }
@@ -10,6 +10,7 @@ import (
 
 	"github.com/KJHJason/Cultured-Downloader-Logic/cdlerrors"
 	"github.com/KJHJason/Cultured-Downloader-Logic/constants"
+	"github.com/KJHJason/Cultured-Downloader-Logic/filters"
 	"github.com/KJHJason/Cultured-Downloader-Logic/gdrive"
 	"github.com/KJHJason/Cultured-Downloader-Logic/httpfuncs"
 	"github.com/KJHJason/Cultured-Downloader-Logic/iofuncs"
@@ -18,7 +19,7 @@ import (
 	"github.com/KJHJason/Cultured-Downloader-Logic/utils"
 )
 
-func getInlineImages(content, postFolderPath string) []*httpfuncs.ToDownload {
+func getInlineImages(content, postFolderPath string, pathInfo *iofuncs.PathTemplateInfo, dlOptions *KemonoDlOptions) []*httpfuncs.ToDownload {
 	matches := constants.KEMONO_IMG_SRC_TAG_REGEX.FindAllStringSubmatch(content, -1)
 	if len(matches) == 0 {
 		return nil
@@ -31,8 +32,14 @@ func getInlineImages(content, postFolderPath string) []*httpfuncs.ToDownload {
 			continue
 		}
 		toDownload[idx] = &httpfuncs.ToDownload{
-			Url:      constants.KEMONO_URL + imgSrc,
-			FilePath: filepath.Join(postFolderPath, constants.IMAGES_FOLDER, httpfuncs.GetLastPartOfUrl(imgSrc)),
+			Url: constants.KEMONO_URL + imgSrc,
+			FilePath: dlOptions.Base.GetFilePath(
+				postFolderPath,
+				constants.IMAGES_FOLDER,
+				httpfuncs.GetLastPartOfUrl(imgSrc),
+				idx+1,
+				pathInfo,
+			),
 		}
 	}
 	return toDownload
@@ -40,17 +47,17 @@ func getInlineImages(content, postFolderPath string) []*httpfuncs.ToDownload {
 
 // Since the name of each attachment or file is not always the filename of the file as it could be a URL,
 // we need to check if the returned name value is a URL and if it is, we just return the postFolderPath as the file path.
-func getKemonoFilePath(postFolderPath, childDir, fileName string) string {
+func getKemonoFilePath(postFolderPath, childDir, fileName string, idx int, pathInfo *iofuncs.PathTemplateInfo, dlOptions *KemonoDlOptions) string {
 	if strings.HasPrefix(fileName, "http://") || strings.HasPrefix(fileName, "https://") {
-		return filepath.Join(postFolderPath, childDir)
+		return iofuncs.AsDirPath(filepath.Join(postFolderPath, childDir))
 	}
-	return filepath.Join(postFolderPath, childDir, fileName)
+	return dlOptions.Base.GetFilePath(postFolderPath, childDir, fileName, idx, pathInfo)
 }
 
 // Convert "2024-05-24T15:00:00" string to time.Time
 //
 // Note: The value returned by Kemono is UTC+0
-func parsePublishedDate(publishedDate string) time.Time {
+func parsePublishedDate(publishedDate string, l *logger.Logger) time.Time {
 	datePublished, err := time.Parse(constants.KEMONO_PUBLISHED_DATE_LAYOUT, publishedDate)
 	if err != nil {
 		errMsg := fmt.Errorf(
@@ -59,19 +66,33 @@ func parsePublishedDate(publishedDate string) time.Time {
 			publishedDate,
 			err,
 		)
-		logger.LogError(errMsg, logger.ERROR)
+		l.LogError(errMsg, logger.ERROR)
 		return time.Time{}
 	}
 	return datePublished.In(constants.KEMONO_DATETIME_OFFSET) // convert to UTC+9/JST
 }
 
 func processJson(resJson *MainKemonoJson, dlOptions *KemonoDlOptions) ([]*httpfuncs.ToDownload, []*httpfuncs.ToDownload) {
-	publishedDate := parsePublishedDate(resJson.Published)
-	if !dlOptions.Base.Filters.IsPostDateValid(publishedDate) {
+	publishedDate := parsePublishedDate(resJson.Published, dlOptions.Base.Session.GetLogger())
+	filterInfo := &filters.PostInfo{
+		Title: resJson.Title,
+		Fee:   filters.UNKNOWN_FEE,
+		Date:  publishedDate,
+	}
+	postFilters := dlOptions.Base.GetFilters(resJson.Service + "/" + resJson.User)
+	if !postFilters.IsPostDateValid(publishedDate) ||
+		!postFilters.IsPostExprValid(filterInfo) {
 		return nil, nil
 	}
 
-	var creatorNamePath string
+	pathInfo := &iofuncs.PathTemplateInfo{
+		CreatorId: resJson.User,
+		PostId:    resJson.Id,
+		Title:     resJson.Title,
+		Service:   resJson.Service,
+		Date:      publishedDate,
+	}
+	metadataCreator := resJson.User
 	if creatorName, err := getCreatorName(resJson.Service, resJson.User, dlOptions); err != nil {
 		if errors.Is(err, context.Canceled) {
 			dlOptions.CancelCtx()
@@ -83,18 +104,13 @@ func processJson(resJson *MainKemonoJson, dlOptions *KemonoDlOptions) ([]*httpfu
 			resJson.Service,
 			err,
 		)
-		logger.LogError(err, logger.ERROR)
-		creatorNamePath = resJson.User
+		dlOptions.Base.Session.GetLogger().LogError(err, logger.ERROR)
 	} else {
-		creatorNamePath = fmt.Sprintf("%s [%s]", creatorName, resJson.User)
+		pathInfo.CreatorName = creatorName
+		metadataCreator = creatorName
 	}
-
-	postFolderPath := iofuncs.GetPostFolder(
-		filepath.Join(dlOptions.Base.DownloadDirPath, resJson.Service),
-		creatorNamePath,
-		resJson.Id,
-		resJson.Title,
-	)
+	// uses the original "<service>/<creator ID>" folder if the creator name is unknown
+	postFolderPath := dlOptions.Base.GetPostFolder(pathInfo)
 
 	if dlOptions.Base.SetMetadata {
 		postMetadata := metadata.KemonoPost{
@@ -115,8 +131,10 @@ func processJson(resJson *MainKemonoJson, dlOptions *KemonoDlOptions) ([]*httpfu
 				Subject:     resJson.Embed.Subject,
 				Url:         resJson.Embed.Url,
 			},
+			Creator:   metadataCreator,
+			CreatorId: resJson.User,
 		}
-		if err := metadata.WriteMetadata(postMetadata, postFolderPath); err != nil {
+		if err := metadata.WriteMetadataWithOptions(postMetadata, postFolderPath, dlOptions.Base.MetadataOptions()); err != nil {
 			return nil, nil
 		}
 	}
@@ -124,14 +142,14 @@ func processJson(resJson *MainKemonoJson, dlOptions *KemonoDlOptions) ([]*httpfu
 	var gdriveLinks []*httpfuncs.ToDownload
 	var toDownload []*httpfuncs.ToDownload
 	if dlOptions.Base.DlAttachments {
-		toDownload = getInlineImages(resJson.Content, postFolderPath)
-		for _, attachment := range resJson.Attachments {
-			if !dlOptions.Base.Filters.IsFileNameValid(attachment.Name) || !dlOptions.Base.Filters.IsFilePathExtValid(attachment.Name) {
+		toDownload = getInlineImages(resJson.Content, postFolderPath, pathInfo, dlOptions)
+		for idx, attachment := range resJson.Attachments {
+			if !postFilters.IsFileNameValid(attachment.Name) || !postFilters.IsFilePathExtValid(attachment.Name) {
 				continue
 			}
 			toDownload = append(toDownload, &httpfuncs.ToDownload{
 				Url:      constants.KEMONO_URL + attachment.Path,
-				FilePath: getKemonoFilePath(postFolderPath, constants.KEMONO_CONTENT_FOLDER, attachment.Name),
+				FilePath: getKemonoFilePath(postFolderPath, constants.KEMONO_CONTENT_FOLDER, attachment.Name, idx+1, pathInfo, dlOptions),
 			})
 		}
 
@@ -149,11 +167,11 @@ func processJson(resJson *MainKemonoJson, dlOptions *KemonoDlOptions) ([]*httpfu
 		}
 
 		if resJson.File.Path != "" {
-			if dlOptions.Base.Filters.IsFileNameValid(resJson.File.Name) && dlOptions.Base.Filters.IsFilePathExtValid(resJson.File.Name) {
+			if postFilters.IsFileNameValid(resJson.File.Name) && postFilters.IsFilePathExtValid(resJson.File.Name) {
 				// usually is the thumbnail of the post
 				toDownload = append(toDownload, &httpfuncs.ToDownload{
 					Url:      constants.KEMONO_URL + resJson.File.Path,
-					FilePath: getKemonoFilePath(postFolderPath, "", resJson.File.Name),
+					FilePath: getKemonoFilePath(postFolderPath, "", resJson.File.Name, 0, pathInfo, dlOptions),
 				})
 			}
 		}
@@ -166,6 +184,13 @@ func processJson(resJson *MainKemonoJson, dlOptions *KemonoDlOptions) ([]*httpfu
 		dlOptions.Base.Configs.LogUrls,
 	)
 	gdriveLinks = append(gdriveLinks, contentGdriveLinks...)
+	httpfuncs.SetPostInfo(toDownload, filterInfo)
+	httpfuncs.SetPostInfo(gdriveLinks, filterInfo)
+	httpfuncs.SetFilters(toDownload, postFilters)
+	httpfuncs.SetFilters(gdriveLinks, postFilters)
+	source := dlOptions.Base.NewFileSource(pathInfo, getPostApiUrl(resJson.Service, resJson.User, resJson.Id))
+	httpfuncs.SetSource(toDownload, source)
+	httpfuncs.SetSource(gdriveLinks, source)
 	return toDownload, gdriveLinks
 }
 
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"github.com/KJHJason/Cultured-Downloader-Logic/constants"
+	"github.com/KJHJason/Cultured-Downloader-Logic/database"
+	"github.com/KJHJason/Cultured-Downloader-Logic/iofuncs"
+	"github.com/KJHJason/Cultured-Downloader-Logic/metadata"
+)
+
+// NewFileSource returns the source of the post's files for the download manifest
+// if UseCacheDb is enabled, otherwise nil so that the files will not be recorded.
+//
+// postUrl should be the URL that the post is cached under so that
+// the manifest entries have the same key as the post cache.
+func (b *BaseDl) NewFileSource(info *iofuncs.PathTemplateInfo, postUrl string) *database.FileSource {
+	if !b.UseCacheDb || b.Session.GetDb() == nil || info == nil {
+		return nil
+	}
+
+	site := b.site
+	if site == constants.PIXIV_MOBILE { // same posts as Pixiv web
+		site = constants.PIXIV
+	}
+
+	creator := info.CreatorId
+	if info.Service != "" { // Kemono
+		creator = info.Service + "/" + creator
+	}
+	return &database.FileSource{
+		Site:    site,
+		Creator: creator,
+		PostKey: database.ParsePostKey(postUrl, site),
+	}
+}
+
+// GetSearchIndex returns the database to add the post metadata to
+// if IndexMetadata and UseCacheDb are enabled, otherwise nil.
+func (b *BaseDl) GetSearchIndex() *database.DbWrapper {
+	if !b.IndexMetadata || !b.UseCacheDb {
+		return nil
+	}
+	return b.Session.GetDb()
+}
+
+// MetadataOptions returns the options to write the post metadata with.
+func (b *BaseDl) MetadataOptions() *metadata.WriteOptions {
+	return &metadata.WriteOptions{
+		SearchIndex: b.GetSearchIndex(),
+		Logger:      b.Session.GetLogger(),
+	}
+}
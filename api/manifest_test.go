@@ -0,0 +1,32 @@
+package api
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/KJHJason/Cultured-Downloader-Logic/constants"
+	"github.com/KJHJason/Cultured-Downloader-Logic/database"
+	"github.com/KJHJason/Cultured-Downloader-Logic/iofuncs"
+)
+
+func TestFileSourcePostKey(t *testing.T) {
+	db, err := database.NewDb(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create db: %v", err)
+	}
+	defer db.Close()
+
+	b := &BaseDl{site: constants.KEMONO, UseCacheDb: true, Session: NewSession(db, nil)}
+	postUrl := constants.KEMONO_API_URL + "/fanbox/user/123/post/1"
+	source := b.NewFileSource(&iofuncs.PathTemplateInfo{Service: "fanbox", CreatorId: "123", PostId: "1"}, postUrl)
+	if expected := database.ParsePostKey(postUrl, constants.KEMONO); source.PostKey != expected {
+		t.Errorf("Expected the post cache key %q but got %q", expected, source.PostKey)
+	}
+
+	// Kemono post IDs are only unique for the creator
+	otherUrl := constants.KEMONO_API_URL + "/patreon/user/456/post/1"
+	other := b.NewFileSource(&iofuncs.PathTemplateInfo{Service: "patreon", CreatorId: "456", PostId: "1"}, otherUrl)
+	if source.PostKey == other.PostKey {
+		t.Errorf("Expected different keys for the posts of different creators but got %q for both", source.PostKey)
+	}
+}
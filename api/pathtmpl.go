@@ -0,0 +1,274 @@
+package api
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/KJHJason/Cultured-Downloader-Logic/cdlerrors"
+	"github.com/KJHJason/Cultured-Downloader-Logic/constants"
+	"github.com/KJHJason/Cultured-Downloader-Logic/iofuncs"
+	"github.com/KJHJason/Cultured-Downloader-Logic/logger"
+)
+
+var commonPostFolderTmplFields = []string{
+	iofuncs.TMPL_SITE,
+	iofuncs.TMPL_CREATOR_NAME,
+	iofuncs.TMPL_CREATOR_ID,
+	iofuncs.TMPL_POST_ID,
+	iofuncs.TMPL_TITLE,
+	iofuncs.TMPL_DATE,
+}
+
+// Fields that can be used in the post folder template of each platform
+var postFolderTmplFields = map[string][]string{
+	constants.FANTIA:       commonPostFolderTmplFields,
+	constants.PIXIV_FANBOX: commonPostFolderTmplFields,
+	constants.PIXIV:        commonPostFolderTmplFields,
+	constants.PIXIV_MOBILE: commonPostFolderTmplFields,
+	constants.KEMONO:       append([]string{iofuncs.TMPL_SERVICE}, commonPostFolderTmplFields...),
+}
+
+// Default post folder templates which follows the original folder layout of each platform
+var defaultPostFolderTmpls = map[string]string{
+	constants.FANTIA:       "{creator_name}/[{post_id}] {title}",
+	constants.PIXIV_FANBOX: "{creator_id}/[{post_id}] {title}",
+	constants.PIXIV:        "{creator_name}/[{post_id}] {title}",
+	constants.PIXIV_MOBILE: "{creator_name}/[{post_id}] {title}",
+	constants.KEMONO:       "{service}/{creator_name} [{creator_id}]/[{post_id}] {title}",
+}
+
+// Default post folder templates for posts whose creator name could not be retrieved
+// which follows the original folder layout of using only the creator ID.
+var defaultNoCreatorFolderTmpls = map[string]string{
+	constants.KEMONO: "{service}/{creator_id}/[{post_id}] {title}",
+}
+
+// Platforms that save the files of a post in subfolders like "images" and "attachments"
+// where the index of the files restarts in each subfolder.
+var subfolderSites = []string{
+	constants.FANTIA,
+	constants.PIXIV_FANBOX,
+	constants.KEMONO,
+}
+
+// Returns the fields that can be used in the file name template of the platform
+func getFileNameTmplFields(site string) []string {
+	fields := append([]string{}, postFolderTmplFields[site]...)
+	fields = append(fields, iofuncs.TMPL_INDEX, iofuncs.TMPL_FILENAME, iofuncs.TMPL_EXT)
+	if slices.Contains(subfolderSites, site) {
+		fields = append(fields, iofuncs.TMPL_SUBFOLDER)
+	}
+	return fields
+}
+
+// ValidatePathTemplates parses the PostFolderTemplate and FileNameTemplate for the given site.
+//
+// If PostFolderTemplate is empty, the default template of the site will be used
+// which follows the original folder layout of "<creator>/[<post ID>] <title>".
+func (b *BaseDl) ValidatePathTemplates(site string) error {
+	fields, ok := postFolderTmplFields[site]
+	if !ok {
+		return fmt.Errorf(
+			"error %d: unsupported site %q for path templates",
+			cdlerrors.DEV_ERROR,
+			site,
+		)
+	}
+	b.site = site
+	if b.Sanitizer != nil {
+		if err := b.Sanitizer.ValidateArgs(); err != nil {
+			return err
+		}
+	}
+
+	b.noCreatorFolderTmpl = nil
+	if b.PostFolderTemplate == "" {
+		b.PostFolderTemplate = defaultPostFolderTmpls[site]
+		if tmpl, ok := defaultNoCreatorFolderTmpls[site]; ok {
+			noCreatorFolderTmpl, err := iofuncs.ParsePathTemplate(tmpl, fields)
+			if err != nil {
+				return err
+			}
+			b.noCreatorFolderTmpl = noCreatorFolderTmpl
+		}
+	}
+	postFolderTmpl, err := iofuncs.ParsePathTemplate(b.PostFolderTemplate, fields)
+	if err != nil {
+		return err
+	}
+	if !postFolderTmpl.HasField(iofuncs.TMPL_POST_ID) {
+		return fmt.Errorf(
+			"error %d: post folder template %q must contain {%s} to avoid mixing posts in the same folder",
+			cdlerrors.INPUT_ERROR,
+			b.PostFolderTemplate,
+			iofuncs.TMPL_POST_ID,
+		)
+	}
+	b.postFolderTmpl = postFolderTmpl
+
+	if b.FileNameTemplate == "" {
+		b.fileNameTmpl = nil
+		return nil
+	}
+	fileNameTmpl, err := iofuncs.ParsePathTemplate(b.FileNameTemplate, getFileNameTmplFields(site))
+	if err != nil {
+		return err
+	}
+	if !fileNameTmpl.HasField(iofuncs.TMPL_EXT) {
+		return fmt.Errorf(
+			"error %d: file name template %q must contain {%s}",
+			cdlerrors.INPUT_ERROR,
+			b.FileNameTemplate,
+			iofuncs.TMPL_EXT,
+		)
+	}
+	if !fileNameTmpl.HasField(iofuncs.TMPL_INDEX) && !fileNameTmpl.HasField(iofuncs.TMPL_FILENAME) {
+		return fmt.Errorf(
+			"error %d: file name template %q must contain either {%s} or {%s} to avoid overwriting files",
+			cdlerrors.INPUT_ERROR,
+			b.FileNameTemplate,
+			iofuncs.TMPL_INDEX,
+			iofuncs.TMPL_FILENAME,
+		)
+	}
+	// The index and the file names are only unique within a subfolder,
+	// e.g. an image and an attachment can both be the first file of the post.
+	if slices.Contains(subfolderSites, site) && !fileNameTmpl.HasField(iofuncs.TMPL_SUBFOLDER) {
+		return fmt.Errorf(
+			"error %d: file name template %q must contain {%s} for %s to avoid overwriting files in different subfolders",
+			cdlerrors.INPUT_ERROR,
+			b.FileNameTemplate,
+			iofuncs.TMPL_SUBFOLDER,
+			site,
+		)
+	}
+	b.fileNameTmpl = fileNameTmpl
+	return nil
+}
+
+// Returns the info with the creator ID as the creator name if the creator name is unknown
+func withCreatorNameFallback(info *iofuncs.PathTemplateInfo) *iofuncs.PathTemplateInfo {
+	if info.CreatorName != "" {
+		return info
+	}
+	fallbackInfo := *info
+	fallbackInfo.CreatorName = info.CreatorId
+	return &fallbackInfo
+}
+
+// GetPostFolder returns the directory path for a post based on the post folder template.
+//
+// If the creator name is empty, the creator ID will be used instead.
+func (b *BaseDl) GetPostFolder(info *iofuncs.PathTemplateInfo) string {
+	return b.getPostFolder(info, "")
+}
+
+// GetPostFolderInGroup is the same as GetPostFolder but the post folder is placed
+// in groupDir which is inserted before the directory containing the post ID in the template,
+// e.g. "<creator>/products/[<post ID>] <title>" for Fantia products.
+func (b *BaseDl) GetPostFolderInGroup(info *iofuncs.PathTemplateInfo, groupDir string) string {
+	return b.getPostFolder(info, groupDir)
+}
+
+func (b *BaseDl) getPostFolder(info *iofuncs.PathTemplateInfo, groupDir string) string {
+	if b.postFolderTmpl == nil {
+		// ValidatePathTemplates was not called, use the original folder layout.
+		info = withCreatorNameFallback(info)
+		postFolder := iofuncs.GetPostFolder(b.DownloadDirPath, info.CreatorName, info.PostId, info.Title)
+		if groupDir == "" {
+			return postFolder
+		}
+		postFolder = filepath.Clean(postFolder)
+		return iofuncs.AsDirPath(filepath.Join(filepath.Dir(postFolder), groupDir, filepath.Base(postFolder)))
+	}
+
+	info.Site = b.site
+	tmpl := b.postFolderTmpl
+	if info.CreatorName == "" && b.noCreatorFolderTmpl != nil {
+		tmpl = b.noCreatorFolderTmpl
+	}
+	if groupDir != "" {
+		groupTmpl, err := insertTmplDir(tmpl, groupDir, iofuncs.TMPL_POST_ID, postFolderTmplFields[b.site])
+		if err != nil {
+			// should not happen as the directory name is not from user input
+			b.Session.GetLogger().LogError(err, logger.ERROR)
+		} else {
+			tmpl = groupTmpl
+		}
+	}
+	return iofuncs.AsDirPath(
+		filepath.Join(b.DownloadDirPath, tmpl.Execute(withCreatorNameFallback(info), b.Sanitizer, false)),
+	)
+}
+
+// Returns a copy of the template with dirName inserted as a directory
+// before the directory that contains the given field.
+func insertTmplDir(tmpl *iofuncs.PathTemplate, dirName, field string, allowedFields []string) (*iofuncs.PathTemplate, error) {
+	raw := tmpl.String()
+	fieldIdx := strings.Index(raw, "{"+field)
+	if fieldIdx == -1 {
+		return nil, fmt.Errorf(
+			"error %d: field {%s} not found in the template %q",
+			cdlerrors.DEV_ERROR,
+			field,
+			raw,
+		)
+	}
+	dirIdx := strings.LastIndexByte(raw[:fieldIdx], '/') + 1
+	return iofuncs.ParsePathTemplate(raw[:dirIdx]+dirName+"/"+raw[dirIdx:], allowedFields)
+}
+
+// GetFilePath returns the file path of a file in a post by applying the file name template
+// to the given file name if the user has set one. The index should start from 1.
+//
+// subfolder is the folder of the file in the post folder like "images" and can be empty.
+// It replaces {subfolder} in the file name template which is required for the platforms with subfolders.
+//
+// If filename is empty, the subfolder path will be returned and the file name will be determined when downloading.
+func (b *BaseDl) GetFilePath(postFolderPath, subfolder, filename string, index int, info *iofuncs.PathTemplateInfo) string {
+	if filename == "" || b.fileNameTmpl == nil {
+		dirPath := filepath.Join(postFolderPath, subfolder)
+		if filename == "" {
+			return dirPath
+		}
+
+		sanitizer := b.Sanitizer
+		if sanitizer == nil {
+			sanitizer = iofuncs.DefaultPathSanitizer
+		}
+		return filepath.Join(dirPath, sanitizer.CleanFilename(filename))
+	}
+
+	fileInfo := *withCreatorNameFallback(info)
+	fileInfo.Site = b.site
+	fileInfo.Index = index
+	fileInfo.Ext = filepath.Ext(filename)
+	fileInfo.Filename = iofuncs.RemoveExtFromFilename(filename)
+	fileInfo.Subfolder = subfolder
+	return filepath.Join(postFolderPath, b.fileNameTmpl.Execute(&fileInfo, b.Sanitizer, true))
+}
+
+// GetFilePathFromUrl is the same as GetFilePath but for files whose names
+// are only known from the URL which would be determined when downloading.
+//
+// Returns the subfolder path if the user has not set a file name template.
+func (b *BaseDl) GetFilePathFromUrl(postFolderPath, subfolder, fileUrl string, index int, info *iofuncs.PathTemplateInfo) string {
+	if b.fileNameTmpl == nil {
+		return filepath.Join(postFolderPath, subfolder)
+	}
+
+	filename := filepath.Base(fileUrl)
+	if parsedUrl, err := url.Parse(fileUrl); err == nil {
+		filename = filepath.Base(parsedUrl.Path)
+		if unescaped, err := url.PathUnescape(filename); err == nil {
+			filename = unescaped
+		}
+	}
+	if filepath.Ext(filename) == "" {
+		return filepath.Join(postFolderPath, subfolder)
+	}
+	return b.GetFilePath(postFolderPath, subfolder, filename, index, info)
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/KJHJason/Cultured-Downloader-Logic/constants"
+	"github.com/KJHJason/Cultured-Downloader-Logic/iofuncs"
+)
+
+func newTestPathTmplBase(t *testing.T, site, postFolderTmpl, fileNameTmpl string) *BaseDl {
+	b := &BaseDl{
+		DownloadDirPath:    "dl",
+		PostFolderTemplate: postFolderTmpl,
+		FileNameTemplate:   fileNameTmpl,
+	}
+	if err := b.ValidatePathTemplates(site); err != nil {
+		t.Fatalf("Failed to validate the path templates: %v", err)
+	}
+	return b
+}
+
+func TestPostFolderInGroup(t *testing.T) {
+	info := &iofuncs.PathTemplateInfo{CreatorName: "creator", PostId: "1", Title: "title"}
+	testCases := map[string]string{
+		"":                                   filepath.Join("dl", "creator", "products", "[1] title"),
+		"{creator_name}/{post_id}/{title}":   filepath.Join("dl", "creator", "products", "1", "title"),
+		"[{post_id}] {creator_name} {title}": filepath.Join("dl", "products", "[1] creator title"),
+	}
+	for tmpl, expected := range testCases {
+		b := newTestPathTmplBase(t, constants.FANTIA, tmpl, "")
+		if got := b.GetPostFolderInGroup(info, constants.FANTIA_PRODUCT_DIR_NAME); got != iofuncs.AsDirPath(expected) {
+			t.Errorf("Expected %q for the template %q but got %q", expected, tmpl, got)
+		}
+	}
+}
+
+func TestPostFolderWithoutCreatorName(t *testing.T) {
+	info := &iofuncs.PathTemplateInfo{CreatorId: "123", PostId: "1", Title: "title", Service: "fanbox"}
+	b := newTestPathTmplBase(t, constants.KEMONO, "", "")
+	expected := iofuncs.AsDirPath(filepath.Join("dl", "fanbox", "123", "[1] title"))
+	if got := b.GetPostFolder(info); got != expected {
+		t.Errorf("Expected the original folder layout %q but got %q", expected, got)
+	}
+
+	// the creator ID is used as the creator name for the user's templates
+	b = newTestPathTmplBase(t, constants.KEMONO, "{creator_name}/{post_id}", "")
+	expected = iofuncs.AsDirPath(filepath.Join("dl", "123", "1"))
+	if got := b.GetPostFolder(info); got != expected {
+		t.Errorf("Expected %q but got %q", expected, got)
+	}
+}
+
+func TestFilePathSubfolder(t *testing.T) {
+	info := &iofuncs.PathTemplateInfo{PostId: "1"}
+	postFolderPath := filepath.Join("dl", "post")
+	testCases := []struct {
+		fileNameTmpl string
+		expected     []string // image and attachment paths
+	}{
+		{"", []string{
+			filepath.Join(postFolderPath, constants.IMAGES_FOLDER, "a.png"),
+			filepath.Join(postFolderPath, constants.ATTACHMENT_FOLDER, "a.png"),
+		}},
+		{"{subfolder}/{index}.{ext}", []string{
+			filepath.Join(postFolderPath, constants.IMAGES_FOLDER, "1.png"),
+			filepath.Join(postFolderPath, constants.ATTACHMENT_FOLDER, "1.png"),
+		}},
+	}
+	for _, tc := range testCases {
+		b := newTestPathTmplBase(t, constants.PIXIV_FANBOX, "", tc.fileNameTmpl)
+		// both files are the first file of their subfolder
+		imagePath := b.GetFilePath(postFolderPath, constants.IMAGES_FOLDER, "a.png", 1, info)
+		attachmentPath := b.GetFilePath(postFolderPath, constants.ATTACHMENT_FOLDER, "a.png", 1, info)
+		if imagePath == attachmentPath {
+			t.Errorf("Expected distinct paths for the template %q but got %q for both", tc.fileNameTmpl, imagePath)
+		}
+		if imagePath != tc.expected[0] || attachmentPath != tc.expected[1] {
+			t.Errorf("Expected %q for the template %q but got %q and %q", tc.expected, tc.fileNameTmpl, imagePath, attachmentPath)
+		}
+	}
+
+	// the files of different subfolders would overwrite each other without {subfolder}
+	for _, site := range []string{constants.FANTIA, constants.PIXIV_FANBOX, constants.KEMONO} {
+		b := &BaseDl{FileNameTemplate: "{index}_{filename}.{ext}"}
+		if err := b.ValidatePathTemplates(site); err == nil {
+			t.Errorf("Expected an error for %s without {subfolder} in the file name template", site)
+		}
+	}
+	b := &BaseDl{FileNameTemplate: "{index}_{filename}.{ext}"}
+	if err := b.ValidatePathTemplates(constants.PIXIV); err != nil {
+		t.Errorf("Expected no error for Pixiv which has no subfolders but got %v", err)
+	}
+}
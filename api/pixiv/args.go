@@ -1,11 +1,15 @@
 package pixiv
 
 import (
+	"fmt"
+
+	pixivcommon "github.com/KJHJason/Cultured-Downloader-Logic/api/pixiv/common"
+	"github.com/KJHJason/Cultured-Downloader-Logic/cdlerrors"
 	"github.com/KJHJason/Cultured-Downloader-Logic/utils"
 )
 
-// PixivDl contains the IDs of the Pixiv artworks and
-// illustrators and Tag Names to download.
+// PixivDl contains the IDs of the Pixiv artworks, manga series, novels, and
+// illustrators, Tag Names, bookmarks, rankings, and the follow feed to download.
 type PixivDl struct {
 	ArtworkIds []string
 
@@ -14,6 +18,26 @@ type PixivDl struct {
 
 	TagNames         []string
 	TagNamesPageNums []string
+
+	Bookmarks []*pixivcommon.Bookmarks
+
+	Rankings []*pixivcommon.Ranking
+
+	// New works from the followed artists, nil to skip
+	FollowFeed *pixivcommon.FollowFeed
+
+	// Manga series IDs like 12345 in https://www.pixiv.net/user/{userId}/series/12345
+	// where the chapters are saved in order in numbered folders in the series folder
+	MangaSeriesIds []string
+
+	// Export each chapter of the manga series as a CBZ file with a ComicInfo.xml
+	MangaSeriesCbz bool
+
+	// Novels are only supported by the web API, see ValidateForMobileApi
+	NovelIds            []string
+	NovelSeriesIds      []string
+	NovelArtistIds      []string
+	NovelArtistPageNums []string
 }
 
 // ValidateArgs validates the IDs of the Pixiv artworks and illustrators to download.
@@ -72,5 +96,68 @@ func (p *PixivDl) ValidateArgs() error {
 		p.TagNames,
 		p.TagNamesPageNums,
 	)
+
+	if err := utils.ValidateIds(p.MangaSeriesIds); err != nil {
+		return err
+	}
+	p.MangaSeriesIds = utils.RemoveDuplicatesFromSlice(p.MangaSeriesIds)
+
+	for _, ids := range [][]string{p.NovelIds, p.NovelSeriesIds, p.NovelArtistIds} {
+		if err := utils.ValidateIds(ids); err != nil {
+			return err
+		}
+	}
+	p.NovelIds = utils.RemoveDuplicatesFromSlice(p.NovelIds)
+	p.NovelSeriesIds = utils.RemoveDuplicatesFromSlice(p.NovelSeriesIds)
+	if len(p.NovelArtistPageNums) > 0 {
+		err = utils.ValidatePageNumInput(
+			len(p.NovelArtistIds),
+			p.NovelArtistPageNums,
+			[]string{
+				"Number of novel illustrators ID(s) and novel illustrators' page numbers must be equal.",
+			},
+		)
+		if err != nil {
+			return err
+		}
+	} else {
+		p.NovelArtistPageNums = make([]string, len(p.NovelArtistIds))
+	}
+	p.NovelArtistIds, p.NovelArtistPageNums = utils.RemoveDuplicateIdAndPageNum(
+		p.NovelArtistIds,
+		p.NovelArtistPageNums,
+	)
+
+	for _, bookmarks := range p.Bookmarks {
+		if err := bookmarks.Validate(); err != nil {
+			return err
+		}
+	}
+
+	for _, ranking := range p.Rankings {
+		if err := ranking.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if p.FollowFeed != nil {
+		if err := p.FollowFeed.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidateForMobileApi returns an error if there are targets that
+// the mobile API does not support instead of skipping them silently.
+//
+// Should be called in addition to ValidateArgs when downloading with the mobile API.
+func (p *PixivDl) ValidateForMobileApi() error {
+	if len(p.NovelIds) > 0 || len(p.NovelSeriesIds) > 0 || len(p.NovelArtistIds) > 0 {
+		return fmt.Errorf(
+			"pixiv mobile error %d: novels are only supported by the web API, please remove the novel, novel series and novel artist IDs",
+			cdlerrors.INPUT_ERROR,
+		)
+	}
 	return nil
 }
@@ -0,0 +1,18 @@
+package pixiv
+
+import "testing"
+
+func TestValidateForMobileApi(t *testing.T) {
+	if err := (&PixivDl{ArtworkIds: []string{"1"}}).ValidateForMobileApi(); err != nil {
+		t.Errorf("Expected artworks to be supported by the mobile API but got %v", err)
+	}
+	for _, p := range []*PixivDl{
+		{NovelIds: []string{"1"}},
+		{NovelSeriesIds: []string{"1"}},
+		{NovelArtistIds: []string{"1"}},
+	} {
+		if err := p.ValidateForMobileApi(); err == nil {
+			t.Errorf("Expected an error for the novel targets %+v with the mobile API", p)
+		}
+	}
+}
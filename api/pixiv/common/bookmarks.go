@@ -0,0 +1,84 @@
+package pixivcommon
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/KJHJason/Cultured-Downloader-Logic/cdlerrors"
+	"github.com/KJHJason/Cultured-Downloader-Logic/constants"
+	"github.com/KJHJason/Cultured-Downloader-Logic/utils"
+)
+
+const (
+	BOOKMARKS_PUBLIC  = "public"
+	BOOKMARKS_PRIVATE = "private"
+	BOOKMARKS_ALL     = "all"
+)
+
+// Bookmarks contains the arguments to download the bookmarked artworks of a Pixiv user.
+type Bookmarks struct {
+	// The ID of the user whose bookmarks to download.
+	// Leave empty to download the bookmarks of the logged in user.
+	UserId string
+
+	// Can be "public", "private", or "all" where the private bookmarks
+	// can only be retrieved for the logged in user. Defaults to "public".
+	Restrict string
+
+	// Only download the bookmarks with this bookmark tag, e.g. "未分類" for the uncategorised bookmarks.
+	// Leave empty to download all the bookmarks.
+	Tag string
+
+	// Page numbers of the bookmarks like "1-10", leave empty to download all the pages.
+	PageNum string
+}
+
+// Validate validates the arguments of the bookmarks to download.
+func (b *Bookmarks) Validate() error {
+	if b.UserId != "" {
+		if err := utils.ValidateId(b.UserId); err != nil {
+			return err
+		}
+	}
+
+	if b.Restrict == "" {
+		b.Restrict = BOOKMARKS_PUBLIC
+	}
+	b.Restrict = strings.ToLower(b.Restrict)
+	_, err := utils.ValidateStrArgs(
+		b.Restrict,
+		constants.ACCEPTED_BOOKMARK_RESTRICT,
+		[]string{
+			fmt.Sprintf(
+				"pixiv error %d: Bookmark restrict %s is not allowed",
+				cdlerrors.INPUT_ERROR,
+				b.Restrict,
+			),
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	if b.PageNum != "" {
+		return utils.ValidatePageNumInput(1, []string{b.PageNum}, nil)
+	}
+	return nil
+}
+
+// Restricts returns the visibilities of the bookmarks to download, "public" and/or "private".
+func (b *Bookmarks) Restricts() []string {
+	if b.Restrict == BOOKMARKS_ALL {
+		return []string{BOOKMARKS_PUBLIC, BOOKMARKS_PRIVATE}
+	}
+	return []string{b.Restrict}
+}
+
+// Description returns a readable description of the bookmarks for logs and errors.
+func (b *Bookmarks) Description(userId, restrict string) string {
+	desc := fmt.Sprintf("%s bookmarks of user %s", restrict, userId)
+	if b.Tag != "" {
+		desc += fmt.Sprintf(" with the tag %q", b.Tag)
+	}
+	return desc
+}
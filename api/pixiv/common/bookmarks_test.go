@@ -0,0 +1,35 @@
+package pixivcommon
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestBookmarksValidate(t *testing.T) {
+	bookmarks := &Bookmarks{}
+	if err := bookmarks.Validate(); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if !slices.Equal(bookmarks.Restricts(), []string{BOOKMARKS_PUBLIC}) {
+		t.Errorf("Expected the public bookmarks by default but got %v", bookmarks.Restricts())
+	}
+
+	bookmarks = &Bookmarks{UserId: "1234567", Restrict: "ALL", PageNum: "1-3"}
+	if err := bookmarks.Validate(); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if !slices.Equal(bookmarks.Restricts(), []string{BOOKMARKS_PUBLIC, BOOKMARKS_PRIVATE}) {
+		t.Errorf("Expected both public and private bookmarks but got %v", bookmarks.Restricts())
+	}
+
+	invalid := []*Bookmarks{
+		{UserId: "user"},
+		{Restrict: "hidden"},
+		{PageNum: "0-2"},
+	}
+	for _, b := range invalid {
+		if err := b.Validate(); err == nil {
+			t.Errorf("Expected an error for %+v", b)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package pixivcommon
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/KJHJason/Cultured-Downloader-Logic/cdlerrors"
 	"github.com/KJHJason/Cultured-Downloader-Logic/constants"
@@ -22,9 +23,115 @@ type PixivFilters struct {
 	SearchAiMode int
 	RatingMode   string
 	ArtworkType  string
+
+	// Date range of the search results in the format of YYYY-MM-DD, leave empty for no limit.
+	StartDate string
+	EndDate   string
+
+	// Tags to exclude from the search results, added as "-tag" to the search word.
+	ExcludedTags []string
+
+	// Groups of tags where the results must match at least one tag in each group,
+	// added as "(a OR b)" to the search word.
+	OrTagGroups [][]string
+
+	// Minimum bookmarks and likes of the search results, 0 for no limit.
+	//
+	// As the web API search results do not include the counts, they are checked with the artwork details.
+	// The mobile API does not return the likes so MinLikes is ignored for the mobile API.
+	MinBookmarks int
+	MinLikes     int
+
+	// Minimum and maximum number of pages of the search results, 0 for no limit.
+	MinPages int
+	MaxPages int
+}
+
+const SEARCH_DATE_LAYOUT = "2006-01-02"
+
+// Returns the tags without the surrounding whitespace and empty tags
+func cleanTags(tags []string) []string {
+	cleaned := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			cleaned = append(cleaned, tag)
+		}
+	}
+	return cleaned
+}
+
+// Parses the search date which can be empty for no limit
+func parseSearchDate(site, name, date string) (time.Time, error) {
+	if date == "" {
+		return time.Time{}, nil
+	}
+	parsedDate, err := time.Parse(SEARCH_DATE_LAYOUT, date)
+	if err != nil {
+		return time.Time{}, fmt.Errorf(
+			"%s error %d: Search %s %q must be in the format of YYYY-MM-DD",
+			site,
+			cdlerrors.INPUT_ERROR,
+			name,
+			date,
+		)
+	}
+	return parsedDate, nil
+}
+
+// Validates the search filters that are not part of the API's accepted values
+// like the date range and the thresholds.
+func (p *PixivFilters) validateSearchFilters(site string) error {
+	startDate, err := parseSearchDate(site, "start date", p.StartDate)
+	if err != nil {
+		return err
+	}
+	endDate, err := parseSearchDate(site, "end date", p.EndDate)
+	if err != nil {
+		return err
+	}
+	if p.StartDate != "" && p.EndDate != "" && startDate.After(endDate) {
+		return fmt.Errorf(
+			"%s error %d: Search start date %q cannot be after the end date %q",
+			site,
+			cdlerrors.INPUT_ERROR,
+			p.StartDate,
+			p.EndDate,
+		)
+	}
+
+	if p.MinBookmarks < 0 || p.MinLikes < 0 || p.MinPages < 0 || p.MaxPages < 0 {
+		return fmt.Errorf(
+			"%s error %d: Minimum bookmarks, likes, and page limits cannot be negative",
+			site,
+			cdlerrors.INPUT_ERROR,
+		)
+	}
+	if p.MaxPages > 0 && p.MinPages > p.MaxPages {
+		return fmt.Errorf(
+			"%s error %d: Minimum pages %d cannot be more than the maximum pages %d",
+			site,
+			cdlerrors.INPUT_ERROR,
+			p.MinPages,
+			p.MaxPages,
+		)
+	}
+
+	p.ExcludedTags = cleanTags(p.ExcludedTags)
+	orTagGroups := make([][]string, 0, len(p.OrTagGroups))
+	for _, group := range p.OrTagGroups {
+		if group = cleanTags(group); len(group) > 0 {
+			orTagGroups = append(orTagGroups, group)
+		}
+	}
+	p.OrTagGroups = orTagGroups
+	return nil
 }
 
 func (p *PixivFilters) ValidateForMobileApi(userIsPremium bool) error {
+	if err := p.validateSearchFilters("pixiv mobile"); err != nil {
+		return err
+	}
+
 	p.SortOrder = strings.ToLower(p.SortOrder)
 	_, err := utils.ValidateStrArgs(
 		p.SortOrder,
@@ -157,6 +264,10 @@ func (p *PixivFilters) ValidateForWebApi() error {
 		p.SearchAiMode = 1 // Default to filter AI works
 	}
 
+	if err := p.validateSearchFilters("pixiv web"); err != nil {
+		return err
+	}
+
 	p.SortOrder = strings.ToLower(p.SortOrder)
 	_, err := utils.ValidateStrArgs(
 		p.SortOrder,
@@ -222,3 +333,79 @@ func (p *PixivFilters) ValidateForWebApi() error {
 	}
 	return nil
 }
+
+// IsArtworkTypeValid returns true if the artwork type, "illust", "manga", or "ugoira",
+// matches the ArtworkType filter for results that cannot be filtered by the API like the rankings.
+func (p *PixivFilters) IsArtworkTypeValid(artworkType string) bool {
+	switch p.ArtworkType {
+	case "manga":
+		return artworkType == "manga"
+	case "illust_and_ugoira", "illust": // "illust" after ValidateForMobileApi
+		return artworkType != "manga"
+	default:
+		return true
+	}
+}
+
+// IsRatingValid returns true if the artwork matches the RatingMode filter
+// for results that cannot be filtered by the API like the rankings.
+func (p *PixivFilters) IsRatingValid(isR18 bool) bool {
+	switch p.RatingMode {
+	case "safe":
+		return !isR18
+	case "r18":
+		return isR18
+	default:
+		return true
+	}
+}
+
+// SearchWord returns the search word with the OR groups and the excluded tags
+// like "tag (a OR b) -c" which are supported by both the web and mobile API.
+func (p *PixivFilters) SearchWord(tagName string) string {
+	words := []string{tagName}
+	for _, group := range p.OrTagGroups {
+		if len(group) == 1 {
+			words = append(words, group[0])
+			continue
+		}
+		words = append(words, "("+strings.Join(group, " OR ")+")")
+	}
+	for _, tag := range p.ExcludedTags {
+		words = append(words, "-"+tag)
+	}
+	return strings.Join(words, " ")
+}
+
+// IsTagsValid returns false if the artwork has any of the excluded tags.
+//
+// The excluded tags are already part of the search word but partial
+// matches by the search mode may still return artworks with the excluded tags.
+func (p *PixivFilters) IsTagsValid(tags []string) bool {
+	for _, excludedTag := range p.ExcludedTags {
+		for _, tag := range tags {
+			if strings.EqualFold(tag, excludedTag) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// IsPageCountValid returns true if the number of pages of the artwork is within the page limits.
+func (p *PixivFilters) IsPageCountValid(pageCount int) bool {
+	return pageCount >= p.MinPages && (p.MaxPages == 0 || pageCount <= p.MaxPages)
+}
+
+// HasPopularityFilter returns true if the results have to be checked with IsPopularityValid.
+func (p *PixivFilters) HasPopularityFilter() bool {
+	return p.MinBookmarks > 0 || p.MinLikes > 0
+}
+
+// IsPopularityValid returns true if the artwork has at least the minimum bookmarks and likes.
+//
+// Use a negative value for counts that are not returned by the API to skip the check.
+func (p *PixivFilters) IsPopularityValid(bookmarks, likes int) bool {
+	return (bookmarks < 0 || bookmarks >= p.MinBookmarks) &&
+		(likes < 0 || likes >= p.MinLikes)
+}
@@ -0,0 +1,98 @@
+package pixivcommon
+
+import "testing"
+
+func newTestSearchFilters() *PixivFilters {
+	return &PixivFilters{
+		SortOrder:   "date_d",
+		SearchMode:  "s_tag",
+		RatingMode:  "all",
+		ArtworkType: "all",
+	}
+}
+
+func TestValidateSearchFilters(t *testing.T) {
+	filters := newTestSearchFilters()
+	filters.StartDate = "2024-01-01"
+	filters.EndDate = "2024-01-31"
+	filters.ExcludedTags = []string{" R-18 ", ""}
+	filters.OrTagGroups = [][]string{{"cat", " dog"}, {" "}}
+	filters.MinPages = 2
+	filters.MaxPages = 2
+	if err := filters.ValidateForWebApi(); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if len(filters.ExcludedTags) != 1 || filters.ExcludedTags[0] != "R-18" {
+		t.Errorf("Unexpected excluded tags %q", filters.ExcludedTags)
+	}
+	if len(filters.OrTagGroups) != 1 || len(filters.OrTagGroups[0]) != 2 || filters.OrTagGroups[0][1] != "dog" {
+		t.Errorf("Unexpected OR groups %q", filters.OrTagGroups)
+	}
+
+	invalidFilters := []func(*PixivFilters){
+		func(p *PixivFilters) { p.StartDate = "20240101" },
+		func(p *PixivFilters) { p.EndDate = "2024-13-01" },
+		func(p *PixivFilters) { p.StartDate, p.EndDate = "2024-02-01", "2024-01-01" },
+		func(p *PixivFilters) { p.MinBookmarks = -1 },
+		func(p *PixivFilters) { p.MinPages, p.MaxPages = 3, 2 },
+	}
+	for idx, modify := range invalidFilters {
+		filters := newTestSearchFilters()
+		modify(filters)
+		if err := filters.ValidateForWebApi(); err == nil {
+			t.Errorf("Expected an error for the web API with invalid filters %d: %+v", idx, filters)
+		}
+
+		filters = newTestSearchFilters()
+		modify(filters)
+		if err := filters.ValidateForMobileApi(false); err == nil {
+			t.Errorf("Expected an error for the mobile API with invalid filters %d: %+v", idx, filters)
+		}
+	}
+}
+
+func TestSearchWord(t *testing.T) {
+	filters := &PixivFilters{
+		ExcludedTags: []string{"R-18", "AI"},
+		OrTagGroups:  [][]string{{"cat", "dog"}, {"original"}},
+	}
+	expected := "animal (cat OR dog) original -R-18 -AI"
+	if word := filters.SearchWord("animal"); word != expected {
+		t.Errorf("Expected %q but got %q", expected, word)
+	}
+
+	filters = &PixivFilters{}
+	if word := filters.SearchWord("animal"); word != "animal" {
+		t.Errorf("Expected the tag name only but got %q", word)
+	}
+}
+
+func TestSearchFiltersClientSide(t *testing.T) {
+	filters := &PixivFilters{
+		ExcludedTags: []string{"AI"},
+		MinBookmarks: 100,
+		MinLikes:     50,
+		MinPages:     2,
+		MaxPages:     5,
+	}
+	if filters.IsTagsValid([]string{"cat", "ai"}) || !filters.IsTagsValid([]string{"cat", "AIR"}) {
+		t.Errorf("Expected only artworks without the excluded tags to be valid")
+	}
+	if filters.IsPageCountValid(1) || !filters.IsPageCountValid(2) || !filters.IsPageCountValid(5) || filters.IsPageCountValid(6) {
+		t.Errorf("Expected only artworks with 2 to 5 pages to be valid")
+	}
+	if !filters.HasPopularityFilter() {
+		t.Errorf("Expected the popularity filter to be enabled")
+	}
+	if !filters.IsPopularityValid(100, 50) || filters.IsPopularityValid(99, 50) || filters.IsPopularityValid(100, 49) {
+		t.Errorf("Expected only artworks with at least 100 bookmarks and 50 likes to be valid")
+	}
+	if !filters.IsPopularityValid(100, -1) {
+		t.Errorf("Expected unknown likes to be skipped")
+	}
+
+	filters = &PixivFilters{}
+	if filters.HasPopularityFilter() || !filters.IsPageCountValid(100) || !filters.IsPopularityValid(0, 0) {
+		t.Errorf("Expected all artworks to be valid without the limits")
+	}
+}
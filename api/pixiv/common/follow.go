@@ -0,0 +1,57 @@
+package pixivcommon
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/KJHJason/Cultured-Downloader-Logic/cdlerrors"
+	"github.com/KJHJason/Cultured-Downloader-Logic/constants"
+	"github.com/KJHJason/Cultured-Downloader-Logic/utils"
+)
+
+// Prefix of the incremental sync watermark key of the follow feed
+// which is suffixed with the restrict or mode of the feed.
+const FOLLOW_FEED_WATERMARK_PREFIX = "follow_feed/"
+
+// FollowFeed contains the arguments to download the new works
+// from the artists followed by the logged in user.
+//
+// If IncrementalSync and UseCacheDb are enabled, the feed stops at
+// the newest artwork from the previous sync of the same feed.
+type FollowFeed struct {
+	// Mobile API only, can be "public", "private", or "all"
+	// for the artists followed publicly, privately, or both. Defaults to "all".
+	//
+	// The web API always returns the works of all the followed artists.
+	Restrict string
+
+	// Page numbers of the feed like "1-5", leave empty to download all the pages.
+	PageNum string
+}
+
+// Validate validates the arguments of the follow feed to download.
+func (f *FollowFeed) Validate() error {
+	if f.Restrict == "" {
+		f.Restrict = BOOKMARKS_ALL
+	}
+	f.Restrict = strings.ToLower(f.Restrict)
+	_, err := utils.ValidateStrArgs(
+		f.Restrict,
+		constants.ACCEPTED_BOOKMARK_RESTRICT,
+		[]string{
+			fmt.Sprintf(
+				"pixiv error %d: Follow feed restrict %s is not allowed",
+				cdlerrors.INPUT_ERROR,
+				f.Restrict,
+			),
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	if f.PageNum != "" {
+		return utils.ValidatePageNumInput(1, []string{f.PageNum}, nil)
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+package pixivcommon
+
+import "testing"
+
+func TestFollowFeedValidate(t *testing.T) {
+	feed := &FollowFeed{}
+	if err := feed.Validate(); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if feed.Restrict != BOOKMARKS_ALL {
+		t.Errorf("Expected the restrict to default to %q but got %q", BOOKMARKS_ALL, feed.Restrict)
+	}
+
+	if err := (&FollowFeed{Restrict: "Private", PageNum: "1-5"}).Validate(); err != nil {
+		t.Errorf("Expected no error but got %v", err)
+	}
+	if err := (&FollowFeed{Restrict: "friends"}).Validate(); err == nil {
+		t.Errorf("Expected an error for an invalid restrict")
+	}
+	if err := (&FollowFeed{PageNum: "a"}).Validate(); err == nil {
+		t.Errorf("Expected an error for an invalid page number")
+	}
+}
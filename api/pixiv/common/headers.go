@@ -31,3 +31,31 @@ func GetUserUrl(userId string) string {
 		userId,
 	)
 }
+
+// Get the Pixiv novel page URL which is also used as the cache key of the novel
+func GetNovelUrl(novelId string) string {
+	return fmt.Sprintf(
+		"%s/novel/show.php?id=%s",
+		constants.PIXIV_URL,
+		novelId,
+	)
+}
+
+// Get the Pixiv novel series page URL for the referral header value
+func GetNovelSeriesUrl(seriesId string) string {
+	return fmt.Sprintf(
+		"%s/novel/series/%s",
+		constants.PIXIV_URL,
+		seriesId,
+	)
+}
+
+// Get the Pixiv manga series page URL
+func GetMangaSeriesUrl(userId, seriesId string) string {
+	return fmt.Sprintf(
+		"%s/user/%s/series/%s",
+		constants.PIXIV_URL,
+		userId,
+		seriesId,
+	)
+}
@@ -0,0 +1,166 @@
+package pixivcommon
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/KJHJason/Cultured-Downloader-Logic/cdlerrors"
+	"github.com/KJHJason/Cultured-Downloader-Logic/constants"
+	"github.com/KJHJason/Cultured-Downloader-Logic/utils"
+)
+
+const (
+	RANKING_CONTENT_ALL    = "all"
+	RANKING_CONTENT_ILLUST = "illust"
+	RANKING_CONTENT_MANGA  = "manga"
+	RANKING_CONTENT_UGOIRA = "ugoira"
+
+	RANKING_DATE_LAYOUT = "2006-01-02"
+)
+
+// The mobile API equivalent of the web API ranking modes
+var mobileRankingModes = map[string]string{
+	"daily":        "day",
+	"weekly":       "week",
+	"monthly":      "month",
+	"rookie":       "week_rookie",
+	"original":     "week_original",
+	"daily_ai":     "day_ai",
+	"male":         "day_male",
+	"female":       "day_female",
+	"daily_r18":    "day_r18",
+	"weekly_r18":   "week_r18",
+	"daily_r18_ai": "day_r18_ai",
+	"male_r18":     "day_male_r18",
+	"female_r18":   "day_female_r18",
+	"r18g":         "week_r18g",
+}
+
+// The mobile API has separate modes for the manga rankings
+var mobileMangaRankingModes = map[string]string{
+	"daily":   "day_manga",
+	"weekly":  "week_manga",
+	"monthly": "month_manga",
+	"rookie":  "week_rookie_manga",
+}
+
+// Ranking contains the arguments to download the artworks in a Pixiv ranking.
+type Ranking struct {
+	// Can be "daily", "weekly", "monthly", "rookie", "original", "daily_ai", "male", "female",
+	// or the R-18 modes, "daily_r18", "weekly_r18", "daily_r18_ai", "male_r18", "female_r18", and "r18g".
+	Mode string
+
+	// Date of the ranking in the format of YYYY-MM-DD, leave empty for the latest ranking.
+	Date string
+
+	// Can be "all", "illust", "manga", or "ugoira". Defaults to "all".
+	//
+	// Not all modes support all the content types, e.g. the "original" ranking is only for "all".
+	Content string
+
+	// Page numbers of the ranking like "1-2", leave empty to download all the pages.
+	PageNum string
+}
+
+// Validate validates the arguments of the ranking to download.
+func (r *Ranking) Validate() error {
+	r.Mode = strings.ToLower(r.Mode)
+	_, err := utils.ValidateStrArgs(
+		r.Mode,
+		constants.ACCEPTED_RANKING_MODE,
+		[]string{
+			fmt.Sprintf(
+				"pixiv error %d: Ranking mode %s is not allowed",
+				cdlerrors.INPUT_ERROR,
+				r.Mode,
+			),
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	if r.Content == "" {
+		r.Content = RANKING_CONTENT_ALL
+	}
+	r.Content = strings.ToLower(r.Content)
+	_, err = utils.ValidateStrArgs(
+		r.Content,
+		constants.ACCEPTED_RANKING_CONTENT,
+		[]string{
+			fmt.Sprintf(
+				"pixiv error %d: Ranking content %s is not allowed",
+				cdlerrors.INPUT_ERROR,
+				r.Content,
+			),
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	if r.Date != "" {
+		date, err := time.Parse(RANKING_DATE_LAYOUT, r.Date)
+		if err != nil {
+			return fmt.Errorf(
+				"pixiv error %d: Ranking date %q must be in the format of YYYY-MM-DD",
+				cdlerrors.INPUT_ERROR,
+				r.Date,
+			)
+		}
+		if date.After(time.Now()) {
+			return fmt.Errorf(
+				"pixiv error %d: Ranking date %q cannot be in the future",
+				cdlerrors.INPUT_ERROR,
+				r.Date,
+			)
+		}
+	}
+
+	if r.PageNum != "" {
+		return utils.ValidatePageNumInput(1, []string{r.PageNum}, nil)
+	}
+	return nil
+}
+
+// IsR18 returns true if the ranking only contains R-18 or R-18G artworks.
+func (r *Ranking) IsR18() bool {
+	return strings.Contains(r.Mode, "r18")
+}
+
+// WebDate returns the date in the format used by the web API, YYYYMMDD.
+func (r *Ranking) WebDate() string {
+	return strings.ReplaceAll(r.Date, "-", "")
+}
+
+// MobileMode returns the mode used by the mobile API
+// and whether the content type is supported by the mode.
+//
+// If the content type is not supported, the results should be filtered by the content type instead.
+func (r *Ranking) MobileMode() (string, bool) {
+	switch r.Content {
+	case RANKING_CONTENT_ALL:
+		return mobileRankingModes[r.Mode], true
+	case RANKING_CONTENT_MANGA:
+		if mode, ok := mobileMangaRankingModes[r.Mode]; ok {
+			return mode, true
+		}
+	}
+	return mobileRankingModes[r.Mode], false
+}
+
+// IsContentValid returns true if the artwork type, "illust", "manga", or "ugoira",
+// is part of the content type of the ranking.
+func (r *Ranking) IsContentValid(artworkType string) bool {
+	return r.Content == RANKING_CONTENT_ALL || r.Content == artworkType
+}
+
+// Description returns a readable description of the ranking for logs and errors.
+func (r *Ranking) Description() string {
+	date := r.Date
+	if date == "" {
+		date = "latest"
+	}
+	return fmt.Sprintf("%s %s ranking (%s)", r.Mode, r.Content, date)
+}
@@ -0,0 +1,70 @@
+package pixivcommon
+
+import "testing"
+
+func TestRankingValidate(t *testing.T) {
+	ranking := &Ranking{Mode: "Daily_R18", Date: "2024-01-02"}
+	if err := ranking.Validate(); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if ranking.Mode != "daily_r18" || ranking.Content != RANKING_CONTENT_ALL {
+		t.Errorf("Unexpected mode %q or content %q", ranking.Mode, ranking.Content)
+	}
+	if !ranking.IsR18() || ranking.WebDate() != "20240102" {
+		t.Errorf("Expected an R-18 ranking with the web date 20240102 but got %q", ranking.WebDate())
+	}
+
+	invalidRankings := []*Ranking{
+		{Mode: "yearly"},
+		{Mode: "daily", Content: "novel"},
+		{Mode: "daily", Date: "20240102"},
+		{Mode: "daily", Date: "2999-01-01"},
+		{Mode: "daily", PageNum: "a"},
+	}
+	for _, ranking := range invalidRankings {
+		if err := ranking.Validate(); err == nil {
+			t.Errorf("Expected an error for %+v", ranking)
+		}
+	}
+}
+
+func TestRankingMobileMode(t *testing.T) {
+	tests := []struct {
+		mode      string
+		content   string
+		expected  string
+		supported bool
+	}{
+		{"daily", RANKING_CONTENT_ALL, "day", true},
+		{"weekly", RANKING_CONTENT_MANGA, "week_manga", true},
+		{"original", RANKING_CONTENT_MANGA, "week_original", false},
+		{"daily_r18", RANKING_CONTENT_UGOIRA, "day_r18", false},
+	}
+	for _, test := range tests {
+		ranking := &Ranking{Mode: test.mode, Content: test.content}
+		mode, supported := ranking.MobileMode()
+		if mode != test.expected || supported != test.supported {
+			t.Errorf("Expected (%q, %v) for %+v but got (%q, %v)", test.expected, test.supported, ranking, mode, supported)
+		}
+	}
+
+	ranking := &Ranking{Mode: "daily", Content: RANKING_CONTENT_UGOIRA}
+	if ranking.IsContentValid("illust") || !ranking.IsContentValid("ugoira") {
+		t.Errorf("Expected only ugoira to be valid for %+v", ranking)
+	}
+}
+
+func TestPixivFiltersClientSide(t *testing.T) {
+	filters := &PixivFilters{ArtworkType: "illust_and_ugoira", RatingMode: "safe"}
+	if !filters.IsArtworkTypeValid("ugoira") || filters.IsArtworkTypeValid("manga") {
+		t.Errorf("Expected only illustrations and ugoira to be valid")
+	}
+	if !filters.IsRatingValid(false) || filters.IsRatingValid(true) {
+		t.Errorf("Expected only all-ages artworks to be valid")
+	}
+
+	filters = &PixivFilters{ArtworkType: "all", RatingMode: "all"}
+	if !filters.IsArtworkTypeValid("manga") || !filters.IsRatingValid(true) {
+		t.Errorf("Expected all artworks to be valid")
+	}
+}
@@ -1,9 +1,18 @@
 package pixivcommon
 
 import (
+	"html"
+	"regexp"
+	"strings"
+
 	"github.com/KJHJason/Cultured-Downloader-Logic/utils"
 )
 
+var (
+	htmlBrRegex  = regexp.MustCompile(`(?i)<br\s*/?>`)
+	htmlTagRegex = regexp.MustCompile(`<[^>]*>`)
+)
+
 // Convert the page number to the offset as one page will have 60 illustrations.
 //
 // Usually for paginated results from Pixiv's mobile API, checkPixivMax should be set to true.
@@ -24,3 +33,24 @@ func ConvertPageNumToOffset(minPageNum, maxPageNum, perPage int, checkPixivMax b
 	}
 	return minOffset, maxOffset
 }
+
+// GetReadableArtworkType returns the readable artwork type of "illust", "manga", or "ugoira" for the metadata.
+func GetReadableArtworkType(artworkType string) string {
+	switch artworkType {
+	case "illust":
+		return "Illustration"
+	case "manga":
+		return "Manga"
+	case "ugoira":
+		return "Ugoira"
+	default:
+		return "Unknown"
+	}
+}
+
+// HtmlToText converts the HTML of the captions and descriptions to plain text.
+func HtmlToText(content string) string {
+	content = htmlBrRegex.ReplaceAllString(content, "\n")
+	content = htmlTagRegex.ReplaceAllString(content, "")
+	return strings.TrimSpace(html.UnescapeString(content))
+}
@@ -0,0 +1,232 @@
+package manga
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/KJHJason/Cultured-Downloader-Logic/cdlerrors"
+	"github.com/KJHJason/Cultured-Downloader-Logic/constants"
+)
+
+const COMIC_INFO_FILENAME = "ComicInfo.xml"
+
+var imageExts = []string{".jpg", ".jpeg", ".png", ".gif", ".webp"}
+
+// ComicInfo is the ComicRack metadata schema supported by most comic readers.
+//
+// https://anansi-project.github.io/docs/comicinfo/schemas/v2.0
+type ComicInfo struct {
+	XMLName   xml.Name `xml:"ComicInfo"`
+	Title     string   `xml:"Title,omitempty"`
+	Series    string   `xml:"Series,omitempty"`
+	Number    int      `xml:"Number,omitempty"`
+	Count     int      `xml:"Count,omitempty"`
+	Summary   string   `xml:"Summary,omitempty"`
+	Year      int      `xml:"Year,omitempty"`
+	Month     int      `xml:"Month,omitempty"`
+	Day       int      `xml:"Day,omitempty"`
+	Writer    string   `xml:"Writer,omitempty"`
+	Tags      string   `xml:"Tags,omitempty"`
+	Web       string   `xml:"Web,omitempty"`
+	PageCount int      `xml:"PageCount,omitempty"`
+	Manga     string   `xml:"Manga,omitempty"`
+	AgeRating string   `xml:"AgeRating,omitempty"`
+}
+
+// NewComicInfo returns the ComicInfo of the chapter in the series.
+func NewComicInfo(series *Series, chapter *Chapter, pageCount int) *ComicInfo {
+	info := &ComicInfo{
+		Title:     chapter.Title,
+		Series:    series.Title,
+		Number:    chapter.Number,
+		Count:     len(series.Chapters),
+		Summary:   series.Description,
+		Writer:    series.Creator,
+		Tags:      strings.Join(chapter.Tags, ","),
+		Web:       chapter.Url,
+		PageCount: pageCount,
+		Manga:     "Yes",
+	}
+	if !chapter.Date.IsZero() {
+		info.Year = chapter.Date.Year()
+		info.Month = int(chapter.Date.Month())
+		info.Day = chapter.Date.Day()
+	}
+	if chapter.IsR18 {
+		info.AgeRating = "R18+"
+	}
+	return info
+}
+
+// Compares the file names with the numbers compared by their values
+// so that "p2.jpg" is before "p10.jpg".
+func compareNatural(a, b string) int {
+	for a != "" && b != "" {
+		aIsDigit, bIsDigit := unicode.IsDigit(rune(a[0])), unicode.IsDigit(rune(b[0]))
+		if aIsDigit && bIsDigit {
+			aEnd := strings.IndexFunc(a, func(r rune) bool { return !unicode.IsDigit(r) })
+			if aEnd == -1 {
+				aEnd = len(a)
+			}
+			bEnd := strings.IndexFunc(b, func(r rune) bool { return !unicode.IsDigit(r) })
+			if bEnd == -1 {
+				bEnd = len(b)
+			}
+			aNum, bNum := strings.TrimLeft(a[:aEnd], "0"), strings.TrimLeft(b[:bEnd], "0")
+			if len(aNum) != len(bNum) {
+				return len(aNum) - len(bNum)
+			}
+			if cmp := strings.Compare(aNum, bNum); cmp != 0 {
+				return cmp
+			}
+			a, b = a[aEnd:], b[bEnd:]
+			continue
+		}
+		if a[0] != b[0] {
+			return int(a[0]) - int(b[0])
+		}
+		a, b = a[1:], b[1:]
+	}
+	return len(a) - len(b)
+}
+
+// GetChapterImages returns the paths of the images in the chapter folder in the order of the pages.
+func GetChapterImages(chapterDir string) ([]string, error) {
+	entries, err := os.ReadDir(chapterDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var fileNames []string
+	for _, entry := range entries {
+		if !entry.IsDir() && slices.Contains(imageExts, strings.ToLower(filepath.Ext(entry.Name()))) {
+			fileNames = append(fileNames, entry.Name())
+		}
+	}
+	slices.SortFunc(fileNames, compareNatural)
+
+	imagePaths := make([]string, 0, len(fileNames))
+	for _, fileName := range fileNames {
+		imagePaths = append(imagePaths, filepath.Join(chapterDir, fileName))
+	}
+	return imagePaths, nil
+}
+
+// WriteCbz writes the images as a CBZ archive with the ComicInfo.xml to w.
+//
+// The images are renamed to their page numbers to keep the order in all comic readers.
+func WriteCbz(w io.Writer, info *ComicInfo, imagePaths []string) error {
+	zw := zip.NewWriter(w)
+	digits := max(MIN_CHAPTER_DIGITS, len(strconv.Itoa(len(imagePaths))))
+	for idx, imagePath := range imagePaths {
+		// the images are already compressed
+		fw, err := zw.CreateHeader(&zip.FileHeader{
+			Name:   fmt.Sprintf("%0*d%s", digits, idx+1, strings.ToLower(filepath.Ext(imagePath))),
+			Method: zip.Store,
+		})
+		if err != nil {
+			return err
+		}
+		if err := copyFile(fw, imagePath); err != nil {
+			return err
+		}
+	}
+
+	fw, err := zw.Create(COMIC_INFO_FILENAME)
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(fw, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(fw)
+	enc.Indent("", "  ")
+	if err := enc.Encode(info); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// WriteCbzFile writes the images as a CBZ archive with the ComicInfo.xml to the file path.
+func WriteCbzFile(filePath string, info *ComicInfo, imagePaths []string) error {
+	if err := os.MkdirAll(filepath.Dir(filePath), constants.DEFAULT_PERMS); err != nil {
+		return err
+	}
+	f, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	if err := WriteCbz(f, info, imagePaths); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func copyFile(w io.Writer, filePath string) error {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// CbzPath returns the path of the CBZ file of the chapter which is next to the chapter folder.
+func (c *Chapter) CbzPath() string {
+	return strings.TrimRight(c.Dir, `/\`) + ".cbz"
+}
+
+// WriteChapterCbz writes the downloaded images of the chapter to a CBZ file next to the chapter folder.
+//
+// Chapters without any downloaded images, e.g. skipped by the filters or the cache, are skipped.
+func WriteChapterCbz(series *Series, chapter *Chapter) error {
+	imagePaths, err := GetChapterImages(chapter.Dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf(
+			"pixiv error %d: failed to read the images of chapter %d of the manga series %q, more info => %w",
+			cdlerrors.OS_ERROR,
+			chapter.Number,
+			series.Title,
+			err,
+		)
+	}
+	if len(imagePaths) == 0 {
+		return nil
+	}
+
+	cbzPath := chapter.CbzPath()
+	if err := WriteCbzFile(cbzPath, NewComicInfo(series, chapter, len(imagePaths)), imagePaths); err != nil {
+		os.Remove(cbzPath)
+		return fmt.Errorf(
+			"pixiv error %d: failed to write CBZ file %q, more info => %w",
+			cdlerrors.OS_ERROR,
+			cbzPath,
+			err,
+		)
+	}
+	return nil
+}
+
+// ExportCbz writes a CBZ file for each downloaded chapter of the series.
+func (s *Series) ExportCbz() []error {
+	var errSlice []error
+	for _, chapter := range s.Chapters {
+		if err := WriteChapterCbz(s, chapter); err != nil {
+			errSlice = append(errSlice, err)
+		}
+	}
+	return errSlice
+}
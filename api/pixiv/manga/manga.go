@@ -0,0 +1,72 @@
+package manga
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/KJHJason/Cultured-Downloader-Logic/iofuncs"
+)
+
+// Minimum number of digits of the chapter numbers in the folder names
+// so that the chapters are sorted in order by file managers.
+const MIN_CHAPTER_DIGITS = 3
+
+// Series contains the details of a Pixiv manga series and its chapters in order.
+type Series struct {
+	Id          string
+	Url         string
+	Title       string
+	Description string
+	Creator     string
+	CreatorId   string
+	Dir         string
+	Chapters    []*Chapter
+}
+
+// Chapter is an artwork in a manga series which is saved in its own numbered folder in the series folder.
+type Chapter struct {
+	ArtworkId string
+	Url       string
+	Title     string
+	Number    int // order of the chapter in the series starting from 1
+	Tags      []string
+	Date      time.Time
+	IsR18     bool
+	Dir       string
+}
+
+// SortChapters sorts the chapters by their numbers.
+func (s *Series) SortChapters() {
+	slices.SortStableFunc(s.Chapters, func(a, b *Chapter) int {
+		return a.Number - b.Number
+	})
+}
+
+// SetChapterDirs sets the folder of each chapter to "<number> - <title>" in the series folder
+// where the number is zero-padded to the number of digits of the total chapters.
+func (s *Series) SetChapterDirs(sanitizer *iofuncs.PathSanitizer) {
+	if sanitizer == nil {
+		sanitizer = iofuncs.DefaultPathSanitizer
+	}
+
+	digits := MIN_CHAPTER_DIGITS
+	for _, chapter := range s.Chapters {
+		digits = max(digits, len(strconv.Itoa(chapter.Number)))
+	}
+	for _, chapter := range s.Chapters {
+		dirName := fmt.Sprintf("%0*d - %s", digits, chapter.Number, chapter.Title)
+		chapter.Dir = iofuncs.AsDirPath(filepath.Join(s.Dir, sanitizer.CleanFilename(dirName)))
+	}
+}
+
+// ArtworkIds returns the IDs of the chapters in order.
+func (s *Series) ArtworkIds() []string {
+	artworkIds := make([]string, 0, len(s.Chapters))
+	for _, chapter := range s.Chapters {
+		artworkIds = append(artworkIds, chapter.ArtworkId)
+	}
+	return artworkIds
+}
@@ -0,0 +1,126 @@
+package manga
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestSeries(t *testing.T) *Series {
+	series := &Series{
+		Id:          "123",
+		Title:       "Test Series",
+		Description: "A <test> series",
+		Creator:     "Creator",
+		Dir:         t.TempDir(),
+		Chapters: []*Chapter{
+			{ArtworkId: "3", Title: "Finale", Number: 10},
+			{ArtworkId: "1", Title: "Start: 1/2", Number: 1, Tags: []string{"manga", "original"}, IsR18: true,
+				Date: time.Date(2024, 5, 24, 0, 0, 0, 0, time.UTC)},
+		},
+	}
+	series.SortChapters()
+	series.SetChapterDirs(nil)
+	return series
+}
+
+func TestSetChapterDirs(t *testing.T) {
+	series := newTestSeries(t)
+	if ids := series.ArtworkIds(); len(ids) != 2 || ids[0] != "1" || ids[1] != "3" {
+		t.Fatalf("Expected the chapters to be sorted by their numbers but got %q", ids)
+	}
+
+	expected := []string{"001 - Start- 1-2", "010 - Finale"}
+	for idx, chapter := range series.Chapters {
+		if filepath.Dir(filepath.Clean(chapter.Dir)) != filepath.Clean(series.Dir) {
+			t.Errorf("Expected chapter %d to be in the series folder but got %q", chapter.Number, chapter.Dir)
+		}
+		if name := filepath.Base(chapter.Dir); name != expected[idx] {
+			t.Errorf("Expected the folder name %q but got %q", expected[idx], name)
+		}
+	}
+}
+
+func TestCompareNatural(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"1_p2.jpg", "1_p10.jpg", -1},
+		{"1_p10.jpg", "1_p2.jpg", 1},
+		{"1_p02.jpg", "1_p2.jpg", 0},
+		{"a.jpg", "b.jpg", -1},
+	}
+	for _, test := range tests {
+		cmp := compareNatural(test.a, test.b)
+		if (cmp < 0 && test.expected >= 0) || (cmp > 0 && test.expected <= 0) || (cmp == 0 && test.expected != 0) {
+			t.Errorf("Expected %q compared to %q to be %d but got %d", test.a, test.b, test.expected, cmp)
+		}
+	}
+}
+
+func TestWriteChapterCbz(t *testing.T) {
+	series := newTestSeries(t)
+	chapter := series.Chapters[0]
+	if err := os.MkdirAll(chapter.Dir, 0755); err != nil {
+		t.Fatalf("Failed to create the chapter folder: %v", err)
+	}
+	for _, fileName := range []string{"1_p10.png", "1_p2.jpg", "1_p1.jpg", "post_metadata.json"} {
+		if err := os.WriteFile(filepath.Join(chapter.Dir, fileName), []byte(fileName), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", fileName, err)
+		}
+	}
+
+	if errs := series.ExportCbz(); len(errs) > 0 {
+		t.Fatalf("Failed to export the CBZ files: %v", errs)
+	}
+	if _, err := os.Stat(series.Chapters[1].CbzPath()); !os.IsNotExist(err) {
+		t.Errorf("Expected the chapter without images to be skipped")
+	}
+
+	zr, err := zip.OpenReader(chapter.CbzPath())
+	if err != nil {
+		t.Fatalf("Failed to open the CBZ file: %v", err)
+	}
+	defer zr.Close()
+
+	files := make(map[string]string)
+	var names []string
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("Failed to open %s: %v", f.Name, err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		files[f.Name] = string(data)
+		names = append(names, f.Name)
+	}
+
+	expectedNames := []string{"001.jpg", "002.jpg", "003.png", COMIC_INFO_FILENAME}
+	if strings.Join(names, ",") != strings.Join(expectedNames, ",") {
+		t.Fatalf("Expected the files %q but got %q", expectedNames, names)
+	}
+	if files["001.jpg"] != "1_p1.jpg" || files["002.jpg"] != "1_p2.jpg" || files["003.png"] != "1_p10.png" {
+		t.Errorf("Expected the images to be in the order of the pages")
+	}
+	for _, expected := range []string{
+		"<Title>Start: 1/2</Title>",
+		"<Series>Test Series</Series>",
+		"<Number>1</Number>",
+		"<Count>2</Count>",
+		"<Summary>A &lt;test&gt; series</Summary>",
+		"<Year>2024</Year>",
+		"<Tags>manga,original</Tags>",
+		"<PageCount>3</PageCount>",
+		"<AgeRating>R18+</AgeRating>",
+	} {
+		if !strings.Contains(files[COMIC_INFO_FILENAME], expected) {
+			t.Errorf("Expected the ComicInfo.xml to contain %q but got:\n%s", expected, files[COMIC_INFO_FILENAME])
+		}
+	}
+}
@@ -33,7 +33,7 @@ func (pixiv *PixivMobile) getUgoiraMetadata(cacheKey, illustId, dlFilePath strin
 	)
 
 	if pixiv.Base.UseCacheDb {
-		if database.UgoiraCacheExists(cacheKey) {
+		if pixiv.Base.Session.GetDb().UgoiraCacheExists(cacheKey) {
 			return nil, nil
 		}
 	}
@@ -45,6 +45,8 @@ func (pixiv *PixivMobile) getUgoiraMetadata(cacheKey, illustId, dlFilePath strin
 			Headers:     additionalHeaders,
 			Params:      params,
 			Context:     pixiv.ctx,
+			Transports:  pixiv.Base.Session.GetTransports(),
+			Logger:      pixiv.Base.Session.GetLogger(),
 		},
 	)
 	if err != nil {
@@ -85,8 +87,9 @@ func (pixiv *PixivMobile) getArtworkDetails(artworkId string) ([]*httpfuncs.ToDo
 	params := map[string]string{"illust_id": artworkId}
 	if pixiv.Base.UseCacheDb {
 		ugoiraCacheKey = getUgoiraUrl(artworkId)
-		artworkUrl := constants.PIXIV_MOBILE_ARTWORK_URL + "?illust_id=" + artworkId
-		if database.PostCacheExists(artworkUrl, constants.PIXIV) || database.UgoiraCacheExists(ugoiraCacheKey) {
+		artworkUrl := getArtworkUrl(artworkId)
+		db := pixiv.Base.Session.GetDb()
+		if db.PostCacheExists(artworkUrl, constants.PIXIV) || db.UgoiraCacheExists(ugoiraCacheKey) {
 			// either the artwork or the ugoira is already in the cache
 			return nil, nil, nil
 		}
@@ -99,6 +102,8 @@ func (pixiv *PixivMobile) getArtworkDetails(artworkId string) ([]*httpfuncs.ToDo
 			Url:         constants.PIXIV_MOBILE_ARTWORK_URL,
 			Params:      params,
 			CheckStatus: true,
+			Transports:  pixiv.Base.Session.GetTransports(),
+			Logger:      pixiv.Base.Session.GetLogger(),
 		},
 	)
 	if err != nil {
@@ -122,7 +127,7 @@ func (pixiv *PixivMobile) getArtworkDetails(artworkId string) ([]*httpfuncs.ToDo
 	if pixiv.Base.UseCacheDb {
 		for _, artwork := range artworkDetails {
 			artwork.CacheKey = artworkCacheKey
-			artwork.CacheFn = database.CachePost
+			artwork.CacheFn = pixiv.Base.Session.GetDb().CachePost
 		}
 	}
 	return artworkDetails, ugoiraToDl, err
@@ -181,7 +186,7 @@ func (pixiv *PixivMobile) GetMultipleArtworkDetails(artworkIds []string) ([]*htt
 
 	hasErr := len(errSlice) > 0
 	if hasErr {
-		logger.LogErrors(logger.ERROR, errSlice...)
+		pixiv.Base.Session.GetLogger().LogErrors(logger.ERROR, errSlice...)
 	}
 	progress.Stop(hasErr)
 	return artworksToDownload, ugoiraSlice, errSlice
@@ -193,6 +198,7 @@ func (pixiv *PixivMobile) getArtistPostMainLogic(params map[string]string, userI
 	var artworksToDownload []*httpfuncs.ToDownload
 	nextUrl := constants.PIXIV_MOBILE_ARTIST_POSTS_URL
 
+	watermark := pixiv.Base.NewSyncWatermark(params["type"] + "/" + userId)
 	curOffset := offsetArg.minOffset
 	for nextUrl != "" {
 		res, err := pixiv.SendRequest(
@@ -201,6 +207,8 @@ func (pixiv *PixivMobile) getArtistPostMainLogic(params map[string]string, userI
 				Url:         nextUrl,
 				Params:      params,
 				CheckStatus: true,
+				Transports:  pixiv.Base.Session.GetTransports(),
+				Logger:      pixiv.Base.Session.GetLogger(),
 			},
 		)
 		if err != nil {
@@ -224,6 +232,16 @@ func (pixiv *PixivMobile) getArtistPostMainLogic(params map[string]string, userI
 			return nil, nil, errSlice, false
 		}
 
+		if watermark != nil {
+			unseenIllusts := make([]*IllustJson, 0, len(resJson.Illusts))
+			for _, illust := range resJson.Illusts {
+				if !watermark.IsSeen(strconv.Itoa(illust.ID), illust.CreateDate) {
+					unseenIllusts = append(unseenIllusts, illust)
+				}
+			}
+			resJson.Illusts = unseenIllusts
+		}
+
 		artworks, ugoiraS, errS := pixiv.processMultipleArtworkJson(&resJson)
 		if len(errS) > 0 {
 			errSlice = append(errSlice, errS...)
@@ -234,13 +252,16 @@ func (pixiv *PixivMobile) getArtistPostMainLogic(params map[string]string, userI
 		curOffset += constants.PIXIV_MOBILE_PER_PAGE
 		params["offset"] = strconv.Itoa(curOffset)
 		jsonNextUrl := resJson.NextUrl
-		if jsonNextUrl == nil || (offsetArg.hasMax && curOffset >= offsetArg.maxOffset) {
+		if jsonNextUrl == nil || (offsetArg.hasMax && curOffset >= offsetArg.maxOffset) || watermark.ReachedSeen() {
 			nextUrl = ""
 		} else {
 			nextUrl = *jsonNextUrl
 			pixiv.Sleep()
 		}
 	}
+	if len(errSlice) == 0 {
+		watermark.Done()
+	}
 	return artworksToDownload, ugoiraSlice, errSlice, false
 }
 
@@ -330,7 +351,7 @@ func (pixiv *PixivMobile) GetMultipleArtistsPosts(userIds, pageNums []string) ([
 			pageNums[idx],
 		)
 		if err != nil {
-			if hasCancelled := logger.LogErrors(logger.ERROR, err...); hasCancelled {
+			if hasCancelled := pixiv.Base.Session.GetLogger().LogErrors(logger.ERROR, err...); hasCancelled {
 				pixiv.cancel()
 				progress.StopInterrupt("Stopped getting artwork details from artists(s) on Pixiv!")
 				return nil, nil, errSlice
@@ -358,13 +379,19 @@ func (pixiv *PixivMobile) tagSearchLogic(tagName string, offsetArg *offsetArgs)
 	var ugoiraSlice []*ugoira.Ugoira
 	var artworksToDownload []*httpfuncs.ToDownload
 	params := map[string]string{
-		"word":           tagName,
+		"word":           pixiv.pFilters.SearchWord(tagName),
 		"search_target":  pixiv.pFilters.SearchMode,
 		"sort":           pixiv.pFilters.SortOrder,
 		"filter":         "for_ios",
 		"offset":         strconv.Itoa(offsetArg.minOffset),
 		"search_ai_type": strconv.Itoa(pixiv.pFilters.SearchAiMode),
 	}
+	if pixiv.pFilters.StartDate != "" {
+		params["start_date"] = pixiv.pFilters.StartDate
+	}
+	if pixiv.pFilters.EndDate != "" {
+		params["end_date"] = pixiv.pFilters.EndDate
+	}
 	curOffset := offsetArg.minOffset
 	nextUrl := constants.PIXIV_MOBILE_ILLUST_SEARCH_URL
 	for nextUrl != "" {
@@ -374,6 +401,8 @@ func (pixiv *PixivMobile) tagSearchLogic(tagName string, offsetArg *offsetArgs)
 				Params:      params,
 				CheckStatus: true,
 				Context:     pixiv.ctx,
+				Transports:  pixiv.Base.Session.GetTransports(),
+				Logger:      pixiv.Base.Session.GetLogger(),
 			},
 		)
 		if err != nil {
@@ -399,6 +428,7 @@ func (pixiv *PixivMobile) tagSearchLogic(tagName string, offsetArg *offsetArgs)
 			continue
 		}
 
+		resJson.Illusts = pixiv.filterSearchResults(resJson.Illusts)
 		artworks, ugoiraS, errS := pixiv.processMultipleArtworkJson(&resJson)
 		errSlice = append(errSlice, errS...)
 		artworksToDownload = append(artworksToDownload, artworks...)
@@ -422,7 +452,7 @@ func (pixiv *PixivMobile) tagSearchLogic(tagName string, offsetArg *offsetArgs)
 func (pixiv *PixivMobile) TagSearch(tagName, pageNum string) ([]*httpfuncs.ToDownload, []*ugoira.Ugoira, []error, bool) {
 	minPage, maxPage, hasMax, err := utils.GetMinMaxFromStr(pageNum)
 	if err != nil {
-		logger.LogError(err, logger.ERROR)
+		pixiv.Base.Session.GetLogger().LogError(err, logger.ERROR)
 		return nil, nil, []error{err}, false
 	}
 	minOffset, maxOffset := pixivcommon.ConvertPageNumToOffset(minPage, maxPage, constants.PIXIV_PER_PAGE, false)
@@ -436,7 +466,7 @@ func (pixiv *PixivMobile) TagSearch(tagName, pageNum string) ([]*httpfuncs.ToDow
 		},
 	)
 	if len(errSlice) > 0 {
-		if hasCancelled := logger.LogErrors(logger.ERROR, errSlice...); hasCancelled {
+		if hasCancelled := pixiv.Base.Session.GetLogger().LogErrors(logger.ERROR, errSlice...); hasCancelled {
 			return nil, nil, errSlice, true
 		}
 	}
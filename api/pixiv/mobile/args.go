@@ -45,6 +45,13 @@ func (p *PixivMobile) ValidateArgs() error {
 		p.Base.DownloadDirPath = dlDirPath
 	}
 
+	if err := p.Base.ValidatePathTemplates(constants.PIXIV_MOBILE); err != nil {
+		return err
+	}
+	if err := p.Base.ValidateFilterProfiles(); err != nil {
+		return err
+	}
+
 	if p.Base.Notifier == nil {
 		return fmt.Errorf(
 			"pixiv mobile error %d: notifier is nil",
@@ -0,0 +1,156 @@
+package pixivmobile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	pixivcommon "github.com/KJHJason/Cultured-Downloader-Logic/api/pixiv/common"
+	"github.com/KJHJason/Cultured-Downloader-Logic/cdlerrors"
+	"github.com/KJHJason/Cultured-Downloader-Logic/constants"
+	"github.com/KJHJason/Cultured-Downloader-Logic/httpfuncs"
+	"github.com/KJHJason/Cultured-Downloader-Logic/logger"
+	"github.com/KJHJason/Cultured-Downloader-Logic/utils"
+)
+
+// Paginates through the bookmarks using the next URL which contains the max_bookmark_id
+// as the mobile API does not support offsets for the bookmarks.
+func (pixiv *PixivMobile) getBookmarksLogic(bookmarks *pixivcommon.Bookmarks, userId, restrict string) ([]string, error) {
+	minPage, maxPage, hasMax, err := utils.GetMinMaxFromStr(bookmarks.PageNum)
+	if err != nil {
+		return nil, err
+	}
+
+	params := map[string]string{
+		"user_id":  userId,
+		"restrict": restrict,
+		"filter":   "for_ios",
+	}
+	if bookmarks.Tag != "" {
+		params["tag"] = bookmarks.Tag
+	}
+
+	desc := bookmarks.Description(userId, restrict)
+	var artworkIds []string
+	nextUrl := constants.PIXIV_MOBILE_BOOKMARKS_URL
+	for page := 1; nextUrl != "" && (!hasMax || page <= maxPage); page++ {
+		res, err := pixiv.SendRequest(
+			&httpfuncs.RequestArgs{
+				Context:     pixiv.ctx,
+				Url:         nextUrl,
+				Params:      params,
+				CheckStatus: true,
+				Transports:  pixiv.Base.Session.GetTransports(),
+				Logger:      pixiv.Base.Session.GetLogger(),
+			},
+		)
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				return nil, err
+			}
+			return nil, fmt.Errorf(
+				"pixiv mobile error %d: failed to get the %s, more info => %w",
+				cdlerrors.CONNECTION_ERROR,
+				desc,
+				err,
+			)
+		}
+
+		var resJson ArtworksJson
+		if err := httpfuncs.LoadJsonFromResponse(res.Resp, &resJson); err != nil {
+			return nil, err
+		}
+		if page >= minPage {
+			artworkIds = append(artworkIds, pixiv.filterArtworkIds(&resJson, nil)...)
+		}
+
+		if resJson.NextUrl == nil {
+			break
+		}
+		// the next URL already contains the query parameters
+		nextUrl = *resJson.NextUrl
+		params = nil
+		pixiv.Sleep()
+	}
+	return artworkIds, nil
+}
+
+// Query Pixiv's API (mobile) for the bookmarked artworks of a user and returns a slice of artwork IDs
+func (pixiv *PixivMobile) getBookmarks(bookmarks *pixivcommon.Bookmarks) ([]string, []error) {
+	userId := bookmarks.UserId
+	if userId == "" {
+		if pixiv.user == nil {
+			return nil, []error{
+				fmt.Errorf(
+					"pixiv mobile error %d: failed to get the logged in user, please specify the user ID of the bookmarks",
+					cdlerrors.INPUT_ERROR,
+				),
+			}
+		}
+		userId = pixiv.user.ID
+	}
+
+	var errSlice []error
+	var artworkIds []string
+	for _, restrict := range bookmarks.Restricts() {
+		ids, err := pixiv.getBookmarksLogic(bookmarks, userId, restrict)
+		if err != nil {
+			errSlice = append(errSlice, err)
+			if errors.Is(err, context.Canceled) {
+				return nil, errSlice
+			}
+			continue
+		}
+		artworkIds = append(artworkIds, ids...)
+	}
+	return artworkIds, errSlice
+}
+
+// Get the bookmarked artworks of multiple users and returns a slice of artwork IDs
+// to be used with GetMultipleArtworkDetails
+func (pixiv *PixivMobile) GetMultipleBookmarks(bookmarksSlice []*pixivcommon.Bookmarks) ([]string, []error) {
+	var errSlice []error
+	var artworkIdsSlice []string
+	bookmarksLen := len(bookmarksSlice)
+	lastIdx := bookmarksLen - 1
+
+	baseMsg := "Getting bookmarked artworks from Pixiv's Mobile API [%d/" + fmt.Sprintf("%d]...", bookmarksLen)
+	progress := pixiv.Base.MainProgBar()
+	progress.UpdateBaseMsg(baseMsg)
+	progress.UpdateSuccessMsg(
+		fmt.Sprintf(
+			"Finished getting bookmarked artworks from %d bookmark list(s) from Pixiv's Mobile API!",
+			bookmarksLen,
+		),
+	)
+	progress.UpdateErrorMsg(
+		fmt.Sprintf(
+			"Something went wrong while getting bookmarked artworks from %d bookmark list(s) from Pixiv's Mobile API!\nPlease refer to the logs for more details.",
+			bookmarksLen,
+		),
+	)
+	progress.SetToProgressBar()
+	progress.UpdateMax(bookmarksLen)
+	progress.Start()
+	defer progress.SnapshotTask()
+	for idx, bookmarks := range bookmarksSlice {
+		artworkIds, err := pixiv.getBookmarks(bookmarks)
+		if len(err) > 0 {
+			if hasCancelled := pixiv.Base.Session.GetLogger().LogErrors(logger.ERROR, err...); hasCancelled {
+				pixiv.cancel()
+				progress.StopInterrupt("Stopped getting bookmarked artworks from Pixiv's Mobile API!")
+				return nil, append(errSlice, err...)
+			}
+			errSlice = append(errSlice, err...)
+		}
+		artworkIdsSlice = append(artworkIdsSlice, artworkIds...)
+
+		if idx != lastIdx {
+			pixiv.Sleep()
+		}
+		progress.Increment()
+	}
+
+	progress.Stop(len(errSlice) > 0)
+	return artworkIdsSlice, errSlice
+}
@@ -0,0 +1,89 @@
+package pixivmobile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	pixivcommon "github.com/KJHJason/Cultured-Downloader-Logic/api/pixiv/common"
+	"github.com/KJHJason/Cultured-Downloader-Logic/cdlerrors"
+	"github.com/KJHJason/Cultured-Downloader-Logic/constants"
+	"github.com/KJHJason/Cultured-Downloader-Logic/httpfuncs"
+	"github.com/KJHJason/Cultured-Downloader-Logic/logger"
+	"github.com/KJHJason/Cultured-Downloader-Logic/utils"
+)
+
+// Query Pixiv's API (mobile) for the new works from the artists followed by the logged in user
+// and returns a slice of artwork IDs to be used with GetMultipleArtworkDetails.
+//
+// With IncrementalSync, the feed stops at the newest artwork of the previous sync
+// and the new watermark is only saved by CommitSyncWatermarks after the artworks are downloaded.
+//
+// Returns the slice, the errors, and a boolean indicating if the user cancelled the operation.
+func (pixiv *PixivMobile) GetFollowFeed(feed *pixivcommon.FollowFeed) ([]string, []error, bool) {
+	minPage, maxPage, hasMax, err := utils.GetMinMaxFromStr(feed.PageNum)
+	if err != nil {
+		pixiv.Base.Session.GetLogger().LogError(err, logger.ERROR)
+		return nil, []error{err}, false
+	}
+
+	params := map[string]string{
+		"restrict": feed.Restrict,
+	}
+
+	var errSlice []error
+	var artworkIds []string
+	watermark := pixiv.Base.NewSyncWatermark(pixivcommon.FOLLOW_FEED_WATERMARK_PREFIX + feed.Restrict)
+	nextUrl := constants.PIXIV_MOBILE_FOLLOW_FEED_URL
+	for page := 1; nextUrl != "" && (!hasMax || page <= maxPage); page++ {
+		res, err := pixiv.SendRequest(
+			&httpfuncs.RequestArgs{
+				Context:     pixiv.ctx,
+				Url:         nextUrl,
+				Params:      params,
+				CheckStatus: true,
+				Transports:  pixiv.Base.Session.GetTransports(),
+				Logger:      pixiv.Base.Session.GetLogger(),
+			},
+		)
+		if err != nil {
+			if !errors.Is(err, context.Canceled) {
+				err = fmt.Errorf(
+					"pixiv mobile error %d: failed to get page %d of the follow feed, more info => %w",
+					cdlerrors.CONNECTION_ERROR,
+					page,
+					err,
+				)
+			}
+			errSlice = append(errSlice, err)
+			break
+		}
+
+		var resJson ArtworksJson
+		if err := httpfuncs.LoadJsonFromResponse(res.Resp, &resJson); err != nil {
+			errSlice = append(errSlice, err)
+			break
+		}
+		if page >= minPage {
+			artworkIds = append(artworkIds, pixiv.filterArtworkIds(&resJson, watermark)...)
+		}
+
+		if resJson.NextUrl == nil || watermark.ReachedSeen() {
+			break
+		}
+		// the next URL already contains the query parameters
+		nextUrl = *resJson.NextUrl
+		params = nil
+		pixiv.Sleep()
+	}
+
+	if len(errSlice) > 0 {
+		if hasCancelled := pixiv.Base.Session.GetLogger().LogErrors(logger.ERROR, errSlice...); hasCancelled {
+			pixiv.cancel()
+			return nil, errSlice, true
+		}
+	} else {
+		watermark.Done()
+	}
+	return artworkIds, errSlice, false
+}
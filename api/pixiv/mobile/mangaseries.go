@@ -0,0 +1,165 @@
+package pixivmobile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+
+	pixivcommon "github.com/KJHJason/Cultured-Downloader-Logic/api/pixiv/common"
+	"github.com/KJHJason/Cultured-Downloader-Logic/api/pixiv/manga"
+	"github.com/KJHJason/Cultured-Downloader-Logic/cdlerrors"
+	"github.com/KJHJason/Cultured-Downloader-Logic/constants"
+	"github.com/KJHJason/Cultured-Downloader-Logic/httpfuncs"
+	"github.com/KJHJason/Cultured-Downloader-Logic/iofuncs"
+	"github.com/KJHJason/Cultured-Downloader-Logic/logger"
+)
+
+// Query Pixiv's API (mobile) for the details of the manga series and its chapters in order
+//
+// As the mobile API does not return the order of the chapters, the chapter number is the position in the series.
+func (pixiv *PixivMobile) getMangaSeries(seriesId string) (*manga.Series, error) {
+	params := map[string]string{
+		"illust_series_id": seriesId,
+		"filter":           "for_ios",
+	}
+
+	series := &manga.Series{Id: seriesId}
+	var illusts []*IllustJson
+	var firstIllust *IllustJson
+	nextUrl := constants.PIXIV_MOBILE_MANGA_SERIES_URL
+	for page := 1; nextUrl != ""; page++ {
+		res, err := pixiv.SendRequest(
+			&httpfuncs.RequestArgs{
+				Context:     pixiv.ctx,
+				Url:         nextUrl,
+				Params:      params,
+				CheckStatus: true,
+				Transports:  pixiv.Base.Session.GetTransports(),
+				Logger:      pixiv.Base.Session.GetLogger(),
+			},
+		)
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				return nil, err
+			}
+			return nil, fmt.Errorf(
+				"pixiv mobile error %d: failed to get page %d of the manga series %s, more info => %w",
+				cdlerrors.CONNECTION_ERROR,
+				page,
+				seriesId,
+				err,
+			)
+		}
+
+		var resJson MangaSeriesJson
+		if err := httpfuncs.LoadJsonFromResponse(res.Resp, &resJson); err != nil {
+			return nil, err
+		}
+		if page == 1 {
+			seriesDetail := resJson.IllustSeriesDetail
+			series.Title = seriesDetail.Title
+			series.Description = pixivcommon.HtmlToText(seriesDetail.Caption)
+			series.Creator = seriesDetail.User.Name
+			series.CreatorId = strconv.Itoa(seriesDetail.User.ID)
+			firstIllust = resJson.IllustSeriesFirstIllust
+		}
+		illusts = append(illusts, resJson.Illusts...)
+
+		if resJson.NextUrl == nil {
+			break
+		}
+		// the next URL already contains the query parameters
+		nextUrl = *resJson.NextUrl
+		params = nil
+		pixiv.Sleep()
+	}
+
+	// the works are usually listed from the newest to the oldest
+	// so the list is reversed if the first chapter is at the end
+	if len(illusts) > 1 && firstIllust != nil && illusts[len(illusts)-1].ID == firstIllust.ID {
+		slices.Reverse(illusts)
+	}
+	for idx, illust := range illusts {
+		// deleted or private works cannot be downloaded but still count towards the chapter number
+		if !illust.Visible {
+			continue
+		}
+		artworkId := strconv.Itoa(illust.ID)
+		tags := make([]string, 0, len(illust.Tags))
+		for _, tag := range illust.Tags {
+			tags = append(tags, tag.Name)
+		}
+		series.Chapters = append(series.Chapters, &manga.Chapter{
+			ArtworkId: artworkId,
+			Url:       pixivcommon.GetIllustUrl(artworkId),
+			Title:     illust.Title,
+			Number:    idx + 1,
+			Tags:      tags,
+			Date:      illust.CreateDate,
+			IsR18:     illust.XRestrict > 0,
+		})
+	}
+
+	series.Url = pixivcommon.GetMangaSeriesUrl(series.CreatorId, seriesId)
+	series.Dir = pixiv.Base.GetPostFolder(&iofuncs.PathTemplateInfo{
+		CreatorName: series.Creator,
+		CreatorId:   series.CreatorId,
+		PostId:      seriesId,
+		Title:       series.Title,
+	})
+	series.SetChapterDirs(pixiv.Base.Sanitizer)
+	return series, nil
+}
+
+// Get the chapters from multiple manga series and returns the series
+// where the chapters are downloaded with GetMultipleArtworkDetails to their numbered chapter folders.
+func (pixiv *PixivMobile) GetMultipleMangaSeries(seriesIds []string) ([]*manga.Series, []error) {
+	var errSlice []error
+	var seriesSlice []*manga.Series
+	seriesIdsLen := len(seriesIds)
+	lastIdx := seriesIdsLen - 1
+
+	baseMsg := "Getting chapters from manga series on Pixiv's Mobile API [%d/" + fmt.Sprintf("%d]...", seriesIdsLen)
+	progress := pixiv.Base.MainProgBar()
+	progress.UpdateBaseMsg(baseMsg)
+	progress.UpdateSuccessMsg(
+		fmt.Sprintf(
+			"Finished getting chapters from %d manga series from Pixiv's Mobile API!",
+			seriesIdsLen,
+		),
+	)
+	progress.UpdateErrorMsg(
+		fmt.Sprintf(
+			"Something went wrong while getting chapters from %d manga series from Pixiv's Mobile API!\nPlease refer to the logs for more details.",
+			seriesIdsLen,
+		),
+	)
+	progress.SetToProgressBar()
+	progress.UpdateMax(seriesIdsLen)
+	progress.Start()
+	defer progress.SnapshotTask()
+	for idx, seriesId := range seriesIds {
+		series, err := pixiv.getMangaSeries(seriesId)
+		if err != nil {
+			if hasCancelled := pixiv.Base.Session.GetLogger().LogErrors(logger.ERROR, err); hasCancelled {
+				pixiv.cancel()
+				progress.StopInterrupt("Stopped getting chapters from manga series from Pixiv's Mobile API!")
+				return nil, append(errSlice, err)
+			}
+			errSlice = append(errSlice, err)
+		} else {
+			pixiv.addMangaChapters(series)
+			seriesSlice = append(seriesSlice, series)
+		}
+
+		if idx != lastIdx {
+			pixiv.Sleep()
+		}
+		progress.Increment()
+	}
+
+	progress.Stop(len(errSlice) > 0)
+	return seriesSlice, errSlice
+}
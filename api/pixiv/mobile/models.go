@@ -75,10 +75,10 @@ type IllustJson struct {
 	// 	Medium       string `json:"medium"`
 	// 	Large        string `json:"large"`
 	// } `json:"image_urls"`
-	// Caption  string `json:"caption"`
+	Caption string `json:"caption"` // HTML
 	// Restrict int    `json:"restrict"`
 	User struct {
-		// ID               int    `json:"id"`
+		ID   int    `json:"id"`
 		Name string `json:"name"`
 		// Account          string `json:"account"`
 		// ProfileImageUrls struct {
@@ -86,18 +86,21 @@ type IllustJson struct {
 		// } `json:"profile_image_urls"`
 		// IsFollowed bool `json:"is_followed"`
 	} `json:"user"`
-	// Tags []struct {
-	// 	Name           string `json:"name"`
-	// 	TranslatedName any    `json:"translated_name"`
-	// } `json:"tags"`
+	Tags []struct {
+		Name           string  `json:"name"`
+		TranslatedName *string `json:"translated_name"`
+	} `json:"tags"`
 	// Tools          []any     `json:"tools"`
 	CreateDate time.Time `json:"create_date"`
-	// PageCount      int       `json:"page_count"`
-	// Width          int       `json:"width"`
-	// Height         int       `json:"height"`
+	PageCount  int       `json:"page_count"`
+	Width      int       `json:"width"` // dimensions of the first page
+	Height     int       `json:"height"`
 	// SanityLevel    int       `json:"sanity_level"`
-	// XRestrict      int       `json:"x_restrict"`
-	// Series         any       `json:"series"`
+	XRestrict int `json:"x_restrict"` // 0: SFW, 1: R18, 2: R18G
+	Series    *struct {
+		ID    int    `json:"id"`
+		Title string `json:"title"`
+	} `json:"series"` // null if the artwork is not in a series
 	MetaSinglePage struct {
 		OriginalImageURL string `json:"original_image_url"`
 	} `json:"meta_single_page"`
@@ -106,13 +109,13 @@ type IllustJson struct {
 			Original string `json:"original"`
 		} `json:"image_urls"`
 	} `json:"meta_pages"`
-	// TotalView            int   `json:"total_view"`
-	// TotalBookmarks       int   `json:"total_bookmarks"`
+	TotalView      int `json:"total_view"`
+	TotalBookmarks int `json:"total_bookmarks"`
 	// IsBookmarked         bool  `json:"is_bookmarked"`
-	// Visible              bool  `json:"visible"`
+	Visible bool `json:"visible"` // false for deleted or private works in the bookmarks
 	// IsMuted              bool  `json:"is_muted"`
 	// TotalComments        int   `json:"total_comments"`
-	// IllustAiType         int   `json:"illust_ai_type"`
+	IllustAiType int `json:"illust_ai_type"` // 0: unknown, 1: not AI-generated, 2: AI-generated
 	// IllustBookStyle      int   `json:"illust_book_style"`
 	// CommentAccessControl int   `json:"comment_access_control"`
 }
@@ -125,3 +128,18 @@ type ArtworksJson struct {
 	Illusts []*IllustJson `json:"illusts"`
 	NextUrl *string       `json:"next_url"`
 }
+
+type MangaSeriesJson struct {
+	IllustSeriesDetail struct {
+		ID      int    `json:"id"`
+		Title   string `json:"title"`
+		Caption string `json:"caption"`
+		User    struct {
+			ID   int    `json:"id"`
+			Name string `json:"name"`
+		} `json:"user"`
+	} `json:"illust_series_detail"`
+	IllustSeriesFirstIllust *IllustJson   `json:"illust_series_first_illust"`
+	Illusts                 []*IllustJson `json:"illusts"`
+	NextUrl                 *string       `json:"next_url"`
+}
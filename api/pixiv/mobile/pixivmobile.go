@@ -10,8 +10,10 @@ import (
 
 	"github.com/KJHJason/Cultured-Downloader-Logic/api"
 	pixivcommon "github.com/KJHJason/Cultured-Downloader-Logic/api/pixiv/common"
+	"github.com/KJHJason/Cultured-Downloader-Logic/api/pixiv/manga"
 	"github.com/KJHJason/Cultured-Downloader-Logic/constants"
 	"github.com/KJHJason/Cultured-Downloader-Logic/httpfuncs"
+	"github.com/KJHJason/Cultured-Downloader-Logic/metadata"
 )
 
 type PixivMobile struct {
@@ -22,6 +24,12 @@ type PixivMobile struct {
 	Base     *api.BaseDl
 	pFilters *pixivcommon.PixivFilters
 
+	// Artwork ID -> positions in the rankings to be saved in the metadata
+	rankings map[string][]metadata.PixivRanking
+
+	// Artwork ID -> chapter of a manga series to download the artwork to the chapter folder
+	mangaChapters map[string]*manga.Chapter
+
 	// API information and its endpoints
 	refreshToken string
 
@@ -39,6 +47,8 @@ func (p *PixivMobile) GetCaptchaHandler() httpfuncs.CaptchaHandler {
 		constants.PIXIV_URL, // not using constants.PIXIV_MOBILE_URL as it's under the same domain
 		p.Base.Configs.UserAgent,
 		p.Base.Notifier,
+		p.Base.Session.GetCfCache(),
+		p.Base.Session.GetLogger(),
 	)
 }
 
@@ -63,6 +73,22 @@ func (p *PixivMobile) CtxIsActive() bool {
 	return p.ctx.Err() == nil
 }
 
+func (pixiv *PixivMobile) addRanking(artworkId string, ranking metadata.PixivRanking) {
+	if pixiv.rankings == nil {
+		pixiv.rankings = make(map[string][]metadata.PixivRanking)
+	}
+	pixiv.rankings[artworkId] = append(pixiv.rankings[artworkId], ranking)
+}
+
+func (pixiv *PixivMobile) addMangaChapters(series *manga.Series) {
+	if pixiv.mangaChapters == nil {
+		pixiv.mangaChapters = make(map[string]*manga.Chapter)
+	}
+	for _, chapter := range series.Chapters {
+		pixiv.mangaChapters[chapter.ArtworkId] = chapter
+	}
+}
+
 func (pixiv *PixivMobile) SetPixivFilters(filters pixivcommon.PixivFilters) error {
 	if pixiv.user == nil {
 		panic("pixiv user is nil, did you forget to use NewPixivMobile() or forgot to refresh the access token first?")
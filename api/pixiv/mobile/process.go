@@ -4,13 +4,22 @@ import (
 	"fmt"
 	"strconv"
 
+	"github.com/KJHJason/Cultured-Downloader-Logic/api"
+	pixivcommon "github.com/KJHJason/Cultured-Downloader-Logic/api/pixiv/common"
 	"github.com/KJHJason/Cultured-Downloader-Logic/api/pixiv/ugoira"
 	"github.com/KJHJason/Cultured-Downloader-Logic/constants"
+	"github.com/KJHJason/Cultured-Downloader-Logic/database"
+	"github.com/KJHJason/Cultured-Downloader-Logic/filters"
 	"github.com/KJHJason/Cultured-Downloader-Logic/httpfuncs"
 	"github.com/KJHJason/Cultured-Downloader-Logic/iofuncs"
 	"github.com/KJHJason/Cultured-Downloader-Logic/metadata"
 )
 
+// Returns the URL of the artwork details which is also used as the cache key of the artwork
+func getArtworkUrl(artworkId string) string {
+	return fmt.Sprintf("%s?illust_id=%s", constants.PIXIV_MOBILE_ARTWORK_URL, artworkId)
+}
+
 func getUgoiraUrl(artworkId string) string {
 	return fmt.Sprintf("%s?illust_id=%s", constants.PIXIV_MOBILE_UGOIRA_URL, artworkId)
 }
@@ -19,6 +28,52 @@ func getUgoiraUrlFromInt(artworkId int) string {
 	return getUgoiraUrl(strconv.Itoa(artworkId))
 }
 
+// Writes the metadata of the artwork if SetMetadata is enabled
+// where ugoiraInfo is used for the frames of the ugoira and can be nil.
+func (pixiv *PixivMobile) writeArtworkMetadata(artworkJson *IllustJson, tags []string, ugoiraInfo *ugoira.Ugoira, artworkFolderPath string) error {
+	if !pixiv.Base.SetMetadata {
+		return nil
+	}
+
+	artworkId := strconv.Itoa(artworkJson.ID)
+	postMetadata := metadata.PixivPost{
+		Url:          fmt.Sprintf("https://www.pixiv.net/artworks/%s", artworkId),
+		Title:        artworkJson.Title,
+		Type:         pixivcommon.GetReadableArtworkType(artworkJson.Type),
+		Creator:      artworkJson.User.Name,
+		CreatorId:    strconv.Itoa(artworkJson.User.ID),
+		Caption:      artworkJson.Caption,
+		Tags:         tags,
+		CreatedAt:    artworkJson.CreateDate,
+		UploadedAt:   artworkJson.CreateDate,
+		PageCount:    artworkJson.PageCount,
+		Width:        artworkJson.Width,
+		Height:       artworkJson.Height,
+		Views:        artworkJson.TotalView,
+		Bookmarks:    artworkJson.TotalBookmarks,
+		AiType:       artworkJson.IllustAiType,
+		XRestrict:    artworkJson.XRestrict,
+		UgoiraFrames: ugoiraInfo.FramesMetadata(),
+		Rankings:     pixiv.rankings[artworkId],
+	}
+	for _, tag := range artworkJson.Tags {
+		if tag.TranslatedName == nil || *tag.TranslatedName == "" {
+			continue
+		}
+		if postMetadata.TagTranslations == nil {
+			postMetadata.TagTranslations = make(map[string]string)
+		}
+		postMetadata.TagTranslations[tag.Name] = *tag.TranslatedName
+	}
+	if series := artworkJson.Series; series != nil {
+		postMetadata.Series = &metadata.PixivSeries{
+			Id:    strconv.Itoa(series.ID),
+			Title: series.Title,
+		}
+	}
+	return metadata.WriteMetadataWithOptions(postMetadata, artworkFolderPath, pixiv.Base.MetadataOptions())
+}
+
 // Process the artwork JSON and returns a slice of map that contains the urls of the images and the file path
 func (pixiv *PixivMobile) processArtworkJson(ugoiraCacheKey string, artworkJson *IllustJson) ([]*httpfuncs.ToDownload, *ugoira.Ugoira, error) {
 	if artworkJson == nil {
@@ -28,24 +83,34 @@ func (pixiv *PixivMobile) processArtworkJson(ugoiraCacheKey string, artworkJson
 	artworkId := strconv.Itoa(artworkJson.ID)
 	artworkTitle := artworkJson.Title
 	artworkType := artworkJson.Type
-	artistName := artworkJson.User.Name
-	artworkFolderPath := iofuncs.GetPostFolder(
-		pixiv.Base.DownloadDirPath, artistName, artworkId, artworkTitle,
-	)
-
-	if !pixiv.Base.Filters.IsPostDateValid(artworkJson.CreateDate) {
-		return nil, nil, nil
+	pathInfo := &iofuncs.PathTemplateInfo{
+		CreatorName: artworkJson.User.Name,
+		CreatorId:   strconv.Itoa(artworkJson.User.ID),
+		PostId:      artworkId,
+		Title:       artworkTitle,
+		Date:        artworkJson.CreateDate,
+	}
+	artworkFolderPath := pixiv.Base.GetPostFolder(pathInfo)
+	if chapter, ok := pixiv.mangaChapters[artworkId]; ok {
+		// chapters of a manga series are saved in order in the series folder
+		artworkFolderPath = chapter.Dir
 	}
 
-	if pixiv.Base.SetMetadata {
-		postMetadata := metadata.PixivPost{
-			Url:   fmt.Sprintf("https://www.pixiv.net/artworks/%s", artworkId),
-			Title: artworkTitle,
-			Type:  artworkType,
-		}
-		if err := metadata.WriteMetadata(postMetadata, artworkFolderPath); err != nil {
-			return nil, nil, err
-		}
+	filterInfo := &filters.PostInfo{
+		Title: artworkTitle,
+		Tags:  make([]string, 0, len(artworkJson.Tags)),
+		Fee:   filters.UNKNOWN_FEE,
+		Date:  artworkJson.CreateDate,
+		Type:  artworkType,
+	}
+	for _, tag := range artworkJson.Tags {
+		filterInfo.Tags = append(filterInfo.Tags, tag.Name)
+	}
+	postFilters := pixiv.Base.GetFilters(pathInfo.CreatorId)
+	if !postFilters.IsAdultContentValid(artworkJson.XRestrict > 0) ||
+		!postFilters.IsPostDateValid(artworkJson.CreateDate) ||
+		!postFilters.IsPostExprValid(filterInfo) {
+		return nil, nil, nil
 	}
 
 	if artworkType == "ugoira" {
@@ -53,25 +118,48 @@ func (pixiv *PixivMobile) processArtworkJson(ugoiraCacheKey string, artworkJson
 		if err != nil {
 			return nil, nil, err
 		}
+		ugoiraInfo.Filters = postFilters
+		if err := pixiv.writeArtworkMetadata(artworkJson, filterInfo.Tags, ugoiraInfo, artworkFolderPath); err != nil {
+			return nil, nil, err
+		}
 		return nil, ugoiraInfo, nil
 	}
+	if err := pixiv.writeArtworkMetadata(artworkJson, filterInfo.Tags, nil, artworkFolderPath); err != nil {
+		return nil, nil, err
+	}
 
 	var artworksToDownload []*httpfuncs.ToDownload
 	singlePageImageUrl := artworkJson.MetaSinglePage.OriginalImageURL
 	if singlePageImageUrl != "" {
+		if !postFilters.IsImageDimensionValid(artworkJson.Width, artworkJson.Height) {
+			// there are no downloads to cache the artwork
+			// which would be fetched again on every run otherwise
+			if pixiv.Base.UseCacheDb {
+				pixiv.Base.Session.GetDb().CachePost(database.ParsePostKey(getArtworkUrl(artworkId), constants.PIXIV))
+			}
+			return nil, nil, nil
+		}
 		artworksToDownload = append(artworksToDownload, &httpfuncs.ToDownload{
 			Url:      singlePageImageUrl,
-			FilePath: artworkFolderPath,
+			FilePath: pixiv.Base.GetFilePathFromUrl(artworkFolderPath, "", singlePageImageUrl, 1, pathInfo),
 		})
 	} else {
-		for _, image := range artworkJson.MetaPages {
+		for idx, image := range artworkJson.MetaPages {
+			// The API only returns the dimensions of the first page,
+			// the other pages are checked by DlToFile after they are downloaded.
+			if idx == 0 && !postFilters.IsImageDimensionValid(artworkJson.Width, artworkJson.Height) {
+				continue
+			}
 			imageUrl := image.ImageUrls.Original
 			artworksToDownload = append(artworksToDownload, &httpfuncs.ToDownload{
 				Url:      imageUrl,
-				FilePath: artworkFolderPath,
+				FilePath: pixiv.Base.GetFilePathFromUrl(artworkFolderPath, "", imageUrl, idx+1, pathInfo),
 			})
 		}
 	}
+	httpfuncs.SetPostInfo(artworksToDownload, filterInfo)
+	httpfuncs.SetFilters(artworksToDownload, postFilters)
+	httpfuncs.SetSource(artworksToDownload, pixiv.Base.NewFileSource(pathInfo, getArtworkUrl(artworkId)))
 	return artworksToDownload, nil, nil
 }
 
@@ -106,3 +194,58 @@ func (pixiv *PixivMobile) processMultipleArtworkJson(resJson *ArtworksJson) ([]*
 	}
 	return artworksToDl, ugoiraToDl, errSlice
 }
+
+// Returns true if the artwork is visible and passes the filters
+func (pixiv *PixivMobile) isArtworkValid(illust *IllustJson) bool {
+	if !illust.Visible {
+		return false
+	}
+	filterInfo := &filters.PostInfo{
+		Title: illust.Title,
+		Tags:  make([]string, 0, len(illust.Tags)),
+		Fee:   filters.UNKNOWN_FEE,
+		Date:  illust.CreateDate,
+		Type:  illust.Type,
+	}
+	for _, tag := range illust.Tags {
+		filterInfo.Tags = append(filterInfo.Tags, tag.Name)
+	}
+	postFilters := pixiv.Base.GetFilters(strconv.Itoa(illust.User.ID))
+	return postFilters.IsAdultContentValid(illust.XRestrict > 0) &&
+		postFilters.IsPostDateValid(illust.CreateDate) &&
+		postFilters.IsPostExprValid(filterInfo)
+}
+
+// Returns the search results that passes the search filters which are not supported by the API
+// like the excluded tags, the page limits, and the minimum bookmarks.
+func (pixiv *PixivMobile) filterSearchResults(illusts []*IllustJson) []*IllustJson {
+	filtered := make([]*IllustJson, 0, len(illusts))
+	for _, illust := range illusts {
+		tags := make([]string, 0, len(illust.Tags))
+		for _, tag := range illust.Tags {
+			tags = append(tags, tag.Name)
+		}
+		// the likes are not returned by the mobile API
+		if !pixiv.pFilters.IsTagsValid(tags) ||
+			!pixiv.pFilters.IsPageCountValid(illust.PageCount) ||
+			!pixiv.pFilters.IsPopularityValid(illust.TotalBookmarks, -1) {
+			continue
+		}
+		filtered = append(filtered, illust)
+	}
+	return filtered
+}
+
+// Returns the IDs of the visible artworks in the JSON that passes the filters
+//
+// If the watermark is not nil, artworks from the previous sync are skipped.
+func (pixiv *PixivMobile) filterArtworkIds(resJson *ArtworksJson, watermark *api.SyncWatermark) []string {
+	var artworkIds []string
+	for _, illust := range resJson.Illusts {
+		if !illust.Visible || watermark.IsSeen(strconv.Itoa(illust.ID), illust.CreateDate) || !pixiv.isArtworkValid(illust) {
+			continue
+		}
+		artworkIds = append(artworkIds, strconv.Itoa(illust.ID))
+	}
+	return artworkIds
+}
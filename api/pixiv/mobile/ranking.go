@@ -0,0 +1,147 @@
+package pixivmobile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	pixivcommon "github.com/KJHJason/Cultured-Downloader-Logic/api/pixiv/common"
+	"github.com/KJHJason/Cultured-Downloader-Logic/cdlerrors"
+	"github.com/KJHJason/Cultured-Downloader-Logic/constants"
+	"github.com/KJHJason/Cultured-Downloader-Logic/httpfuncs"
+	"github.com/KJHJason/Cultured-Downloader-Logic/logger"
+	"github.com/KJHJason/Cultured-Downloader-Logic/metadata"
+	"github.com/KJHJason/Cultured-Downloader-Logic/utils"
+)
+
+// Query Pixiv's API (mobile) for the artworks in the ranking and returns a slice of artwork IDs
+//
+// As the mobile API does not return the rank, the rank is the position of the artwork in the results.
+func (pixiv *PixivMobile) getRanking(ranking *pixivcommon.Ranking) ([]string, error) {
+	minPage, maxPage, hasMax, err := utils.GetMinMaxFromStr(ranking.PageNum)
+	if err != nil {
+		return nil, err
+	}
+
+	// The mobile API has no rankings for some of the content types
+	// so the results are filtered by the artwork type instead.
+	mode, contentSupported := ranking.MobileMode()
+	params := map[string]string{
+		"mode":   mode,
+		"filter": "for_ios",
+	}
+	if ranking.Date != "" {
+		params["date"] = ranking.Date
+	}
+
+	desc := ranking.Description()
+	var artworkIds []string
+	rank := 0
+	nextUrl := constants.PIXIV_MOBILE_RANKING_URL
+	for page := 1; nextUrl != "" && (!hasMax || page <= maxPage); page++ {
+		res, err := pixiv.SendRequest(
+			&httpfuncs.RequestArgs{
+				Context:     pixiv.ctx,
+				Url:         nextUrl,
+				Params:      params,
+				CheckStatus: true,
+				Transports:  pixiv.Base.Session.GetTransports(),
+				Logger:      pixiv.Base.Session.GetLogger(),
+			},
+		)
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				return nil, err
+			}
+			return nil, fmt.Errorf(
+				"pixiv mobile error %d: failed to get page %d of the %s, more info => %w",
+				cdlerrors.CONNECTION_ERROR,
+				page,
+				desc,
+				err,
+			)
+		}
+
+		var resJson ArtworksJson
+		if err := httpfuncs.LoadJsonFromResponse(res.Resp, &resJson); err != nil {
+			return nil, err
+		}
+		for _, illust := range resJson.Illusts {
+			rank++
+			if page < minPage ||
+				(!contentSupported && !ranking.IsContentValid(illust.Type)) ||
+				!pixiv.pFilters.IsArtworkTypeValid(illust.Type) ||
+				!pixiv.isArtworkValid(illust) {
+				continue
+			}
+
+			artworkId := strconv.Itoa(illust.ID)
+			pixiv.addRanking(artworkId, metadata.PixivRanking{
+				Mode:    ranking.Mode,
+				Content: ranking.Content,
+				Date:    ranking.Date,
+				Rank:    rank,
+			})
+			artworkIds = append(artworkIds, artworkId)
+		}
+
+		if resJson.NextUrl == nil {
+			break
+		}
+		// the next URL already contains the query parameters
+		nextUrl = *resJson.NextUrl
+		params = nil
+		pixiv.Sleep()
+	}
+	return artworkIds, nil
+}
+
+// Get the artworks from multiple rankings and returns a slice of artwork IDs
+// to be used with GetMultipleArtworkDetails where the rank positions will be saved in the metadata.
+func (pixiv *PixivMobile) GetMultipleRankings(rankings []*pixivcommon.Ranking) ([]string, []error) {
+	var errSlice []error
+	var artworkIdsSlice []string
+	rankingsLen := len(rankings)
+	lastIdx := rankingsLen - 1
+
+	baseMsg := "Getting artworks from rankings on Pixiv's Mobile API [%d/" + fmt.Sprintf("%d]...", rankingsLen)
+	progress := pixiv.Base.MainProgBar()
+	progress.UpdateBaseMsg(baseMsg)
+	progress.UpdateSuccessMsg(
+		fmt.Sprintf(
+			"Finished getting artworks from %d ranking(s) from Pixiv's Mobile API!",
+			rankingsLen,
+		),
+	)
+	progress.UpdateErrorMsg(
+		fmt.Sprintf(
+			"Something went wrong while getting artworks from %d ranking(s) from Pixiv's Mobile API!\nPlease refer to the logs for more details.",
+			rankingsLen,
+		),
+	)
+	progress.SetToProgressBar()
+	progress.UpdateMax(rankingsLen)
+	progress.Start()
+	defer progress.SnapshotTask()
+	for idx, ranking := range rankings {
+		artworkIds, err := pixiv.getRanking(ranking)
+		if err != nil {
+			if hasCancelled := pixiv.Base.Session.GetLogger().LogErrors(logger.ERROR, err); hasCancelled {
+				pixiv.cancel()
+				progress.StopInterrupt("Stopped getting artworks from rankings from Pixiv's Mobile API!")
+				return nil, append(errSlice, err)
+			}
+			errSlice = append(errSlice, err)
+		}
+		artworkIdsSlice = append(artworkIdsSlice, artworkIds...)
+
+		if idx != lastIdx {
+			pixiv.Sleep()
+		}
+		progress.Increment()
+	}
+
+	progress.Stop(len(errSlice) > 0)
+	return artworkIdsSlice, errSlice
+}
@@ -0,0 +1,459 @@
+package novel
+
+import (
+	"archive/zip"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Book contains one or more novels to be exported as an EPUB, e.g. a single novel or a novel series.
+type Book struct {
+	Id          string // unique identifier of the book like the novel or series URL
+	Title       string
+	Author      string
+	Description string
+	Language    string // defaults to "ja"
+	Date        time.Time
+	CoverPath   string
+	Novels      []*Novel
+}
+
+// NewBook returns a book of a single novel.
+func NewBook(novel *Novel) *Book {
+	return &Book{
+		Id:          novel.Url,
+		Title:       novel.Title,
+		Author:      novel.Author,
+		Description: novel.Description,
+		Date:        novel.Date,
+		CoverPath:   novel.CoverPath,
+		Novels:      []*Novel{novel},
+	}
+}
+
+type epubItem struct {
+	id         string
+	href       string // relative to the OEBPS directory
+	mediaType  string
+	properties string
+}
+
+type epubWriter struct {
+	zw     *zip.Writer
+	book   *Book
+	items  []*epubItem
+	spine  []string
+	images map[string]string // local file path -> href
+}
+
+func getImageMediaType(filePath string) string {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".jpg", ".jpeg":
+		return "image/jpeg"
+	case ".png":
+		return "image/png"
+	case ".gif":
+		return "image/gif"
+	case ".webp":
+		return "image/webp"
+	case ".svg":
+		return "image/svg+xml"
+	default:
+		return ""
+	}
+}
+
+func escapeXml(text string) string {
+	return html.EscapeString(text)
+}
+
+func (w *epubWriter) writeFile(name, content string) error {
+	f, err := w.zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(f, content)
+	return err
+}
+
+func (w *epubWriter) addItem(item *epubItem, content string) error {
+	w.items = append(w.items, item)
+	return w.writeFile("OEBPS/"+item.href, content)
+}
+
+// Adds the image to the EPUB and returns its href or an empty string if the image is missing or unsupported
+func (w *epubWriter) addImage(filePath string, properties string) (string, error) {
+	if href, ok := w.images[filePath]; ok {
+		return href, nil
+	}
+
+	mediaType := getImageMediaType(filePath)
+	if mediaType == "" {
+		return "", nil
+	}
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	id := "img" + strconv.Itoa(len(w.images)+1)
+	href := "images/" + id + strings.ToLower(filepath.Ext(filePath))
+	f, err := w.zw.Create("OEBPS/" + href)
+	if err != nil {
+		return "", err
+	}
+	if _, err := f.Write(data); err != nil {
+		return "", err
+	}
+	w.items = append(w.items, &epubItem{id: id, href: href, mediaType: mediaType, properties: properties})
+	w.images[filePath] = href
+	return href, nil
+}
+
+func xhtmlDoc(title, lang, body string) string {
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" xml:lang="%[2]s" lang="%[2]s">
+<head>
+<meta charset="UTF-8"/>
+<title>%[1]s</title>
+<link rel="stylesheet" type="text/css" href="../style.css"/>
+</head>
+<body>
+%[3]s
+</body>
+</html>
+`, escapeXml(title), lang, body)
+}
+
+func getPageHref(novelIdx, pageIdx int) string {
+	return fmt.Sprintf("text/novel%d_page%d.xhtml", novelIdx+1, pageIdx+1)
+}
+
+type xhtmlRenderer struct {
+	w        *epubWriter
+	novel    *Novel
+	novelIdx int
+	chapters int // number of chapters rendered so far to generate the anchors
+	err      error
+}
+
+func (r *xhtmlRenderer) text(text string) string {
+	return escapeXml(text)
+}
+
+func (r *xhtmlRenderer) ruby(base, reading string) string {
+	return "<ruby>" + escapeXml(base) + "<rt>" + escapeXml(reading) + "</rt></ruby>"
+}
+
+func (r *xhtmlRenderer) chapter(title []*Node) string {
+	r.chapters++
+	var sb strings.Builder
+	for _, node := range title {
+		if node.Type == RUBY {
+			sb.WriteString(r.ruby(node.Text, node.Value))
+		} else {
+			sb.WriteString(escapeXml(node.Text))
+		}
+	}
+	return fmt.Sprintf(`<h2 id="chapter%d">%s</h2>`, r.chapters, sb.String())
+}
+
+func (r *xhtmlRenderer) image(node *Node) string {
+	imagePath, ok := r.novel.Images[node.ImageKey()]
+	if !ok {
+		return ""
+	}
+	href, err := r.w.addImage(imagePath, "")
+	if err != nil {
+		r.err = err
+		return ""
+	}
+	if href == "" {
+		return ""
+	}
+	return fmt.Sprintf(`<div class="image"><img src="../%s" alt="%s"/></div>`, href, escapeXml(node.ImageKey()))
+}
+
+func (r *xhtmlRenderer) jumpPage(page string) string {
+	pageNum, err := strconv.Atoi(page)
+	if err != nil || pageNum < 1 || pageNum > len(r.novel.Pages) {
+		return escapeXml(page)
+	}
+	return fmt.Sprintf(`<a href="../%s">%s</a>`, getPageHref(r.novelIdx, pageNum-1), escapeXml(page))
+}
+
+func (r *xhtmlRenderer) jumpUri(text, uri string) string {
+	return fmt.Sprintf(`<a href="%s">%s</a>`, escapeXml(uri), escapeXml(text))
+}
+
+type navPoint struct {
+	title    string
+	href     string
+	children []*navPoint
+}
+
+func (w *epubWriter) writeNovel(novelIdx int, novel *Novel) (*navPoint, error) {
+	lang := w.book.Language
+	r := &xhtmlRenderer{w: w, novel: novel, novelIdx: novelIdx}
+	nav := &navPoint{title: novel.Title, href: getPageHref(novelIdx, 0)}
+	chapters, _ := novel.Chapters()
+	for pageIdx, page := range novel.Pages {
+		var body strings.Builder
+		if pageIdx == 0 {
+			body.WriteString(fmt.Sprintf("<h1>%s</h1>\n", escapeXml(novel.Title)))
+			if len(w.book.Novels) == 1 && novel.CoverPath != "" && w.book.CoverPath == "" {
+				if href, err := w.addImage(novel.CoverPath, ""); err != nil {
+					return nil, err
+				} else if href != "" {
+					body.WriteString(fmt.Sprintf(`<div class="image"><img src="../%s" alt="cover"/></div>`+"\n", href))
+				}
+			}
+		}
+
+		chaptersBefore := r.chapters
+		for _, l := range renderPage(page, r) {
+			switch {
+			case l.block:
+				body.WriteString(l.content + "\n")
+			case l.content == "":
+				body.WriteString("<p><br/></p>\n")
+			default:
+				body.WriteString("<p>" + l.content + "</p>\n")
+			}
+		}
+		if r.err != nil {
+			return nil, r.err
+		}
+
+		href := getPageHref(novelIdx, pageIdx)
+		for chapterIdx := chaptersBefore + 1; chapterIdx <= r.chapters; chapterIdx++ {
+			nav.children = append(nav.children, &navPoint{
+				title: chapters[chapterIdx-1].PlainText(),
+				href:  fmt.Sprintf("%s#chapter%d", href, chapterIdx),
+			})
+		}
+
+		id := fmt.Sprintf("novel%d_page%d", novelIdx+1, pageIdx+1)
+		err := w.addItem(
+			&epubItem{id: id, href: href, mediaType: "application/xhtml+xml"},
+			xhtmlDoc(novel.Title, lang, body.String()),
+		)
+		if err != nil {
+			return nil, err
+		}
+		w.spine = append(w.spine, id)
+	}
+	return nav, nil
+}
+
+func writeNavList(sb *strings.Builder, points []*navPoint, indent string) {
+	sb.WriteString(indent + "<ol>\n")
+	for _, point := range points {
+		sb.WriteString(fmt.Sprintf(`%s<li><a href="%s">%s</a>`, indent+"  ", point.href, escapeXml(point.title)))
+		if len(point.children) > 0 {
+			sb.WriteString("\n")
+			writeNavList(sb, point.children, indent+"    ")
+			sb.WriteString(indent + "  ")
+		}
+		sb.WriteString("</li>\n")
+	}
+	sb.WriteString(indent + "</ol>\n")
+}
+
+func writeNcxPoints(sb *strings.Builder, points []*navPoint, playOrder *int) {
+	for _, point := range points {
+		*playOrder++
+		sb.WriteString(fmt.Sprintf(
+			"<navPoint id=\"navPoint%[1]d\" playOrder=\"%[1]d\"><navLabel><text>%[2]s</text></navLabel><content src=\"%[3]s\"/>\n",
+			*playOrder,
+			escapeXml(point.title),
+			point.href,
+		))
+		writeNcxPoints(sb, point.children, playOrder)
+		sb.WriteString("</navPoint>\n")
+	}
+}
+
+func (w *epubWriter) writeNav(points []*navPoint) error {
+	// A single novel lists its chapters instead of itself
+	if len(points) == 1 && len(points[0].children) > 0 {
+		points = points[0].children
+	}
+
+	var sb strings.Builder
+	sb.WriteString(`<nav epub:type="toc" id="toc">` + "\n<h1>Contents</h1>\n")
+	writeNavList(&sb, points, "")
+	sb.WriteString("</nav>")
+	navDoc := strings.Replace(xhtmlDoc(w.book.Title, w.book.Language, sb.String()), "../style.css", "style.css", 1)
+	if err := w.addItem(&epubItem{id: "nav", href: "nav.xhtml", mediaType: "application/xhtml+xml", properties: "nav"}, navDoc); err != nil {
+		return err
+	}
+
+	// for EPUB 2 readers
+	var ncx strings.Builder
+	ncx.WriteString(fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<ncx xmlns="http://www.daisy.org/z3986/2005/ncx/" version="2005-1">
+<head><meta name="dtb:uid" content="%s"/></head>
+<docTitle><text>%s</text></docTitle>
+<navMap>
+`, escapeXml(w.book.Id), escapeXml(w.book.Title)))
+	playOrder := 0
+	writeNcxPoints(&ncx, points, &playOrder)
+	ncx.WriteString("</navMap>\n</ncx>\n")
+	return w.addItem(&epubItem{id: "ncx", href: "toc.ncx", mediaType: "application/x-dtbncx+xml"}, ncx.String())
+}
+
+func (w *epubWriter) writePackage(coverHref string) error {
+	book := w.book
+	modified := book.Date
+	if modified.IsZero() {
+		modified = time.Now()
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="book-id" xml:lang="%s">
+<metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+<dc:identifier id="book-id">%s</dc:identifier>
+<dc:title>%s</dc:title>
+<dc:language>%s</dc:language>
+<meta property="dcterms:modified">%s</meta>
+`,
+		book.Language,
+		escapeXml(book.Id),
+		escapeXml(book.Title),
+		book.Language,
+		modified.UTC().Format("2006-01-02T15:04:05Z"),
+	))
+	if book.Author != "" {
+		sb.WriteString("<dc:creator>" + escapeXml(book.Author) + "</dc:creator>\n")
+	}
+	if book.Description != "" {
+		sb.WriteString("<dc:description>" + escapeXml(book.Description) + "</dc:description>\n")
+	}
+	if !book.Date.IsZero() {
+		sb.WriteString("<dc:date>" + book.Date.UTC().Format(time.RFC3339) + "</dc:date>\n")
+	}
+	if coverHref != "" {
+		// for EPUB 2 readers
+		coverId := strings.TrimSuffix(path.Base(coverHref), path.Ext(coverHref))
+		sb.WriteString(`<meta name="cover" content="` + coverId + `"/>` + "\n")
+	}
+	sb.WriteString("</metadata>\n<manifest>\n")
+	for _, item := range w.items {
+		sb.WriteString(fmt.Sprintf(`<item id="%s" href="%s" media-type="%s"`, item.id, item.href, item.mediaType))
+		if item.properties != "" {
+			sb.WriteString(fmt.Sprintf(` properties="%s"`, item.properties))
+		}
+		sb.WriteString("/>\n")
+	}
+	sb.WriteString("</manifest>\n<spine toc=\"ncx\">\n")
+	for _, id := range w.spine {
+		sb.WriteString(fmt.Sprintf(`<itemref idref="%s"/>`+"\n", id))
+	}
+	sb.WriteString("</spine>\n</package>\n")
+	return w.writeFile("OEBPS/content.opf", sb.String())
+}
+
+const epubStyle = `body { line-height: 1.8; }
+p { margin: 0; text-indent: 0; }
+h1, h2 { line-height: 1.4; }
+div.image { text-align: center; margin: 1em 0; }
+div.image img { max-width: 100%; max-height: 95vh; }
+`
+
+const epubContainer = `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+<rootfiles>
+<rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+</rootfiles>
+</container>
+`
+
+// WriteEpub writes the book as an EPUB 3 file to w.
+//
+// Images that are missing from the disk will be skipped.
+func WriteEpub(w io.Writer, book *Book) error {
+	if book.Language == "" {
+		book.Language = "ja"
+	}
+
+	zw := zip.NewWriter(w)
+	ew := &epubWriter{zw: zw, book: book, images: make(map[string]string)}
+
+	// The mimetype must be the first file in the archive and must not be compressed
+	mimetype, err := zw.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(mimetype, "application/epub+zip"); err != nil {
+		return err
+	}
+	if err := ew.writeFile("META-INF/container.xml", epubContainer); err != nil {
+		return err
+	}
+	if err := ew.addItem(&epubItem{id: "style", href: "style.css", mediaType: "text/css"}, epubStyle); err != nil {
+		return err
+	}
+
+	var coverHref string
+	if book.CoverPath != "" {
+		if coverHref, err = ew.addImage(book.CoverPath, "cover-image"); err != nil {
+			return err
+		}
+		if coverHref != "" {
+			body := fmt.Sprintf(`<div class="image"><img src="../%s" alt="cover"/></div>`, coverHref)
+			err := ew.addItem(
+				&epubItem{id: "cover", href: "text/cover.xhtml", mediaType: "application/xhtml+xml"},
+				xhtmlDoc(book.Title, book.Language, body),
+			)
+			if err != nil {
+				return err
+			}
+			ew.spine = append(ew.spine, "cover")
+		}
+	}
+
+	points := make([]*navPoint, 0, len(book.Novels))
+	for novelIdx, novel := range book.Novels {
+		point, err := ew.writeNovel(novelIdx, novel)
+		if err != nil {
+			return err
+		}
+		points = append(points, point)
+	}
+	if err := ew.writeNav(points); err != nil {
+		return err
+	}
+	if err := ew.writePackage(coverHref); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// WriteEpubFile writes the book as an EPUB 3 file to the file path.
+func WriteEpubFile(filePath string, book *Book) error {
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	if err := WriteEpub(f, book); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
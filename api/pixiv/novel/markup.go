@@ -0,0 +1,161 @@
+package novel
+
+import (
+	"regexp"
+	"strings"
+)
+
+type NodeType int
+
+const (
+	TEXT           NodeType = iota
+	RUBY                    // [[rb:漢字 > かんじ]]
+	CHAPTER                 // [chapter:title]
+	PIXIV_IMAGE             // [pixivimage:12345] or [pixivimage:12345-2] for the 2nd page of the artwork
+	UPLOADED_IMAGE          // [uploadedimage:12345]
+	JUMP_PAGE               // [jump:2]
+	JUMP_URI                // [[jumpuri:text > https://example.com]]
+)
+
+// Node is a part of a novel page parsed from Pixiv's novel markup.
+type Node struct {
+	Type NodeType
+
+	// The text for TEXT, the base text for RUBY, and the link text for JUMP_URI
+	Text string
+
+	// The reading for RUBY, the ID for the images, the page number for JUMP_PAGE, and the URL for JUMP_URI
+	Value string
+
+	// The parsed title of a CHAPTER as it can contain ruby
+	Children []*Node
+}
+
+// Page is a page of a novel separated by the [newpage] markers.
+type Page []*Node
+
+const (
+	newPageTag  = "[newpage]"
+	rubyPattern = `\[\[rb:\s*(.+?)\s*>\s*(.+?)\s*\]\]`
+)
+
+var (
+	rubyRegex   = regexp.MustCompile(rubyPattern)
+	markupRegex = regexp.MustCompile(
+		`\[chapter:((?:[^\[\]]|\[\[rb:[^\]]*\]\])*)\]` +
+			`|` + rubyPattern +
+			`|\[pixivimage:(\d+(?:-\d+)?)\]` +
+			`|\[uploadedimage:(\d+)\]` +
+			`|\[jump:(\d+)\]` +
+			`|\[\[jumpuri:\s*(.+?)\s*>\s*(\S+?)\s*\]\]`,
+	)
+)
+
+// Parses the text which can only contain ruby like chapter titles
+func parseRuby(text string) []*Node {
+	var nodes []*Node
+	last := 0
+	for _, match := range rubyRegex.FindAllStringSubmatchIndex(text, -1) {
+		if match[0] > last {
+			nodes = append(nodes, &Node{Type: TEXT, Text: text[last:match[0]]})
+		}
+		nodes = append(nodes, &Node{
+			Type:  RUBY,
+			Text:  text[match[2]:match[3]],
+			Value: text[match[4]:match[5]],
+		})
+		last = match[1]
+	}
+	if last < len(text) {
+		nodes = append(nodes, &Node{Type: TEXT, Text: text[last:]})
+	}
+	return nodes
+}
+
+func parsePage(content string) Page {
+	var page Page
+	last := 0
+	for _, match := range markupRegex.FindAllStringSubmatchIndex(content, -1) {
+		if match[0] > last {
+			page = append(page, &Node{Type: TEXT, Text: content[last:match[0]]})
+		}
+		group := func(idx int) string {
+			if match[idx*2] == -1 {
+				return ""
+			}
+			return content[match[idx*2]:match[idx*2+1]]
+		}
+		switch {
+		case match[2] != -1:
+			page = append(page, &Node{Type: CHAPTER, Text: group(1), Children: parseRuby(group(1))})
+		case match[4] != -1:
+			page = append(page, &Node{Type: RUBY, Text: group(2), Value: group(3)})
+		case match[8] != -1:
+			page = append(page, &Node{Type: PIXIV_IMAGE, Value: group(4)})
+		case match[10] != -1:
+			page = append(page, &Node{Type: UPLOADED_IMAGE, Value: group(5)})
+		case match[12] != -1:
+			page = append(page, &Node{Type: JUMP_PAGE, Value: group(6)})
+		default:
+			page = append(page, &Node{Type: JUMP_URI, Text: group(7), Value: group(8)})
+		}
+		last = match[1]
+	}
+	if last < len(content) {
+		page = append(page, &Node{Type: TEXT, Text: content[last:]})
+	}
+	return page
+}
+
+// Parse parses the content of a Pixiv novel into its pages.
+func Parse(content string) []Page {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	rawPages := strings.Split(content, newPageTag)
+	pages := make([]Page, 0, len(rawPages))
+	for _, rawPage := range rawPages {
+		pages = append(pages, parsePage(strings.Trim(rawPage, "\n")))
+	}
+	return pages
+}
+
+// ImageKey returns the key of an image node in Novel.Images,
+// e.g. "pixivimage:12345-2" or "uploadedimage:12345".
+func (n *Node) ImageKey() string {
+	switch n.Type {
+	case PIXIV_IMAGE:
+		return "pixivimage:" + n.Value
+	case UPLOADED_IMAGE:
+		return "uploadedimage:" + n.Value
+	default:
+		return ""
+	}
+}
+
+// PlainText returns the text of the node without any markup, e.g. the base text of a ruby.
+func (n *Node) PlainText() string {
+	switch n.Type {
+	case TEXT, RUBY, JUMP_URI:
+		return n.Text
+	case CHAPTER:
+		var sb strings.Builder
+		for _, child := range n.Children {
+			sb.WriteString(child.PlainText())
+		}
+		return sb.String()
+	default:
+		return ""
+	}
+}
+
+// ImageNodes returns the image nodes in the pages.
+func ImageNodes(pages []Page) []*Node {
+	var nodes []*Node
+	for _, page := range pages {
+		for _, node := range page {
+			if node.Type == PIXIV_IMAGE || node.Type == UPLOADED_IMAGE {
+				nodes = append(nodes, node)
+			}
+		}
+	}
+	return nodes
+}
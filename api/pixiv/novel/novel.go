@@ -0,0 +1,279 @@
+package novel
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	FORMAT_TEXT     = "txt"
+	FORMAT_MARKDOWN = "md"
+	FORMAT_EPUB     = "epub"
+)
+
+var ACCEPTED_FORMATS = []string{FORMAT_TEXT, FORMAT_MARKDOWN, FORMAT_EPUB}
+
+// Novel is a downloaded Pixiv novel to be exported.
+type Novel struct {
+	Id          string
+	Url         string
+	Title       string
+	Author      string
+	AuthorId    string
+	Description string // plain text
+	Tags        []string
+	Date        time.Time
+
+	// 0 if the novel is not part of a series
+	SeriesOrder int
+
+	Pages []Page
+
+	// Local file paths of the downloaded images which can be missing if the download failed
+	CoverPath string
+	Images    map[string]string // Node.ImageKey() -> file path
+}
+
+// Chapters returns the chapter nodes of the novel with their 0-indexed page numbers.
+func (n *Novel) Chapters() ([]*Node, []int) {
+	var chapters []*Node
+	var pageIdxs []int
+	for pageIdx, page := range n.Pages {
+		for _, node := range page {
+			if node.Type == CHAPTER {
+				chapters = append(chapters, node)
+				pageIdxs = append(pageIdxs, pageIdx)
+			}
+		}
+	}
+	return chapters, pageIdxs
+}
+
+// Either an inline or a block element of a rendered line
+type line struct {
+	content string
+	block   bool
+}
+
+type renderer interface {
+	text(text string) string
+	ruby(base, reading string) string
+	chapter(title []*Node) string
+	image(node *Node) string
+	jumpPage(page string) string
+	jumpUri(text, uri string) string
+}
+
+// Renders the page into lines where block elements like chapters and images are on their own lines
+func renderPage(page Page, r renderer) []line {
+	lines := []line{{}}
+	appendInline := func(content string) {
+		lines[len(lines)-1].content += content
+	}
+	appendBlock := func(content string) {
+		if last := lines[len(lines)-1]; last.content == "" && !last.block {
+			lines = lines[:len(lines)-1]
+		}
+		lines = append(lines, line{content: content, block: true}, line{})
+	}
+
+	for _, node := range page {
+		switch node.Type {
+		case TEXT:
+			for i, text := range strings.Split(node.Text, "\n") {
+				if i > 0 {
+					lines = append(lines, line{})
+				}
+				appendInline(r.text(text))
+			}
+		case RUBY:
+			appendInline(r.ruby(node.Text, node.Value))
+		case CHAPTER:
+			appendBlock(r.chapter(node.Children))
+		case PIXIV_IMAGE, UPLOADED_IMAGE:
+			if content := r.image(node); content != "" {
+				appendBlock(content)
+			}
+		case JUMP_PAGE:
+			appendInline(r.jumpPage(node.Value))
+		case JUMP_URI:
+			appendInline(r.jumpUri(node.Text, node.Value))
+		}
+	}
+	if last := lines[len(lines)-1]; last.content == "" && !last.block {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// Returns the image path relative to the output directory or an empty string if the image is missing
+func relImagePath(images map[string]string, node *Node, outputDir string) string {
+	imagePath, ok := images[node.ImageKey()]
+	if !ok {
+		return ""
+	}
+	if relPath, err := filepath.Rel(outputDir, imagePath); err == nil {
+		imagePath = relPath
+	}
+	return filepath.ToSlash(imagePath)
+}
+
+type textRenderer struct {
+	novel     *Novel
+	outputDir string
+}
+
+func (r *textRenderer) text(text string) string {
+	return text
+}
+
+// Uses the Aozora Bunko format, e.g. ｜漢字《かんじ》
+func (r *textRenderer) ruby(base, reading string) string {
+	return "｜" + base + "《" + reading + "》"
+}
+
+func (r *textRenderer) chapter(title []*Node) string {
+	var sb strings.Builder
+	for _, node := range title {
+		if node.Type == RUBY {
+			sb.WriteString(r.ruby(node.Text, node.Value))
+		} else {
+			sb.WriteString(node.Text)
+		}
+	}
+	return "\n【" + sb.String() + "】\n"
+}
+
+func (r *textRenderer) image(node *Node) string {
+	if imagePath := relImagePath(r.novel.Images, node, r.outputDir); imagePath != "" {
+		return "[image: " + imagePath + "]"
+	}
+	return "[" + node.ImageKey() + "]"
+}
+
+func (r *textRenderer) jumpPage(page string) string {
+	return "[→ page " + page + "]"
+}
+
+func (r *textRenderer) jumpUri(text, uri string) string {
+	return text + " (" + uri + ")"
+}
+
+// Text returns the novel as plain text where the ruby is in the Aozora Bunko format
+// and the images are referenced relative to the output directory.
+func (n *Novel) Text(outputDir string) string {
+	var sb strings.Builder
+	sb.WriteString(n.Title + "\n")
+	if n.Author != "" {
+		sb.WriteString("by " + n.Author + "\n")
+	}
+	if n.Url != "" {
+		sb.WriteString(n.Url + "\n")
+	}
+	if n.Description != "" {
+		sb.WriteString("\n" + n.Description + "\n")
+	}
+
+	r := &textRenderer{novel: n, outputDir: outputDir}
+	for pageIdx, page := range n.Pages {
+		sb.WriteString(fmt.Sprintf("\n――――――――― %d ―――――――――\n\n", pageIdx+1))
+		for _, l := range renderPage(page, r) {
+			sb.WriteString(l.content + "\n")
+		}
+	}
+	return sb.String()
+}
+
+type markdownRenderer struct {
+	novel     *Novel
+	outputDir string
+}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"*", `\*`,
+	"_", `\_`,
+	"`", "\\`",
+	"[", `\[`,
+	"]", `\]`,
+	"<", "&lt;",
+	">", "&gt;",
+	"#", `\#`,
+)
+
+func (r *markdownRenderer) text(text string) string {
+	return markdownEscaper.Replace(text)
+}
+
+func (r *markdownRenderer) ruby(base, reading string) string {
+	return "<ruby>" + r.text(base) + "<rt>" + r.text(reading) + "</rt></ruby>"
+}
+
+func (r *markdownRenderer) chapter(title []*Node) string {
+	var sb strings.Builder
+	for _, node := range title {
+		if node.Type == RUBY {
+			sb.WriteString(r.ruby(node.Text, node.Value))
+		} else {
+			sb.WriteString(r.text(node.Text))
+		}
+	}
+	return "## " + sb.String()
+}
+
+func (r *markdownRenderer) image(node *Node) string {
+	if imagePath := relImagePath(r.novel.Images, node, r.outputDir); imagePath != "" {
+		return "![" + node.ImageKey() + "](<" + imagePath + ">)"
+	}
+	return ""
+}
+
+func (r *markdownRenderer) jumpPage(page string) string {
+	return "[page " + page + "](#page-" + page + ")"
+}
+
+func (r *markdownRenderer) jumpUri(text, uri string) string {
+	return "[" + r.text(text) + "](<" + uri + ">)"
+}
+
+// Markdown returns the novel in Markdown where the ruby uses HTML ruby tags
+// and the images are referenced relative to the output directory.
+func (n *Novel) Markdown(outputDir string) string {
+	r := &markdownRenderer{novel: n, outputDir: outputDir}
+	var sb strings.Builder
+	sb.WriteString("# " + r.text(n.Title) + "\n\n")
+	if n.Author != "" {
+		sb.WriteString("by " + r.text(n.Author) + "  \n")
+	}
+	if n.Url != "" {
+		sb.WriteString("<" + n.Url + ">\n")
+	}
+	if n.CoverPath != "" {
+		if relPath, err := filepath.Rel(outputDir, n.CoverPath); err == nil {
+			sb.WriteString("\n![cover](<" + filepath.ToSlash(relPath) + ">)\n")
+		}
+	}
+	if n.Description != "" {
+		sb.WriteString("\n> " + strings.ReplaceAll(r.text(n.Description), "\n", "  \n> ") + "\n")
+	}
+
+	for pageIdx, page := range n.Pages {
+		sb.WriteString(fmt.Sprintf("\n---\n\n<a id=\"page-%d\"></a>\n\n", pageIdx+1))
+		lines := renderPage(page, r)
+		for idx, l := range lines {
+			switch {
+			case l.block:
+				sb.WriteString("\n" + l.content + "\n\n")
+			case l.content == "":
+				sb.WriteString("\n")
+			case idx+1 < len(lines) && !lines[idx+1].block && lines[idx+1].content != "":
+				sb.WriteString(l.content + "  \n") // hard line break
+			default:
+				sb.WriteString(l.content + "\n")
+			}
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,146 @@
+package novel
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testContent = "[chapter:第一章 [[rb:始 > はじ]]まり]\r\n" +
+	"[[rb:漢字 > かんじ]]を読む。\n" +
+	"[pixivimage:12345-2]\n" +
+	"[newpage]\n" +
+	"[uploadedimage:678]\n" +
+	"[jump:1] and [[jumpuri:Pixiv > https://www.pixiv.net]]"
+
+func TestParse(t *testing.T) {
+	pages := Parse(testContent)
+	if len(pages) != 2 {
+		t.Fatalf("Expected 2 pages but got %d", len(pages))
+	}
+
+	chapter := pages[0][0]
+	if chapter.Type != CHAPTER || chapter.PlainText() != "第一章 始まり" {
+		t.Errorf("Unexpected chapter node %+v", chapter)
+	}
+	if len(chapter.Children) != 3 || chapter.Children[1].Type != RUBY || chapter.Children[1].Value != "はじ" {
+		t.Errorf("Expected the chapter title to contain ruby but got %+v", chapter.Children)
+	}
+
+	ruby := pages[0][2]
+	if ruby.Type != RUBY || ruby.Text != "漢字" || ruby.Value != "かんじ" {
+		t.Errorf("Unexpected ruby node %+v", ruby)
+	}
+
+	images := ImageNodes(pages)
+	if len(images) != 2 || images[0].ImageKey() != "pixivimage:12345-2" || images[1].ImageKey() != "uploadedimage:678" {
+		t.Errorf("Unexpected image nodes %+v", images)
+	}
+
+	var jumpPage, jumpUri *Node
+	for _, node := range pages[1] {
+		switch node.Type {
+		case JUMP_PAGE:
+			jumpPage = node
+		case JUMP_URI:
+			jumpUri = node
+		}
+	}
+	if jumpPage == nil || jumpPage.Value != "1" {
+		t.Errorf("Unexpected jump node %+v", jumpPage)
+	}
+	if jumpUri == nil || jumpUri.Text != "Pixiv" || jumpUri.Value != "https://www.pixiv.net" {
+		t.Errorf("Unexpected jump URI node %+v", jumpUri)
+	}
+}
+
+func newTestNovel(t *testing.T) (*Novel, string) {
+	dirPath := t.TempDir()
+	imagePath := filepath.Join(dirPath, "678.png")
+	if err := os.WriteFile(imagePath, []byte("png"), 0644); err != nil {
+		t.Fatalf("Failed to write image: %v", err)
+	}
+	return &Novel{
+		Id:     "1",
+		Url:    "https://www.pixiv.net/novel/show.php?id=1",
+		Title:  "Test <Novel>",
+		Author: "Author",
+		Date:   time.Date(2024, 5, 24, 0, 0, 0, 0, time.UTC),
+		Pages:  Parse(testContent),
+		Images: map[string]string{
+			"uploadedimage:678":  imagePath,
+			"pixivimage:12345-2": filepath.Join(dirPath, "missing.jpg"),
+		},
+	}, dirPath
+}
+
+func TestTextAndMarkdown(t *testing.T) {
+	novel, dirPath := newTestNovel(t)
+
+	text := novel.Text(dirPath)
+	for _, expected := range []string{"【第一章 ｜始《はじ》まり】", "｜漢字《かんじ》を読む。", "[image: 678.png]", "Pixiv (https://www.pixiv.net)"} {
+		if !strings.Contains(text, expected) {
+			t.Errorf("Expected the text to contain %q but got:\n%s", expected, text)
+		}
+	}
+
+	md := novel.Markdown(dirPath)
+	for _, expected := range []string{"# Test &lt;Novel&gt;", "<ruby>漢字<rt>かんじ</rt></ruby>", "![uploadedimage:678](<678.png>)", "[page 1](#page-1)", `<a id="page-2"></a>`} {
+		if !strings.Contains(md, expected) {
+			t.Errorf("Expected the Markdown to contain %q but got:\n%s", expected, md)
+		}
+	}
+}
+
+func TestWriteEpub(t *testing.T) {
+	novel, _ := newTestNovel(t)
+
+	var buf bytes.Buffer
+	if err := WriteEpub(&buf, NewBook(novel)); err != nil {
+		t.Fatalf("Failed to write EPUB: %v", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Failed to read EPUB: %v", err)
+	}
+
+	if zr.File[0].Name != "mimetype" || zr.File[0].Method != zip.Store {
+		t.Errorf("Expected the mimetype to be the first uncompressed file")
+	}
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("Failed to open %s: %v", f.Name, err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		files[f.Name] = string(data)
+	}
+
+	for _, name := range []string{"META-INF/container.xml", "OEBPS/content.opf", "OEBPS/nav.xhtml", "OEBPS/toc.ncx", "OEBPS/images/img1.png"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("Expected %s in the EPUB", name)
+		}
+	}
+	if page := files["OEBPS/text/novel1_page1.xhtml"]; !strings.Contains(page, "<h1>Test &lt;Novel&gt;</h1>") ||
+		!strings.Contains(page, "<ruby>漢字<rt>かんじ</rt></ruby>") {
+		t.Errorf("Unexpected first page:\n%s", page)
+	}
+	if page := files["OEBPS/text/novel1_page2.xhtml"]; !strings.Contains(page, `src="../images/img1.png"`) ||
+		!strings.Contains(page, `href="../text/novel1_page1.xhtml"`) {
+		t.Errorf("Unexpected second page:\n%s", page)
+	}
+	if nav := files["OEBPS/nav.xhtml"]; !strings.Contains(nav, `href="text/novel1_page1.xhtml#chapter1">第一章 始まり</a>`) {
+		t.Errorf("Expected the chapter in the table of contents but got:\n%s", nav)
+	}
+	if opf := files["OEBPS/content.opf"]; !strings.Contains(opf, `<itemref idref="novel1_page2"/>`) ||
+		strings.Contains(opf, "missing") {
+		t.Errorf("Unexpected package document:\n%s", opf)
+	}
+}
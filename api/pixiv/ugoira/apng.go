@@ -0,0 +1,257 @@
+package ugoira
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/draw"
+	"io"
+)
+
+// https://wiki.mozilla.org/APNG_Specification
+const (
+	PNG_SIGNATURE = "\x89PNG\r\n\x1a\n"
+
+	pngColorTypeRGB  = 2
+	pngColorTypeRGBA = 6
+
+	pngFilterNone    = 0
+	pngFilterSub     = 1
+	pngFilterUp      = 2
+	pngFilterAverage = 3
+	pngFilterPaeth   = 4
+)
+
+type apngWriter struct {
+	w        io.Writer
+	width    int
+	height   int
+	hasAlpha bool
+	seq      uint32 // sequence number of the fcTL and fdAT chunks
+}
+
+func (a *apngWriter) writeChunk(chunkType string, data []byte) error {
+	header := make([]byte, 8)
+	binary.BigEndian.PutUint32(header[:4], uint32(len(data)))
+	copy(header[4:], chunkType)
+
+	crc := crc32.NewIEEE()
+	crc.Write(header[4:])
+	crc.Write(data)
+	footer := binary.BigEndian.AppendUint32(nil, crc.Sum32())
+
+	for _, b := range [][]byte{header, data, footer} {
+		if _, err := a.w.Write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *apngWriter) writeHeader(frameCount int) error {
+	if _, err := io.WriteString(a.w, PNG_SIGNATURE); err != nil {
+		return err
+	}
+
+	colorType := byte(pngColorTypeRGB)
+	if a.hasAlpha {
+		colorType = pngColorTypeRGBA
+	}
+	ihdr := binary.BigEndian.AppendUint32(nil, uint32(a.width))
+	ihdr = binary.BigEndian.AppendUint32(ihdr, uint32(a.height))
+	ihdr = append(ihdr, 8, colorType, 0, 0, 0) // bit depth, colour type, compression, filter, interlace
+	if err := a.writeChunk("IHDR", ihdr); err != nil {
+		return err
+	}
+
+	actl := binary.BigEndian.AppendUint32(nil, uint32(frameCount))
+	actl = binary.BigEndian.AppendUint32(actl, 0) // loop infinitely
+	return a.writeChunk("acTL", actl)
+}
+
+// Returns the delay in milliseconds as a fraction of a second that fits in the fcTL chunk.
+func apngDelay(delay int64) (uint16, uint16) {
+	delay = max(delay, 0)
+	for _, den := range []int64{1000, 100, 10} {
+		if num := delay * den / 1000; num <= 0xffff {
+			return uint16(num), uint16(den)
+		}
+	}
+	return uint16(min(delay/1000, 0xffff)), 1
+}
+
+func (a *apngWriter) writeFrame(img *image.NRGBA, delay int64, isFirst bool) error {
+	delayNum, delayDen := apngDelay(delay)
+	fctl := binary.BigEndian.AppendUint32(nil, a.seq)
+	fctl = binary.BigEndian.AppendUint32(fctl, uint32(a.width))
+	fctl = binary.BigEndian.AppendUint32(fctl, uint32(a.height))
+	fctl = binary.BigEndian.AppendUint32(fctl, 0) // x offset
+	fctl = binary.BigEndian.AppendUint32(fctl, 0) // y offset
+	fctl = binary.BigEndian.AppendUint16(fctl, delayNum)
+	fctl = binary.BigEndian.AppendUint16(fctl, delayDen)
+	fctl = append(fctl, 0, 0) // dispose_op none, blend_op source
+	if err := a.writeChunk("fcTL", fctl); err != nil {
+		return err
+	}
+	a.seq++
+
+	data, err := a.compressFrame(img)
+	if err != nil {
+		return err
+	}
+	if isFirst {
+		// the first frame is also the default image for decoders without APNG support
+		return a.writeChunk("IDAT", data)
+	}
+
+	fdat := binary.BigEndian.AppendUint32(make([]byte, 0, 4+len(data)), a.seq)
+	fdat = append(fdat, data...)
+	a.seq++
+	return a.writeChunk("fdAT", fdat)
+}
+
+// Filters each row of the image with the filter that most likely compresses
+// the best and returns the zlib compressed image data.
+func (a *apngWriter) compressFrame(img *image.NRGBA) ([]byte, error) {
+	bpp := 3
+	if a.hasAlpha {
+		bpp = 4
+	}
+	rowLen := a.width * bpp
+
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	prev := make([]byte, rowLen)
+	cur := make([]byte, rowLen)
+	var filtered [5][]byte
+	for filter := range filtered {
+		filtered[filter] = make([]byte, rowLen+1)
+		filtered[filter][0] = byte(filter)
+	}
+	for y := 0; y < a.height; y++ {
+		row := img.Pix[y*img.Stride : y*img.Stride+a.width*4]
+		if a.hasAlpha {
+			copy(cur, row)
+		} else {
+			for x := 0; x < a.width; x++ {
+				copy(cur[x*3:x*3+3], row[x*4:x*4+3])
+			}
+		}
+
+		if _, err := zw.Write(filterRow(&filtered, cur, prev, bpp)); err != nil {
+			return nil, err
+		}
+		prev, cur = cur, prev
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func absDiff(a, b int) int {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+func paeth(left, up, upLeft byte) byte {
+	p := int(left) + int(up) - int(upLeft)
+	pa, pb, pc := absDiff(p, int(left)), absDiff(p, int(up)), absDiff(p, int(upLeft))
+	if pa <= pb && pa <= pc {
+		return left
+	}
+	if pb <= pc {
+		return up
+	}
+	return upLeft
+}
+
+// Returns the filtered row with the lowest sum of absolute differences
+// which is the heuristic recommended by the PNG specification.
+func filterRow(filtered *[5][]byte, cur, prev []byte, bpp int) []byte {
+	for x := range cur {
+		var left, upLeft byte
+		if x >= bpp {
+			left, upLeft = cur[x-bpp], prev[x-bpp]
+		}
+		up := prev[x]
+		filtered[pngFilterNone][x+1] = cur[x]
+		filtered[pngFilterSub][x+1] = cur[x] - left
+		filtered[pngFilterUp][x+1] = cur[x] - up
+		filtered[pngFilterAverage][x+1] = cur[x] - byte((int(left)+int(up))/2)
+		filtered[pngFilterPaeth][x+1] = cur[x] - paeth(left, up, upLeft)
+	}
+
+	best, bestSum := 0, -1
+	for filter, row := range filtered {
+		sum := 0
+		for _, b := range row[1:] {
+			sum += absDiff(int(int8(b)), 0)
+		}
+		if bestSum == -1 || sum < bestSum {
+			best, bestSum = filter, sum
+		}
+	}
+	return filtered[best]
+}
+
+// Returns the image as a non-premultiplied RGBA image starting at the origin.
+func toNRGBA(img image.Image) *image.NRGBA {
+	if nrgba, ok := img.(*image.NRGBA); ok && nrgba.Rect.Min == (image.Point{}) {
+		return nrgba
+	}
+	bounds := img.Bounds()
+	nrgba := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(nrgba, nrgba.Rect, img, bounds.Min, draw.Src)
+	return nrgba
+}
+
+// Encodes the frames to an infinitely looping lossless APNG.
+//
+// The colour type is based on the first frame,
+// so the transparency of later frames is dropped if the first frame is opaque.
+func encodeApng(w io.Writer, frameCount int, frames frameFunc) error {
+	aw := &apngWriter{w: w}
+	written := 0
+	err := frames(func(img image.Image, delay int64) error {
+		nrgba := toNRGBA(img)
+		if written == 0 {
+			aw.width, aw.height = nrgba.Rect.Dx(), nrgba.Rect.Dy()
+			aw.hasAlpha = !nrgba.Opaque()
+			if err := aw.writeHeader(frameCount); err != nil {
+				return err
+			}
+		} else if nrgba.Rect.Dx() != aw.width || nrgba.Rect.Dy() != aw.height {
+			return fmt.Errorf(
+				"frame %d is %dx%d instead of %dx%d",
+				written+1,
+				nrgba.Rect.Dx(),
+				nrgba.Rect.Dy(),
+				aw.width,
+				aw.height,
+			)
+		}
+
+		if err := aw.writeFrame(nrgba, delay, written == 0); err != nil {
+			return err
+		}
+		written++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if written == 0 {
+		return errors.New("no frames to encode")
+	}
+	if written != frameCount {
+		return fmt.Errorf("expected %d frames but encoded %d frames", frameCount, written)
+	}
+	return aw.writeChunk("IEND", nil)
+}
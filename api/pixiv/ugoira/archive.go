@@ -0,0 +1,315 @@
+package ugoira
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/KJHJason/Cultured-Downloader-Logic/cdlerrors"
+	"github.com/KJHJason/Cultured-Downloader-Logic/configs"
+	"github.com/KJHJason/Cultured-Downloader-Logic/constants"
+	"github.com/KJHJason/Cultured-Downloader-Logic/httpfuncs"
+	"github.com/KJHJason/Cultured-Downloader-Logic/iofuncs"
+	"github.com/KJHJason/Cultured-Downloader-Logic/utils/threadsafe"
+)
+
+const (
+	// Name used by PixivUtil2 for the frame timings inside its .ugoira files.
+	ANIMATION_JSON_FILENAME = "animation.json"
+
+	// Suffix of the frame timing sidecar of an archived ugoira which is named after its zip file,
+	// e.g. "123_ugoira1920x1080.animation.json" for "123_ugoira1920x1080.zip".
+	ANIMATION_JSON_SIDECAR_SUFFIX = "." + ANIMATION_JSON_FILENAME
+
+	// File extension of PixivUtil2's ugoira archives which are zip files with the animation.json inside
+	PIXIVUTIL2_UGOIRA_EXT = ".ugoira"
+)
+
+// UgoiraMetadata is the ugoira_meta body returned by Pixiv's API.
+type UgoiraMetadata struct {
+	Src      string           `json:"src"`
+	MimeType string           `json:"mime_type"`
+	Frames   UgoiraFramesJson `json:"frames"`
+}
+
+// AnimationJson is the animation.json written by PixivUtil2
+// which wraps Pixiv's ugoira_meta body in ugokuIllustData.
+type AnimationJson struct {
+	UgokuIllustData *UgoiraMetadata `json:"ugokuIllustData"`
+}
+
+// AnimationJson returns the frame filenames and delays of the ugoira in PixivUtil2's animation.json format.
+func (u *Ugoira) AnimationJson() *AnimationJson {
+	sortedFilenames := u.SortedFrameNames()
+	frames := make(UgoiraFramesJson, 0, len(sortedFilenames))
+	for _, fileName := range sortedFilenames {
+		frames = append(frames, struct {
+			File  string  `json:"file"`
+			Delay float64 `json:"delay"`
+		}{
+			File:  fileName,
+			Delay: float64(u.Frames[fileName]),
+		})
+	}
+
+	var mimeType string
+	if len(sortedFilenames) > 0 {
+		mimeType = mime.TypeByExtension(filepath.Ext(sortedFilenames[0]))
+	}
+	return &AnimationJson{
+		UgokuIllustData: &UgoiraMetadata{
+			Src:      u.Url,
+			MimeType: mimeType,
+			Frames:   frames,
+		},
+	}
+}
+
+// Returns the path of the animation.json sidecar of the ugoira which is next to its zip file
+// and named after it so that ugoira saved in the same folder will not overwrite each other's sidecar.
+func GetAnimationJsonPath(zipFilePath string) string {
+	return iofuncs.RemoveExtFromFilename(zipFilePath) + ANIMATION_JSON_SIDECAR_SUFFIX
+}
+
+// WriteAnimationJson writes the frame filenames and delays of the ugoira
+// to the "<zip name>.animation.json" sidecar next to its zip file.
+func WriteAnimationJson(ugoiraInfo *Ugoira, zipFilePath string) error {
+	jsonPath := GetAnimationJsonPath(zipFilePath)
+	jsonBytes, err := json.MarshalIndent(ugoiraInfo.AnimationJson(), "", "    ")
+	if err != nil {
+		return fmt.Errorf(
+			"pixiv error %d: failed to marshal the frame timings of %s, more info => %w",
+			cdlerrors.JSON_ERROR,
+			zipFilePath,
+			err,
+		)
+	}
+	if err := os.WriteFile(jsonPath, jsonBytes, constants.DEFAULT_PERMS); err != nil {
+		return fmt.Errorf(
+			"pixiv error %d: failed to write %s, more info => %w",
+			cdlerrors.OS_ERROR,
+			jsonPath,
+			err,
+		)
+	}
+	return nil
+}
+
+// Parses the animation.json in PixivUtil2's format or Pixiv's unwrapped ugoira_meta body.
+func parseAnimationJson(jsonBytes []byte) (*UgoiraMetadata, error) {
+	var animationJson AnimationJson
+	if err := json.Unmarshal(jsonBytes, &animationJson); err != nil {
+		return nil, err
+	}
+	if animationJson.UgokuIllustData != nil {
+		return animationJson.UgokuIllustData, nil
+	}
+
+	var ugoiraMetadata UgoiraMetadata
+	if err := json.Unmarshal(jsonBytes, &ugoiraMetadata); err != nil {
+		return nil, err
+	}
+	return &ugoiraMetadata, nil
+}
+
+// Reads the animation.json inside the zip file like in PixivUtil2's .ugoira files.
+func readZippedAnimationJson(zipFilePath string) ([]byte, error) {
+	zipReader, err := zip.OpenReader(zipFilePath)
+	if err != nil {
+		return nil, err
+	}
+	defer zipReader.Close()
+
+	f, err := zipReader.Open(ANIMATION_JSON_FILENAME)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+// LoadArchivedUgoira returns the ugoira of an archived zip file with its frame delays
+// from the animation.json inside the zip file or from the animation.json sidecar next to it.
+func LoadArchivedUgoira(zipFilePath string) (*Ugoira, error) {
+	jsonBytes, err := readZippedAnimationJson(zipFilePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		jsonBytes, err = os.ReadFile(GetAnimationJsonPath(zipFilePath))
+	}
+	if err != nil {
+		return nil, fmt.Errorf(
+			"pixiv error %d: failed to read the frame timings of %s, more info => %w",
+			cdlerrors.OS_ERROR,
+			zipFilePath,
+			err,
+		)
+	}
+
+	ugoiraMetadata, err := parseAnimationJson(jsonBytes)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"pixiv error %d: failed to parse the frame timings of %s, more info => %w",
+			cdlerrors.JSON_ERROR,
+			zipFilePath,
+			err,
+		)
+	}
+	if len(ugoiraMetadata.Frames) == 0 {
+		return nil, fmt.Errorf(
+			"pixiv error %d: no frames found in the frame timings of %s",
+			cdlerrors.INPUT_ERROR,
+			zipFilePath,
+		)
+	}
+
+	return &Ugoira{
+		Url:      ugoiraMetadata.Src,
+		FilePath: filepath.Dir(zipFilePath),
+		Frames:   MapDelaysToFilename(ugoiraMetadata.Frames),
+	}, nil
+}
+
+// RegenerateUgoira converts an archived ugoira zip file or PixivUtil2's .ugoira file
+// to the output format of the options and returns the path of the converted file.
+//
+// The converted file is saved next to the zip file and is skipped if it already exists.
+func RegenerateUgoira(ctx context.Context, zipFilePath string, ugoiraOptions *UgoiraOptions, config *configs.Config) (string, error) {
+	useNative, err := ugoiraOptions.useNativeEncoder(ctx, config)
+	if err != nil {
+		return "", err
+	}
+	return regenerateUgoira(ctx, zipFilePath, ugoiraOptions, config, useNative)
+}
+
+func regenerateUgoira(ctx context.Context, zipFilePath string, ugoiraOptions *UgoiraOptions, config *configs.Config, useNative bool) (string, error) {
+	outputPath := iofuncs.RemoveExtFromFilename(zipFilePath) + ugoiraOptions.OutputFormat
+	if iofuncs.PathExists(outputPath) {
+		return outputPath, nil
+	}
+
+	ugoiraInfo, err := LoadArchivedUgoira(zipFilePath)
+	if err != nil {
+		return "", err
+	}
+	if err := convertZip(ctx, ugoiraInfo, zipFilePath, outputPath, ugoiraOptions, config, useNative, nil); err != nil {
+		return "", err
+	}
+	return outputPath, nil
+}
+
+// Returns the paths of the archived ugoira in the folder and its subfolders
+// which are the zip files referenced by the animation.json sidecars and PixivUtil2's .ugoira files.
+//
+// The errors of the sidecars that cannot be read are returned separately as they do not stop the search.
+func findArchivedUgoira(rootDir string) ([]string, []error, error) {
+	var zipFilePaths []string
+	var sidecarErrs []error
+	err := filepath.WalkDir(rootDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		if strings.EqualFold(filepath.Ext(path), PIXIVUTIL2_UGOIRA_EXT) {
+			zipFilePaths = append(zipFilePaths, path)
+			return nil
+		}
+		if !strings.HasSuffix(d.Name(), ANIMATION_JSON_SIDECAR_SUFFIX) {
+			return nil
+		}
+
+		jsonBytes, err := os.ReadFile(path)
+		if err != nil {
+			// skip the unreadable sidecar instead of stopping the search for the other ugoira
+			sidecarErrs = append(sidecarErrs, fmt.Errorf(
+				"pixiv error %d: failed to read %s, more info => %w",
+				cdlerrors.OS_ERROR,
+				path,
+				err,
+			))
+			return nil
+		}
+		ugoiraMetadata, err := parseAnimationJson(jsonBytes)
+		if err != nil || ugoiraMetadata.Src == "" {
+			// not an animation.json written by us
+			return nil
+		}
+		zipFilePath := filepath.Join(filepath.Dir(path), httpfuncs.GetLastPartOfUrl(ugoiraMetadata.Src))
+		if iofuncs.PathExists(zipFilePath) {
+			zipFilePaths = append(zipFilePaths, zipFilePath)
+		}
+		return nil
+	})
+	return zipFilePaths, sidecarErrs, err
+}
+
+// RegenerateAllUgoira converts all the archived ugoira in the folder and its subfolders
+// to the output format of the options without downloading them again.
+//
+// Archived ugoira are the zip files kept with UgoiraOptions.Archive and PixivUtil2's .ugoira files.
+// The returned errors include the animation.json sidecars that could not be read.
+func RegenerateAllUgoira(ctx context.Context, rootDir string, ugoiraOptions *UgoiraOptions, config *configs.Config) []error {
+	zipFilePaths, sidecarErrs, err := findArchivedUgoira(rootDir)
+	if err != nil {
+		return []error{
+			fmt.Errorf(
+				"pixiv error %d: failed to find archived ugoira in %s, more info => %w",
+				cdlerrors.OS_ERROR,
+				rootDir,
+				err,
+			),
+		}
+	}
+	if len(zipFilePaths) == 0 {
+		return sidecarErrs
+	}
+
+	useNative, err := ugoiraOptions.useNativeEncoder(ctx, config)
+	if err != nil {
+		return append(sidecarErrs, err)
+	}
+
+	maxConcurrency := config.FfmpegWorkers
+	if maxConcurrency <= 0 {
+		maxConcurrency = constants.FFMPEG_MAX_CONCURRENCY
+	}
+	var wg sync.WaitGroup
+	queue := make(chan struct{}, maxConcurrency)
+	errTsSlice := threadsafe.NewSlice[error]()
+	for _, err := range sidecarErrs {
+		errTsSlice.Append(err)
+	}
+	for _, zipFilePath := range zipFilePaths {
+		wg.Add(1)
+		go func() {
+			defer func() {
+				wg.Done()
+				<-queue
+			}()
+			queue <- struct{}{}
+			if ctx.Err() != nil {
+				return
+			}
+			if _, err := regenerateUgoira(ctx, zipFilePath, ugoiraOptions, config, useNative); err != nil {
+				errTsSlice.Append(err)
+			}
+		}()
+	}
+	wg.Wait()
+	close(queue)
+
+	if err := ctx.Err(); err != nil {
+		errTsSlice.Append(err)
+	}
+	return errTsSlice.CopyItems()
+}
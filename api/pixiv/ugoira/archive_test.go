@@ -0,0 +1,155 @@
+package ugoira
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/KJHJason/Cultured-Downloader-Logic/configs"
+)
+
+const testUgoiraUrl = "https://i.pximg.net/img-zip-ugoira/img/2024/01/01/00/00/00/123_ugoira1920x1080.zip"
+
+func TestWriteAnimationJson(t *testing.T) {
+	ugoiraInfo, zipFilePath := writeTestUgoiraZip(t, []int64{100, 35, 1000})
+	ugoiraInfo.Url = testUgoiraUrl
+	if err := WriteAnimationJson(ugoiraInfo, zipFilePath); err != nil {
+		t.Fatalf("Failed to write the animation.json: %v", err)
+	}
+
+	jsonBytes, err := os.ReadFile(filepath.Join(filepath.Dir(zipFilePath), "123_ugoira1920x1080.animation.json"))
+	if err != nil {
+		t.Fatalf("Failed to read the animation.json: %v", err)
+	}
+	var animationJson struct {
+		UgokuIllustData struct {
+			Src      string `json:"src"`
+			MimeType string `json:"mime_type"`
+			Frames   []struct {
+				File  string `json:"file"`
+				Delay int    `json:"delay"`
+			} `json:"frames"`
+		} `json:"ugokuIllustData"`
+	}
+	if err := json.Unmarshal(jsonBytes, &animationJson); err != nil {
+		t.Fatalf("Failed to parse the animation.json: %v", err)
+	}
+
+	data := animationJson.UgokuIllustData
+	if data.Src != testUgoiraUrl || data.MimeType != "image/png" || len(data.Frames) != 3 {
+		t.Fatalf("Unexpected animation.json:\n%s", jsonBytes)
+	}
+	if data.Frames[1].File != "000001.png" || data.Frames[1].Delay != 35 {
+		t.Errorf("Expected the frames to be in order with their delays but got %+v", data.Frames)
+	}
+
+	loaded, err := LoadArchivedUgoira(zipFilePath)
+	if err != nil {
+		t.Fatalf("Failed to load the archived ugoira: %v", err)
+	}
+	if loaded.Url != testUgoiraUrl || loaded.FilePath != filepath.Dir(zipFilePath) {
+		t.Errorf("Unexpected archived ugoira %+v", loaded)
+	}
+	for fileName, delay := range ugoiraInfo.Frames {
+		if loaded.Frames[fileName] != delay {
+			t.Errorf("Expected %s to have a delay of %d but got %d", fileName, delay, loaded.Frames[fileName])
+		}
+	}
+}
+
+// Rewrites the zip file as a PixivUtil2 .ugoira file with the frame delays inside it.
+func writeTestPixivUtil2Ugoira(t *testing.T, zipFilePath, ugoiraFilePath string) {
+	zipReader, err := zip.OpenReader(zipFilePath)
+	if err != nil {
+		t.Fatalf("Failed to open the zip file: %v", err)
+	}
+	defer zipReader.Close()
+
+	f, err := os.Create(ugoiraFilePath)
+	if err != nil {
+		t.Fatalf("Failed to create the .ugoira file: %v", err)
+	}
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	for _, file := range zipReader.File {
+		if err := zw.Copy(file); err != nil {
+			t.Fatalf("Failed to copy %s: %v", file.Name, err)
+		}
+	}
+	fw, err := zw.Create(ANIMATION_JSON_FILENAME)
+	if err != nil {
+		t.Fatalf("Failed to create the animation.json: %v", err)
+	}
+	io.WriteString(fw, `{"ugokuIllustData": {"src": "", "mime_type": "image/png", "frames": [
+		{"file": "000000.png", "delay": 50}, {"file": "000001.png", "delay": 50}, {"file": "000002.png", "delay": 50}
+	]}}`)
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Failed to close the .ugoira file: %v", err)
+	}
+}
+
+func TestRegenerateAllUgoira(t *testing.T) {
+	ugoiraInfo, zipFilePath := writeTestUgoiraZip(t, []int64{100, 35, 1000})
+	ugoiraInfo.Url = testUgoiraUrl
+	if err := WriteAnimationJson(ugoiraInfo, zipFilePath); err != nil {
+		t.Fatalf("Failed to write the animation.json: %v", err)
+	}
+
+	rootDir := filepath.Dir(zipFilePath)
+
+	// ugoira in the same folder have their own sidecars
+	zipBytes, err := os.ReadFile(zipFilePath)
+	if err != nil {
+		t.Fatalf("Failed to read the zip file: %v", err)
+	}
+	otherZipFilePath := filepath.Join(rootDir, "789_ugoira600x600.zip")
+	if err := os.WriteFile(otherZipFilePath, zipBytes, 0644); err != nil {
+		t.Fatalf("Failed to write the zip file: %v", err)
+	}
+	ugoiraInfo.Url = "https://i.pximg.net/img-zip-ugoira/img/2024/01/01/00/00/00/789_ugoira600x600.zip"
+	if err := WriteAnimationJson(ugoiraInfo, otherZipFilePath); err != nil {
+		t.Fatalf("Failed to write the animation.json: %v", err)
+	}
+
+	// unreadable sidecars are skipped and returned with the errors
+	brokenSidecarPath := filepath.Join(rootDir, "000_ugoira600x600.animation.json")
+	if err := os.Symlink(filepath.Join(rootDir, "missing"), brokenSidecarPath); err != nil {
+		t.Fatalf("Failed to create the broken sidecar: %v", err)
+	}
+
+	pixivUtil2Dir := filepath.Join(rootDir, "pixivutil2")
+	if err := os.Mkdir(pixivUtil2Dir, 0755); err != nil {
+		t.Fatalf("Failed to create the folder: %v", err)
+	}
+	writeTestPixivUtil2Ugoira(t, zipFilePath, filepath.Join(pixivUtil2Dir, "456"+PIXIVUTIL2_UGOIRA_EXT))
+
+	ugoiraOptions := &UgoiraOptions{OutputFormat: ".apng", Encoder: UGOIRA_ENCODER_NATIVE}
+	if err := ugoiraOptions.ValidateArgs(); err != nil {
+		t.Fatalf("Failed to validate the options: %v", err)
+	}
+	errs := RegenerateAllUgoira(context.Background(), rootDir, ugoiraOptions, &configs.Config{})
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), brokenSidecarPath) {
+		t.Fatalf("Expected only the broken sidecar to fail but got %v", errs)
+	}
+
+	for _, outputPath := range []string{
+		filepath.Join(rootDir, "123_ugoira1920x1080.apng"),
+		filepath.Join(rootDir, "789_ugoira600x600.apng"),
+		filepath.Join(pixivUtil2Dir, "456.apng"),
+	} {
+		if _, err := os.Stat(outputPath); err != nil {
+			t.Errorf("Expected %s to be regenerated: %v", outputPath, err)
+		}
+	}
+
+	// the original zip files are kept
+	if _, err := os.Stat(zipFilePath); err != nil {
+		t.Errorf("Expected the zip file to be kept: %v", err)
+	}
+}
@@ -7,7 +7,6 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
-	"sort"
 	"strconv"
 
 	"github.com/KJHJason/Cultured-Downloader-Logic/cdlerrors"
@@ -24,12 +23,7 @@ type ffmpegOptions struct {
 }
 
 func writeDelays(ugoiraInfo *Ugoira, imagesFolderPath string) (string, []string, error) {
-	// sort the ugoira frames by their filename which are %6d.imageExt
-	sortedFilenames := make([]string, 0, len(ugoiraInfo.Frames))
-	for fileName := range ugoiraInfo.Frames {
-		sortedFilenames = append(sortedFilenames, fileName)
-	}
-	sort.Strings(sortedFilenames)
+	sortedFilenames := ugoiraInfo.SortedFrameNames()
 
 	// write the frames' variable delays to a text file
 	baseFmtStr := "file '%s'\nduration %f\n"
@@ -119,6 +113,31 @@ func getFlagsForWebmAndMp4(outputExt string, ugoiraQuality int) []string {
 	return args
 }
 
+func getFlagsForAvif(ugoiraQuality int) []string {
+	// crf range is 0-63 for .avif files
+	// https://trac.ffmpeg.org/wiki/Encode/AV1
+	args := []string{
+		"-c:v", "libaom-av1",
+		"-cpu-used", "6", // libaom is very slow at its default speed
+		"-row-mt", "1",
+	}
+	if ugoiraQuality <= 0 {
+		args = append(args, "-aom-params", "lossless=1", "-pix_fmt", "yuv444p")
+	} else {
+		args = append(
+			args,
+			"-crf", strconv.Itoa(min(ugoiraQuality, 63)),
+			"-b:v", "0", // constant quality mode
+			"-pix_fmt", "yuv420p",
+		)
+	}
+	return append(
+		args,
+		"-loop", "0", // loop the avif
+		"-vsync", "passthrough", // Prevents frame dropping
+	)
+}
+
 func getFlagsForGif(options *ffmpegOptions, imagesFolderPath string) ([]string, error) {
 	// Generate a palette for the gif using FFmpeg for better quality
 	palettePath := filepath.Join(imagesFolderPath, "palette.png")
@@ -130,14 +149,16 @@ func getFlagsForGif(options *ffmpegOptions, imagesFolderPath string) ([]string,
 	imagePaletteCmd := exec.CommandContext(
 		options.ugoiraArgs.context,
 		options.ugoiraArgs.ffmpegPath,
+		"-hide_banner",
 		"-i", filepath.Join(imagesFolderPath, ffmpegImages),
 		"-vf", "palettegen",
 		palettePath,
 	)
 	utils.PrepareCmdForBgTask(imagePaletteCmd)
+	stderr := newFfmpegStderr()
+	imagePaletteCmd.Stderr = stderr.writer()
 	if constants.DEBUG_MODE {
 		imagePaletteCmd.Stdout = os.Stdout
-		imagePaletteCmd.Stderr = os.Stderr
 	}
 
 	err := imagePaletteCmd.Run()
@@ -148,7 +169,7 @@ func getFlagsForGif(options *ffmpegOptions, imagesFolderPath string) ([]string,
 		return nil, fmt.Errorf(
 			"pixiv error %d: failed to generate palette for ugoira gif, more info => %w",
 			cdlerrors.CMD_ERROR,
-			err,
+			stderr.wrapErr(err),
 		)
 	}
 	return []string{
@@ -162,6 +183,9 @@ func getFfmpegFlagsForUgoira(options *ffmpegOptions, imagesFolderPath string) ([
 	// FFmpeg flags: https://www.ffmpeg.org/ffmpeg.html
 	args := []string{
 		"-y",           // overwrite output file if it exists
+		"-hide_banner", // only keep the relevant logs in the error
+		"-nostats",     // the progress is written to stdout instead
+		"-progress", "pipe:1",
 		"-an",          // disable audio
 		"-f", "concat", // input is a concat file
 		"-safe", "0", // allow absolute paths in the concat file
@@ -183,6 +207,15 @@ func getFfmpegFlagsForUgoira(options *ffmpegOptions, imagesFolderPath string) ([
 			"-vf",
 			"setpts=PTS-STARTPTS,hqdn3d=1.5:1.5:6:6", // set the setpts filter and apply some denoising
 		)
+	case ".avif":
+		args = append(args, getFlagsForAvif(options.ugoiraArgs.ugoiraQuality)...)
+	case ".mkv":
+		args = append(
+			args,
+			"-c:v", "ffv1", // lossless video codec
+			"-level", "3",
+			"-vsync", "passthrough", // Prevents frame dropping
+		)
 	case ".webp": // outputExt == ".webp"
 		args = append(
 			args,
@@ -198,7 +231,9 @@ func getFfmpegFlagsForUgoira(options *ffmpegOptions, imagesFolderPath string) ([
 			options.outputExt,
 		)
 	}
-	if options.outputExt != ".webp" {
+	switch options.outputExt {
+	case ".webp", ".avif", ".mkv":
+	default:
 		args = append(args, "-quality", "best")
 	}
 
@@ -0,0 +1,150 @@
+package ugoira
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/KJHJason/Cultured-Downloader-Logic/constants"
+	"github.com/KJHJason/Cultured-Downloader-Logic/progress"
+)
+
+// Number of bytes kept from the end of FFmpeg's stderr to be added to the errors
+const FFMPEG_STDERR_TAIL_SIZE = 4096
+
+// ffmpegStderr keeps the last bytes of FFmpeg's stderr
+// as the cause of the error is usually at the end of the logs.
+type ffmpegStderr struct {
+	buf []byte
+	mu  sync.Mutex
+}
+
+func newFfmpegStderr() *ffmpegStderr {
+	return &ffmpegStderr{}
+}
+
+func (s *ffmpegStderr) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.buf = append(s.buf, p...)
+	if len(s.buf) > FFMPEG_STDERR_TAIL_SIZE {
+		s.buf = s.buf[len(s.buf)-FFMPEG_STDERR_TAIL_SIZE:]
+	}
+	return len(p), nil
+}
+
+// Returns the writer to be used as the stderr of the command
+// which also writes to os.Stderr in debug mode.
+func (s *ffmpegStderr) writer() io.Writer {
+	if constants.DEBUG_MODE {
+		return io.MultiWriter(s, os.Stderr)
+	}
+	return s
+}
+
+func (s *ffmpegStderr) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return strings.TrimSpace(string(s.buf))
+}
+
+// Adds the end of FFmpeg's stderr to the error.
+func (s *ffmpegStderr) wrapErr(err error) error {
+	output := s.String()
+	if output == "" {
+		return err
+	}
+	return fmt.Errorf("%w, FFmpeg output:\n%s", err, output)
+}
+
+// ffmpegProgress parses the key=value lines written by FFmpeg's -progress
+// and updates the progress bar based on the encoded duration of the ugoira.
+//
+// https://ffmpeg.org/ffmpeg.html#Advanced-options
+type ffmpegProgress struct {
+	progBar    *progress.DownloadProgressBar
+	durationUs int64
+	startTime  time.Time
+	buf        []byte
+}
+
+func newFfmpegProgress(progBar *progress.DownloadProgressBar, durationMs int64) *ffmpegProgress {
+	return &ffmpegProgress{
+		progBar:    progBar,
+		durationUs: durationMs * 1000,
+		startTime:  time.Now(),
+	}
+}
+
+func (p *ffmpegProgress) Write(b []byte) (int, error) {
+	p.buf = append(p.buf, b...)
+	for {
+		idx := bytes.IndexByte(p.buf, '\n')
+		if idx == -1 {
+			break
+		}
+		p.parseLine(string(p.buf[:idx]))
+		p.buf = p.buf[idx+1:]
+	}
+	return len(b), nil
+}
+
+func (p *ffmpegProgress) parseLine(line string) {
+	key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+	if !ok {
+		return
+	}
+
+	switch key {
+	case "out_time_us":
+		outTimeUs, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || outTimeUs <= 0 || p.durationUs <= 0 {
+			return // "N/A" before the first frame is encoded
+		}
+		p.updatePercentage(min(float64(outTimeUs)/float64(p.durationUs)*100, 100))
+	case "progress":
+		if value == "end" {
+			p.updatePercentage(100)
+		}
+	}
+}
+
+func (p *ffmpegProgress) updatePercentage(percentage float64) {
+	if p.progBar == nil {
+		return
+	}
+
+	eta := float64(-1) // -1 indicates that the ETA is unknown
+	if percentage > 0 && percentage < 100 {
+		elapsed := time.Since(p.startTime).Seconds()
+		eta = elapsed / percentage * (100 - percentage)
+	}
+	p.progBar.UpdateDownloadETA(eta)
+	p.progBar.UpdatePercentage(int(percentage))
+}
+
+// Returns a progress bar for the conversion of the ugoira to the output path
+// or nil if the caller does not display the download progress bars.
+func newConversionProgBar(ctx context.Context, progBarInfo *progress.ProgressBarInfo, outputPath string) *progress.DownloadProgressBar {
+	if progBarInfo == nil || progBarInfo.DownloadProgressBars == nil {
+		return nil
+	}
+
+	outputFormat := strings.TrimPrefix(strings.ToLower(filepath.Ext(outputPath)), ".")
+	progBar := progress.NewDlProgressBar(ctx, progress.Messages{
+		Msg:        fmt.Sprintf("Converting ugoira to %s...", outputFormat),
+		ErrMsg:     fmt.Sprintf("Failed to convert ugoira to %s!", outputFormat),
+		SuccessMsg: fmt.Sprintf("Finished converting ugoira to %s!", outputFormat),
+	})
+	progBar.UpdateFilename(filepath.Base(outputPath))
+	progBarInfo.AppendDlProgBar(progBar)
+	return progBar
+}
@@ -0,0 +1,142 @@
+package ugoira
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/KJHJason/Cultured-Downloader-Logic/progress"
+)
+
+func TestFfmpegProgress(t *testing.T) {
+	progBar := progress.NewDlProgressBar(context.Background(), progress.Messages{})
+	p := newFfmpegProgress(progBar, 2000)
+
+	// the lines can be split across writes
+	fmt.Fprint(p, "frame=10\nout_time_us=N/A\nout_time_us=50")
+	if progBar.GetPercentage() != 0 {
+		t.Fatalf("Expected the incomplete line to be ignored but got %d%%", progBar.GetPercentage())
+	}
+	fmt.Fprint(p, "0000\nprogress=continue\n")
+	if progBar.GetPercentage() != 25 {
+		t.Errorf("Expected 25%% but got %d%%", progBar.GetPercentage())
+	}
+
+	fmt.Fprint(p, "out_time_us=3000000\n")
+	if progBar.GetPercentage() != 100 {
+		t.Errorf("Expected the percentage to be capped at 100%% but got %d%%", progBar.GetPercentage())
+	}
+
+	progBar.UpdatePercentage(0)
+	fmt.Fprint(p, "progress=end\n")
+	if progBar.GetPercentage() != 100 || progBar.GetDownloadETA() != -1 {
+		t.Errorf("Expected 100%% with no ETA at the end but got %d%% with an ETA of %f", progBar.GetPercentage(), progBar.GetDownloadETA())
+	}
+
+	// the output should still be consumed without a progress bar
+	if _, err := fmt.Fprint(newFfmpegProgress(nil, 2000), "out_time_us=1000000\n"); err != nil {
+		t.Errorf("Expected no error but got %v", err)
+	}
+}
+
+func TestFfmpegStderr(t *testing.T) {
+	stderr := newFfmpegStderr()
+	if err := stderr.wrapErr(errors.New("exit status 1")); err.Error() != "exit status 1" {
+		t.Errorf("Expected the error to be unchanged without any output but got %q", err)
+	}
+
+	fmt.Fprint(stderr, strings.Repeat("a", FFMPEG_STDERR_TAIL_SIZE))
+	fmt.Fprint(stderr, "Unknown encoder 'libaom-av1'\n")
+	output := stderr.String()
+	if len(output) > FFMPEG_STDERR_TAIL_SIZE || !strings.HasSuffix(output, "Unknown encoder 'libaom-av1'") {
+		t.Errorf("Expected only the end of the output to be kept but got %d bytes ending with %q", len(output), output[len(output)-30:])
+	}
+
+	baseErr := errors.New("exit status 1")
+	err := stderr.wrapErr(baseErr)
+	if !errors.Is(err, baseErr) || !strings.Contains(err.Error(), "Unknown encoder") {
+		t.Errorf("Expected the error to wrap the original error with the output but got %q", err)
+	}
+}
+
+func TestFfmpegFlagsForNewFormats(t *testing.T) {
+	tests := []struct {
+		outputExt string
+		quality   int
+		expected  []string
+	}{
+		{".avif", 30, []string{"libaom-av1", "-crf", "30", "-loop"}},
+		{".avif", 0, []string{"libaom-av1", "lossless=1", "yuv444p"}},
+		{".mkv", 30, []string{"ffv1"}},
+	}
+	for _, test := range tests {
+		args, err := getFfmpegFlagsForUgoira(
+			&ffmpegOptions{
+				ugoiraArgs: &UgoiraFfmpegArgs{
+					context:       context.Background(),
+					outputPath:    "ugoira" + test.outputExt,
+					ugoiraQuality: test.quality,
+				},
+				outputExt:           test.outputExt,
+				concatDelayFilePath: "delays.txt",
+			},
+			"",
+		)
+		if err != nil {
+			t.Fatalf("Failed to get the FFmpeg flags for %s: %v", test.outputExt, err)
+		}
+		for _, flag := range append(test.expected, "-progress") {
+			if !slices.Contains(args, flag) {
+				t.Errorf("Expected the flags for %s with quality %d to contain %q but got %q", test.outputExt, test.quality, flag, args)
+			}
+		}
+		if args[len(args)-1] != "ugoira"+test.outputExt {
+			t.Errorf("Expected the output path to be the last argument but got %q", args)
+		}
+	}
+}
+
+func TestValidateArgsPreset(t *testing.T) {
+	tests := []struct {
+		outputFormat string
+		preset       string
+		quality      int
+		expected     int
+	}{
+		{".mp4", "High", 5, 18},
+		{".webm", "lossless", 5, 0},
+		{".avif", "medium", 5, 30},
+		{".gif", "low", 5, 5}, // ignored for formats without a quality setting
+		{".avif", "", 40, 40},
+	}
+	for _, test := range tests {
+		options := &UgoiraOptions{OutputFormat: test.outputFormat, Preset: test.preset, Quality: test.quality}
+		if err := options.ValidateArgs(); err != nil {
+			t.Fatalf("Expected no error for %s with the preset %q but got %v", test.outputFormat, test.preset, err)
+		}
+		if options.Quality != test.expected {
+			t.Errorf("Expected the quality of %s with the preset %q to be %d but got %d", test.outputFormat, test.preset, test.expected, options.Quality)
+		}
+	}
+
+	invalidOptions := []*UgoiraOptions{
+		{OutputFormat: ".mp4", Preset: "ultra"},
+		{OutputFormat: ".mp4", Quality: 52},
+		{OutputFormat: ".avif", Quality: -1},
+		{OutputFormat: ".mkv", Encoder: UGOIRA_ENCODER_NATIVE},
+	}
+	for _, options := range invalidOptions {
+		if err := options.ValidateArgs(); err == nil {
+			t.Errorf("Expected an error for the options %+v", options)
+		}
+	}
+
+	// the quality is not limited for formats without a quality setting
+	options := &UgoiraOptions{OutputFormat: ".gif", Quality: 60}
+	if err := options.ValidateArgs(); err != nil {
+		t.Errorf("Expected no error for .gif but got %v", err)
+	}
+}
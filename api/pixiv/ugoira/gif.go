@@ -0,0 +1,276 @@
+package ugoira
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"io"
+	"slices"
+)
+
+const (
+	// Most browsers slow down GIF frames with a delay
+	// below 2 hundredths of a second to 10 hundredths of a second.
+	GIF_MIN_DELAY = 2
+
+	// Number of bits per channel kept when building the colour histogram of a frame
+	histogramBits = 5
+	// Number of bits per channel used to cache the nearest palette colours when dithering
+	lookupBits = 6
+)
+
+// Encodes the frames to an infinitely looping animated GIF
+// where each frame has its own palette for better colours.
+func encodeGif(w io.Writer, frames frameFunc) error {
+	anim := &gif.GIF{}
+	var elapsed int64
+	err := frames(func(img image.Image, delay int64) error {
+		// round the total elapsed time instead of each delay
+		// so that the rounding errors don't add up over the frames
+		start := (elapsed + 5) / 10
+		elapsed += delay
+		anim.Image = append(anim.Image, quantizeImage(img))
+		anim.Delay = append(anim.Delay, max(int((elapsed+5)/10-start), GIF_MIN_DELAY))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(anim.Image) == 0 {
+		return errors.New("no frames to encode")
+	}
+	return gif.EncodeAll(w, anim)
+}
+
+// Returns the image as an RGBA image starting at the origin.
+func toRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok && rgba.Rect.Min == (image.Point{}) {
+		return rgba
+	}
+	bounds := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Rect, img, bounds.Min, draw.Src)
+	return rgba
+}
+
+// Reduces the image to at most 256 colours using median cut
+// and maps the pixels to the palette with Floyd-Steinberg dithering.
+//
+// The alpha channel is ignored as GIF only supports fully transparent pixels.
+func quantizeImage(img image.Image) *image.Paletted {
+	rgba := toRGBA(img)
+	palette := medianCut(rgba, 256)
+	return ditherImage(rgba, palette)
+}
+
+type histogramBin struct {
+	count            int
+	sumR, sumG, sumB int
+}
+
+type colorBox struct {
+	bins     []int // indexes of the non-empty histogram bins
+	count    int
+	min, max [3]int
+}
+
+func histogramIdx(r, g, b int) int {
+	return r<<(2*histogramBits) | g<<histogramBits | b
+}
+
+func histogramChannel(idx, channel int) int {
+	return idx >> ((2 - channel) * histogramBits) & (1<<histogramBits - 1)
+}
+
+func newColorBox(bins []int, histogram []histogramBin) *colorBox {
+	box := &colorBox{bins: bins, min: [3]int{1 << histogramBits, 1 << histogramBits, 1 << histogramBits}}
+	for _, idx := range bins {
+		box.count += histogram[idx].count
+		for channel := 0; channel < 3; channel++ {
+			value := histogramChannel(idx, channel)
+			box.min[channel] = min(box.min[channel], value)
+			box.max[channel] = max(box.max[channel], value)
+		}
+	}
+	return box
+}
+
+// Returns the channel with the widest range of values and its range.
+func (b *colorBox) longestChannel() (int, int) {
+	channel, length := 0, -1
+	for c := 0; c < 3; c++ {
+		if l := b.max[c] - b.min[c]; l > length {
+			channel, length = c, l
+		}
+	}
+	return channel, length
+}
+
+// Splits the box at the median pixel of its longest channel.
+func (b *colorBox) split(histogram []histogramBin) (*colorBox, *colorBox) {
+	channel, _ := b.longestChannel()
+	slices.SortFunc(b.bins, func(x, y int) int {
+		return histogramChannel(x, channel) - histogramChannel(y, channel)
+	})
+
+	splitIdx, count := len(b.bins)-1, 0
+	for idx, bin := range b.bins[:len(b.bins)-1] {
+		count += histogram[bin].count
+		if count*2 >= b.count {
+			splitIdx = idx + 1
+			break
+		}
+	}
+	return newColorBox(b.bins[:splitIdx], histogram), newColorBox(b.bins[splitIdx:], histogram)
+}
+
+// Returns a palette of at most maxColors colours which represents the colours of the image.
+func medianCut(img *image.RGBA, maxColors int) color.Palette {
+	histogram := make([]histogramBin, 1<<(3*histogramBits))
+	const shift = 8 - histogramBits
+	for y := 0; y < img.Rect.Dy(); y++ {
+		row := img.Pix[y*img.Stride : y*img.Stride+img.Rect.Dx()*4]
+		for x := 0; x < len(row); x += 4 {
+			r, g, b := int(row[x]), int(row[x+1]), int(row[x+2])
+			bin := &histogram[histogramIdx(r>>shift, g>>shift, b>>shift)]
+			bin.count++
+			bin.sumR += r
+			bin.sumG += g
+			bin.sumB += b
+		}
+	}
+
+	var bins []int
+	for idx, bin := range histogram {
+		if bin.count > 0 {
+			bins = append(bins, idx)
+		}
+	}
+	if len(bins) == 0 {
+		return color.Palette{color.RGBA{A: 0xff}}
+	}
+
+	boxes := []*colorBox{newColorBox(bins, histogram)}
+	for len(boxes) < maxColors {
+		// split the box with the most pixels spread over the widest range of colours
+		splitIdx, bestScore := -1, 0
+		for idx, box := range boxes {
+			if len(box.bins) < 2 {
+				continue
+			}
+			if _, length := box.longestChannel(); box.count*length > bestScore {
+				splitIdx, bestScore = idx, box.count*length
+			}
+		}
+		if splitIdx == -1 {
+			break
+		}
+
+		first, second := boxes[splitIdx].split(histogram)
+		boxes[splitIdx] = first
+		boxes = append(boxes, second)
+	}
+
+	palette := make(color.Palette, 0, len(boxes))
+	for _, box := range boxes {
+		var sumR, sumG, sumB int
+		for _, idx := range box.bins {
+			sumR += histogram[idx].sumR
+			sumG += histogram[idx].sumG
+			sumB += histogram[idx].sumB
+		}
+		palette = append(palette, color.RGBA{
+			R: uint8((sumR + box.count/2) / box.count),
+			G: uint8((sumG + box.count/2) / box.count),
+			B: uint8((sumB + box.count/2) / box.count),
+			A: 0xff,
+		})
+	}
+	return palette
+}
+
+// paletteLookup finds the nearest palette colour of a colour
+// and caches the results by the most significant bits of the colour.
+type paletteLookup struct {
+	colors [][3]int
+	cache  []int16
+}
+
+func newPaletteLookup(palette color.Palette) *paletteLookup {
+	lookup := &paletteLookup{
+		colors: make([][3]int, len(palette)),
+		cache:  make([]int16, 1<<(3*lookupBits)),
+	}
+	for idx, c := range palette {
+		rgba := c.(color.RGBA)
+		lookup.colors[idx] = [3]int{int(rgba.R), int(rgba.G), int(rgba.B)}
+	}
+	for idx := range lookup.cache {
+		lookup.cache[idx] = -1
+	}
+	return lookup
+}
+
+func (l *paletteLookup) nearest(r, g, b int) int {
+	const shift = 8 - lookupBits
+	cacheIdx := r>>shift<<(2*lookupBits) | g>>shift<<lookupBits | b>>shift
+	if idx := l.cache[cacheIdx]; idx >= 0 {
+		return int(idx)
+	}
+
+	bestIdx, bestDist := 0, -1
+	for idx, c := range l.colors {
+		dr, dg, db := r-c[0], g-c[1], b-c[2]
+		if dist := dr*dr + dg*dg + db*db; bestDist == -1 || dist < bestDist {
+			bestIdx, bestDist = idx, dist
+		}
+	}
+	l.cache[cacheIdx] = int16(bestIdx)
+	return bestIdx
+}
+
+func clampColor(value int) int {
+	return min(max(value, 0), 0xff)
+}
+
+// Maps the pixels of the image to the palette using serpentine Floyd-Steinberg dithering.
+func ditherImage(img *image.RGBA, palette color.Palette) *image.Paletted {
+	width, height := img.Rect.Dx(), img.Rect.Dy()
+	paletted := image.NewPaletted(image.Rect(0, 0, width, height), palette)
+	lookup := newPaletteLookup(palette)
+
+	// errors are multiplied by 16 and padded by a pixel on each side
+	curErr := make([]int, (width+2)*3)
+	nextErr := make([]int, (width+2)*3)
+	for y := 0; y < height; y++ {
+		clear(nextErr)
+		x, end, step := 0, width, 1
+		if y%2 == 1 {
+			x, end, step = width-1, -1, -1
+		}
+		for ; x != end; x += step {
+			pixIdx := y*img.Stride + x*4
+			errIdx := (x + 1) * 3
+			var target [3]int
+			for c := 0; c < 3; c++ {
+				target[c] = clampColor(int(img.Pix[pixIdx+c]) + (curErr[errIdx+c]+8)>>4)
+			}
+
+			colorIdx := lookup.nearest(target[0], target[1], target[2])
+			paletted.Pix[y*paletted.Stride+x] = uint8(colorIdx)
+
+			chosen := lookup.colors[colorIdx]
+			for c := 0; c < 3; c++ {
+				diff := target[c] - chosen[c]
+				curErr[errIdx+step*3+c] += diff * 7
+				nextErr[errIdx-step*3+c] += diff * 3
+				nextErr[errIdx+c] += diff * 5
+				nextErr[errIdx+step*3+c] += diff
+			}
+		}
+		curErr, nextErr = nextErr, curErr
+	}
+	return paletted
+}
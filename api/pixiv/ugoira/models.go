@@ -1,10 +1,54 @@
 package ugoira
 
+import (
+	"sort"
+
+	"github.com/KJHJason/Cultured-Downloader-Logic/filters"
+	"github.com/KJHJason/Cultured-Downloader-Logic/metadata"
+)
+
 type Ugoira struct {
 	CacheKey string
 	Url      string
 	FilePath string
 	Frames   map[string]int64
+	Filters  *filters.Filters // filters of the creator's filter profile, UgoiraArgs.Filters is used if nil
+}
+
+// SortedFrameNames returns the filenames of the frames in order
+// as the frames are named %6d.imageExt
+func (u *Ugoira) SortedFrameNames() []string {
+	sortedFilenames := make([]string, 0, len(u.Frames))
+	for fileName := range u.Frames {
+		sortedFilenames = append(sortedFilenames, fileName)
+	}
+	sort.Strings(sortedFilenames)
+	return sortedFilenames
+}
+
+// DurationMs returns the total duration of the ugoira in milliseconds
+func (u *Ugoira) DurationMs() int64 {
+	var durationMs int64
+	for _, delay := range u.Frames {
+		durationMs += delay
+	}
+	return durationMs
+}
+
+// FramesMetadata returns the frames in order with their delays to be saved in the metadata
+func (u *Ugoira) FramesMetadata() []metadata.PixivUgoiraFrame {
+	if u == nil {
+		return nil
+	}
+
+	frames := make([]metadata.PixivUgoiraFrame, 0, len(u.Frames))
+	for _, fileName := range u.SortedFrameNames() {
+		frames = append(frames, metadata.PixivUgoiraFrame{
+			File:  fileName,
+			Delay: u.Frames[fileName],
+		})
+	}
+	return frames
 }
 
 type UgoiraFramesJson []struct {
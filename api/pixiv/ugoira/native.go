@@ -0,0 +1,149 @@
+package ugoira
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/KJHJason/Cultured-Downloader-Logic/cdlerrors"
+	"github.com/KJHJason/Cultured-Downloader-Logic/progress"
+)
+
+// frameFunc calls fn with each frame of the ugoira in order and its delay in milliseconds.
+type frameFunc func(fn func(img image.Image, delay int64) error) error
+
+// Returns a frameFunc that decodes the frames in the order of the frame delays from the ugoira zip file.
+func zipFrames(ctx context.Context, ugoiraInfo *Ugoira, zipReader *zip.Reader) frameFunc {
+	return func(fn func(img image.Image, delay int64) error) error {
+		files := make(map[string]*zip.File, len(zipReader.File))
+		for _, f := range zipReader.File {
+			files[path.Base(f.Name)] = f
+		}
+
+		for _, frameName := range ugoiraInfo.SortedFrameNames() {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			f, ok := files[frameName]
+			if !ok {
+				return fmt.Errorf("frame %s not found in the zip file", frameName)
+			}
+			img, err := decodeZipImage(f)
+			if err != nil {
+				return fmt.Errorf("failed to decode frame %s, more info => %w", frameName, err)
+			}
+			if err := fn(img, ugoiraInfo.Frames[frameName]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func decodeZipImage(f *zip.File) (image.Image, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	img, _, err := image.Decode(rc)
+	return img, err
+}
+
+// Encodes the frames to the writer based on the output extension.
+func encodeUgoira(w io.Writer, outputExt string, frameCount int, frames frameFunc) error {
+	switch outputExt {
+	case ".gif":
+		return encodeGif(w, frames)
+	case ".apng":
+		return encodeApng(w, frameCount, frames)
+	default:
+		return fmt.Errorf("output extension %s is not supported by the native encoder", outputExt)
+	}
+}
+
+// Updates the progress bar with the percentage of frames that have been encoded.
+func withFrameProgress(frames frameFunc, frameCount int, progBar *progress.DownloadProgressBar) frameFunc {
+	return func(fn func(img image.Image, delay int64) error) error {
+		encoded := 0
+		return frames(func(img image.Image, delay int64) error {
+			if err := fn(img, delay); err != nil {
+				return err
+			}
+			encoded++
+			progBar.UpdatePercentage(encoded * 100 / frameCount)
+			return nil
+		})
+	}
+}
+
+// Converts the Ugoira to the desired output path without FFmpeg
+// by reading the frames directly from the downloaded zip file.
+//
+// Only .gif and .apng are supported.
+func ConvertUgoiraNative(ctx context.Context, ugoiraInfo *Ugoira, zipFilePath, outputPath string) error {
+	return convertUgoiraNative(ctx, ugoiraInfo, zipFilePath, outputPath, nil)
+}
+
+func convertUgoiraNative(ctx context.Context, ugoiraInfo *Ugoira, zipFilePath, outputPath string, progBar *progress.DownloadProgressBar) error {
+	if len(ugoiraInfo.Frames) == 0 {
+		return fmt.Errorf(
+			"pixiv error %d: ugoira %s has no frames to convert",
+			cdlerrors.INPUT_ERROR,
+			zipFilePath,
+		)
+	}
+
+	zipReader, err := zip.OpenReader(zipFilePath)
+	if err != nil {
+		return fmt.Errorf(
+			"pixiv error %d: failed to open zip file %s, more info => %w",
+			cdlerrors.OS_ERROR,
+			zipFilePath,
+			err,
+		)
+	}
+	defer zipReader.Close()
+
+	f, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf(
+			"pixiv error %d: failed to create file %s, more info => %w",
+			cdlerrors.OS_ERROR,
+			outputPath,
+			err,
+		)
+	}
+
+	frames := zipFrames(ctx, ugoiraInfo, &zipReader.Reader)
+	if progBar != nil {
+		frames = withFrameProgress(frames, len(ugoiraInfo.Frames), progBar)
+	}
+	err = encodeUgoira(f, filepath.Ext(outputPath), len(ugoiraInfo.Frames), frames)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(outputPath)
+		if errors.Is(err, context.Canceled) {
+			return err
+		}
+		return fmt.Errorf(
+			"pixiv error %d: failed to convert ugoira to %s, more info => %w",
+			cdlerrors.UNEXPECTED_ERROR,
+			outputPath,
+			err,
+		)
+	}
+	return nil
+}
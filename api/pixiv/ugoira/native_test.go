@@ -0,0 +1,229 @@
+package ugoira
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var testFrameColors = []color.RGBA{
+	{R: 0xff, A: 0xff},
+	{G: 0xff, A: 0xff},
+	{B: 0xff, A: 0xff},
+}
+
+// Writes a ugoira zip file with a frame for each test colour where
+// the left half is the colour and the right half is a grey gradient.
+func writeTestUgoiraZip(t *testing.T, delays []int64) (*Ugoira, string) {
+	zipFilePath := filepath.Join(t.TempDir(), "123_ugoira1920x1080.zip")
+	f, err := os.Create(zipFilePath)
+	if err != nil {
+		t.Fatalf("Failed to create the zip file: %v", err)
+	}
+	defer f.Close()
+
+	ugoiraInfo := &Ugoira{Frames: map[string]int64{}}
+	zw := zip.NewWriter(f)
+	for idx, frameColor := range testFrameColors {
+		img := image.NewRGBA(image.Rect(0, 0, 32, 16))
+		for y := 0; y < 16; y++ {
+			for x := 0; x < 32; x++ {
+				if x < 16 {
+					img.Set(x, y, frameColor)
+				} else {
+					img.Set(x, y, color.Gray{Y: uint8(x * 8)})
+				}
+			}
+		}
+
+		frameName := []string{"000000.png", "000001.png", "000002.png"}[idx]
+		fw, err := zw.Create(frameName)
+		if err != nil {
+			t.Fatalf("Failed to create %s in the zip file: %v", frameName, err)
+		}
+		if err := png.Encode(fw, img); err != nil {
+			t.Fatalf("Failed to encode %s: %v", frameName, err)
+		}
+		ugoiraInfo.Frames[frameName] = delays[idx]
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Failed to close the zip file: %v", err)
+	}
+	return ugoiraInfo, zipFilePath
+}
+
+func TestConvertUgoiraNativeGif(t *testing.T) {
+	ugoiraInfo, zipFilePath := writeTestUgoiraZip(t, []int64{100, 35, 1000})
+	outputPath := filepath.Join(filepath.Dir(zipFilePath), "123_ugoira1920x1080.gif")
+	if err := ConvertUgoiraNative(context.Background(), ugoiraInfo, zipFilePath, outputPath); err != nil {
+		t.Fatalf("Failed to convert the ugoira: %v", err)
+	}
+
+	f, err := os.Open(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to open the GIF: %v", err)
+	}
+	defer f.Close()
+	anim, err := gif.DecodeAll(f)
+	if err != nil {
+		t.Fatalf("Failed to decode the GIF: %v", err)
+	}
+
+	if len(anim.Image) != len(testFrameColors) {
+		t.Fatalf("Expected %d frames but got %d", len(testFrameColors), len(anim.Image))
+	}
+	if anim.LoopCount != 0 {
+		t.Errorf("Expected the GIF to loop infinitely but got a loop count of %d", anim.LoopCount)
+	}
+	// 35ms is rounded based on the elapsed time from 100ms to 135ms
+	expectedDelays := []int{10, 4, 100}
+	for idx, frame := range anim.Image {
+		if anim.Delay[idx] != expectedDelays[idx] {
+			t.Errorf("Expected frame %d to have a delay of %d but got %d", idx, expectedDelays[idx], anim.Delay[idx])
+		}
+		if frame.Bounds() != image.Rect(0, 0, 32, 16) {
+			t.Errorf("Unexpected bounds of frame %d: %v", idx, frame.Bounds())
+		}
+		if color.RGBAModel.Convert(frame.At(0, 0)) != testFrameColors[idx] {
+			t.Errorf("Expected frame %d to start with %v but got %v", idx, testFrameColors[idx], frame.At(0, 0))
+		}
+	}
+}
+
+func TestConvertUgoiraNativeApng(t *testing.T) {
+	ugoiraInfo, zipFilePath := writeTestUgoiraZip(t, []int64{100, 70000, 40})
+	outputPath := filepath.Join(filepath.Dir(zipFilePath), "123_ugoira1920x1080.apng")
+	if err := ConvertUgoiraNative(context.Background(), ugoiraInfo, zipFilePath, outputPath); err != nil {
+		t.Fatalf("Failed to convert the ugoira: %v", err)
+	}
+
+	data, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to read the APNG: %v", err)
+	}
+
+	// decoders without APNG support should show the first frame losslessly
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Failed to decode the APNG as a PNG: %v", err)
+	}
+	if c := color.RGBAModel.Convert(img.At(0, 0)); c != testFrameColors[0] {
+		t.Errorf("Expected the default image to start with %v but got %v", testFrameColors[0], c)
+	}
+	if c := color.GrayModel.Convert(img.At(31, 15)).(color.Gray); c.Y != 31*8 {
+		t.Errorf("Expected the default image to end with %d but got %d", 31*8, c.Y)
+	}
+
+	chunkCounts := map[string]int{}
+	var frameCount uint32
+	var delays [][2]uint16
+	var seqs []uint32
+	for offset := len(PNG_SIGNATURE); offset < len(data); {
+		length := int(binary.BigEndian.Uint32(data[offset:]))
+		chunkType := string(data[offset+4 : offset+8])
+		chunk := data[offset+8 : offset+8+length]
+		chunkCounts[chunkType]++
+		switch chunkType {
+		case "acTL":
+			frameCount = binary.BigEndian.Uint32(chunk)
+		case "fcTL":
+			seqs = append(seqs, binary.BigEndian.Uint32(chunk))
+			delays = append(delays, [2]uint16{binary.BigEndian.Uint16(chunk[20:]), binary.BigEndian.Uint16(chunk[22:])})
+		case "fdAT":
+			seqs = append(seqs, binary.BigEndian.Uint32(chunk))
+		}
+		offset += 12 + length
+	}
+
+	if frameCount != 3 || chunkCounts["fcTL"] != 3 || chunkCounts["IDAT"] != 1 || chunkCounts["fdAT"] != 2 {
+		t.Fatalf("Unexpected APNG chunks %v with %d frames", chunkCounts, frameCount)
+	}
+	for idx, seq := range seqs {
+		if seq != uint32(idx) {
+			t.Fatalf("Expected the sequence numbers to be in order but got %v", seqs)
+		}
+	}
+	expectedDelays := [][2]uint16{{100, 1000}, {7000, 100}, {40, 1000}}
+	for idx, delay := range delays {
+		if delay != expectedDelays[idx] {
+			t.Errorf("Expected frame %d to have a delay of %v but got %v", idx, expectedDelays[idx], delay)
+		}
+	}
+}
+
+func TestConvertUgoiraNativeMissingFrame(t *testing.T) {
+	ugoiraInfo, zipFilePath := writeTestUgoiraZip(t, []int64{100, 100, 100})
+	ugoiraInfo.Frames["000003.png"] = 100
+	outputPath := filepath.Join(filepath.Dir(zipFilePath), "123_ugoira1920x1080.gif")
+	if err := ConvertUgoiraNative(context.Background(), ugoiraInfo, zipFilePath, outputPath); err == nil {
+		t.Fatalf("Expected an error for a frame missing from the zip file")
+	}
+	if _, err := os.Stat(outputPath); !os.IsNotExist(err) {
+		t.Errorf("Expected the incomplete GIF to be removed")
+	}
+}
+
+func TestQuantizeImage(t *testing.T) {
+	// a gradient with more than 256 colours
+	img := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 64; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x * 4), G: uint8(y * 4), B: uint8((x + y) * 2), A: 0xff})
+		}
+	}
+
+	paletted := quantizeImage(img)
+	if len(paletted.Palette) > 256 {
+		t.Fatalf("Expected at most 256 colours but got %d", len(paletted.Palette))
+	}
+
+	// dithering should keep the average colour of each area close to the original
+	const blockSize = 8
+	for by := 0; by < 64; by += blockSize {
+		for bx := 0; bx < 64; bx += blockSize {
+			var diff [3]int
+			for y := by; y < by+blockSize; y++ {
+				for x := bx; x < bx+blockSize; x++ {
+					expected := img.RGBAAt(x, y)
+					actual := paletted.Palette[paletted.ColorIndexAt(x, y)].(color.RGBA)
+					diff[0] += int(actual.R) - int(expected.R)
+					diff[1] += int(actual.G) - int(expected.G)
+					diff[2] += int(actual.B) - int(expected.B)
+				}
+			}
+			for _, d := range diff {
+				if avg := d / (blockSize * blockSize); avg < -4 || avg > 4 {
+					t.Fatalf("Expected the block at (%d, %d) to have a similar average colour but got a difference of %v", bx, by, diff)
+				}
+			}
+		}
+	}
+}
+
+func TestValidateArgsEncoder(t *testing.T) {
+	options := &UgoiraOptions{OutputFormat: ".GIF"}
+	if err := options.ValidateArgs(); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if options.Encoder != UGOIRA_ENCODER_AUTO {
+		t.Errorf("Expected the encoder to default to %q but got %q", UGOIRA_ENCODER_AUTO, options.Encoder)
+	}
+
+	options = &UgoiraOptions{OutputFormat: ".mp4", Encoder: "Native"}
+	if err := options.ValidateArgs(); err == nil {
+		t.Errorf("Expected an error as the native encoder does not support .mp4")
+	}
+
+	options = &UgoiraOptions{OutputFormat: ".gif", Encoder: "gifski"}
+	if err := options.ValidateArgs(); err == nil {
+		t.Errorf("Expected an error for an unknown encoder")
+	}
+}
@@ -10,11 +10,11 @@ import (
 	"path/filepath"
 	"sync"
 
+	"github.com/KJHJason/Cultured-Downloader-Logic/api"
 	pixivcommon "github.com/KJHJason/Cultured-Downloader-Logic/api/pixiv/common"
 	"github.com/KJHJason/Cultured-Downloader-Logic/cdlerrors"
 	"github.com/KJHJason/Cultured-Downloader-Logic/configs"
 	"github.com/KJHJason/Cultured-Downloader-Logic/constants"
-	"github.com/KJHJason/Cultured-Downloader-Logic/database"
 	"github.com/KJHJason/Cultured-Downloader-Logic/extractor"
 	"github.com/KJHJason/Cultured-Downloader-Logic/filters"
 	"github.com/KJHJason/Cultured-Downloader-Logic/httpfuncs"
@@ -39,6 +39,7 @@ type UgoiraFfmpegArgs struct {
 	ffmpegPath    string
 	outputPath    string
 	ugoiraQuality int
+	progBar       *progress.DownloadProgressBar // optional
 }
 
 // Converts the Ugoira to the desired output path using FFmpeg
@@ -73,8 +74,9 @@ func ConvertUgoira(ugoiraInfo *Ugoira, imagesFolderPath string, ugoiraFfmpeg *Ug
 	// convert the frames to a gif or a video
 	cmd := exec.CommandContext(ugoiraFfmpeg.context, ugoiraFfmpeg.ffmpegPath, args...)
 	utils.PrepareCmdForBgTask(cmd)
-	// cmd.Stderr = os.Stderr
-	// cmd.Stdout = os.Stdout
+	stderr := newFfmpegStderr()
+	cmd.Stderr = stderr.writer()
+	cmd.Stdout = newFfmpegProgress(ugoiraFfmpeg.progBar, ugoiraInfo.DurationMs())
 
 	err = cmd.Run()
 	if err != nil {
@@ -86,7 +88,7 @@ func ConvertUgoira(ugoiraInfo *Ugoira, imagesFolderPath string, ugoiraFfmpeg *Ug
 			"pixiv error %d: failed to convert ugoira to %s, more info => %w",
 			cdlerrors.CMD_ERROR,
 			ugoiraFfmpeg.outputPath,
-			err,
+			stderr.wrapErr(err),
 		)
 	}
 
@@ -103,15 +105,53 @@ func GetUgoiraFilePaths(ugoireFilePath, ugoiraUrl, outputFormat string) (string,
 	return filePath, outputFilePath
 }
 
-func convertUgoira(ctx context.Context, ugoira *Ugoira, ugoiraOptions *UgoiraOptions, config *configs.Config) error {
+func convertUgoira(ctx context.Context, ugoira *Ugoira, ugoiraArgs *UgoiraArgs, ugoiraOptions *UgoiraOptions, config *configs.Config, useNative bool, progBarInfo *progress.ProgressBarInfo) error {
 	zipFilePath, outputPath := GetUgoiraFilePaths(ugoira.FilePath, ugoira.Url, ugoiraOptions.OutputFormat)
-	if iofuncs.PathExists(outputPath) || !iofuncs.PathExists(zipFilePath) {
+	if !iofuncs.PathExists(zipFilePath) {
+		return nil
+	}
+	if ugoiraOptions.Archive {
+		// written before the conversion so that the archive is complete even if the conversion fails
+		if err := WriteAnimationJson(ugoira, zipFilePath); err != nil {
+			return err
+		}
+	}
+	if iofuncs.PathExists(outputPath) {
 		return nil
 	}
 
+	progBar := newConversionProgBar(ctx, progBarInfo, outputPath)
+	err := convertZip(ctx, ugoira, zipFilePath, outputPath, ugoiraOptions, config, useNative, progBar)
+	if err == nil {
+		if ugoiraOptions.DeleteZip && !ugoiraOptions.Archive {
+			os.Remove(zipFilePath)
+		}
+		if ugoiraOptions.UseCacheDb {
+			ugoiraArgs.Session.GetDb().CacheUgoira(ugoira.CacheKey)
+		}
+	}
+	return err
+}
+
+// Converts the ugoira zip file to the output path with the native encoders or FFmpeg
+// and updates the progress bar of the conversion if it's not nil.
+func convertZip(ctx context.Context, ugoira *Ugoira, zipFilePath, outputPath string, ugoiraOptions *UgoiraOptions, config *configs.Config, useNative bool, progBar *progress.DownloadProgressBar) error {
+	var err error
+	if useNative {
+		err = convertUgoiraNative(ctx, ugoira, zipFilePath, outputPath, progBar)
+	} else {
+		err = convertUgoiraWithFfmpeg(ctx, ugoira, zipFilePath, outputPath, ugoiraOptions, config, progBar)
+	}
+	if progBar != nil {
+		progBar.Stop(err != nil)
+	}
+	return err
+}
+
+func convertUgoiraWithFfmpeg(ctx context.Context, ugoira *Ugoira, zipFilePath, outputPath string, ugoiraOptions *UgoiraOptions, config *configs.Config, progBar *progress.DownloadProgressBar) error {
 	unzipFolderPath := filepath.Join(
 		filepath.Dir(zipFilePath),
-		"unzipped",
+		constants.UGOIRA_UNZIPPED_FOLDER,
 	)
 	err := extractor.ExtractFiles(ctx, zipFilePath, unzipFolderPath, true)
 	if err != nil {
@@ -127,7 +167,7 @@ func convertUgoira(ctx context.Context, ugoira *Ugoira, ugoiraOptions *UgoiraOpt
 		return err
 	}
 
-	err = ConvertUgoira(
+	return ConvertUgoira(
 		ugoira,
 		unzipFolderPath,
 		&UgoiraFfmpegArgs{
@@ -135,24 +175,28 @@ func convertUgoira(ctx context.Context, ugoira *Ugoira, ugoiraOptions *UgoiraOpt
 			ffmpegPath:    config.FfmpegPath,
 			outputPath:    outputPath,
 			ugoiraQuality: ugoiraOptions.Quality,
+			progBar:       progBar,
 		},
 	)
-	if err == nil {
-		if ugoiraOptions.DeleteZip {
-			os.Remove(zipFilePath)
-		}
-		if ugoiraOptions.UseCacheDb {
-			database.CacheUgoira(ugoira.CacheKey)
-		}
-	}
-	return err
 }
 
-func convertMultipleUgoira(ugoiraArgs *UgoiraArgs, ugoiraOptions *UgoiraOptions, config *configs.Config) []error {
+func convertMultipleUgoira(ugoiraArgs *UgoiraArgs, ugoiraOptions *UgoiraOptions, config *configs.Config, progBarInfo *progress.ProgressBarInfo) []error {
 	ctx, cancel := context.WithCancel(ugoiraArgs.context)
 	defer cancel()
 
 	downloadInfoLen := len(ugoiraArgs.ToDownload)
+	if downloadInfoLen == 0 {
+		return nil
+	}
+
+	useNative, err := ugoiraOptions.useNativeEncoder(ctx, config)
+	if err != nil {
+		if hasCancelled := ugoiraArgs.Session.GetLogger().LogErrors(logger.ERROR, err); hasCancelled {
+			ugoiraArgs.cancel()
+		}
+		return []error{err}
+	}
+
 	maxConcurrency := config.FfmpegWorkers
 	if maxConcurrency <= 0 {
 		maxConcurrency = constants.FFMPEG_MAX_CONCURRENCY
@@ -193,7 +237,7 @@ func convertMultipleUgoira(ugoiraArgs *UgoiraArgs, ugoiraOptions *UgoiraOptions,
 				<-queue
 			}()
 			queue <- struct{}{}
-			err := convertUgoira(ctx, ugoira, ugoiraOptions, config)
+			err := convertUgoira(ctx, ugoira, ugoiraArgs, ugoiraOptions, config, useNative, progBarInfo)
 			if err != nil {
 				errTsSlice.Append(err)
 			}
@@ -207,7 +251,7 @@ func convertMultipleUgoira(ugoiraArgs *UgoiraArgs, ugoiraOptions *UgoiraOptions,
 	hasErr := errTsSlice.LenUnsafe() > 0
 	if hasErr {
 		var hasCancelled bool
-		if hasCancelled, errSlice = logger.LogSliceErrors(logger.ERROR, errTsSlice); hasCancelled {
+		if hasCancelled, errSlice = ugoiraArgs.Session.GetLogger().LogSliceErrors(logger.ERROR, errTsSlice); hasCancelled {
 			prog.StopInterrupt(
 				fmt.Sprintf("Stopped converting ugoira to %s!", ugoiraOptions.OutputFormat),
 			)
@@ -223,22 +267,35 @@ type UgoiraArgs struct {
 	cancel         context.CancelFunc
 	UseMobileApi   bool
 	Filters        *filters.Filters
+	Sanitizer      *iofuncs.PathSanitizer // defaults to iofuncs.DefaultPathSanitizer if nil
 	CaptchaHandler httpfuncs.CaptchaHandler
 	ToDownload     []*Ugoira
 	Cookies        []*http.Cookie
 	MainProgBar    progress.ProgressBar
+	Session        *api.Session // uses the default session if nil
 }
 
 func (u *UgoiraArgs) SetContext(ctx context.Context) {
 	u.context, u.cancel = context.WithCancel(ctx)
 }
 
+// Since we're mainly dealing with .zip, we have to add it into the filters if it's not there
+func allowUgoiraZip(ugoiraFilters *filters.Filters) *filters.Filters {
+	const ugoiraFileExt = ".zip"
+	if ugoiraFilters.IsFileExtValid(ugoiraFileExt) {
+		return ugoiraFilters
+	}
+	zipFilters := ugoiraFilters.Copy()
+	zipFilters.FileExt = append(zipFilters.FileExt, ugoiraFileExt)
+	return zipFilters
+}
+
 // Downloads multiple Ugoira artworks and converts them based on the output format
 func DownloadMultipleUgoira(ugoiraArgs *UgoiraArgs, ugoiraOptions *UgoiraOptions, config *configs.Config, reqHandler httpfuncs.RequestHandler, progBarInfo *progress.ProgressBarInfo) []error {
 	if ugoiraOptions.UseCacheDb {
 		filteredUgoira := make([]*Ugoira, 0, len(ugoiraArgs.ToDownload))
 		for _, ugoira := range ugoiraArgs.ToDownload {
-			if ugoira.CacheKey != "" && database.UgoiraCacheExists(ugoira.CacheKey) {
+			if ugoira.CacheKey != "" && ugoiraArgs.Session.GetDb().UgoiraCacheExists(ugoira.CacheKey) {
 				continue
 			}
 			filteredUgoira = append(filteredUgoira, ugoira)
@@ -253,11 +310,16 @@ func DownloadMultipleUgoira(ugoiraArgs *UgoiraArgs, ugoiraOptions *UgoiraOptions
 			ugoira.Url,
 			ugoiraOptions.OutputFormat,
 		)
-		if !iofuncs.PathExists(outputFilePath) {
-			urlsToDownload = append(urlsToDownload, &httpfuncs.ToDownload{
+		// archived ugoira are downloaded again if their zip files were deleted
+		if !iofuncs.PathExists(outputFilePath) || (ugoiraOptions.Archive && !iofuncs.PathExists(filePath)) {
+			toDownload := &httpfuncs.ToDownload{
 				Url:      ugoira.Url,
 				FilePath: filePath,
-			})
+			}
+			if ugoira.Filters != nil {
+				toDownload.Filters = allowUgoiraZip(ugoira.Filters)
+			}
+			urlsToDownload = append(urlsToDownload, toDownload)
 		}
 	}
 
@@ -272,16 +334,6 @@ func DownloadMultipleUgoira(ugoiraArgs *UgoiraArgs, ugoiraOptions *UgoiraOptions
 		useHttp3 = httpfuncs.IsHttp3Supported(constants.PIXIV, true)
 	}
 
-	// since we're mainly dealing with .zip, we have to add it into the filters if it's not there
-	var filters *filters.Filters
-	const ugoiraFileExt = ".zip"
-	if ugoiraArgs.Filters.IsFileExtValid(ugoiraFileExt) {
-		filters = ugoiraArgs.Filters
-	} else {
-		filters = ugoiraArgs.Filters.Copy()
-		filters.FileExt = append(filters.FileExt, ugoiraFileExt)
-	}
-
 	cancelled, err := httpfuncs.DownloadUrlsWithHandler(
 		urlsToDownload,
 		&httpfuncs.DlOptions{
@@ -292,9 +344,13 @@ func DownloadMultipleUgoira(ugoiraArgs *UgoiraArgs, ugoiraOptions *UgoiraOptions
 			Headers:         headers,
 			Cookies:         ugoiraArgs.Cookies,
 			UseHttp3:        useHttp3,
-			Filters:         filters,
+			Filters:         allowUgoiraZip(ugoiraArgs.Filters),
+			Sanitizer:       ugoiraArgs.Sanitizer,
 			ProgressBarInfo: progBarInfo,
 			CaptchaHandler:  ugoiraArgs.CaptchaHandler,
+			Db:              ugoiraArgs.Session.GetDb(),
+			Logger:          ugoiraArgs.Session.GetLogger(),
+			Transports:      ugoiraArgs.Session.GetTransports(),
 		},
 		config, // Note: if isMobileApi is true, custom user-agent will be ignored
 		reqHandler,
@@ -307,5 +363,5 @@ func DownloadMultipleUgoira(ugoiraArgs *UgoiraArgs, ugoiraOptions *UgoiraOptions
 		return err
 	}
 
-	return convertMultipleUgoira(ugoiraArgs, ugoiraOptions, config)
+	return convertMultipleUgoira(ugoiraArgs, ugoiraOptions, config, progBarInfo)
 }
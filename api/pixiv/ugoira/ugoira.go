@@ -1,20 +1,57 @@
 package ugoira
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"strings"
 
 	"github.com/KJHJason/Cultured-Downloader-Logic/cdlerrors"
+	"github.com/KJHJason/Cultured-Downloader-Logic/configs"
 	"github.com/KJHJason/Cultured-Downloader-Logic/utils"
 )
 
 // UgoiraDlOptions is the struct that contains the
 // configs for the processing of the ugoira images after downloading from Pixiv.
 type UgoiraOptions struct {
-	DeleteZip    bool
+	DeleteZip bool
+	// Quality is the CRF value for .mp4, .webm and .avif where lower is better
 	Quality      int
 	OutputFormat string
 	UseCacheDb   bool
+
+	// Preset is one of the UGOIRA_PRESET_* which overrides Quality with the preset's value for the output format.
+	//
+	// Formats without a quality setting, i.e. .gif, .apng, .webp and .mkv, ignore the preset.
+	Preset string
+
+	// Encoder is the encoder used to convert the ugoira, defaults to UGOIRA_ENCODER_AUTO
+	Encoder string
+
+	// Archive keeps the original zip file regardless of DeleteZip and writes the frame delays
+	// to a "<zip name>.animation.json" next to it so that the ugoira can be converted again losslessly
+	// with RegenerateUgoira or RegenerateAllUgoira.
+	Archive bool
+}
+
+const (
+	// Uses FFmpeg if it's available and falls back to the pure-Go encoders otherwise
+	UGOIRA_ENCODER_AUTO   = "auto"
+	UGOIRA_ENCODER_FFMPEG = "ffmpeg"
+	// Uses the pure-Go encoders which only supports .gif and .apng
+	UGOIRA_ENCODER_NATIVE = "native"
+)
+
+var UGOIRA_ACCEPTED_ENCODERS = []string{
+	UGOIRA_ENCODER_AUTO,
+	UGOIRA_ENCODER_FFMPEG,
+	UGOIRA_ENCODER_NATIVE,
+}
+
+// Output formats that can be converted without FFmpeg
+var UGOIRA_NATIVE_ACCEPTED_EXT = []string{
+	".gif",
+	".apng",
 }
 
 var UGOIRA_ACCEPTED_EXT = []string{
@@ -23,6 +60,51 @@ var UGOIRA_ACCEPTED_EXT = []string{
 	".webp",
 	".webm",
 	".mp4",
+	".avif",
+	".mkv", // lossless FFV1
+}
+
+// Maximum quality (CRF) values of the output formats that support them
+var UGOIRA_MAX_QUALITY = map[string]int{
+	".mp4":  51,
+	".webm": 63,
+	".avif": 63,
+}
+
+const (
+	UGOIRA_PRESET_LOSSLESS = "lossless"
+	UGOIRA_PRESET_HIGH     = "high"
+	UGOIRA_PRESET_MEDIUM   = "medium"
+	UGOIRA_PRESET_LOW      = "low"
+)
+
+var UGOIRA_ACCEPTED_PRESETS = []string{
+	UGOIRA_PRESET_LOSSLESS,
+	UGOIRA_PRESET_HIGH,
+	UGOIRA_PRESET_MEDIUM,
+	UGOIRA_PRESET_LOW,
+}
+
+// Quality (CRF) values of the presets for each output format
+var UGOIRA_QUALITY_PRESETS = map[string]map[string]int{
+	".mp4": {
+		UGOIRA_PRESET_LOSSLESS: 0,
+		UGOIRA_PRESET_HIGH:     18,
+		UGOIRA_PRESET_MEDIUM:   23,
+		UGOIRA_PRESET_LOW:      28,
+	},
+	".webm": {
+		UGOIRA_PRESET_LOSSLESS: 0,
+		UGOIRA_PRESET_HIGH:     24,
+		UGOIRA_PRESET_MEDIUM:   31,
+		UGOIRA_PRESET_LOW:      40,
+	},
+	".avif": {
+		UGOIRA_PRESET_LOSSLESS: 0,
+		UGOIRA_PRESET_HIGH:     20,
+		UGOIRA_PRESET_MEDIUM:   30,
+		UGOIRA_PRESET_LOW:      40,
+	},
 }
 
 // ValidateArgs validates the arguments of the ugoira process options.
@@ -31,22 +113,38 @@ var UGOIRA_ACCEPTED_EXT = []string{
 func (u *UgoiraOptions) ValidateArgs() error {
 	u.OutputFormat = strings.ToLower(u.OutputFormat)
 
-	// u.Quality is only for .mp4 and .webm
-	if u.OutputFormat == ".mp4" && u.Quality < 0 || u.Quality > 51 {
-		return fmt.Errorf(
-			"pixiv error %d: Ugoira quality of %d is not allowed\nUgoira quality for FFmpeg must be between 0 and 51 for .mp4",
-			cdlerrors.INPUT_ERROR,
-			u.Quality,
+	u.Preset = strings.ToLower(strings.TrimSpace(u.Preset))
+	if u.Preset != "" {
+		_, err := utils.ValidateStrArgs(
+			u.Preset,
+			UGOIRA_ACCEPTED_PRESETS,
+			[]string{
+				fmt.Sprintf(
+					"pixiv error %d: Ugoira quality preset %q is not allowed",
+					cdlerrors.INPUT_ERROR,
+					u.Preset,
+				),
+			},
 		)
-	} else if u.OutputFormat == ".webm" && u.Quality < 0 || u.Quality > 63 {
+		if err != nil {
+			return err
+		}
+		if quality, ok := UGOIRA_QUALITY_PRESETS[u.OutputFormat][u.Preset]; ok {
+			u.Quality = quality
+		}
+	}
+
+	// u.Quality is only for .mp4, .webm and .avif
+	if maxQuality, ok := UGOIRA_MAX_QUALITY[u.OutputFormat]; ok && (u.Quality < 0 || u.Quality > maxQuality) {
 		return fmt.Errorf(
-			"pixiv error %d: Ugoira quality of %d is not allowed\nUgoira quality for FFmpeg must be between 0 and 63 for .webm",
+			"pixiv error %d: Ugoira quality of %d is not allowed\nUgoira quality for FFmpeg must be between 0 and %d for %s",
 			cdlerrors.INPUT_ERROR,
 			u.Quality,
+			maxQuality,
+			u.OutputFormat,
 		)
 	}
 
-	u.OutputFormat = strings.ToLower(u.OutputFormat)
 	_, err := utils.ValidateStrArgs(
 		u.OutputFormat,
 		UGOIRA_ACCEPTED_EXT,
@@ -61,5 +159,62 @@ func (u *UgoiraOptions) ValidateArgs() error {
 	if err != nil {
 		return err
 	}
+
+	u.Encoder = strings.ToLower(strings.TrimSpace(u.Encoder))
+	if u.Encoder == "" {
+		u.Encoder = UGOIRA_ENCODER_AUTO
+	}
+	_, err = utils.ValidateStrArgs(
+		u.Encoder,
+		UGOIRA_ACCEPTED_ENCODERS,
+		[]string{
+			fmt.Sprintf(
+				"pixiv error %d: Ugoira encoder %q is not allowed",
+				cdlerrors.INPUT_ERROR,
+				u.Encoder,
+			),
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if u.Encoder == UGOIRA_ENCODER_NATIVE && !utils.SliceContains(UGOIRA_NATIVE_ACCEPTED_EXT, u.OutputFormat) {
+		return fmt.Errorf(
+			"pixiv error %d: Output extension %q requires FFmpeg, the native encoder only supports %s",
+			cdlerrors.INPUT_ERROR,
+			u.OutputFormat,
+			strings.Join(UGOIRA_NATIVE_ACCEPTED_EXT, ", "),
+		)
+	}
 	return nil
 }
+
+// Returns true if the ugoira should be converted with the pure-Go encoders.
+//
+// In auto mode, the pure-Go encoders are only used if FFmpeg is unavailable.
+func (u *UgoiraOptions) useNativeEncoder(ctx context.Context, config *configs.Config) (bool, error) {
+	switch u.Encoder {
+	case UGOIRA_ENCODER_NATIVE:
+		return true, nil
+	case UGOIRA_ENCODER_FFMPEG:
+		return false, nil
+	}
+
+	ffmpegErr := config.ValidateFfmpegPathLogic(ctx)
+	if ffmpegErr == nil {
+		return false, nil
+	}
+	if errors.Is(ffmpegErr, context.Canceled) {
+		return false, ffmpegErr
+	}
+	if !utils.SliceContains(UGOIRA_NATIVE_ACCEPTED_EXT, u.OutputFormat) {
+		return false, fmt.Errorf(
+			"pixiv error %d: FFmpeg is required to convert ugoira to %s, please install FFmpeg or use %s instead, more info => %w",
+			cdlerrors.CMD_ERROR,
+			u.OutputFormat,
+			strings.Join(UGOIRA_NATIVE_ACCEPTED_EXT, " or "),
+			ffmpegErr,
+		)
+	}
+	return true, nil
+}
@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
+	"github.com/KJHJason/Cultured-Downloader-Logic/api"
 	pixivcommon "github.com/KJHJason/Cultured-Downloader-Logic/api/pixiv/common"
 	"github.com/KJHJason/Cultured-Downloader-Logic/api/pixiv/ugoira"
 	"github.com/KJHJason/Cultured-Downloader-Logic/cdlerrors"
@@ -93,6 +95,47 @@ func getArtworkUrlsToDlLogic(artworkType int, artworkId string, reqArgs *httpfun
 	return artworkUrlsRes.Resp, nil
 }
 
+// Returns the metadata of the artwork from the artwork details
+func getArtworkMetadata(artworkDetails *ArtworkDetails, webUrl string, tags []string) *metadata.PixivPost {
+	artworkJsonBody := artworkDetails.Body
+	artworkMetadata := &metadata.PixivPost{
+		Url:        webUrl,
+		Title:      artworkJsonBody.Title,
+		Type:       pixivcommon.GetReadableArtworkType(getIllustTypeStr(artworkJsonBody.IllustType)),
+		Creator:    artworkJsonBody.UserName,
+		CreatorId:  artworkJsonBody.UserID,
+		Caption:    artworkJsonBody.Description,
+		Tags:       tags,
+		CreatedAt:  artworkJsonBody.CreateDate,
+		UploadedAt: artworkJsonBody.UploadDate,
+		PageCount:  artworkJsonBody.PageCount,
+		Width:      artworkJsonBody.Width,
+		Height:     artworkJsonBody.Height,
+		Views:      artworkJsonBody.ViewCount,
+		Bookmarks:  artworkJsonBody.BookmarkCount,
+		Likes:      artworkJsonBody.LikeCount,
+		AiType:     artworkJsonBody.AiType,
+		XRestrict:  artworkJsonBody.XRestrict,
+	}
+	for _, tag := range artworkJsonBody.Tags.Tags {
+		if tag.Translation.En == "" {
+			continue
+		}
+		if artworkMetadata.TagTranslations == nil {
+			artworkMetadata.TagTranslations = make(map[string]string)
+		}
+		artworkMetadata.TagTranslations[tag.Tag] = tag.Translation.En
+	}
+	if series := artworkJsonBody.SeriesNavData; series != nil {
+		artworkMetadata.Series = &metadata.PixivSeries{
+			Id:    series.SeriesID.String(),
+			Title: series.Title,
+			Order: series.Order,
+		}
+	}
+	return artworkMetadata
+}
+
 // Retrieves details of an artwork ID and returns
 // the folder path to download the artwork to, the JSON response, and the artwork type
 func getArtworkDetails(artworkId string, dlOptions *PixivWebDlOptions) ([]*httpfuncs.ToDownload, *ugoira.Ugoira, error) {
@@ -105,7 +148,8 @@ func getArtworkDetails(artworkId string, dlOptions *PixivWebDlOptions) ([]*httpf
 	url := getArtworkDetailsApi(artworkId) // API URL
 	webUrl := fmt.Sprintf("https://www.pixiv.net/artworks/%s", artworkId)
 	if dlOptions.Base.UseCacheDb {
-		if database.PostCacheExists(webUrl, constants.PIXIV) || database.UgoiraCacheExists(webUrl) {
+		db := dlOptions.Base.Session.GetDb()
+		if db.PostCacheExists(webUrl, constants.PIXIV) || db.UgoiraCacheExists(webUrl) {
 			// either the artwork or the ugoira cache exists
 			return nil, nil, nil
 		}
@@ -127,6 +171,8 @@ func getArtworkDetails(artworkId string, dlOptions *PixivWebDlOptions) ([]*httpf
 		Http3:          useHttp3,
 		Context:        dlOptions.GetContext(),
 		CaptchaHandler: dlOptions.GetCaptchaHandler(),
+		Transports:     dlOptions.Base.Session.GetTransports(),
+		Logger:         dlOptions.Base.Session.GetLogger(),
 	}
 	artworkDetailsJsonRes, err := getArtworkDetailsLogic(artworkId, reqArgs)
 	if err != nil {
@@ -137,39 +183,39 @@ func getArtworkDetails(artworkId string, dlOptions *PixivWebDlOptions) ([]*httpf
 	}
 
 	artworkJsonBody := artworkDetailsJsonRes.Body
-	if !dlOptions.Base.Filters.IsPostDateValid(artworkJsonBody.UploadDate) {
+	filterInfo := &filters.PostInfo{
+		Title: artworkJsonBody.Title,
+		Tags:  make([]string, 0, len(artworkJsonBody.Tags.Tags)),
+		Fee:   filters.UNKNOWN_FEE,
+		Date:  artworkJsonBody.UploadDate,
+		Type:  getIllustTypeStr(artworkJsonBody.IllustType),
+	}
+	for _, tag := range artworkJsonBody.Tags.Tags {
+		filterInfo.Tags = append(filterInfo.Tags, tag.Tag)
+	}
+	postFilters := dlOptions.Base.GetFilters(artworkJsonBody.UserID)
+	if !postFilters.IsAdultContentValid(artworkJsonBody.XRestrict > 0) ||
+		!postFilters.IsPostDateValid(artworkJsonBody.UploadDate) ||
+		!postFilters.IsPostExprValid(filterInfo) {
+		return nil, nil, nil
+	}
+	if _, ok := dlOptions.searchResultIds[artworkId]; ok &&
+		!dlOptions.pFilters.IsPopularityValid(artworkJsonBody.BookmarkCount, artworkJsonBody.LikeCount) {
 		return nil, nil, nil
 	}
 
-	illustratorName := artworkJsonBody.UserName
 	artworkName := artworkJsonBody.Title
-	artworkPostDir := iofuncs.GetPostFolder(
-		dlOptions.Base.DownloadDirPath,
-		illustratorName,
-		artworkId,
-		artworkName,
-	)
-
-	if dlOptions.Base.SetMetadata {
-		var readableIllustType string
-		switch artworkJsonBody.IllustType {
-		case ILLUST:
-			readableIllustType = "Illustration"
-		case MANGA:
-			readableIllustType = "Manga"
-		case UGOIRA:
-			readableIllustType = "Ugoira"
-		default:
-			readableIllustType = "Unknown"
-		}
-		artworkMetadata := metadata.PixivPost{
-			Url:   webUrl,
-			Title: artworkName,
-			Type:  readableIllustType,
-		}
-		if err := metadata.WriteMetadata(artworkMetadata, artworkPostDir); err != nil {
-			return nil, nil, err
-		}
+	pathInfo := &iofuncs.PathTemplateInfo{
+		CreatorName: artworkJsonBody.UserName,
+		CreatorId:   artworkJsonBody.UserID,
+		PostId:      artworkId,
+		Title:       artworkName,
+		Date:        artworkJsonBody.UploadDate,
+	}
+	artworkPostDir := dlOptions.Base.GetPostFolder(pathInfo)
+	if chapter, ok := dlOptions.mangaChapters[artworkId]; ok {
+		// chapters of a manga series are saved in order in the series folder
+		artworkPostDir = chapter.Dir
 	}
 
 	artworkType := artworkJsonBody.IllustType
@@ -187,10 +233,25 @@ func getArtworkDetails(artworkId string, dlOptions *PixivWebDlOptions) ([]*httpf
 		artworkUrlsRes,
 		artworkType,
 		artworkPostDir,
+		pathInfo,
+		dlOptions,
 	)
 	if err != nil {
 		return nil, nil, err
 	}
+
+	if dlOptions.Base.SetMetadata {
+		// written after processing the artwork to include the ugoira frames
+		artworkMetadata := getArtworkMetadata(artworkDetailsJsonRes, webUrl, filterInfo.Tags)
+		artworkMetadata.UgoiraFrames = ugoiraInfo.FramesMetadata()
+		artworkMetadata.Rankings = dlOptions.rankings[artworkId]
+		if err := metadata.WriteMetadataWithOptions(*artworkMetadata, artworkPostDir, dlOptions.Base.MetadataOptions()); err != nil {
+			return nil, nil, err
+		}
+	}
+	httpfuncs.SetPostInfo(urlsToDl, filterInfo)
+	httpfuncs.SetFilters(urlsToDl, postFilters)
+	httpfuncs.SetSource(urlsToDl, dlOptions.Base.NewFileSource(pathInfo, webUrl))
 	return urlsToDl, ugoiraInfo, nil
 }
 
@@ -255,7 +316,7 @@ func GetMultipleArtworkDetails(artworkIds []string, dlOptions *PixivWebDlOptions
 	hasErr := false
 	if len(errSlice) > 0 {
 		hasErr = true
-		if hasCancelled := logger.LogErrors(logger.ERROR, errSlice...); hasCancelled {
+		if hasCancelled := dlOptions.Base.Session.GetLogger().LogErrors(logger.ERROR, errSlice...); hasCancelled {
 			dlOptions.CancelCtx()
 			progress.StopInterrupt("Stopped getting and processing artwork details from Pixiv!")
 			return nil, nil, errSlice
@@ -266,8 +327,8 @@ func GetMultipleArtworkDetails(artworkIds []string, dlOptions *PixivWebDlOptions
 	return artworkDetails, ugoiraDetails, errSlice
 }
 
-// Query Pixiv's API for all the illustrator's posts
-func getArtistPosts(illustratorId, pageNum string, dlOptions *PixivWebDlOptions) ([]string, error) {
+// Query Pixiv's API for the illustrator's profile which contains the IDs of all their works
+func getArtistProfile(illustratorId string, dlOptions *PixivWebDlOptions) (*IllustratorJson, error) {
 	headers := pixivcommon.GetPixivRequestHeaders()
 	headers["Referer"] = pixivcommon.GetIllustUrl(illustratorId)
 	url := getArtistArtworksApi(illustratorId)
@@ -284,6 +345,8 @@ func getArtistPosts(illustratorId, pageNum string, dlOptions *PixivWebDlOptions)
 			Http3:          useHttp3,
 			Context:        dlOptions.GetContext(),
 			CaptchaHandler: dlOptions.GetCaptchaHandler(),
+			Transports:     dlOptions.Base.Session.GetTransports(),
+			Logger:         dlOptions.Base.Session.GetLogger(),
 		},
 	)
 	if err != nil {
@@ -311,8 +374,34 @@ func getArtistPosts(illustratorId, pageNum string, dlOptions *PixivWebDlOptions)
 	if err := httpfuncs.LoadJsonFromResponse(res.Resp, &jsonBody); err != nil {
 		return nil, err
 	}
-	artworkIds, err := processArtistPostsJson(&jsonBody, pageNum, dlOptions)
-	return artworkIds, err
+	return &jsonBody, nil
+}
+
+// Query Pixiv's API for all the illustrator's posts
+func getArtistPosts(illustratorId, pageNum string, dlOptions *PixivWebDlOptions) ([]string, error) {
+	jsonBody, err := getArtistProfile(illustratorId, dlOptions)
+	if err != nil {
+		return nil, err
+	}
+	artworkIds, err := processArtistPostsJson(jsonBody, pageNum, dlOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	// Pixiv returns all the artwork IDs of the illustrator at once
+	// but skipping the seen artworks avoids getting their details.
+	watermark := dlOptions.Base.NewSyncWatermark(illustratorId)
+	if watermark != nil {
+		unseenIds := make([]string, 0, len(artworkIds))
+		for _, artworkId := range artworkIds {
+			if !watermark.IsSeen(artworkId, time.Time{}) {
+				unseenIds = append(unseenIds, artworkId)
+			}
+		}
+		artworkIds = unseenIds
+	}
+	watermark.Done()
+	return artworkIds, nil
 }
 
 // Get posts from multiple illustrators and returns a slice of artwork IDs
@@ -362,7 +451,7 @@ func GetMultipleArtistsPosts(illustratorIds, pageNums []string, dlOptions *Pixiv
 	hasErr := false
 	if len(errSlice) > 0 {
 		hasErr = true
-		if hasCancelled := logger.LogErrors(logger.ERROR, errSlice...); hasCancelled {
+		if hasCancelled := dlOptions.Base.Session.GetLogger().LogErrors(logger.ERROR, errSlice...); hasCancelled {
 			dlOptions.CancelCtx()
 			progress.StopInterrupt("Stopped getting artwork details from artist(s) on Pixiv!")
 			return nil, errSlice
@@ -379,7 +468,7 @@ type pageNumArgs struct {
 	hasMax  bool
 }
 
-func tagSearchLogic(filters *filters.Filters, tagName string, reqArgs *httpfuncs.RequestArgs, pageNumArgs *pageNumArgs) ([]string, []error) {
+func tagSearchLogic(base *api.BaseDl, pFilters *pixivcommon.PixivFilters, tagName string, reqArgs *httpfuncs.RequestArgs, pageNumArgs *pageNumArgs) ([]string, []error) {
 	var errSlice []error
 	var artworkIds []string
 	page := 0
@@ -409,13 +498,15 @@ func tagSearchLogic(filters *filters.Filters, tagName string, reqArgs *httpfuncs
 			continue
 		}
 
-		tagArtworkIds, err := processTagJsonResults(filters, res.Resp)
+		tagArtworkIds, resultsCount, err := processTagJsonResults(base, pFilters, res.Resp)
 		if err != nil {
 			errSlice = append(errSlice, err)
 			continue
 		}
 
-		if len(tagArtworkIds) == 0 {
+		// all the results in the page may be filtered out
+		// so only stop when there are no more results
+		if resultsCount == 0 {
 			break
 		}
 
@@ -433,14 +524,16 @@ func tagSearchLogic(filters *filters.Filters, tagName string, reqArgs *httpfuncs
 func TagSearch(tagName, pageNum string, dlOptions *PixivWebDlOptions) ([]string, []error, bool) {
 	minPage, maxPage, hasMax, err := utils.GetMinMaxFromStr(pageNum)
 	if err != nil {
-		logger.LogError(err, logger.ERROR)
+		dlOptions.Base.Session.GetLogger().LogError(err, logger.ERROR)
 		return nil, []error{err}, false
 	}
 
-	url := getTagArtworksApi(tagName)
+	// search term with the OR groups and the excluded tags
+	searchWord := dlOptions.pFilters.SearchWord(tagName)
+	url := getTagArtworksApi(searchWord)
 	params := map[string]string{
 		// search term
-		"word": tagName,
+		"word": searchWord,
 
 		// search mode: s_tag, s_tag_full, s_tc
 		"s_mode": dlOptions.pFilters.SearchMode,
@@ -458,12 +551,20 @@ func TagSearch(tagName, pageNum string, dlOptions *PixivWebDlOptions) ([]string,
 		// 0: display AI works, 1: hide AI works
 		"ai_type": strconv.Itoa(dlOptions.pFilters.SearchAiMode),
 	}
+	// date range: YYYY-MM-DD
+	if dlOptions.pFilters.StartDate != "" {
+		params["scd"] = dlOptions.pFilters.StartDate
+	}
+	if dlOptions.pFilters.EndDate != "" {
+		params["ecd"] = dlOptions.pFilters.EndDate
+	}
 
 	useHttp3 := httpfuncs.IsHttp3Supported(constants.PIXIV, true)
 	headers := pixivcommon.GetPixivRequestHeaders()
 	headers["Referer"] = fmt.Sprintf("%s/tags/%s/artworks", constants.PIXIV_URL, tagName)
 	artworkIds, errSlice := tagSearchLogic(
-		dlOptions.Base.Filters,
+		dlOptions.Base,
+		dlOptions.pFilters,
 		tagName,
 		&httpfuncs.RequestArgs{
 			Url:            url,
@@ -477,6 +578,8 @@ func TagSearch(tagName, pageNum string, dlOptions *PixivWebDlOptions) ([]string,
 			Http3:          useHttp3,
 			Context:        dlOptions.GetContext(),
 			CaptchaHandler: dlOptions.GetCaptchaHandler(),
+			Transports:     dlOptions.Base.Session.GetTransports(),
+			Logger:         dlOptions.Base.Session.GetLogger(),
 		},
 		&pageNumArgs{
 			minPage: minPage,
@@ -486,11 +589,14 @@ func TagSearch(tagName, pageNum string, dlOptions *PixivWebDlOptions) ([]string,
 	)
 
 	if len(errSlice) > 0 {
-		if cancelled := logger.LogErrors(logger.ERROR, errSlice...); cancelled {
+		if cancelled := dlOptions.Base.Session.GetLogger().LogErrors(logger.ERROR, errSlice...); cancelled {
 			dlOptions.CancelCtx()
 			return nil, errSlice, true
 		}
 	}
 
+	if dlOptions.pFilters.HasPopularityFilter() {
+		dlOptions.addSearchResults(artworkIds)
+	}
 	return artworkIds, errSlice, false
 }
@@ -4,12 +4,16 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/KJHJason/Cultured-Downloader-Logic/api"
 	pixivcommon "github.com/KJHJason/Cultured-Downloader-Logic/api/pixiv/common"
+	"github.com/KJHJason/Cultured-Downloader-Logic/api/pixiv/manga"
+	"github.com/KJHJason/Cultured-Downloader-Logic/api/pixiv/novel"
 	"github.com/KJHJason/Cultured-Downloader-Logic/cdlerrors"
 	"github.com/KJHJason/Cultured-Downloader-Logic/constants"
 	"github.com/KJHJason/Cultured-Downloader-Logic/httpfuncs"
+	"github.com/KJHJason/Cultured-Downloader-Logic/metadata"
 	"github.com/KJHJason/Cultured-Downloader-Logic/utils"
 )
 
@@ -21,6 +25,20 @@ type PixivWebDlOptions struct {
 	Base *api.BaseDl
 
 	pFilters *pixivcommon.PixivFilters
+
+	// Artwork ID -> positions in the rankings to be saved in the metadata
+	rankings map[string][]metadata.PixivRanking
+
+	// IDs of the artworks from the tag search to check the minimum bookmarks and likes
+	// with the artwork details as the search results do not include the counts
+	searchResultIds map[string]struct{}
+
+	// Artwork ID -> chapter of a manga series to download the artwork to the chapter folder
+	mangaChapters map[string]*manga.Chapter
+
+	// Formats to export the novels to like "txt", "md", and "epub".
+	// Defaults to all the formats.
+	NovelFormats []string
 }
 
 func (p *PixivWebDlOptions) GetCaptchaHandler() httpfuncs.CaptchaHandler {
@@ -29,6 +47,8 @@ func (p *PixivWebDlOptions) GetCaptchaHandler() httpfuncs.CaptchaHandler {
 		constants.PIXIV_URL,
 		p.Base.Configs.UserAgent,
 		p.Base.Notifier,
+		p.Base.Session.GetCfCache(),
+		p.Base.Session.GetLogger(),
 	)
 }
 
@@ -49,6 +69,31 @@ func (p *PixivWebDlOptions) CtxIsActive() bool {
 	return p.ctx.Err() == nil
 }
 
+func (p *PixivWebDlOptions) addRanking(artworkId string, ranking metadata.PixivRanking) {
+	if p.rankings == nil {
+		p.rankings = make(map[string][]metadata.PixivRanking)
+	}
+	p.rankings[artworkId] = append(p.rankings[artworkId], ranking)
+}
+
+func (p *PixivWebDlOptions) addSearchResults(artworkIds []string) {
+	if p.searchResultIds == nil {
+		p.searchResultIds = make(map[string]struct{})
+	}
+	for _, artworkId := range artworkIds {
+		p.searchResultIds[artworkId] = struct{}{}
+	}
+}
+
+func (p *PixivWebDlOptions) addMangaChapters(series *manga.Series) {
+	if p.mangaChapters == nil {
+		p.mangaChapters = make(map[string]*manga.Chapter)
+	}
+	for _, chapter := range series.Chapters {
+		p.mangaChapters[chapter.ArtworkId] = chapter
+	}
+}
+
 func (p *PixivWebDlOptions) SetPixivFilters(filters pixivcommon.PixivFilters) error {
 	if err := filters.ValidateForWebApi(); err != nil {
 		return err
@@ -114,5 +159,35 @@ func (p *PixivWebDlOptions) ValidateArgs(userAgent string) error {
 	} else {
 		p.Base.DownloadDirPath = dlDirPath
 	}
+
+	if err := p.Base.ValidatePathTemplates(constants.PIXIV); err != nil {
+		return err
+	}
+	if err := p.Base.ValidateFilterProfiles(); err != nil {
+		return err
+	}
+
+	if len(p.NovelFormats) == 0 {
+		p.NovelFormats = append([]string{}, novel.ACCEPTED_FORMATS...)
+	}
+	for idx, format := range p.NovelFormats {
+		format = strings.TrimPrefix(strings.ToLower(format), ".")
+		_, err := utils.ValidateStrArgs(
+			format,
+			novel.ACCEPTED_FORMATS,
+			[]string{
+				fmt.Sprintf(
+					"pixiv web error %d: Novel format %s is not allowed",
+					cdlerrors.INPUT_ERROR,
+					format,
+				),
+			},
+		)
+		if err != nil {
+			return err
+		}
+		p.NovelFormats[idx] = format
+	}
+	p.NovelFormats = utils.RemoveDuplicatesFromSlice(p.NovelFormats)
 	return nil
 }
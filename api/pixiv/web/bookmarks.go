@@ -0,0 +1,186 @@
+package pixivweb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	pixivcommon "github.com/KJHJason/Cultured-Downloader-Logic/api/pixiv/common"
+	"github.com/KJHJason/Cultured-Downloader-Logic/cdlerrors"
+	"github.com/KJHJason/Cultured-Downloader-Logic/constants"
+	"github.com/KJHJason/Cultured-Downloader-Logic/httpfuncs"
+	"github.com/KJHJason/Cultured-Downloader-Logic/logger"
+	"github.com/KJHJason/Cultured-Downloader-Logic/parsers"
+	"github.com/KJHJason/Cultured-Downloader-Logic/utils"
+)
+
+// Returns the user ID of the logged in user from the session cookie
+// as its value is in the format of "<user_id>_<random_string>".
+func getLoggedInUserId(cookies []*http.Cookie) (string, error) {
+	cookieName := parsers.GetSessionCookieInfo(constants.PIXIV).Name
+	for _, cookie := range cookies {
+		if cookie.Name != cookieName {
+			continue
+		}
+		userId, _, found := strings.Cut(cookie.Value, "_")
+		if found && constants.NUMBER_REGEX.MatchString(userId) {
+			return userId, nil
+		}
+	}
+	return "", fmt.Errorf(
+		"pixiv web error %d: failed to get the user ID from the session cookie, please specify the user ID of the bookmarks",
+		cdlerrors.INPUT_ERROR,
+	)
+}
+
+// The web API uses "show" and "hide" for the public and private bookmarks respectively
+func getBookmarkRest(restrict string) string {
+	if restrict == pixivcommon.BOOKMARKS_PRIVATE {
+		return "hide"
+	}
+	return "show"
+}
+
+func getBookmarksLogic(bookmarks *pixivcommon.Bookmarks, userId, restrict string, dlOptions *PixivWebDlOptions) ([]string, error) {
+	minPage, maxPage, hasMax, err := utils.GetMinMaxFromStr(bookmarks.PageNum)
+	if err != nil {
+		return nil, err
+	}
+
+	desc := bookmarks.Description(userId, restrict)
+	headers := pixivcommon.GetPixivRequestHeaders()
+	headers["Referer"] = pixivcommon.GetUserUrl(userId) + "/bookmarks/artworks"
+	useHttp3 := httpfuncs.IsHttp3Supported(constants.PIXIV, true)
+	reqArgs := &httpfuncs.RequestArgs{
+		Url:     getBookmarksApi(userId),
+		Method:  "GET",
+		Cookies: dlOptions.Base.SessionCookies,
+		Headers: headers,
+		Params: map[string]string{
+			"tag":   bookmarks.Tag,
+			"limit": strconv.Itoa(constants.PIXIV_BOOKMARKS_PER_PAGE),
+			"rest":  getBookmarkRest(restrict),
+		},
+		CheckStatus:    true,
+		UserAgent:      dlOptions.Base.Configs.UserAgent,
+		Http2:          !useHttp3,
+		Http3:          useHttp3,
+		Context:        dlOptions.GetContext(),
+		CaptchaHandler: dlOptions.GetCaptchaHandler(),
+		Transports:     dlOptions.Base.Session.GetTransports(),
+		Logger:         dlOptions.Base.Session.GetLogger(),
+	}
+
+	var artworkIds []string
+	for page := minPage; !hasMax || page <= maxPage; page++ {
+		offset := (page - 1) * constants.PIXIV_BOOKMARKS_PER_PAGE
+		reqArgs.Params["offset"] = strconv.Itoa(offset)
+		res, err := httpfuncs.CallRequest(reqArgs)
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				return nil, err
+			}
+			return nil, fmt.Errorf(
+				"pixiv web error %d: failed to get the %s due to %w",
+				cdlerrors.CONNECTION_ERROR,
+				desc,
+				err,
+			)
+		}
+
+		var resJson BookmarksJson
+		if err := httpfuncs.LoadJsonFromResponse(res.Resp, &resJson); err != nil {
+			return nil, err
+		}
+		artworkIds = append(artworkIds, filterIllustThumbnails(dlOptions.Base, resJson.Body.Works, nil)...)
+
+		if len(resJson.Body.Works) == 0 || offset+len(resJson.Body.Works) >= resJson.Body.Total {
+			break
+		}
+		pixivSleep()
+	}
+	return artworkIds, nil
+}
+
+// Query Pixiv's API for the bookmarked artworks of a user and returns a slice of artwork IDs
+func getBookmarks(bookmarks *pixivcommon.Bookmarks, dlOptions *PixivWebDlOptions) ([]string, []error) {
+	userId := bookmarks.UserId
+	if userId == "" {
+		var err error
+		if userId, err = getLoggedInUserId(dlOptions.Base.SessionCookies); err != nil {
+			return nil, []error{err}
+		}
+	}
+
+	var errSlice []error
+	var artworkIds []string
+	for _, restrict := range bookmarks.Restricts() {
+		ids, err := getBookmarksLogic(bookmarks, userId, restrict, dlOptions)
+		if err != nil {
+			errSlice = append(errSlice, err)
+			if errors.Is(err, context.Canceled) {
+				return nil, errSlice
+			}
+			continue
+		}
+		artworkIds = append(artworkIds, ids...)
+	}
+	return artworkIds, errSlice
+}
+
+// Get the bookmarked artworks of multiple users and returns a slice of artwork IDs
+// to be used with GetMultipleArtworkDetails
+func GetMultipleBookmarks(bookmarksSlice []*pixivcommon.Bookmarks, dlOptions *PixivWebDlOptions) ([]string, []error) {
+	var errSlice []error
+	var artworkIdsSlice []string
+	bookmarksLen := len(bookmarksSlice)
+	lastIdx := bookmarksLen - 1
+
+	baseMsg := "Getting bookmarked artworks on Pixiv [%d/" + fmt.Sprintf("%d]...", bookmarksLen)
+	progress := dlOptions.Base.MainProgBar()
+	progress.UpdateBaseMsg(baseMsg)
+	progress.UpdateSuccessMsg(
+		fmt.Sprintf(
+			"Finished getting bookmarked artworks from %d bookmark list(s) on Pixiv!",
+			bookmarksLen,
+		),
+	)
+	progress.UpdateErrorMsg(
+		fmt.Sprintf(
+			"Something went wrong while getting bookmarked artworks from %d bookmark list(s) on Pixiv!\nPlease refer to the logs for more details.",
+			bookmarksLen,
+		),
+	)
+	progress.SetToProgressBar()
+	progress.UpdateMax(bookmarksLen)
+	progress.Start()
+	defer progress.SnapshotTask()
+	for idx, bookmarks := range bookmarksSlice {
+		artworkIds, err := getBookmarks(bookmarks, dlOptions)
+		if len(err) > 0 {
+			errSlice = append(errSlice, err...)
+		}
+		artworkIdsSlice = append(artworkIdsSlice, artworkIds...)
+
+		if idx != lastIdx {
+			pixivSleep()
+		}
+		progress.Increment()
+	}
+
+	hasErr := false
+	if len(errSlice) > 0 {
+		hasErr = true
+		if hasCancelled := dlOptions.Base.Session.GetLogger().LogErrors(logger.ERROR, errSlice...); hasCancelled {
+			dlOptions.CancelCtx()
+			progress.StopInterrupt("Stopped getting bookmarked artworks on Pixiv!")
+			return nil, errSlice
+		}
+	}
+	progress.Stop(hasErr)
+
+	return artworkIdsSlice, errSlice
+}
@@ -0,0 +1,98 @@
+package pixivweb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	pixivcommon "github.com/KJHJason/Cultured-Downloader-Logic/api/pixiv/common"
+	"github.com/KJHJason/Cultured-Downloader-Logic/cdlerrors"
+	"github.com/KJHJason/Cultured-Downloader-Logic/constants"
+	"github.com/KJHJason/Cultured-Downloader-Logic/httpfuncs"
+	"github.com/KJHJason/Cultured-Downloader-Logic/logger"
+	"github.com/KJHJason/Cultured-Downloader-Logic/utils"
+)
+
+// Query Pixiv's API for the new works from the artists followed by the logged in user
+// and returns a slice of artwork IDs to be used with GetMultipleArtworkDetails.
+//
+// With IncrementalSync, the feed stops at the newest artwork of the previous sync
+// and the new watermark is only saved by CommitSyncWatermarks after the artworks are downloaded.
+//
+// Returns the slice, the errors, and a boolean indicating if the user cancelled the operation.
+func GetFollowFeed(feed *pixivcommon.FollowFeed, dlOptions *PixivWebDlOptions) ([]string, []error, bool) {
+	minPage, maxPage, hasMax, err := utils.GetMinMaxFromStr(feed.PageNum)
+	if err != nil {
+		dlOptions.Base.Session.GetLogger().LogError(err, logger.ERROR)
+		return nil, []error{err}, false
+	}
+
+	// r18 or all for both
+	mode := "all"
+	if dlOptions.pFilters.RatingMode == "r18" {
+		mode = "r18"
+	}
+
+	headers := pixivcommon.GetPixivRequestHeaders()
+	headers["Referer"] = constants.PIXIV_URL + "/bookmark_new_illust.php"
+	useHttp3 := httpfuncs.IsHttp3Supported(constants.PIXIV, true)
+	reqArgs := &httpfuncs.RequestArgs{
+		Url:            getFollowFeedApi(),
+		Method:         "GET",
+		Cookies:        dlOptions.Base.SessionCookies,
+		Headers:        headers,
+		Params:         map[string]string{"mode": mode},
+		CheckStatus:    true,
+		UserAgent:      dlOptions.Base.Configs.UserAgent,
+		Http2:          !useHttp3,
+		Http3:          useHttp3,
+		Context:        dlOptions.GetContext(),
+		CaptchaHandler: dlOptions.GetCaptchaHandler(),
+		Transports:     dlOptions.Base.Session.GetTransports(),
+		Logger:         dlOptions.Base.Session.GetLogger(),
+	}
+
+	var errSlice []error
+	var artworkIds []string
+	watermark := dlOptions.Base.NewSyncWatermark(pixivcommon.FOLLOW_FEED_WATERMARK_PREFIX + mode)
+	for page := minPage; !hasMax || page <= maxPage; page++ {
+		reqArgs.Params["p"] = strconv.Itoa(page)
+		res, err := httpfuncs.CallRequest(reqArgs)
+		if err != nil {
+			if !errors.Is(err, context.Canceled) {
+				err = fmt.Errorf(
+					"pixiv web error %d: failed to get page %d of the follow feed due to %w",
+					cdlerrors.CONNECTION_ERROR,
+					page,
+					err,
+				)
+			}
+			errSlice = append(errSlice, err)
+			break
+		}
+
+		var resJson FollowFeedJson
+		if err := httpfuncs.LoadJsonFromResponse(res.Resp, &resJson); err != nil {
+			errSlice = append(errSlice, err)
+			break
+		}
+
+		illusts := resJson.Body.Thumbnails.Illust
+		artworkIds = append(artworkIds, filterIllustThumbnails(dlOptions.Base, illusts, watermark)...)
+		if len(illusts) == 0 || watermark.ReachedSeen() {
+			break
+		}
+		pixivSleep()
+	}
+
+	if len(errSlice) > 0 {
+		if cancelled := dlOptions.Base.Session.GetLogger().LogErrors(logger.ERROR, errSlice...); cancelled {
+			dlOptions.CancelCtx()
+			return nil, errSlice, true
+		}
+	} else {
+		watermark.Done()
+	}
+	return artworkIds, errSlice, false
+}
@@ -0,0 +1,79 @@
+package pixivweb
+
+import (
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/KJHJason/Cultured-Downloader-Logic/api"
+	pixivcommon "github.com/KJHJason/Cultured-Downloader-Logic/api/pixiv/common"
+	"github.com/KJHJason/Cultured-Downloader-Logic/constants"
+	"github.com/KJHJason/Cultured-Downloader-Logic/database"
+	"github.com/KJHJason/Cultured-Downloader-Logic/filters"
+	"github.com/KJHJason/Cultured-Downloader-Logic/logger"
+)
+
+func newTestFollowFeedPage(artworkIds ...string) []*IllustThumbnail {
+	date := time.Date(2024, 5, 12, 0, 0, 0, 0, time.UTC)
+	illusts := make([]*IllustThumbnail, 0, len(artworkIds))
+	for _, artworkId := range artworkIds {
+		id, _ := json.Number(artworkId).Int64()
+		illusts = append(illusts, &IllustThumbnail{
+			ID:         json.Number(artworkId),
+			CreateDate: date.Add(time.Duration(id) * time.Hour),
+		})
+	}
+	return illusts
+}
+
+// Processes the follow feed like GetFollowFeed in a new download session
+// and saves the watermark if the artworks were downloaded successfully.
+func syncTestFollowFeed(t *testing.T, db *database.DbWrapper, illusts []*IllustThumbnail, dlSucceeded bool) []string {
+	l := logger.NewLogger(io.Discard)
+	base := &api.BaseDl{
+		Session:         api.NewSession(db, &l),
+		UseCacheDb:      true,
+		IncrementalSync: true,
+		Filters:         &filters.Filters{},
+	}
+	if err := base.ValidatePathTemplates(constants.PIXIV); err != nil {
+		t.Fatalf("Failed to validate the path templates: %v", err)
+	}
+
+	watermark := base.NewSyncWatermark(pixivcommon.FOLLOW_FEED_WATERMARK_PREFIX + "all")
+	artworkIds := filterIllustThumbnails(base, illusts, watermark)
+	watermark.Done()
+	if dlSucceeded {
+		if err := base.CommitSyncWatermarks(); err != nil {
+			t.Fatalf("Failed to commit the watermarks: %v", err)
+		}
+	}
+	return artworkIds
+}
+
+func TestFollowFeedIncrementalSync(t *testing.T) {
+	db, err := database.NewDb(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create db: %v", err)
+	}
+	defer db.Close()
+
+	// the artworks are not downloaded, so the next sync should get them again
+	if artworkIds := syncTestFollowFeed(t, db, newTestFollowFeedPage("2", "1"), false); !slices.Equal(artworkIds, []string{"2", "1"}) {
+		t.Fatalf("Expected all the artworks in the first sync but got %q", artworkIds)
+	}
+	if artworkIds := syncTestFollowFeed(t, db, newTestFollowFeedPage("2", "1"), true); !slices.Equal(artworkIds, []string{"2", "1"}) {
+		t.Fatalf("Expected the artworks of the failed sync to be retried but got %q", artworkIds)
+	}
+
+	if artworkIds := syncTestFollowFeed(t, db, newTestFollowFeedPage("4", "3", "2", "1"), true); !slices.Equal(artworkIds, []string{"4", "3"}) {
+		t.Errorf("Expected the sync to stop at the watermark but got %q", artworkIds)
+	}
+	watermark := db.GetCreatorWatermark(pixivcommon.FOLLOW_FEED_WATERMARK_PREFIX+"all", constants.PIXIV)
+	if watermark == nil || watermark.PostId != "4" {
+		t.Errorf("Expected the newest artwork to be the watermark but got %+v", watermark)
+	}
+}
@@ -0,0 +1,175 @@
+package pixivweb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	pixivcommon "github.com/KJHJason/Cultured-Downloader-Logic/api/pixiv/common"
+	"github.com/KJHJason/Cultured-Downloader-Logic/api/pixiv/manga"
+	"github.com/KJHJason/Cultured-Downloader-Logic/cdlerrors"
+	"github.com/KJHJason/Cultured-Downloader-Logic/constants"
+	"github.com/KJHJason/Cultured-Downloader-Logic/httpfuncs"
+	"github.com/KJHJason/Cultured-Downloader-Logic/iofuncs"
+	"github.com/KJHJason/Cultured-Downloader-Logic/logger"
+)
+
+// Query Pixiv's API for the details of the manga series and its chapters in order
+func getMangaSeries(seriesId string, dlOptions *PixivWebDlOptions) (*manga.Series, error) {
+	headers := pixivcommon.GetPixivRequestHeaders()
+	params := map[string]string{}
+	useHttp3 := httpfuncs.IsHttp3Supported(constants.PIXIV, true)
+	reqArgs := &httpfuncs.RequestArgs{
+		Url:            getMangaSeriesApi(seriesId),
+		Method:         "GET",
+		Cookies:        dlOptions.Base.SessionCookies,
+		Headers:        headers,
+		Params:         params,
+		CheckStatus:    true,
+		UserAgent:      dlOptions.Base.Configs.UserAgent,
+		Http2:          !useHttp3,
+		Http3:          useHttp3,
+		Context:        dlOptions.GetContext(),
+		CaptchaHandler: dlOptions.GetCaptchaHandler(),
+		Transports:     dlOptions.Base.Session.GetTransports(),
+		Logger:         dlOptions.Base.Session.GetLogger(),
+	}
+
+	series := &manga.Series{Id: seriesId}
+	entriesCount := 0
+	for page := 1; ; page++ {
+		params["p"] = strconv.Itoa(page)
+		res, err := httpfuncs.CallRequest(reqArgs)
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				return nil, err
+			}
+			return nil, fmt.Errorf(
+				"pixiv web error %d: failed to get page %d of the manga series %s due to %w",
+				cdlerrors.CONNECTION_ERROR,
+				page,
+				seriesId,
+				err,
+			)
+		}
+
+		var resJson MangaSeriesJson
+		if err := httpfuncs.LoadJsonFromResponse(res.Resp, &resJson); err != nil {
+			return nil, err
+		}
+
+		resBody := resJson.Body
+		if page == 1 {
+			for _, illustSeries := range resBody.IllustSeries {
+				if illustSeries.ID != seriesId {
+					continue
+				}
+				series.Title = illustSeries.Title
+				series.Description = pixivcommon.HtmlToText(illustSeries.Caption)
+				series.CreatorId = illustSeries.UserID
+			}
+			for _, user := range resBody.Users {
+				if user.UserID == series.CreatorId {
+					series.Creator = user.Name
+				}
+			}
+			if series.CreatorId == "" {
+				return nil, fmt.Errorf(
+					"pixiv web error %d: manga series %s not found in the response",
+					cdlerrors.RESPONSE_ERROR,
+					seriesId,
+				)
+			}
+		}
+
+		thumbnails := make(map[string]*IllustThumbnail, len(resBody.Thumbnails.Illust))
+		for _, illust := range resBody.Thumbnails.Illust {
+			thumbnails[illust.ID.String()] = illust
+		}
+		for _, entry := range resBody.Page.Series {
+			// deleted or private works cannot be downloaded
+			illust, ok := thumbnails[entry.WorkID]
+			if !ok || illust.IsMasked {
+				continue
+			}
+			series.Chapters = append(series.Chapters, &manga.Chapter{
+				ArtworkId: entry.WorkID,
+				Url:       pixivcommon.GetIllustUrl(entry.WorkID),
+				Title:     illust.Title,
+				Number:    entry.Order,
+				Tags:      illust.Tags,
+				Date:      illust.CreateDate,
+				IsR18:     illust.XRestrict > 0,
+			})
+		}
+
+		entriesCount += len(resBody.Page.Series)
+		if len(resBody.Page.Series) == 0 || entriesCount >= resBody.Page.Total {
+			break
+		}
+		pixivSleep()
+	}
+
+	series.Url = pixivcommon.GetMangaSeriesUrl(series.CreatorId, seriesId)
+	series.SortChapters()
+	series.Dir = dlOptions.Base.GetPostFolder(&iofuncs.PathTemplateInfo{
+		CreatorName: series.Creator,
+		CreatorId:   series.CreatorId,
+		PostId:      seriesId,
+		Title:       series.Title,
+	})
+	series.SetChapterDirs(dlOptions.Base.Sanitizer)
+	return series, nil
+}
+
+// Get the chapters from multiple manga series and returns the series
+// where the chapters are downloaded with GetMultipleArtworkDetails to their numbered chapter folders.
+func GetMultipleMangaSeries(seriesIds []string, dlOptions *PixivWebDlOptions) ([]*manga.Series, []error) {
+	var errSlice []error
+	var seriesSlice []*manga.Series
+	seriesIdsLen := len(seriesIds)
+	lastIdx := seriesIdsLen - 1
+
+	baseMsg := "Getting chapters from manga series on Pixiv [%d/" + fmt.Sprintf("%d]...", seriesIdsLen)
+	progress := dlOptions.Base.MainProgBar()
+	progress.UpdateBaseMsg(baseMsg)
+	progress.UpdateSuccessMsg(
+		fmt.Sprintf(
+			"Finished getting chapters from %d manga series on Pixiv!",
+			seriesIdsLen,
+		),
+	)
+	progress.UpdateErrorMsg(
+		fmt.Sprintf(
+			"Something went wrong while getting chapters from %d manga series on Pixiv!\nPlease refer to the logs for more details.",
+			seriesIdsLen,
+		),
+	)
+	progress.SetToProgressBar()
+	progress.UpdateMax(seriesIdsLen)
+	progress.Start()
+	defer progress.SnapshotTask()
+	for idx, seriesId := range seriesIds {
+		series, err := getMangaSeries(seriesId, dlOptions)
+		if err != nil {
+			if hasCancelled := dlOptions.Base.Session.GetLogger().LogErrors(logger.ERROR, err); hasCancelled {
+				dlOptions.CancelCtx()
+				progress.StopInterrupt("Stopped getting chapters from manga series on Pixiv!")
+				return nil, append(errSlice, err)
+			}
+			errSlice = append(errSlice, err)
+		} else {
+			dlOptions.addMangaChapters(series)
+			seriesSlice = append(seriesSlice, series)
+		}
+
+		if idx != lastIdx {
+			pixivSleep()
+		}
+		progress.Increment()
+	}
+
+	progress.Stop(len(errSlice) > 0)
+	return seriesSlice, errSlice
+}
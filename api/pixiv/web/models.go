@@ -1,6 +1,7 @@
 package pixivweb
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/KJHJason/Cultured-Downloader-Logic/api/pixiv/ugoira"
@@ -14,13 +15,13 @@ type ArtworkDetails struct {
 		// IllustTitle   string    `json:"illustTitle"`
 		// IllustComment string    `json:"illustComment"`
 		// ID            string    `json:"id"`
-		Title string `json:"title"` // should be same as IllustTitle
-		// Description   string    `json:"description"`
-		IllustType int `json:"illustType"`
-		// CreateDate    time.Time `json:"createDate"`
-		UploadDate time.Time `json:"uploadDate"` // 2024-05-12T11:17:00+00:00
+		Title       string    `json:"title"`       // should be same as IllustTitle
+		Description string    `json:"description"` // HTML
+		IllustType  int       `json:"illustType"`
+		CreateDate  time.Time `json:"createDate"`
+		UploadDate  time.Time `json:"uploadDate"` // 2024-05-12T11:17:00+00:00
 		// Restrict      int       `json:"restrict"`
-		// XRestrict     int       `json:"xRestrict"`
+		XRestrict int `json:"xRestrict"` // 0: SFW, 1: R18, 2: R18G
 		// Sl            int       `json:"sl"`
 		// Urls          struct {
 		// 	Mini     string `json:"mini"`
@@ -29,35 +30,35 @@ type ArtworkDetails struct {
 		// 	Regular  string `json:"regular"`
 		// 	Original string `json:"original"`
 		// } `json:"urls"`
-		// Tags struct {
-		// 	AuthorID string `json:"authorId"`
-		// 	IsLocked bool   `json:"isLocked"`
-		// 	Tags     []struct {
-		// 		Tag         string `json:"tag"`
-		// 		Locked      bool   `json:"locked"`
-		// 		Deletable   bool   `json:"deletable"`
-		// 		UserID      string `json:"userId,omitempty"`
-		// 		Romaji      string `json:"romaji"`
-		// 		Translation struct {
-		// 			En string `json:"en"`
-		// 		} `json:"translation,omitempty"`
-		// 		UserName string `json:"userName,omitempty"`
-		// 	} `json:"tags"`
-		// 	Writable bool `json:"writable"`
-		// } `json:"tags"`
+		Tags struct {
+			// AuthorID string `json:"authorId"`
+			// IsLocked bool   `json:"isLocked"`
+			Tags []struct {
+				Tag string `json:"tag"`
+				// Locked      bool   `json:"locked"`
+				// Deletable   bool   `json:"deletable"`
+				// UserID      string `json:"userId,omitempty"`
+				// Romaji      string `json:"romaji"`
+				Translation struct {
+					En string `json:"en"`
+				} `json:"translation,omitempty"`
+				// UserName string `json:"userName,omitempty"`
+			} `json:"tags"`
+			// Writable bool `json:"writable"`
+		} `json:"tags"`
 		// Alt         string `json:"alt"`
-		// UserID      string `json:"userId"`
+		UserID   string `json:"userId"`
 		UserName string `json:"userName"`
 		// UserAccount string `json:"userAccount"`
 		// LikeData             bool  `json:"likeData"`
-		// Width                int   `json:"width"`
-		// Height               int   `json:"height"`
-		// PageCount            int   `json:"pageCount"`
-		// BookmarkCount        int   `json:"bookmarkCount"`
-		// LikeCount            int   `json:"likeCount"`
+		Width         int `json:"width"`
+		Height        int `json:"height"`
+		PageCount     int `json:"pageCount"`
+		BookmarkCount int `json:"bookmarkCount"`
+		LikeCount     int `json:"likeCount"`
 		// CommentCount         int   `json:"commentCount"`
 		// ResponseCount        int   `json:"responseCount"`
-		// ViewCount            int   `json:"viewCount"`
+		ViewCount int `json:"viewCount"`
 		// BookStyle            int   `json:"bookStyle"`
 		// IsHowto              bool  `json:"isHowto"`
 		// IsOriginal           bool  `json:"isOriginal"`
@@ -65,7 +66,11 @@ type ArtworkDetails struct {
 		// ImageResponseData    []any `json:"imageResponseData"`
 		// ImageResponseCount   int   `json:"imageResponseCount"`
 		// PollData             any   `json:"pollData"`
-		// SeriesNavData        any   `json:"seriesNavData"`
+		SeriesNavData *struct {
+			SeriesID json.Number `json:"seriesId"`
+			Title    string      `json:"title"`
+			Order    int         `json:"order"`
+		} `json:"seriesNavData"` // null if the artwork is not in a series
 		// DescriptionBoothID   any   `json:"descriptionBoothId"`
 		// DescriptionYoutubeID any   `json:"descriptionYoutubeId"`
 		// ComicPromotion       any   `json:"comicPromotion"`
@@ -131,7 +136,7 @@ type ArtworkDetails struct {
 		// IsUnlisted               bool `json:"isUnlisted"`
 		// Request                  any  `json:"request"`
 		// CommentOff               int  `json:"commentOff"`
-		// AiType                   int  `json:"aiType"`
+		AiType int `json:"aiType"` // 0: unknown, 1: not AI-generated, 2: AI-generated
 		// ReuploadDate             any  `json:"reuploadDate"`
 		// LocationMask             bool `json:"locationMask"`
 		// CommissionIllustHaveRisk bool `json:"commissionIllustHaveRisk"`
@@ -164,20 +169,20 @@ type PixivTag struct {
 	Body struct {
 		IllustManga struct {
 			Data []struct {
-				ID string `json:"id"`
-				// Title                   string   `json:"title"`
-				// IllustType              int      `json:"illustType"`
-				// XRestrict               int      `json:"xRestrict"`
+				ID         string `json:"id"`
+				Title      string `json:"title"`
+				IllustType int    `json:"illustType"`
+				XRestrict  int    `json:"xRestrict"` // 0: SFW, 1: R18, 2: R18G
 				// Restrict                int      `json:"restrict"`
 				// Sl                      int      `json:"sl"`
 				// URL                     string   `json:"url"`
 				// Description             string   `json:"description"`
-				// Tags                    []string `json:"tags"`
-				// UserID                  string   `json:"userId"`
+				Tags   []string `json:"tags"`
+				UserID string   `json:"userId"`
 				// UserName                string   `json:"userName"`
 				// Width                   int      `json:"width"`
 				// Height                  int      `json:"height"`
-				// PageCount               int      `json:"pageCount"`
+				PageCount int `json:"pageCount"`
 				// IsBookmarkable          bool     `json:"isBookmarkable"`
 				// BookmarkData            any      `json:"bookmarkData"`
 				// Alt                     string   `json:"alt"`
@@ -205,5 +210,152 @@ type IllustratorJson struct {
 	Body struct {
 		Illusts any `json:"illusts"`
 		Manga   any `json:"manga"`
+		Novels  any `json:"novels"`
+	} `json:"body"`
+}
+
+// Artwork details in the bookmarks and the follow feed
+type IllustThumbnail struct {
+	ID         json.Number `json:"id"` // usually a string but deleted works may have a number
+	Title      string      `json:"title"`
+	IllustType int         `json:"illustType"`
+	XRestrict  int         `json:"xRestrict"` // 0: SFW, 1: R18, 2: R18G
+	Tags       []string    `json:"tags"`
+	UserID     string      `json:"userId"`
+	CreateDate time.Time   `json:"createDate"` // 2024-07-19T14:39:25+09:00
+	IsMasked   bool        `json:"isMasked"`   // deleted or private works of other users
+}
+
+type BookmarksJson struct {
+	Body struct {
+		Works []*IllustThumbnail `json:"works"`
+		Total int                `json:"total"`
+	} `json:"body"`
+}
+
+type FollowFeedJson struct {
+	Body struct {
+		Thumbnails struct {
+			Illust []*IllustThumbnail `json:"illust"`
+		} `json:"thumbnails"`
 	} `json:"body"`
 }
+
+// As to why TextEmbeddedImages is a json.RawMessage,
+// Pixiv returns an empty array instead of an object when there are no uploaded images.
+type NovelJson struct {
+	Body struct {
+		ID          string    `json:"id"`
+		Title       string    `json:"title"`
+		Description string    `json:"description"` // HTML
+		Content     string    `json:"content"`
+		CoverUrl    string    `json:"coverUrl"`
+		UserID      string    `json:"userId"`
+		UserName    string    `json:"userName"`
+		XRestrict   int       `json:"xRestrict"`  // 0: SFW, 1: R18, 2: R18G
+		UploadDate  time.Time `json:"uploadDate"` // 2024-07-19T05:39:25+00:00
+		Tags        struct {
+			Tags []struct {
+				Tag string `json:"tag"`
+			} `json:"tags"`
+		} `json:"tags"`
+		SeriesNavData *struct {
+			SeriesID json.Number `json:"seriesId"`
+			Title    string      `json:"title"`
+			Order    int         `json:"order"`
+		} `json:"seriesNavData"`
+		TextEmbeddedImages json.RawMessage `json:"textEmbeddedImages"`
+	} `json:"body"`
+}
+
+type NovelEmbeddedImage struct {
+	Urls struct {
+		Original string `json:"original"`
+	} `json:"urls"`
+}
+
+// The images of the artworks inserted with [pixivimage:<id>] where the key is the ID in the markup
+type NovelInsertIllustsJson struct {
+	Body map[string]*struct {
+		Visible bool `json:"visible"`
+		Illust  *struct {
+			Images struct {
+				Original string `json:"original"`
+			} `json:"images"`
+		} `json:"illust"`
+	} `json:"body"`
+}
+
+type NovelSeriesJson struct {
+	Body struct {
+		ID       string `json:"id"`
+		Title    string `json:"title"`
+		Caption  string `json:"caption"`
+		UserID   string `json:"userId"`
+		UserName string `json:"userName"`
+		Total    int    `json:"total"`
+		Cover    struct {
+			Urls struct {
+				Original string `json:"original"`
+			} `json:"urls"`
+		} `json:"cover"`
+		CreateDate time.Time `json:"createDate"`
+	} `json:"body"`
+}
+
+type NovelSeriesContentJson struct {
+	Body struct {
+		Page struct {
+			SeriesContents []struct {
+				ID     string `json:"id"`
+				Series struct {
+					ContentOrder int `json:"contentOrder"`
+				} `json:"series"`
+			} `json:"seriesContents"`
+		} `json:"page"`
+	} `json:"body"`
+}
+
+type MangaSeriesJson struct {
+	Body struct {
+		IllustSeries []struct {
+			ID      string `json:"id"`
+			UserID  string `json:"userId"`
+			Title   string `json:"title"`
+			Caption string `json:"caption"`
+			Total   int    `json:"total"`
+		} `json:"illustSeries"`
+		Page struct {
+			Series []struct {
+				WorkID string `json:"workId"`
+				Order  int    `json:"order"`
+			} `json:"series"`
+			Total int `json:"total"`
+		} `json:"page"`
+		Thumbnails struct {
+			Illust []*IllustThumbnail `json:"illust"`
+		} `json:"thumbnails"`
+		Users []struct {
+			UserID string `json:"userId"`
+			Name   string `json:"name"`
+		} `json:"users"`
+	} `json:"body"`
+}
+
+// As to why Next is any,
+// Pixiv returns the next page number or false if it is the last page.
+type RankingJson struct {
+	Contents []struct {
+		IllustID   int      `json:"illust_id"`
+		Title      string   `json:"title"`
+		Tags       []string `json:"tags"`
+		IllustType string   `json:"illust_type"` // "0": illust, "1": manga, "2": ugoira
+		UserID     int      `json:"user_id"`
+		UserName   string   `json:"user_name"`
+		Rank       int      `json:"rank"`
+		UploadedAt int64    `json:"illust_upload_timestamp"`
+	} `json:"contents"`
+	Date      string `json:"date"` // 20240719
+	Next      any    `json:"next"`
+	RankTotal int    `json:"rank_total"`
+}
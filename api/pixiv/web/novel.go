@@ -0,0 +1,732 @@
+package pixivweb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	pixivcommon "github.com/KJHJason/Cultured-Downloader-Logic/api/pixiv/common"
+	"github.com/KJHJason/Cultured-Downloader-Logic/api/pixiv/novel"
+	"github.com/KJHJason/Cultured-Downloader-Logic/cdlerrors"
+	"github.com/KJHJason/Cultured-Downloader-Logic/constants"
+	"github.com/KJHJason/Cultured-Downloader-Logic/database"
+	"github.com/KJHJason/Cultured-Downloader-Logic/filters"
+	"github.com/KJHJason/Cultured-Downloader-Logic/httpfuncs"
+	"github.com/KJHJason/Cultured-Downloader-Logic/iofuncs"
+	"github.com/KJHJason/Cultured-Downloader-Logic/logger"
+	"github.com/KJHJason/Cultured-Downloader-Logic/metadata"
+)
+
+// Novel contains the details of a Pixiv novel to be exported after its images have been downloaded.
+type Novel struct {
+	*novel.Novel
+
+	Dir        string
+	CacheKey   string // empty if the cache database is not used
+	Metadata   *metadata.PixivNovel
+	ToDownload []*httpfuncs.ToDownload // the cover and the embedded images
+
+	// file paths of the images that were downloaded without errors
+	// which includes the images that were excluded by the filters
+	finishedMu     sync.Mutex
+	finishedImages map[string]struct{}
+}
+
+// Called by the download process for each image that was downloaded without errors
+func (n *Novel) finishImage(filePath string) {
+	n.finishedMu.Lock()
+	defer n.finishedMu.Unlock()
+	if n.finishedImages == nil {
+		n.finishedImages = make(map[string]struct{})
+	}
+	n.finishedImages[filePath] = struct{}{}
+}
+
+func (n *Novel) isImageFinished(filePath string) bool {
+	n.finishedMu.Lock()
+	defer n.finishedMu.Unlock()
+	_, ok := n.finishedImages[filePath]
+	return ok
+}
+
+// NovelSeries contains the novels of a Pixiv novel series to be exported as one EPUB.
+type NovelSeries struct {
+	Id          string
+	Url         string
+	Title       string
+	Author      string
+	Description string
+	Date        time.Time
+	CoverPath   string
+
+	Dir        string
+	Novels     []*Novel
+	ToDownload []*httpfuncs.ToDownload // the cover of the series
+}
+
+func getNovelApiJson(apiUrl, referer string, params map[string]string, dlOptions *PixivWebDlOptions, v any) error {
+	headers := pixivcommon.GetPixivRequestHeaders()
+	headers["Referer"] = referer
+
+	useHttp3 := httpfuncs.IsHttp3Supported(constants.PIXIV, true)
+	res, err := httpfuncs.CallRequest(
+		&httpfuncs.RequestArgs{
+			Url:            apiUrl,
+			Method:         "GET",
+			Cookies:        dlOptions.Base.SessionCookies,
+			Headers:        headers,
+			Params:         params,
+			CheckStatus:    true,
+			UserAgent:      dlOptions.Base.Configs.UserAgent,
+			Http2:          !useHttp3,
+			Http3:          useHttp3,
+			Context:        dlOptions.GetContext(),
+			CaptchaHandler: dlOptions.GetCaptchaHandler(),
+			Transports:     dlOptions.Base.Session.GetTransports(),
+			Logger:         dlOptions.Base.Session.GetLogger(),
+		},
+	)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			return err
+		}
+		return fmt.Errorf(
+			"pixiv web error %d: failed to get %s due to %w",
+			cdlerrors.CONNECTION_ERROR,
+			apiUrl,
+			err,
+		)
+	}
+	return httpfuncs.LoadJsonFromResponse(res.Resp, v)
+}
+
+// Returns the original image URLs of the artworks inserted into the novel with [pixivimage:<id>]
+// where the keys are the same as the novel.Node.ImageKey()
+func getNovelInsertedIllusts(novelId string, illustIds []string, dlOptions *PixivWebDlOptions) (map[string]string, error) {
+	// the IDs have to be passed as id[]=<id>&id[]=<id> which is not supported by the params map
+	query := url.Values{"id[]": illustIds}
+	apiUrl := getNovelInsertIllustsApi(novelId) + "?" + query.Encode()
+
+	var resJson NovelInsertIllustsJson
+	if err := getNovelApiJson(apiUrl, pixivcommon.GetNovelUrl(novelId), nil, dlOptions, &resJson); err != nil {
+		return nil, err
+	}
+
+	imageUrls := make(map[string]string, len(resJson.Body))
+	for illustId, illust := range resJson.Body {
+		if illust == nil || !illust.Visible || illust.Illust == nil || illust.Illust.Images.Original == "" {
+			continue
+		}
+		imageUrls["pixivimage:"+illustId] = illust.Illust.Images.Original
+	}
+	return imageUrls, nil
+}
+
+// Returns the original image URLs of the images uploaded to the novel
+// where the keys are the same as the novel.Node.ImageKey()
+func getNovelUploadedImages(resJson *NovelJson) map[string]string {
+	// Pixiv returns an empty array instead of an object if there are no uploaded images
+	var embeddedImages map[string]*NovelEmbeddedImage
+	if err := json.Unmarshal(resJson.Body.TextEmbeddedImages, &embeddedImages); err != nil {
+		embeddedImages = nil
+	}
+
+	imageUrls := make(map[string]string, len(embeddedImages))
+	for imageId, image := range embeddedImages {
+		if image != nil && image.Urls.Original != "" {
+			imageUrls["uploadedimage:"+imageId] = image.Urls.Original
+		}
+	}
+	return imageUrls
+}
+
+// Retrieves the details of a novel and returns nil if the novel should be skipped.
+//
+// Novels in a series will not be skipped if they were cached so that the series EPUB will be complete.
+func getNovel(novelId string, inSeries bool, dlOptions *PixivWebDlOptions) (*Novel, error) {
+	novelUrl := pixivcommon.GetNovelUrl(novelId)
+	var cacheKey string
+	if dlOptions.Base.UseCacheDb {
+		if !inSeries && dlOptions.Base.Session.GetDb().PostCacheExists(novelUrl, constants.PIXIV) {
+			return nil, nil
+		}
+		cacheKey = database.ParsePostKey(novelUrl, constants.PIXIV)
+	}
+
+	var resJson NovelJson
+	if err := getNovelApiJson(getNovelDetailsApi(novelId), novelUrl, nil, dlOptions, &resJson); err != nil {
+		if errors.Is(err, context.Canceled) {
+			return nil, err
+		}
+		return nil, fmt.Errorf(
+			"%w\ndetails: failed to get details for Pixiv novel ID %s",
+			err,
+			novelId,
+		)
+	}
+
+	novelJsonBody := resJson.Body
+	filterInfo := &filters.PostInfo{
+		Title: novelJsonBody.Title,
+		Tags:  make([]string, 0, len(novelJsonBody.Tags.Tags)),
+		Fee:   filters.UNKNOWN_FEE,
+		Date:  novelJsonBody.UploadDate,
+		Type:  "novel",
+	}
+	for _, tag := range novelJsonBody.Tags.Tags {
+		filterInfo.Tags = append(filterInfo.Tags, tag.Tag)
+	}
+	postFilters := dlOptions.Base.GetFilters(novelJsonBody.UserID)
+	if !postFilters.IsAdultContentValid(novelJsonBody.XRestrict > 0) ||
+		!postFilters.IsPostDateValid(novelJsonBody.UploadDate) ||
+		!postFilters.IsPostExprValid(filterInfo) {
+		return nil, nil
+	}
+
+	pathInfo := &iofuncs.PathTemplateInfo{
+		CreatorName: novelJsonBody.UserName,
+		CreatorId:   novelJsonBody.UserID,
+		PostId:      novelId,
+		Title:       novelJsonBody.Title,
+		Date:        novelJsonBody.UploadDate,
+	}
+	novelDir := dlOptions.Base.GetPostFolder(pathInfo)
+
+	n := &Novel{
+		Novel: &novel.Novel{
+			Id:          novelId,
+			Url:         novelUrl,
+			Title:       novelJsonBody.Title,
+			Author:      novelJsonBody.UserName,
+			AuthorId:    novelJsonBody.UserID,
+			Description: pixivcommon.HtmlToText(novelJsonBody.Description),
+			Tags:        filterInfo.Tags,
+			Date:        novelJsonBody.UploadDate,
+			Pages:       novel.Parse(novelJsonBody.Content),
+			Images:      make(map[string]string),
+		},
+		Dir:      novelDir,
+		CacheKey: cacheKey,
+		Metadata: &metadata.PixivNovel{
+			Url:         novelUrl,
+			Title:       novelJsonBody.Title,
+			Description: pixivcommon.HtmlToText(novelJsonBody.Description),
+			Creator:     novelJsonBody.UserName,
+			CreatorId:   novelJsonBody.UserID,
+			Tags:        filterInfo.Tags,
+			UploadedAt:  novelJsonBody.UploadDate,
+		},
+	}
+	if seriesNavData := novelJsonBody.SeriesNavData; seriesNavData != nil {
+		n.SeriesOrder = seriesNavData.Order
+		n.Metadata.SeriesId = seriesNavData.SeriesID.String()
+		n.Metadata.SeriesTitle = seriesNavData.Title
+		n.Metadata.SeriesOrder = seriesNavData.Order
+	}
+
+	imageUrls := getNovelUploadedImages(&resJson)
+	var illustIds []string
+	for _, node := range novel.ImageNodes(n.Pages) {
+		if node.Type == novel.PIXIV_IMAGE && !slices.Contains(illustIds, node.Value) {
+			illustIds = append(illustIds, node.Value)
+		}
+	}
+	if len(illustIds) > 0 {
+		insertedIllusts, err := getNovelInsertedIllusts(novelId, illustIds, dlOptions)
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				return nil, err
+			}
+			// the novel can still be exported without the inserted artworks
+			dlOptions.Base.Session.GetLogger().LogError(err, logger.ERROR)
+		}
+		for key, imageUrl := range insertedIllusts {
+			imageUrls[key] = imageUrl
+		}
+	}
+
+	source := dlOptions.Base.NewFileSource(pathInfo, pixivcommon.GetNovelUrl(n.Id))
+	addImage := func(imageUrl string) string {
+		filePath := dlOptions.Base.GetFilePath(
+			novelDir,
+			"",
+			httpfuncs.GetLastPartOfUrl(imageUrl),
+			len(n.ToDownload)+1,
+			pathInfo,
+		)
+		n.ToDownload = append(n.ToDownload, &httpfuncs.ToDownload{
+			CacheKey: filePath,
+			CacheFn:  n.finishImage,
+			Url:      imageUrl,
+			FilePath: filePath,
+			PostInfo: filterInfo,
+			Source:   source,
+			Filters:  postFilters,
+		})
+		return filePath
+	}
+	if novelJsonBody.CoverUrl != "" {
+		n.CoverPath = addImage(novelJsonBody.CoverUrl)
+	}
+	for _, node := range novel.ImageNodes(n.Pages) {
+		key := node.ImageKey()
+		if _, ok := n.Images[key]; ok {
+			continue
+		}
+		if imageUrl, ok := imageUrls[key]; ok {
+			n.Images[key] = addImage(imageUrl)
+		}
+	}
+	return n, nil
+}
+
+// Retrieves multiple novel details based on the given slice of novel IDs
+// and returns the novels to be exported with ExportNovels.
+func GetMultipleNovels(novelIds []string, dlOptions *PixivWebDlOptions) ([]*Novel, []error) {
+	var errSlice []error
+	var novels []*Novel
+	novelIdsLen := len(novelIds)
+	lastIdx := novelIdsLen - 1
+
+	baseMsg := "Getting and processing novel details from Pixiv [%d/" + fmt.Sprintf("%d]...", novelIdsLen)
+	progress := dlOptions.Base.MainProgBar()
+	progress.UpdateBaseMsg(baseMsg)
+	progress.UpdateSuccessMsg(
+		fmt.Sprintf(
+			"Finished getting and processing %d novel details from Pixiv!",
+			novelIdsLen,
+		),
+	)
+	progress.UpdateErrorMsg(
+		fmt.Sprintf(
+			"Something went wrong while getting and processing %d novel details from Pixiv!\nPlease refer to the logs for more details.",
+			novelIdsLen,
+		),
+	)
+	progress.SetToProgressBar()
+	progress.UpdateMax(novelIdsLen)
+	progress.Start()
+	defer progress.SnapshotTask()
+	for idx, novelId := range novelIds {
+		n, err := getNovel(novelId, false, dlOptions)
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				dlOptions.CancelCtx()
+				progress.StopInterrupt("Stopped getting and processing novel details from Pixiv!")
+				return nil, append(errSlice, err)
+			}
+			errSlice = append(errSlice, err)
+		} else if n != nil {
+			novels = append(novels, n)
+		}
+
+		progress.Increment()
+		if idx != lastIdx {
+			pixivSleep()
+		}
+	}
+
+	hasErr := false
+	if len(errSlice) > 0 {
+		hasErr = true
+		if hasCancelled := dlOptions.Base.Session.GetLogger().LogErrors(logger.ERROR, errSlice...); hasCancelled {
+			dlOptions.CancelCtx()
+			progress.StopInterrupt("Stopped getting and processing novel details from Pixiv!")
+			return nil, errSlice
+		}
+	}
+	progress.Stop(hasErr)
+	return novels, errSlice
+}
+
+// Returns the IDs of the novels in the series in the order of the series
+func getNovelSeriesContent(seriesId string, dlOptions *PixivWebDlOptions) ([]string, error) {
+	params := map[string]string{
+		"limit":    strconv.Itoa(constants.PIXIV_NOVEL_SERIES_PER_PAGE),
+		"order_by": "asc",
+	}
+
+	var novelIds []string
+	for lastOrder := 0; ; lastOrder += constants.PIXIV_NOVEL_SERIES_PER_PAGE {
+		params["last_order"] = strconv.Itoa(lastOrder)
+		var resJson NovelSeriesContentJson
+		err := getNovelApiJson(
+			getNovelSeriesContentApi(seriesId),
+			pixivcommon.GetNovelSeriesUrl(seriesId),
+			params,
+			dlOptions,
+			&resJson,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		contents := resJson.Body.Page.SeriesContents
+		for _, content := range contents {
+			novelIds = append(novelIds, content.ID)
+		}
+		if len(contents) < constants.PIXIV_NOVEL_SERIES_PER_PAGE {
+			break
+		}
+		pixivSleep()
+	}
+	return novelIds, nil
+}
+
+// Retrieves the details of a novel series and all its novels
+func getNovelSeries(seriesId string, dlOptions *PixivWebDlOptions) (*NovelSeries, []error) {
+	seriesUrl := pixivcommon.GetNovelSeriesUrl(seriesId)
+	var resJson NovelSeriesJson
+	if err := getNovelApiJson(getNovelSeriesApi(seriesId), seriesUrl, nil, dlOptions, &resJson); err != nil {
+		return nil, []error{err}
+	}
+	pixivSleep()
+
+	novelIds, err := getNovelSeriesContent(seriesId, dlOptions)
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	seriesJsonBody := resJson.Body
+	pathInfo := &iofuncs.PathTemplateInfo{
+		CreatorName: seriesJsonBody.UserName,
+		CreatorId:   seriesJsonBody.UserID,
+		PostId:      seriesId,
+		Title:       seriesJsonBody.Title,
+		Date:        seriesJsonBody.CreateDate,
+	}
+	series := &NovelSeries{
+		Id:          seriesId,
+		Url:         seriesUrl,
+		Title:       seriesJsonBody.Title,
+		Author:      seriesJsonBody.UserName,
+		Description: pixivcommon.HtmlToText(seriesJsonBody.Caption),
+		Date:        seriesJsonBody.CreateDate,
+		Dir:         dlOptions.Base.GetPostFolder(pathInfo),
+	}
+	if coverUrl := seriesJsonBody.Cover.Urls.Original; coverUrl != "" {
+		series.CoverPath = dlOptions.Base.GetFilePath(series.Dir, "", httpfuncs.GetLastPartOfUrl(coverUrl), 1, pathInfo)
+		series.ToDownload = append(series.ToDownload, &httpfuncs.ToDownload{
+			Url:      coverUrl,
+			FilePath: series.CoverPath,
+			Source:   dlOptions.Base.NewFileSource(pathInfo, seriesUrl),
+		})
+	}
+
+	var errSlice []error
+	for _, novelId := range novelIds {
+		pixivSleep()
+		n, err := getNovel(novelId, true, dlOptions)
+		if err != nil {
+			errSlice = append(errSlice, err)
+			if errors.Is(err, context.Canceled) {
+				return nil, errSlice
+			}
+			continue
+		}
+		if n != nil {
+			series.Novels = append(series.Novels, n)
+		}
+	}
+	return series, errSlice
+}
+
+// Retrieves multiple novel series and their novels based on the given slice of series IDs
+// and returns the series to be exported with ExportNovels.
+func GetMultipleNovelSeries(seriesIds []string, dlOptions *PixivWebDlOptions) ([]*NovelSeries, []error) {
+	var errSlice []error
+	var seriesSlice []*NovelSeries
+	seriesIdsLen := len(seriesIds)
+	lastIdx := seriesIdsLen - 1
+
+	baseMsg := "Getting novels from novel series on Pixiv [%d/" + fmt.Sprintf("%d]...", seriesIdsLen)
+	progress := dlOptions.Base.MainProgBar()
+	progress.UpdateBaseMsg(baseMsg)
+	progress.UpdateSuccessMsg(
+		fmt.Sprintf(
+			"Finished getting novels from %d novel series on Pixiv!",
+			seriesIdsLen,
+		),
+	)
+	progress.UpdateErrorMsg(
+		fmt.Sprintf(
+			"Something went wrong while getting novels from %d novel series on Pixiv!\nPlease refer to the logs for more details.",
+			seriesIdsLen,
+		),
+	)
+	progress.SetToProgressBar()
+	progress.UpdateMax(seriesIdsLen)
+	progress.Start()
+	defer progress.SnapshotTask()
+	for idx, seriesId := range seriesIds {
+		series, err := getNovelSeries(seriesId, dlOptions)
+		if len(err) > 0 {
+			if hasCancelled := dlOptions.Base.Session.GetLogger().LogErrors(logger.ERROR, err...); hasCancelled {
+				dlOptions.CancelCtx()
+				progress.StopInterrupt("Stopped getting novels from novel series on Pixiv!")
+				return nil, append(errSlice, err...)
+			}
+			errSlice = append(errSlice, err...)
+		}
+		if series != nil {
+			seriesSlice = append(seriesSlice, series)
+		}
+
+		if idx != lastIdx {
+			pixivSleep()
+		}
+		progress.Increment()
+	}
+
+	progress.Stop(len(errSlice) > 0)
+	return seriesSlice, errSlice
+}
+
+// Get the novels from multiple illustrators and returns a slice of novel IDs
+func GetMultipleArtistsNovels(illustratorIds, pageNums []string, dlOptions *PixivWebDlOptions) ([]string, []error) {
+	var errSlice []error
+	var novelIdsSlice []string
+	illustratorIdsLen := len(illustratorIds)
+	lastIllustratorIdx := illustratorIdsLen - 1
+
+	baseMsg := "Getting novels from artist(s) on Pixiv [%d/" + fmt.Sprintf("%d]...", illustratorIdsLen)
+	progress := dlOptions.Base.MainProgBar()
+	progress.UpdateBaseMsg(baseMsg)
+	progress.UpdateSuccessMsg(
+		fmt.Sprintf(
+			"Finished getting novels from %d artist(s) on Pixiv!",
+			illustratorIdsLen,
+		),
+	)
+	progress.UpdateErrorMsg(
+		fmt.Sprintf(
+			"Something went wrong while getting novels from %d artist(s) on Pixiv!\nPlease refer to the logs for more details.",
+			illustratorIdsLen,
+		),
+	)
+	progress.SetToProgressBar()
+	progress.UpdateMax(illustratorIdsLen)
+	progress.Start()
+	defer progress.SnapshotTask()
+	for idx, illustratorId := range illustratorIds {
+		jsonBody, err := getArtistProfile(illustratorId, dlOptions)
+		if err == nil {
+			var novelIds []string
+			novelIds, err = processArtistNovelsJson(jsonBody, pageNums[idx])
+			novelIdsSlice = append(novelIdsSlice, novelIds...)
+		}
+		if err != nil {
+			errSlice = append(errSlice, err)
+		}
+
+		if idx != lastIllustratorIdx {
+			pixivSleep()
+		}
+		progress.Increment()
+	}
+
+	hasErr := false
+	if len(errSlice) > 0 {
+		hasErr = true
+		if hasCancelled := dlOptions.Base.Session.GetLogger().LogErrors(logger.ERROR, errSlice...); hasCancelled {
+			dlOptions.CancelCtx()
+			progress.StopInterrupt("Stopped getting novels from artist(s) on Pixiv!")
+			return nil, errSlice
+		}
+	}
+	progress.Stop(hasErr)
+
+	return novelIdsSlice, errSlice
+}
+
+// GetNovelsToDownload returns the covers and the embedded images of the novels and the novel series
+// which should be downloaded before calling ExportNovels.
+func GetNovelsToDownload(novels []*Novel, seriesSlice []*NovelSeries) []*httpfuncs.ToDownload {
+	var toDownload []*httpfuncs.ToDownload
+	for _, n := range novels {
+		toDownload = append(toDownload, n.ToDownload...)
+	}
+	for _, series := range seriesSlice {
+		toDownload = append(toDownload, series.ToDownload...)
+		for _, n := range series.Novels {
+			toDownload = append(toDownload, n.ToDownload...)
+		}
+	}
+	return toDownload
+}
+
+// Returns the file path of the exported novel or series in the given format
+func getNovelOutputPath(dirPath, title, format string, dlOptions *PixivWebDlOptions) string {
+	sanitizer := dlOptions.Base.Sanitizer
+	if sanitizer == nil {
+		sanitizer = iofuncs.DefaultPathSanitizer
+	}
+	return filepath.Join(dirPath, sanitizer.CleanFilename(title+"."+format))
+}
+
+// Removes the images that failed to download or were excluded by the filters
+// and returns true if none of the images failed to download.
+func removeMissingNovelImages(n *Novel) bool {
+	complete := true
+	if n.CoverPath != "" && !iofuncs.PathExists(n.CoverPath) {
+		complete = complete && n.isImageFinished(n.CoverPath)
+		n.CoverPath = ""
+	}
+	for key, imagePath := range n.Images {
+		if !iofuncs.PathExists(imagePath) {
+			complete = complete && n.isImageFinished(imagePath)
+			delete(n.Images, key)
+		}
+	}
+	return complete
+}
+
+// Writes the novel in the formats set in the options, its metadata,
+// and caches the novel if none of its images failed to download.
+func exportNovel(n *Novel, dlOptions *PixivWebDlOptions) error {
+	complete := removeMissingNovelImages(n)
+	if err := os.MkdirAll(n.Dir, constants.DEFAULT_PERMS); err != nil {
+		return fmt.Errorf(
+			"pixiv web error %d: failed to create the folder %q for novel ID %s => %w",
+			cdlerrors.OS_ERROR,
+			n.Dir,
+			n.Id,
+			err,
+		)
+	}
+
+	for _, format := range dlOptions.NovelFormats {
+		filePath := getNovelOutputPath(n.Dir, n.Title, format, dlOptions)
+		var err error
+		switch format {
+		case novel.FORMAT_TEXT:
+			err = os.WriteFile(filePath, []byte(n.Text(n.Dir)), constants.DEFAULT_PERMS)
+		case novel.FORMAT_MARKDOWN:
+			err = os.WriteFile(filePath, []byte(n.Markdown(n.Dir)), constants.DEFAULT_PERMS)
+		case novel.FORMAT_EPUB:
+			err = novel.WriteEpubFile(filePath, novel.NewBook(n.Novel))
+		}
+		if err != nil {
+			return fmt.Errorf(
+				"pixiv web error %d: failed to export novel ID %s to %q => %w",
+				cdlerrors.OS_ERROR,
+				n.Id,
+				filePath,
+				err,
+			)
+		}
+	}
+
+	if dlOptions.Base.SetMetadata {
+		if err := metadata.WriteMetadataWithOptions(*n.Metadata, n.Dir, dlOptions.Base.MetadataOptions()); err != nil {
+			return err
+		}
+	}
+	if complete && n.CacheKey != "" {
+		dlOptions.Base.Session.GetDb().CachePost(n.CacheKey)
+	}
+	return nil
+}
+
+// Writes all the novels of the series as one EPUB in the series folder
+func exportNovelSeries(series *NovelSeries, dlOptions *PixivWebDlOptions) error {
+	if len(series.Novels) == 0 || !slices.Contains(dlOptions.NovelFormats, novel.FORMAT_EPUB) {
+		return nil
+	}
+	if series.CoverPath != "" && !iofuncs.PathExists(series.CoverPath) {
+		series.CoverPath = ""
+	}
+
+	book := &novel.Book{
+		Id:          series.Url,
+		Title:       series.Title,
+		Author:      series.Author,
+		Description: series.Description,
+		Date:        series.Date,
+		CoverPath:   series.CoverPath,
+		Novels:      make([]*novel.Novel, 0, len(series.Novels)),
+	}
+	for _, n := range series.Novels {
+		book.Novels = append(book.Novels, n.Novel)
+	}
+
+	filePath := getNovelOutputPath(series.Dir, series.Title, novel.FORMAT_EPUB, dlOptions)
+	if err := novel.WriteEpubFile(filePath, book); err != nil {
+		return fmt.Errorf(
+			"pixiv web error %d: failed to export novel series ID %s to %q => %w",
+			cdlerrors.OS_ERROR,
+			series.Id,
+			filePath,
+			err,
+		)
+	}
+	return nil
+}
+
+// ExportNovels writes the novels and the novel series in the formats set in the options.
+//
+// Should be called after downloading the files from GetNovelsToDownload as the novels
+// will only be cached if none of their images failed to download.
+//
+// Each novel is written to its own folder while each series is also
+// written as one EPUB in the series folder if the EPUB format is set.
+func ExportNovels(novels []*Novel, seriesSlice []*NovelSeries, dlOptions *PixivWebDlOptions) []error {
+	var errSlice []error
+	exportLen := len(novels) + len(seriesSlice)
+	if exportLen == 0 {
+		return nil
+	}
+
+	baseMsg := "Exporting novels from Pixiv [%d/" + fmt.Sprintf("%d]...", exportLen)
+	progress := dlOptions.Base.MainProgBar()
+	progress.UpdateBaseMsg(baseMsg)
+	progress.UpdateSuccessMsg(
+		fmt.Sprintf(
+			"Finished exporting %d novel(s) and novel series from Pixiv!",
+			exportLen,
+		),
+	)
+	progress.UpdateErrorMsg(
+		fmt.Sprintf(
+			"Something went wrong while exporting %d novel(s) and novel series from Pixiv!\nPlease refer to the logs for more details.",
+			exportLen,
+		),
+	)
+	progress.SetToProgressBar()
+	progress.UpdateMax(exportLen)
+	progress.Start()
+	defer progress.SnapshotTask()
+	for _, n := range novels {
+		if err := exportNovel(n, dlOptions); err != nil {
+			errSlice = append(errSlice, err)
+		}
+		progress.Increment()
+	}
+	for _, series := range seriesSlice {
+		for _, n := range series.Novels {
+			if err := exportNovel(n, dlOptions); err != nil {
+				errSlice = append(errSlice, err)
+			}
+		}
+		if err := exportNovelSeries(series, dlOptions); err != nil {
+			errSlice = append(errSlice, err)
+		}
+		progress.Increment()
+	}
+
+	hasErr := false
+	if len(errSlice) > 0 {
+		hasErr = true
+		dlOptions.Base.Session.GetLogger().LogErrors(logger.ERROR, errSlice...)
+	}
+	progress.Stop(hasErr)
+	return errSlice
+}
@@ -0,0 +1,46 @@
+package pixivweb
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/KJHJason/Cultured-Downloader-Logic/api/pixiv/novel"
+)
+
+func TestRemoveMissingNovelImages(t *testing.T) {
+	dirPath := t.TempDir()
+	downloaded := filepath.Join(dirPath, "downloaded.png")
+	if err := os.WriteFile(downloaded, []byte("image"), 0644); err != nil {
+		t.Fatalf("Failed to write test image: %v", err)
+	}
+	filtered := filepath.Join(dirPath, "filtered.png")
+	failed := filepath.Join(dirPath, "failed.png")
+
+	newTestNovel := func() *Novel {
+		n := &Novel{Novel: &novel.Novel{
+			CoverPath: downloaded,
+			Images:    map[string]string{"1": filtered, "2": downloaded},
+		}}
+		n.finishImage(downloaded)
+		n.finishImage(filtered) // excluded by the filters without an error
+		return n
+	}
+
+	n := newTestNovel()
+	if !removeMissingNovelImages(n) {
+		t.Error("Expected the novel to be complete when the missing images were filtered out")
+	}
+	if len(n.Images) != 1 || n.Images["2"] != downloaded || n.CoverPath != downloaded {
+		t.Errorf("Expected only the filtered image to be removed but got %v", n.Images)
+	}
+
+	n = newTestNovel()
+	n.Images["3"] = failed
+	if removeMissingNovelImages(n) {
+		t.Error("Expected the novel to be incomplete when an image failed to download")
+	}
+	if _, ok := n.Images["3"]; ok {
+		t.Error("Expected the failed image to be removed")
+	}
+}
@@ -12,6 +12,20 @@ const (
 	UGOIRA
 )
 
+// Returns the artwork type used by the filter expression, e.g. `type == ugoira`
+func getIllustTypeStr(illustType int) string {
+	switch illustType {
+	case ILLUST:
+		return "illust"
+	case MANGA:
+		return "manga"
+	case UGOIRA:
+		return "ugoira"
+	default:
+		return ""
+	}
+}
+
 // This is due to Pixiv's strict rate limiting.
 //
 // Without delays, the user might get 429 too many requests
@@ -2,13 +2,16 @@ package pixivweb
 
 import (
 	"net/http"
+	"slices"
+	"strings"
 
+	"github.com/KJHJason/Cultured-Downloader-Logic/api"
 	pixivcommon "github.com/KJHJason/Cultured-Downloader-Logic/api/pixiv/common"
 	"github.com/KJHJason/Cultured-Downloader-Logic/api/pixiv/ugoira"
 	"github.com/KJHJason/Cultured-Downloader-Logic/constants"
-	"github.com/KJHJason/Cultured-Downloader-Logic/database"
 	"github.com/KJHJason/Cultured-Downloader-Logic/filters"
 	"github.com/KJHJason/Cultured-Downloader-Logic/httpfuncs"
+	"github.com/KJHJason/Cultured-Downloader-Logic/iofuncs"
 	"github.com/KJHJason/Cultured-Downloader-Logic/utils"
 )
 
@@ -64,9 +67,42 @@ func processArtistPostsJson(resJson *IllustratorJson, pageNum string, pixivDlOpt
 	return artworkIds, nil
 }
 
+// Returns the novel IDs of the illustrator from the newest to the oldest
+func processArtistNovelsJson(resJson *IllustratorJson, pageNum string) ([]string, error) {
+	minPage, maxPage, hasMax, err := utils.GetMinMaxFromStr(pageNum)
+	if err != nil {
+		return nil, err
+	}
+
+	novels, ok := resJson.Body.Novels.(map[string]any)
+	if !ok { // where there are no novels or has an unknown type
+		return nil, nil
+	}
+	novelIds := make([]string, 0, len(novels))
+	for novelId := range novels {
+		novelIds = append(novelIds, novelId)
+	}
+	// the IDs are sorted numerically so that the page numbers are consistent
+	slices.SortFunc(novelIds, func(a, b string) int {
+		if len(a) != len(b) {
+			return len(b) - len(a)
+		}
+		return strings.Compare(b, a)
+	})
+
+	startIdx := (minPage - 1) * constants.PIXIV_PER_PAGE
+	if startIdx >= len(novelIds) {
+		return nil, nil
+	}
+	if endIdx := maxPage * constants.PIXIV_PER_PAGE; hasMax && endIdx < len(novelIds) {
+		novelIds = novelIds[:endIdx]
+	}
+	return novelIds[startIdx:], nil
+}
+
 // Process the artwork details JSON and returns a map of urls
 // with its file path or a Ugoira struct (One of them will be null depending on the artworkType)
-func processArtworkJson(ugoiraCacheKey, artworkCacheKey string, res *http.Response, artworkType int, postDownloadDir string) ([]*httpfuncs.ToDownload, *ugoira.Ugoira, error) {
+func processArtworkJson(ugoiraCacheKey, artworkCacheKey string, res *http.Response, artworkType int, postDownloadDir string, pathInfo *iofuncs.PathTemplateInfo, dlOptions *PixivWebDlOptions) ([]*httpfuncs.ToDownload, *ugoira.Ugoira, error) {
 	if artworkType == UGOIRA {
 		var ugoiraJson ArtworkUgoiraJson
 		if err := httpfuncs.LoadJsonFromResponse(res, &ugoiraJson); err != nil {
@@ -80,6 +116,7 @@ func processArtworkJson(ugoiraCacheKey, artworkCacheKey string, res *http.Respon
 			Url:      originalUrl,
 			FilePath: postDownloadDir,
 			Frames:   ugoira.MapDelaysToFilename(ugoiraMap.Frames),
+			Filters:  dlOptions.Base.GetFilters(pathInfo.CreatorId),
 		}
 		return nil, ugoiraInfo, nil
 	}
@@ -89,31 +126,81 @@ func processArtworkJson(ugoiraCacheKey, artworkCacheKey string, res *http.Respon
 		return nil, nil, err
 	}
 
+	postFilters := dlOptions.Base.GetFilters(pathInfo.CreatorId)
 	var urlsToDownload []*httpfuncs.ToDownload
-	for _, artworkUrl := range artworkUrls.Body {
+	for idx, artworkUrl := range artworkUrls.Body {
+		if !postFilters.IsImageDimensionValid(artworkUrl.Width, artworkUrl.Height) {
+			continue
+		}
+		originalUrl := artworkUrl.Urls.Original
 		urlsToDownload = append(urlsToDownload, &httpfuncs.ToDownload{
 			CacheKey: artworkCacheKey,
-			CacheFn:  database.CachePost,
-			Url:      artworkUrl.Urls.Original,
-			FilePath: postDownloadDir,
+			CacheFn:  dlOptions.Base.Session.GetDb().CachePost,
+			Url:      originalUrl,
+			FilePath: dlOptions.Base.GetFilePathFromUrl(postDownloadDir, "", originalUrl, idx+1, pathInfo),
 		})
 	}
+	if len(urlsToDownload) == 0 && artworkCacheKey != "" {
+		// all the pages were filtered out so there are no downloads to cache the artwork
+		// which would be fetched again on every run otherwise
+		dlOptions.Base.Session.GetDb().CachePost(artworkCacheKey)
+	}
 	return urlsToDownload, nil, nil
 }
 
 // Process the tag search results JSON and returns a slice of artwork IDs
-func processTagJsonResults(filters *filters.Filters, res *http.Response) ([]string, error) {
+func processTagJsonResults(base *api.BaseDl, pFilters *pixivcommon.PixivFilters, res *http.Response) ([]string, int, error) {
 	var pixivTagJson PixivTag
 	if err := httpfuncs.LoadJsonFromResponse(res, &pixivTagJson); err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
+	results := pixivTagJson.Body.IllustManga.Data
 	artworksSlice := []string{}
-	for _, illust := range pixivTagJson.Body.IllustManga.Data {
-		if !filters.IsPostDateValid(illust.CreateDate) {
+	for _, illust := range results {
+		postInfo := &filters.PostInfo{
+			Title: illust.Title,
+			Tags:  illust.Tags,
+			Fee:   filters.UNKNOWN_FEE,
+			Date:  illust.CreateDate,
+			Type:  getIllustTypeStr(illust.IllustType),
+		}
+		postFilters := base.GetFilters(illust.UserID)
+		if !postFilters.IsAdultContentValid(illust.XRestrict > 0) ||
+			!postFilters.IsPostDateValid(illust.CreateDate) ||
+			!postFilters.IsPostExprValid(postInfo) ||
+			!pFilters.IsTagsValid(illust.Tags) ||
+			!pFilters.IsPageCountValid(illust.PageCount) {
 			continue
 		}
 		artworksSlice = append(artworksSlice, illust.ID)
 	}
-	return artworksSlice, nil
+	return artworksSlice, len(results), nil
+}
+
+// Returns the IDs of the artworks that passes the filters
+//
+// If the watermark is not nil, artworks from the previous sync are skipped.
+func filterIllustThumbnails(base *api.BaseDl, illusts []*IllustThumbnail, watermark *api.SyncWatermark) []string {
+	var artworkIds []string
+	for _, illust := range illusts {
+		if illust.IsMasked || watermark.IsSeen(illust.ID.String(), illust.CreateDate) {
+			continue
+		}
+		postInfo := &filters.PostInfo{
+			Title: illust.Title,
+			Tags:  illust.Tags,
+			Fee:   filters.UNKNOWN_FEE,
+			Date:  illust.CreateDate,
+			Type:  getIllustTypeStr(illust.IllustType),
+		}
+		postFilters := base.GetFilters(illust.UserID)
+		if !postFilters.IsAdultContentValid(illust.XRestrict > 0) ||
+			!postFilters.IsPostDateValid(illust.CreateDate) ||
+			!postFilters.IsPostExprValid(postInfo) {
+			continue
+		}
+		artworkIds = append(artworkIds, illust.ID.String())
+	}
+	return artworkIds
 }
@@ -0,0 +1,50 @@
+package pixivweb
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/KJHJason/Cultured-Downloader-Logic/api"
+	"github.com/KJHJason/Cultured-Downloader-Logic/constants"
+	"github.com/KJHJason/Cultured-Downloader-Logic/database"
+	"github.com/KJHJason/Cultured-Downloader-Logic/filters"
+	"github.com/KJHJason/Cultured-Downloader-Logic/iofuncs"
+)
+
+func TestFilteredArtworkCached(t *testing.T) {
+	db, err := database.NewDb(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create db: %v", err)
+	}
+	defer db.Close()
+
+	dlOptions := &PixivWebDlOptions{Base: &api.BaseDl{
+		UseCacheDb: true,
+		Filters:    &filters.Filters{ImageDimensionFilters: filters.ImageDimensionFilters{MinWidth: 1000}},
+		Session:    api.NewSession(db, nil),
+	}}
+	reqUrl, _ := url.Parse("https://www.pixiv.net/ajax/illust/1/pages")
+	res := &http.Response{
+		Request: &http.Request{URL: reqUrl},
+		Body: io.NopCloser(strings.NewReader(
+			`{"body":[{"urls":{"original":"https://i.pximg.net/img-original/img/1_p0.png"},"width":600,"height":600}]}`,
+		)),
+	}
+
+	artworkUrl := "https://www.pixiv.net/artworks/1"
+	cacheKey := database.ParsePostKey(artworkUrl, constants.PIXIV)
+	toDownload, _, err := processArtworkJson("", cacheKey, res, ILLUST, t.TempDir(), &iofuncs.PathTemplateInfo{PostId: "1"}, dlOptions)
+	if err != nil {
+		t.Fatalf("Failed to process the artwork: %v", err)
+	}
+	if len(toDownload) != 0 {
+		t.Errorf("Expected the page to be filtered out but got %d downloads", len(toDownload))
+	}
+	if !db.PostCacheExists(artworkUrl, constants.PIXIV) {
+		t.Error("Expected the artwork to be cached when the filters removed all of its pages")
+	}
+}
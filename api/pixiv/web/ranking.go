@@ -0,0 +1,186 @@
+package pixivweb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	pixivcommon "github.com/KJHJason/Cultured-Downloader-Logic/api/pixiv/common"
+	"github.com/KJHJason/Cultured-Downloader-Logic/cdlerrors"
+	"github.com/KJHJason/Cultured-Downloader-Logic/constants"
+	"github.com/KJHJason/Cultured-Downloader-Logic/filters"
+	"github.com/KJHJason/Cultured-Downloader-Logic/httpfuncs"
+	"github.com/KJHJason/Cultured-Downloader-Logic/logger"
+	"github.com/KJHJason/Cultured-Downloader-Logic/metadata"
+	"github.com/KJHJason/Cultured-Downloader-Logic/utils"
+)
+
+// Converts the date of the ranking from YYYYMMDD to YYYY-MM-DD
+func formatRankingDate(date string) string {
+	parsedDate, err := time.Parse("20060102", date)
+	if err != nil {
+		return ""
+	}
+	return parsedDate.Format(pixivcommon.RANKING_DATE_LAYOUT)
+}
+
+// Query Pixiv's API for the artworks in the ranking and returns a slice of artwork IDs
+func getRanking(ranking *pixivcommon.Ranking, dlOptions *PixivWebDlOptions) ([]string, error) {
+	minPage, maxPage, hasMax, err := utils.GetMinMaxFromStr(ranking.PageNum)
+	if err != nil {
+		return nil, err
+	}
+
+	// The ranking API does not return the rating of the artworks
+	// but the R-18 rankings only contain R-18 or R-18G artworks.
+	isR18 := ranking.IsR18()
+	if !dlOptions.pFilters.IsRatingValid(isR18) || !dlOptions.Base.Filters.IsAdultContentValid(isR18) {
+		return nil, nil
+	}
+
+	params := map[string]string{
+		"mode":   ranking.Mode,
+		"format": "json",
+	}
+	if ranking.Content != pixivcommon.RANKING_CONTENT_ALL {
+		params["content"] = ranking.Content
+	}
+	if ranking.Date != "" {
+		params["date"] = ranking.WebDate()
+	}
+
+	desc := ranking.Description()
+	headers := pixivcommon.GetPixivRequestHeaders()
+	headers["Referer"] = getRankingApi()
+	useHttp3 := httpfuncs.IsHttp3Supported(constants.PIXIV, true)
+	reqArgs := &httpfuncs.RequestArgs{
+		Url:            getRankingApi(),
+		Method:         "GET",
+		Cookies:        dlOptions.Base.SessionCookies,
+		Headers:        headers,
+		Params:         params,
+		CheckStatus:    true,
+		UserAgent:      dlOptions.Base.Configs.UserAgent,
+		Http2:          !useHttp3,
+		Http3:          useHttp3,
+		Context:        dlOptions.GetContext(),
+		CaptchaHandler: dlOptions.GetCaptchaHandler(),
+		Transports:     dlOptions.Base.Session.GetTransports(),
+		Logger:         dlOptions.Base.Session.GetLogger(),
+	}
+
+	var artworkIds []string
+	for page := minPage; !hasMax || page <= maxPage; page++ {
+		params["p"] = strconv.Itoa(page)
+		res, err := httpfuncs.CallRequest(reqArgs)
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				return nil, err
+			}
+			return nil, fmt.Errorf(
+				"pixiv web error %d: failed to get page %d of the %s due to %w",
+				cdlerrors.CONNECTION_ERROR,
+				page,
+				desc,
+				err,
+			)
+		}
+
+		var resJson RankingJson
+		if err := httpfuncs.LoadJsonFromResponse(res.Resp, &resJson); err != nil {
+			return nil, err
+		}
+
+		rankingDate := formatRankingDate(resJson.Date)
+		for _, content := range resJson.Contents {
+			illustType, _ := strconv.Atoi(content.IllustType)
+			uploadDate := time.Unix(content.UploadedAt, 0)
+			postInfo := &filters.PostInfo{
+				Title: content.Title,
+				Tags:  content.Tags,
+				Fee:   filters.UNKNOWN_FEE,
+				Date:  uploadDate,
+				Type:  getIllustTypeStr(illustType),
+			}
+			postFilters := dlOptions.Base.GetFilters(strconv.Itoa(content.UserID))
+			if !dlOptions.pFilters.IsArtworkTypeValid(postInfo.Type) ||
+				!postFilters.IsPostDateValid(uploadDate) ||
+				!postFilters.IsPostExprValid(postInfo) {
+				continue
+			}
+
+			artworkId := strconv.Itoa(content.IllustID)
+			dlOptions.addRanking(artworkId, metadata.PixivRanking{
+				Mode:    ranking.Mode,
+				Content: ranking.Content,
+				Date:    rankingDate,
+				Rank:    content.Rank,
+			})
+			artworkIds = append(artworkIds, artworkId)
+		}
+
+		// next is false on the last page
+		if next, ok := resJson.Next.(float64); !ok || next == 0 {
+			break
+		}
+		pixivSleep()
+	}
+	return artworkIds, nil
+}
+
+// Get the artworks from multiple rankings and returns a slice of artwork IDs
+// to be used with GetMultipleArtworkDetails where the rank positions will be saved in the metadata.
+func GetMultipleRankings(rankings []*pixivcommon.Ranking, dlOptions *PixivWebDlOptions) ([]string, []error) {
+	var errSlice []error
+	var artworkIdsSlice []string
+	rankingsLen := len(rankings)
+	lastIdx := rankingsLen - 1
+
+	baseMsg := "Getting artworks from rankings on Pixiv [%d/" + fmt.Sprintf("%d]...", rankingsLen)
+	progress := dlOptions.Base.MainProgBar()
+	progress.UpdateBaseMsg(baseMsg)
+	progress.UpdateSuccessMsg(
+		fmt.Sprintf(
+			"Finished getting artworks from %d ranking(s) on Pixiv!",
+			rankingsLen,
+		),
+	)
+	progress.UpdateErrorMsg(
+		fmt.Sprintf(
+			"Something went wrong while getting artworks from %d ranking(s) on Pixiv!\nPlease refer to the logs for more details.",
+			rankingsLen,
+		),
+	)
+	progress.SetToProgressBar()
+	progress.UpdateMax(rankingsLen)
+	progress.Start()
+	defer progress.SnapshotTask()
+	for idx, ranking := range rankings {
+		artworkIds, err := getRanking(ranking, dlOptions)
+		if err != nil {
+			errSlice = append(errSlice, err)
+		} else {
+			artworkIdsSlice = append(artworkIdsSlice, artworkIds...)
+		}
+
+		if idx != lastIdx {
+			pixivSleep()
+		}
+		progress.Increment()
+	}
+
+	hasErr := false
+	if len(errSlice) > 0 {
+		hasErr = true
+		if hasCancelled := dlOptions.Base.Session.GetLogger().LogErrors(logger.ERROR, errSlice...); hasCancelled {
+			dlOptions.CancelCtx()
+			progress.StopInterrupt("Stopped getting artworks from rankings on Pixiv!")
+			return nil, errSlice
+		}
+	}
+	progress.Stop(hasErr)
+
+	return artworkIdsSlice, errSlice
+}
@@ -2,6 +2,7 @@ package pixivweb
 
 import (
 	"fmt"
+	"net/url"
 
 	"github.com/KJHJason/Cultured-Downloader-Logic/cdlerrors"
 	"github.com/KJHJason/Cultured-Downloader-Logic/constants"
@@ -32,5 +33,38 @@ func getArtistArtworksApi(artistId string) string {
 }
 
 func getTagArtworksApi(tag string) string {
-	return fmt.Sprintf("%s/search/artworks/%s", constants.PIXIV_API_URL, tag)
+	// the search word may contain spaces and parentheses from the OR groups
+	return fmt.Sprintf("%s/search/artworks/%s", constants.PIXIV_API_URL, url.PathEscape(tag))
+}
+
+func getBookmarksApi(userId string) string {
+	return fmt.Sprintf("%s/user/%s/illusts/bookmarks", constants.PIXIV_API_URL, userId)
+}
+
+func getFollowFeedApi() string {
+	return constants.PIXIV_API_URL + "/follow_latest/illust"
+}
+
+func getNovelDetailsApi(novelId string) string {
+	return fmt.Sprintf("%s/novel/%s", constants.PIXIV_API_URL, novelId)
+}
+
+func getNovelInsertIllustsApi(novelId string) string {
+	return fmt.Sprintf("%s/novel/%s/insert_illusts", constants.PIXIV_API_URL, novelId)
+}
+
+func getNovelSeriesApi(seriesId string) string {
+	return fmt.Sprintf("%s/novel/series/%s", constants.PIXIV_API_URL, seriesId)
+}
+
+func getNovelSeriesContentApi(seriesId string) string {
+	return fmt.Sprintf("%s/novel/series_content/%s", constants.PIXIV_API_URL, seriesId)
+}
+
+func getMangaSeriesApi(seriesId string) string {
+	return fmt.Sprintf("%s/series/%s", constants.PIXIV_API_URL, seriesId)
+}
+
+func getRankingApi() string {
+	return constants.PIXIV_URL + "/ranking.php"
 }
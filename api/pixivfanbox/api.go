@@ -7,9 +7,11 @@ import (
 	"net/http"
 	"sync"
 
+	"github.com/KJHJason/Cultured-Downloader-Logic/api"
 	"github.com/KJHJason/Cultured-Downloader-Logic/cdlerrors"
 	"github.com/KJHJason/Cultured-Downloader-Logic/constants"
 	"github.com/KJHJason/Cultured-Downloader-Logic/database"
+	"github.com/KJHJason/Cultured-Downloader-Logic/filters"
 	"github.com/KJHJason/Cultured-Downloader-Logic/httpfuncs"
 	"github.com/KJHJason/Cultured-Downloader-Logic/logger"
 	"github.com/KJHJason/Cultured-Downloader-Logic/utils"
@@ -24,6 +26,11 @@ func GetPixivFanboxHeaders() map[string]string {
 	}
 }
 
+// Returns the API URL of the post details which is also used as the cache key of the post
+func getPostInfoUrl(postId string) string {
+	return fmt.Sprintf("%s/post.info?postId=%s", constants.PIXIV_FANBOX_API_URL, postId)
+}
+
 func getPostDetails(cacheKey, postId, url string, dlOptions *PixivFanboxDlOptions, useHttp3 bool) (*http.Response, string, error) {
 	header := GetPixivFanboxHeaders()
 	params := map[string]string{"postId": postId}
@@ -39,6 +46,8 @@ func getPostDetails(cacheKey, postId, url string, dlOptions *PixivFanboxDlOption
 			Http3:          useHttp3,
 			Context:        dlOptions.ctx,
 			CaptchaHandler: dlOptions.GetCaptchaHandler(),
+			Transports:     dlOptions.Base.Session.GetTransports(),
+			Logger:         dlOptions.Base.Session.GetLogger(),
 		},
 	)
 	if err != nil {
@@ -64,7 +73,7 @@ func getPostDetails(cacheKey, postId, url string, dlOptions *PixivFanboxDlOption
 
 	if dlOptions.Base.UseCacheDb {
 		cacheKey = database.ParsePostKey(cacheKey, constants.PIXIV_FANBOX)
-		database.CachePostViaBatch(cacheKey)
+		dlOptions.Base.Session.GetDb().CachePostViaBatch(cacheKey)
 	}
 	return res.Resp, cacheKey, nil
 }
@@ -112,8 +121,8 @@ func (pf *PixivFanboxDl) GetPostDetails(dlOptions *PixivFanboxDlOptions) ([]*htt
 	for _, postId := range pf.PostIds {
 		var cacheKey string
 		if dlOptions.Base.UseCacheDb {
-			fullUrl := fmt.Sprintf("%s?postId=%s", url, postId)
-			if database.PostCacheExists(fullUrl, constants.PIXIV_FANBOX) {
+			fullUrl := getPostInfoUrl(postId)
+			if dlOptions.Base.Session.GetDb().PostCacheExists(fullUrl, constants.PIXIV_FANBOX) {
 				progress.Increment()
 				continue
 			}
@@ -142,7 +151,7 @@ func (pf *PixivFanboxDl) GetPostDetails(dlOptions *PixivFanboxDlOptions) ([]*htt
 				if dlOptions.Base.UseCacheDb && parsedCacheKey != "" {
 					for _, url := range postUrls {
 						url.CacheKey = parsedCacheKey
-						url.CacheFn = database.CachePost
+						url.CacheFn = dlOptions.Base.Session.GetDb().CachePost
 					}
 				}
 				urlsTsSlice.Append(&urlsChanVal{
@@ -160,7 +169,7 @@ func (pf *PixivFanboxDl) GetPostDetails(dlOptions *PixivFanboxDlOptions) ([]*htt
 	var errSlice []error
 	if hasErr {
 		var errCtxCancelled bool
-		if errCtxCancelled, errSlice = logger.LogSliceErrors(logger.ERROR, errTsSlice); errCtxCancelled {
+		if errCtxCancelled, errSlice = dlOptions.Base.Session.GetLogger().LogSliceErrors(logger.ERROR, errTsSlice); errCtxCancelled {
 			hasCancelled = true
 		}
 	}
@@ -202,6 +211,8 @@ func getCreatorPaginatedPosts(creatorId string, dlOptions *PixivFanboxDlOptions)
 			Http3:          useHttp3,
 			Context:        dlOptions.GetContext(),
 			CaptchaHandler: dlOptions.GetCaptchaHandler(),
+			Transports:     dlOptions.Base.Session.GetTransports(),
+			Logger:         dlOptions.Base.Session.GetLogger(),
 		},
 	)
 	if err != nil || res.Resp.StatusCode != 200 {
@@ -253,6 +264,8 @@ func getFanboxPostsLogic(reqUrl string, headers map[string]string, dlOptions *Pi
 			Http3:          useHttp3,
 			Context:        dlOptions.GetContext(),
 			CaptchaHandler: dlOptions.GetCaptchaHandler(),
+			Transports:     dlOptions.Base.Session.GetTransports(),
+			Logger:         dlOptions.Base.Session.GetLogger(),
 		},
 	)
 	if err != nil || res.Resp.StatusCode != 200 {
@@ -260,7 +273,7 @@ func getFanboxPostsLogic(reqUrl string, headers map[string]string, dlOptions *Pi
 			res.Close()
 		}
 		if !errors.Is(err, context.Canceled) {
-			logger.LogError(
+			dlOptions.Base.Session.GetLogger().LogError(
 				fmt.Errorf(
 					"failed to get post for %s\n%w",
 					reqUrl,
@@ -301,9 +314,36 @@ func getFanboxPosts(creatorId, pageNum string, dlOptions *PixivFanboxDlOptions)
 	if len(paginatedUrls) < maxConcurrency {
 		maxConcurrency = len(paginatedUrls)
 	}
+
+	// For incremental syncs, get the pages one by one
+	// from the newest and stop at the first page with seen posts.
+	watermark := dlOptions.Base.NewSyncWatermark(creatorId)
+	if watermark != nil {
+		maxConcurrency = 1
+	}
 	queue := make(chan struct{}, maxConcurrency)
 	resTsSlice := threadsafe.NewSliceWithCapacity[*resStruct](len(paginatedUrls))
 	for idx, paginatedUrl := range paginatedUrls {
+		if watermark != nil {
+			curPage := idx + 1
+			if curPage < minPage {
+				continue
+			}
+			if hasMax && curPage > maxPage {
+				break
+			}
+
+			res := getFanboxPostsLogic(paginatedUrl, headers, dlOptions, useHttp3)
+			if res == nil {
+				continue
+			}
+			resTsSlice.Append(res)
+			if res.err != nil || hasSeenFanboxPost(res.json, watermark) {
+				break
+			}
+			continue
+		}
+
 		curPage := idx + 1
 		if curPage < minPage {
 			continue
@@ -328,6 +368,7 @@ func getFanboxPosts(creatorId, pageNum string, dlOptions *PixivFanboxDlOptions)
 	close(queue)
 
 	// parse the JSON response
+	postFilters := dlOptions.Base.GetFilters(creatorId)
 	resIter := resTsSlice.NewIter()
 	for resIter.Next() {
 		res := resIter.Item()
@@ -337,7 +378,23 @@ func getFanboxPosts(creatorId, pageNum string, dlOptions *PixivFanboxDlOptions)
 		}
 
 		for _, postInfoMap := range res.json.Body {
-			if dlOptions.Base.Filters.IsPostDateValid(postInfoMap.PublishedDatetime) {
+			if watermark.IsSeen(postInfoMap.ID, postInfoMap.PublishedDatetime) {
+				continue
+			}
+			postInfo := &filters.PostInfo{
+				Title: postInfoMap.Title,
+				Tags:  postInfoMap.Tags,
+				Fee:   postInfoMap.FeeRequired,
+				Date:  postInfoMap.PublishedDatetime,
+			}
+			if isFanboxPostValid(
+				postFilters,
+				postInfoMap.IsRestricted,
+				postInfoMap.FeeRequired,
+				postInfoMap.HasAdultContent,
+			) &&
+				postFilters.IsPostDateValid(postInfoMap.PublishedDatetime) &&
+				postFilters.IsPostExprValid(postInfo) {
 				postIds = append(postIds, postInfoMap.ID)
 			}
 		}
@@ -345,14 +402,26 @@ func getFanboxPosts(creatorId, pageNum string, dlOptions *PixivFanboxDlOptions)
 
 	hasCancelled = false
 	if len(errSlice) > 0 {
-		hasCancelled = logger.LogErrors(logger.ERROR, errSlice...)
+		hasCancelled = dlOptions.Base.Session.GetLogger().LogErrors(logger.ERROR, errSlice...)
 		if hasCancelled {
 			dlOptions.CancelCtx()
 		}
+	} else {
+		watermark.Done()
 	}
 	return postIds, errSlice, hasCancelled
 }
 
+// Returns true if the page contains a post from the previous sync
+func hasSeenFanboxPost(resJson *FanboxCreatorPostsJson, watermark *api.SyncWatermark) bool {
+	for _, post := range resJson.Body {
+		if watermark.IsSeen(post.ID, post.PublishedDatetime) {
+			return true
+		}
+	}
+	return false
+}
+
 // Retrieves all the posts based on the slice of creator IDs and updates its slice of post IDs accordingly
 func (pf *PixivFanboxDl) GetCreatorsPosts(dlOptions *PixivFanboxDlOptions) []error {
 	creatorIdsLen := len(pf.CreatorIds)
@@ -417,7 +486,7 @@ func (pf *PixivFanboxDl) GetCreatorsPosts(dlOptions *PixivFanboxDlOptions) []err
 	hasErr := false
 	if len(errSlice) > 0 {
 		hasErr = true
-		logger.LogErrors(logger.ERROR, errSlice...)
+		dlOptions.Base.Session.GetLogger().LogErrors(logger.ERROR, errSlice...)
 	}
 	progress.Stop(hasErr)
 	pf.PostIds = utils.RemoveDuplicatesFromSlice(pf.PostIds)
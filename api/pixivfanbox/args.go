@@ -74,7 +74,7 @@ type PixivFanboxDlOptions struct {
 }
 
 func (pf *PixivFanboxDlOptions) GetCaptchaHandler() httpfuncs.CaptchaHandler {
-	return NewHttpCaptchaHandler(pf.ctx, pf.Base.Configs.UserAgent, pf.Base.Notifier)
+	return NewHttpCaptchaHandler(pf.ctx, pf.Base.Configs.UserAgent, pf.Base.Notifier, pf.Base.Session.GetCfCache(), pf.Base.Session.GetLogger())
 }
 
 func (pf *PixivFanboxDlOptions) GetContext() context.Context {
@@ -140,6 +140,13 @@ func (pf *PixivFanboxDlOptions) ValidateArgs(userAgent string) error {
 		pf.Base.DownloadDirPath = dlDirPath
 	}
 
+	if err := pf.Base.ValidatePathTemplates(constants.PIXIV_FANBOX); err != nil {
+		return err
+	}
+	if err := pf.Base.ValidateFilterProfiles(); err != nil {
+		return err
+	}
+
 	if pf.Base.SessionCookieId != "" {
 		pf.Base.SessionCookies = []*http.Cookie{
 			api.GetCookie(pf.Base.SessionCookieId, constants.PIXIV_FANBOX),
@@ -164,5 +171,6 @@ func (pf *PixivFanboxDlOptions) ValidateArgs(userAgent string) error {
 	} else if !pf.Base.DlGdrive && pf.Base.GdriveClient != nil {
 		pf.Base.GdriveClient = nil
 	}
+	pf.Base.SetGdriveSession()
 	return nil
 }
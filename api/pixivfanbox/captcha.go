@@ -7,6 +7,7 @@ import (
 	"github.com/KJHJason/Cultured-Downloader-Logic/api/cdlsolvers/cf"
 	"github.com/KJHJason/Cultured-Downloader-Logic/constants"
 	"github.com/KJHJason/Cultured-Downloader-Logic/httpfuncs"
+	"github.com/KJHJason/Cultured-Downloader-Logic/logger"
 	"github.com/KJHJason/Cultured-Downloader-Logic/notify"
 )
 
@@ -14,11 +15,14 @@ var (
 	CaptchaChecker = cf.CaptchaChecker
 )
 
-func NewHttpCaptchaHandler(ctx context.Context, userAgent string, notifier notify.Notifier) httpfuncs.CaptchaHandler {
+// The cache and logger can be nil to use cf.DefaultCache and logger.MainLogger.
+func NewHttpCaptchaHandler(ctx context.Context, userAgent string, notifier notify.Notifier, cache *cf.Cache, l *logger.Logger) httpfuncs.CaptchaHandler {
 	handler := CaptchaHandler{
 		ctx:       ctx,
 		notifier:  notifier,
 		userAgent: userAgent,
+		cache:     cache,
+		logger:    l,
 	}
 	return httpfuncs.CaptchaHandler{
 		Check:         CaptchaChecker,
@@ -32,6 +36,8 @@ type CaptchaHandler struct {
 	ctx       context.Context
 	notifier  notify.Notifier
 	userAgent string
+	cache     *cf.Cache
+	logger    *logger.Logger
 }
 
 func (ch CaptchaHandler) getCacheArgs() cf.CacheArgs {
@@ -41,6 +47,8 @@ func (ch CaptchaHandler) getCacheArgs() cf.CacheArgs {
 		UserAgent: ch.userAgent,
 		Timeout:   constants.CF_BOT_COOKIE_TIMEOUT,
 		Notifier:  ch.notifier,
+		Cache:     ch.cache,
+		Logger:    ch.logger,
 	}
 }
 
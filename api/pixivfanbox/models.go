@@ -57,7 +57,7 @@ type FanboxPostJson struct {
 		FeeRequired       int       `json:"feeRequired"`
 		PublishedDatetime time.Time `json:"publishedDatetime"` // "2023-03-15T14:08:23+09:00",
 		UpdatedDatetime   time.Time `json:"updatedDatetime"`   // "2023-03-15T14:08:23+09:00",
-		Tags              []any     `json:"tags"`
+		Tags              []string  `json:"tags"`
 		IsLiked           bool      `json:"isLiked"`
 		LikeCount         int       `json:"likeCount"`
 		CommentCount      int       `json:"commentCount"`
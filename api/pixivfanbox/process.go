@@ -7,10 +7,12 @@ import (
 	"io/fs"
 	"net/http"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/KJHJason/Cultured-Downloader-Logic/cdlerrors"
 	"github.com/KJHJason/Cultured-Downloader-Logic/constants"
+	"github.com/KJHJason/Cultured-Downloader-Logic/filters"
 	"github.com/KJHJason/Cultured-Downloader-Logic/gdrive"
 	"github.com/KJHJason/Cultured-Downloader-Logic/httpfuncs"
 	"github.com/KJHJason/Cultured-Downloader-Logic/iofuncs"
@@ -96,7 +98,7 @@ func detectUrlsAndLogPasswordsInPost(blocks FanboxArticleBlocks, postFolderPath
 				filePath,
 				err,
 			)
-			logger.LogError(err, logger.ERROR)
+			dlOptions.Base.Session.GetLogger().LogError(err, logger.ERROR)
 			return gdriveLinks
 		}
 
@@ -112,7 +114,37 @@ func detectUrlsAndLogPasswordsInPost(blocks FanboxArticleBlocks, postFolderPath
 	return gdriveLinks
 }
 
-func processFanboxArticlePost(resUrl string, postBody json.RawMessage, postFolderPath string, dlOptions *PixivFanboxDlOptions) ([]*httpfuncs.ToDownload, []*httpfuncs.ToDownload, error) {
+// Returns the 1-based position of each image and file ID in the article blocks
+// so that the index used in file name templates follows the order shown in the post.
+//
+// IDs that are not found in the blocks will be placed after the rest.
+func getArticleBlockIdx(blocks FanboxArticleBlocks, mapIds []string) map[string]int {
+	idxMap := make(map[string]int, len(mapIds))
+	for _, block := range blocks {
+		var id string
+		switch block.Type {
+		case "image":
+			id = block.ImageID
+		case "file":
+			id = block.FileID
+		default:
+			continue
+		}
+		if _, ok := idxMap[id]; !ok {
+			idxMap[id] = len(idxMap) + 1
+		}
+	}
+
+	slices.Sort(mapIds)
+	for _, id := range mapIds {
+		if _, ok := idxMap[id]; !ok {
+			idxMap[id] = len(idxMap) + 1
+		}
+	}
+	return idxMap
+}
+
+func processFanboxArticlePost(resUrl string, postBody json.RawMessage, postFolderPath string, pathInfo *iofuncs.PathTemplateInfo, dlOptions *PixivFanboxDlOptions) ([]*httpfuncs.ToDownload, []*httpfuncs.ToDownload, error) {
 	var articleJson FanboxArticleJson
 	if err := httpfuncs.LoadJsonFromBytes(resUrl, postBody, &articleJson); err != nil {
 		return nil, nil, err
@@ -121,23 +153,46 @@ func processFanboxArticlePost(resUrl string, postBody json.RawMessage, postFolde
 	var urlsSlice, gdriveLinks []*httpfuncs.ToDownload
 	// retrieve images and attachments url(s)
 	imageMap := articleJson.ImageMap
+	attachmentMap := articleJson.FileMap
+	mapIds := make([]string, 0, len(imageMap)+len(attachmentMap))
+	for id := range imageMap {
+		mapIds = append(mapIds, id)
+	}
+	for id := range attachmentMap {
+		mapIds = append(mapIds, id)
+	}
+	blockIdx := getArticleBlockIdx(articleJson.Blocks, mapIds)
 	if imageMap != nil && dlOptions.Base.DlImages {
-		for _, imageInfo := range imageMap {
+		for imageId, imageInfo := range imageMap {
+			if !dlOptions.Base.GetFilters(pathInfo.CreatorId).IsImageDimensionValid(imageInfo.Width, imageInfo.Height) {
+				continue
+			}
 			urlsSlice = append(urlsSlice, &httpfuncs.ToDownload{
-				Url:      imageInfo.OriginalUrl,
-				FilePath: filepath.Join(postFolderPath, constants.IMAGES_FOLDER),
+				Url: imageInfo.OriginalUrl,
+				FilePath: dlOptions.Base.GetFilePathFromUrl(
+					postFolderPath,
+					constants.IMAGES_FOLDER,
+					imageInfo.OriginalUrl,
+					blockIdx[imageId],
+					pathInfo,
+				),
 			})
 		}
 	}
 
-	attachmentMap := articleJson.FileMap
 	if attachmentMap != nil && dlOptions.Base.DlAttachments {
-		for _, attachmentInfo := range attachmentMap {
+		for fileId, attachmentInfo := range attachmentMap {
 			attachmentUrl := attachmentInfo.Url
 			filename := attachmentInfo.Name + "." + attachmentInfo.Extension
 			urlsSlice = append(urlsSlice, &httpfuncs.ToDownload{
-				Url:      attachmentUrl,
-				FilePath: filepath.Join(postFolderPath, constants.ATTACHMENT_FOLDER, filename),
+				Url: attachmentUrl,
+				FilePath: dlOptions.Base.GetFilePath(
+					postFolderPath,
+					constants.ATTACHMENT_FOLDER,
+					filename,
+					blockIdx[fileId],
+					pathInfo,
+				),
 			})
 		}
 	}
@@ -155,7 +210,7 @@ func processFanboxArticlePost(resUrl string, postBody json.RawMessage, postFolde
 	return urlsSlice, gdriveLinks, nil
 }
 
-func processFanboxFilePost(resUrl string, postBody json.RawMessage, postFolderPath string, dlOptions *PixivFanboxDlOptions) ([]*httpfuncs.ToDownload, []*httpfuncs.ToDownload, error) {
+func processFanboxFilePost(resUrl string, postBody json.RawMessage, postFolderPath string, pathInfo *iofuncs.PathTemplateInfo, dlOptions *PixivFanboxDlOptions) ([]*httpfuncs.ToDownload, []*httpfuncs.ToDownload, error) {
 	var filePostJson FanboxFilePostJson
 	if err := httpfuncs.LoadJsonFromBytes(resUrl, postBody, &filePostJson); err != nil {
 		return nil, nil, err
@@ -178,6 +233,7 @@ func processFanboxFilePost(resUrl string, postBody json.RawMessage, postFolderPa
 		return nil, nil, nil
 	}
 
+	imageIdx, attachmentIdx := 0, 0
 	for _, fileInfo := range imageAndAttachmentUrls {
 		fileUrl := fileInfo.Url
 		extension := fileInfo.Extension
@@ -186,9 +242,15 @@ func processFanboxFilePost(resUrl string, postBody json.RawMessage, postFolderPa
 		var filePath string
 		isImage := utils.SliceContains(pixivFanboxAllowedImageExt, extension)
 		if isImage {
-			filePath = filepath.Join(postFolderPath, constants.IMAGES_FOLDER, filename)
+			imageIdx++
+			filePath = dlOptions.Base.GetFilePath(
+				postFolderPath, constants.IMAGES_FOLDER, filename, imageIdx, pathInfo,
+			)
 		} else {
-			filePath = filepath.Join(postFolderPath, constants.ATTACHMENT_FOLDER, filename)
+			attachmentIdx++
+			filePath = dlOptions.Base.GetFilePath(
+				postFolderPath, constants.ATTACHMENT_FOLDER, filename, attachmentIdx, pathInfo,
+			)
 		}
 
 		if (isImage && dlOptions.Base.DlImages) || (!isImage && dlOptions.Base.DlAttachments) {
@@ -201,7 +263,7 @@ func processFanboxFilePost(resUrl string, postBody json.RawMessage, postFolderPa
 	return urlsSlice, gdriveLinks, nil
 }
 
-func processFanboxImagePost(resUrl string, postBody json.RawMessage, postFolderPath string, dlOptions *PixivFanboxDlOptions) ([]*httpfuncs.ToDownload, []*httpfuncs.ToDownload, error) {
+func processFanboxImagePost(resUrl string, postBody json.RawMessage, postFolderPath string, pathInfo *iofuncs.PathTemplateInfo, dlOptions *PixivFanboxDlOptions) ([]*httpfuncs.ToDownload, []*httpfuncs.ToDownload, error) {
 	var imagePostJson FanboxImagePostJson
 	if err := httpfuncs.LoadJsonFromBytes(resUrl, postBody, &imagePostJson); err != nil {
 		return nil, nil, err
@@ -225,6 +287,7 @@ func processFanboxImagePost(resUrl string, postBody json.RawMessage, postFolderP
 		return nil, nil, nil
 	}
 
+	imageIdx, attachmentIdx := 0, 0
 	for _, fileInfo := range imageAndAttachmentUrls {
 		fileUrl := fileInfo.OriginalUrl
 		extension := fileInfo.Extension
@@ -233,11 +296,20 @@ func processFanboxImagePost(resUrl string, postBody json.RawMessage, postFolderP
 		var filePath string
 		isImage := utils.SliceContains(pixivFanboxAllowedImageExt, extension)
 		if isImage {
-			filePath = filepath.Join(postFolderPath, constants.IMAGES_FOLDER, filename)
+			imageIdx++
+			filePath = dlOptions.Base.GetFilePath(
+				postFolderPath, constants.IMAGES_FOLDER, filename, imageIdx, pathInfo,
+			)
 		} else {
-			filePath = filepath.Join(postFolderPath, constants.ATTACHMENT_FOLDER, filename)
+			attachmentIdx++
+			filePath = dlOptions.Base.GetFilePath(
+				postFolderPath, constants.ATTACHMENT_FOLDER, filename, attachmentIdx, pathInfo,
+			)
 		}
 
+		if isImage && !dlOptions.Base.GetFilters(pathInfo.CreatorId).IsImageDimensionValid(fileInfo.Width, fileInfo.Height) {
+			continue
+		}
 		if (isImage && dlOptions.Base.DlImages) || (!isImage && dlOptions.Base.DlAttachments) {
 			urlsSlice = append(urlsSlice, &httpfuncs.ToDownload{
 				Url:      fileUrl,
@@ -248,6 +320,45 @@ func processFanboxImagePost(resUrl string, postBody json.RawMessage, postFolderP
 	return urlsSlice, gdriveLinks, nil
 }
 
+// Checks the restriction, plan fee, and adult content filters of a post
+//
+// Restricted posts have no downloadable content as
+// the user has not subscribed to the required plan.
+func isFanboxPostValid(postFilters *filters.Filters, isRestricted bool, feeRequired int, hasAdultContent bool) bool {
+	return postFilters.IsRestrictedPostValid(isRestricted) &&
+		postFilters.IsPostFeeValid(feeRequired) &&
+		postFilters.IsAdultContentValid(hasAdultContent)
+}
+
+// Returns the text content of the post body for the metadata
+// or an empty string if the body is restricted or cannot be parsed.
+func getPostText(postType string, postBody json.RawMessage) string {
+	if postBody == nil {
+		return ""
+	}
+
+	if postType == "article" {
+		var articleJson FanboxArticleJson
+		if err := json.Unmarshal(postBody, &articleJson); err != nil {
+			return ""
+		}
+		texts := make([]string, 0, len(articleJson.Blocks))
+		for _, block := range articleJson.Blocks {
+			if block.Text != "" {
+				texts = append(texts, block.Text)
+			}
+		}
+		return strings.Join(texts, "\n")
+	}
+
+	// "file", "image" and "text" posts
+	var textPostJson FanboxTextPostJson
+	if err := json.Unmarshal(postBody, &textPostJson); err != nil {
+		return ""
+	}
+	return textPostJson.Text
+}
+
 // Process the JSON response from Pixiv Fanbox's API and
 // returns a map of urls and a map of GDrive urls to download from
 func processFanboxPostJson(res *http.Response, dlOptions *PixivFanboxDlOptions) ([]*httpfuncs.ToDownload, []*httpfuncs.ToDownload, error) {
@@ -257,18 +368,27 @@ func processFanboxPostJson(res *http.Response, dlOptions *PixivFanboxDlOptions)
 	}
 
 	postJson := post.Body
-	if !dlOptions.Base.Filters.IsPostDateValid(postJson.PublishedDatetime) {
+	filterInfo := &filters.PostInfo{
+		Title: postJson.Title,
+		Tags:  postJson.Tags,
+		Fee:   postJson.FeeRequired,
+		Date:  postJson.PublishedDatetime,
+		Type:  postJson.Type,
+	}
+	postFilters := dlOptions.Base.GetFilters(postJson.CreatorID)
+	if !isFanboxPostValid(postFilters, postJson.IsRestricted, postJson.FeeRequired, postJson.HasAdultContent) ||
+		!postFilters.IsPostDateValid(postJson.PublishedDatetime) ||
+		!postFilters.IsPostExprValid(filterInfo) {
 		return nil, nil, nil
 	}
-	postId := postJson.ID
-	postTitle := postJson.Title
-	creatorId := postJson.CreatorID
-	postFolderPath := iofuncs.GetPostFolder(
-		dlOptions.Base.DownloadDirPath,
-		creatorId,
-		postId,
-		postTitle,
-	)
+	pathInfo := &iofuncs.PathTemplateInfo{
+		CreatorName: postJson.User.Name,
+		CreatorId:   postJson.CreatorID,
+		PostId:      postJson.ID,
+		Title:       postJson.Title,
+		Date:        postJson.PublishedDatetime,
+	}
+	postFolderPath := dlOptions.Base.GetPostFolder(pathInfo)
 
 	if dlOptions.Base.SetMetadata {
 		postMetadata := metadata.PixivFanboxPost{
@@ -279,8 +399,12 @@ func processFanboxPostJson(res *http.Response, dlOptions *PixivFanboxDlOptions)
 			RestrictedFromUser: postJson.IsRestricted,
 			PostType:           postJson.Type,
 			PlanFee:            postJson.FeeRequired,
+			Creator:            postJson.User.Name,
+			CreatorId:          postJson.CreatorID,
+			Tags:               postJson.Tags,
+			Text:               getPostText(postJson.Type, postJson.Body),
 		}
-		if err := metadata.WriteMetadata(postMetadata, postFolderPath); err != nil {
+		if err := metadata.WriteMetadataWithOptions(postMetadata, postFolderPath, dlOptions.Base.MetadataOptions()); err != nil {
 			return nil, nil, err
 		}
 	}
@@ -300,6 +424,9 @@ func processFanboxPostJson(res *http.Response, dlOptions *PixivFanboxDlOptions)
 	postType := postJson.Type
 	postBody := postJson.Body
 	if postBody == nil {
+		httpfuncs.SetPostInfo(urlsSlice, filterInfo)
+		httpfuncs.SetFilters(urlsSlice, postFilters)
+		httpfuncs.SetSource(urlsSlice, dlOptions.Base.NewFileSource(pathInfo, getPostInfoUrl(postJson.ID)))
 		return urlsSlice, nil, nil
 	}
 
@@ -310,11 +437,11 @@ func processFanboxPostJson(res *http.Response, dlOptions *PixivFanboxDlOptions)
 	var gdriveLinks []*httpfuncs.ToDownload
 	switch postType {
 	case "file":
-		newUrlsSlice, gdriveLinks, err = processFanboxFilePost(resUrl, postBody, postFolderPath, dlOptions)
+		newUrlsSlice, gdriveLinks, err = processFanboxFilePost(resUrl, postBody, postFolderPath, pathInfo, dlOptions)
 	case "image":
-		newUrlsSlice, gdriveLinks, err = processFanboxImagePost(resUrl, postBody, postFolderPath, dlOptions)
+		newUrlsSlice, gdriveLinks, err = processFanboxImagePost(resUrl, postBody, postFolderPath, pathInfo, dlOptions)
 	case "article":
-		newUrlsSlice, gdriveLinks, err = processFanboxArticlePost(resUrl, postBody, postFolderPath, dlOptions)
+		newUrlsSlice, gdriveLinks, err = processFanboxArticlePost(resUrl, postBody, postFolderPath, pathInfo, dlOptions)
 	case "text": // text post
 		// Usually has no content but try to detect for any external download links
 		var textContent FanboxTextPostJson
@@ -340,5 +467,12 @@ func processFanboxPostJson(res *http.Response, dlOptions *PixivFanboxDlOptions)
 		return nil, nil, err
 	}
 	urlsSlice = append(urlsSlice, newUrlsSlice...)
+	httpfuncs.SetPostInfo(urlsSlice, filterInfo)
+	httpfuncs.SetPostInfo(gdriveLinks, filterInfo)
+	httpfuncs.SetFilters(urlsSlice, postFilters)
+	httpfuncs.SetFilters(gdriveLinks, postFilters)
+	source := dlOptions.Base.NewFileSource(pathInfo, getPostInfoUrl(postJson.ID))
+	httpfuncs.SetSource(urlsSlice, source)
+	httpfuncs.SetSource(gdriveLinks, source)
 	return urlsSlice, gdriveLinks, nil
 }
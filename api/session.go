@@ -0,0 +1,149 @@
+package api
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/KJHJason/Cultured-Downloader-Logic/api/cdlsolvers/cf"
+	"github.com/KJHJason/Cultured-Downloader-Logic/database"
+	"github.com/KJHJason/Cultured-Downloader-Logic/httpfuncs"
+	"github.com/KJHJason/Cultured-Downloader-Logic/logger"
+)
+
+// Session owns the resources of a download session so that multiple sessions,
+// e.g. different accounts or download directories, can run side by side in one process.
+//
+// A nil Session or a nil field uses the package-level defaults
+// like database.AppDb and logger.MainLogger which make up the default session.
+type Session struct {
+	Db     *database.DbWrapper // defaults to database.AppDb
+	Logger *logger.Logger      // defaults to logger.MainLogger
+
+	CfCache            *cf.Cache             // defaults to cf.DefaultCache
+	FantiaCaptcha      *CaptchaCache         // defaults to the Fantia captcha cache of the default session
+	KemonoCreatorNames *NameCache            // defaults to the Kemono creator names of the default session
+	Transports         *httpfuncs.Transports // a new transport is used for each request if nil
+}
+
+var (
+	defaultFantiaCaptcha      = &CaptchaCache{}
+	defaultKemonoCreatorNames = NewNameCache()
+)
+
+// NewSession returns a session with its own captcha caches and HTTP transports.
+//
+// The db and l can be nil to use database.AppDb and logger.MainLogger respectively.
+// Otherwise, the db should be opened with database.NewDb and migrated with Migrate before use.
+//
+// If both are given and the db has no ErrHandler, the errors of the db's cache helpers will be logged to l.
+func NewSession(db *database.DbWrapper, l *logger.Logger) *Session {
+	if db != nil && l != nil && db.ErrHandler == nil {
+		db.ErrHandler = func(err error, logMsg string) {
+			l.LogError(fmt.Errorf("%s: %w", logMsg, err), logger.ERROR)
+		}
+	}
+	return &Session{
+		Db:                 db,
+		Logger:             l,
+		CfCache:            cf.NewCache(),
+		FantiaCaptcha:      &CaptchaCache{},
+		KemonoCreatorNames: NewNameCache(),
+		Transports:         httpfuncs.NewTransports(),
+	}
+}
+
+func (s *Session) GetDb() *database.DbWrapper {
+	if s == nil || s.Db == nil {
+		return database.AppDb
+	}
+	return s.Db
+}
+
+func (s *Session) GetLogger() *logger.Logger {
+	if s == nil || s.Logger == nil {
+		return &logger.MainLogger
+	}
+	return s.Logger
+}
+
+func (s *Session) GetCfCache() *cf.Cache {
+	if s == nil || s.CfCache == nil {
+		return cf.DefaultCache
+	}
+	return s.CfCache
+}
+
+func (s *Session) GetFantiaCaptcha() *CaptchaCache {
+	if s == nil || s.FantiaCaptcha == nil {
+		return defaultFantiaCaptcha
+	}
+	return s.FantiaCaptcha
+}
+
+func (s *Session) GetKemonoCreatorNames() *NameCache {
+	if s == nil || s.KemonoCreatorNames == nil {
+		return defaultKemonoCreatorNames
+	}
+	return s.KemonoCreatorNames
+}
+
+func (s *Session) GetTransports() *httpfuncs.Transports {
+	if s == nil {
+		return nil
+	}
+	return s.Transports
+}
+
+// CaptchaCache remembers when the captcha was last solved
+// so that the concurrent requests that were blocked by the same captcha do not solve it again.
+type CaptchaCache struct {
+	mu         sync.Mutex
+	solvedTime time.Time
+}
+
+// SolveIfExpired calls solve unless the captcha was solved successfully within the timeout.
+func (c *CaptchaCache) SolveIfExpired(timeout time.Duration, solve func() error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.solvedTime.IsZero() && time.Since(c.solvedTime) < timeout {
+		return nil
+	}
+	if err := solve(); err != nil {
+		return err
+	}
+	c.solvedTime = time.Now()
+	return nil
+}
+
+// NameCache is an in-memory cache of names like the creator names
+// for when the cache database is not used.
+type NameCache struct {
+	mu    sync.Mutex
+	names map[string]string
+}
+
+func NewNameCache() *NameCache {
+	return &NameCache{
+		names: make(map[string]string),
+	}
+}
+
+// GetOrFetch returns the cached name of the key or caches the name returned by fetch if there is no error.
+//
+// Concurrent calls are serialised so that the same name will not be fetched more than once.
+func (c *NameCache) GetOrFetch(key string, fetch func() (string, error)) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if name, ok := c.names[key]; ok {
+		return name, nil
+	}
+	name, err := fetch()
+	if err != nil {
+		return name, err
+	}
+	c.names[key] = name
+	return name, nil
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KJHJason/Cultured-Downloader-Logic/api/cdlsolvers/cf"
+	"github.com/KJHJason/Cultured-Downloader-Logic/database"
+	"github.com/KJHJason/Cultured-Downloader-Logic/logger"
+)
+
+func TestDefaultSession(t *testing.T) {
+	var s *Session
+	if s.GetLogger() != &logger.MainLogger || s.GetCfCache() != cf.DefaultCache || s.GetTransports() != nil {
+		t.Error("Expected a nil session to use the package-level defaults")
+	}
+	if s.GetFantiaCaptcha() != (&Session{}).GetFantiaCaptcha() {
+		t.Error("Expected the default sessions to share the Fantia captcha cache")
+	}
+
+	s = NewSession(nil, nil)
+	if s.GetCfCache() == cf.DefaultCache || s.GetFantiaCaptcha() == defaultFantiaCaptcha || s.GetKemonoCreatorNames() == defaultKemonoCreatorNames {
+		t.Error("Expected a new session to have its own caches")
+	}
+	if s.GetTransports() == nil {
+		t.Error("Expected a new session to have its own HTTP transports")
+	}
+}
+
+func TestSessionDbErrors(t *testing.T) {
+	db, err := database.NewDb(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create db: %v", err)
+	}
+	var logs bytes.Buffer
+	l := logger.NewLogger(&logs)
+	NewSession(db, &l)
+
+	// the cache helpers cannot return the error of the closed database
+	db.Close()
+	db.CachePost("post")
+	if !strings.Contains(logs.String(), "Failed to cache post") {
+		t.Errorf("Expected the database errors to be logged to the session's logger but got %q", logs.String())
+	}
+}
+
+func TestCaptchaCache(t *testing.T) {
+	var cache CaptchaCache
+	solves := 0
+	solve := func() error {
+		solves++
+		return nil
+	}
+	for range 2 {
+		if err := cache.SolveIfExpired(time.Minute, solve); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if solves != 1 {
+		t.Errorf("Expected the captcha to be solved once but got %d", solves)
+	}
+
+	var failed CaptchaCache
+	solveErr := errors.New("failed to solve")
+	for range 2 {
+		failed.SolveIfExpired(time.Minute, func() error {
+			solves++
+			return solveErr
+		})
+	}
+	if solves != 3 {
+		t.Errorf("Expected failed solves to be retried but got %d solves", solves)
+	}
+}
+
+func TestNameCache(t *testing.T) {
+	cache := NewNameCache()
+	fetches := 0
+	fetch := func() (string, error) {
+		fetches++
+		return "creator", nil
+	}
+	for range 2 {
+		if name, err := cache.GetOrFetch("fanbox/123", fetch); err != nil || name != "creator" {
+			t.Fatalf("Expected %q but got %q (%v)", "creator", name, err)
+		}
+	}
+	if fetches != 1 {
+		t.Errorf("Expected the name to be fetched once but got %d", fetches)
+	}
+
+	if _, err := cache.GetOrFetch("fanbox/456", func() (string, error) { return "456", errors.New("not found") }); err == nil {
+		t.Error("Expected the fetch error to be returned")
+	}
+	if name, _ := cache.GetOrFetch("fanbox/456", fetch); name != "creator" {
+		t.Error("Expected failed fetches not to be cached")
+	}
+}
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/KJHJason/Cultured-Downloader-Logic/cdlerrors"
+	"github.com/KJHJason/Cultured-Downloader-Logic/database"
+)
+
+type pendingWatermarks struct {
+	mu         sync.Mutex
+	watermarks map[string]*database.CreatorWatermark // creator key -> newest post
+}
+
+// SyncWatermark keeps track of the newest post of a creator
+// while paginating through the creator's posts for incremental syncs.
+//
+// A nil *SyncWatermark is valid and treats every post as unseen.
+type SyncWatermark struct {
+	base       *BaseDl
+	creatorKey string
+	previous   *database.CreatorWatermark // nil if the creator has not been synced before
+	newest     *database.CreatorWatermark
+
+	reachedSeen bool
+}
+
+// NewSyncWatermark returns the watermark of the creator if IncrementalSync and UseCacheDb are enabled, otherwise nil.
+//
+// creatorKey should uniquely identify the creator's listing on the site, e.g. "<service>/<creator ID>" for Kemono.
+func (b *BaseDl) NewSyncWatermark(creatorKey string) *SyncWatermark {
+	if !b.IncrementalSync || !b.UseCacheDb || b.Session.GetDb() == nil {
+		return nil
+	}
+	return &SyncWatermark{
+		base:       b,
+		creatorKey: creatorKey,
+		previous:   b.Session.GetDb().GetCreatorWatermark(creatorKey, b.site),
+	}
+}
+
+// IsSeen returns true if the post is at or older than the watermark from the previous sync.
+//
+// Unseen posts are tracked so that the newest post will be the next watermark.
+func (w *SyncWatermark) IsSeen(postId string, date time.Time) bool {
+	if w == nil {
+		return false
+	}
+	if w.previous != nil && !w.previous.IsNewer(postId, date) {
+		w.reachedSeen = true
+		return true
+	}
+	if w.newest == nil || w.newest.IsNewer(postId, date) {
+		w.newest = &database.CreatorWatermark{
+			PostId: postId,
+			Date:   date,
+		}
+	}
+	return false
+}
+
+// ReachedSeen returns true if a post from the previous sync was found,
+// meaning that there is no need to paginate further.
+func (w *SyncWatermark) ReachedSeen() bool {
+	return w != nil && w.reachedSeen
+}
+
+// Done marks the creator's posts as retrieved successfully.
+//
+// The new watermark will only be saved when CommitSyncWatermarks is called
+// so that posts that failed to download will not be skipped in the next sync.
+func (w *SyncWatermark) Done() {
+	if w == nil || w.newest == nil {
+		return
+	}
+
+	w.base.pendingWatermarks.mu.Lock()
+	defer w.base.pendingWatermarks.mu.Unlock()
+	if w.base.pendingWatermarks.watermarks == nil {
+		w.base.pendingWatermarks.watermarks = make(map[string]*database.CreatorWatermark)
+	}
+	w.base.pendingWatermarks.watermarks[w.creatorKey] = w.newest
+}
+
+// CommitSyncWatermarks saves the watermarks of the creators synced by this download
+// and should be called after all the posts have been downloaded successfully.
+func (b *BaseDl) CommitSyncWatermarks() error {
+	db := b.Session.GetDb()
+	if db == nil {
+		return nil
+	}
+
+	b.pendingWatermarks.mu.Lock()
+	defer b.pendingWatermarks.mu.Unlock()
+	now := time.Now()
+	for creatorKey, watermark := range b.pendingWatermarks.watermarks {
+		watermark.SyncedAt = now
+		if err := db.SetCreatorWatermark(creatorKey, b.site, watermark); err != nil {
+			return fmt.Errorf(
+				"error %d: failed to save the sync watermark of %s, more info => %w",
+				cdlerrors.OS_ERROR,
+				creatorKey,
+				err,
+			)
+		}
+		delete(b.pendingWatermarks.watermarks, creatorKey)
+	}
+	return nil
+}
@@ -59,6 +59,10 @@ const (
 	PIXIV_MOBILE_TITLE             = "Pixiv (Mobile)"
 	PIXIV_PER_PAGE                 = 60
 	PIXIV_MOBILE_PER_PAGE          = 30
+	PIXIV_BOOKMARKS_PER_PAGE       = 48 // max limit of the web API
+	PIXIV_NOVEL_SERIES_PER_PAGE    = 30 // max limit of the web API
+	PIXIV_RANKING_PER_PAGE         = 50
+	PIXIV_MANGA_SERIES_PER_PAGE    = 12
 	PIXIV_URL                      = "https://www.pixiv.net"
 	PIXIV_API_URL                  = "https://www.pixiv.net/ajax"
 	PIXIV_MOBILE_URL               = "https://app-api.pixiv.net"
@@ -77,6 +81,10 @@ const (
 	PIXIV_MOBILE_ARTWORK_URL       = PIXIV_MOBILE_URL + "/v1/illust/detail"
 	PIXIV_MOBILE_ARTIST_POSTS_URL  = PIXIV_MOBILE_URL + "/v1/user/illusts"
 	PIXIV_MOBILE_ILLUST_SEARCH_URL = PIXIV_MOBILE_URL + "/v1/search/illust"
+	PIXIV_MOBILE_BOOKMARKS_URL     = PIXIV_MOBILE_URL + "/v1/user/bookmarks/illust"
+	PIXIV_MOBILE_FOLLOW_FEED_URL   = PIXIV_MOBILE_URL + "/v2/illust/follow"
+	PIXIV_MOBILE_RANKING_URL       = PIXIV_MOBILE_URL + "/v1/illust/ranking"
+	PIXIV_MOBILE_MANGA_SERIES_URL  = PIXIV_MOBILE_URL + "/v1/illust/series"
 
 	PIXIV_FANBOX                      = "fanbox"
 	PIXIV_FANBOX_TITLE                = "Pixiv Fanbox"
@@ -106,6 +114,10 @@ const (
 	PASSWORD_FILENAME = "detected_passwords.txt"
 	ATTACHMENT_FOLDER = "attachments"
 	IMAGES_FOLDER     = "images"
+	METADATA_FILENAME = "post_metadata.json"
+
+	// Temporary folder next to the ugoira zip file with the extracted frames for FFmpeg
+	UGOIRA_UNZIPPED_FOLDER = "unzipped"
 
 	KEMONO_EMBEDS_FOLDER  = "embeds"
 	KEMONO_CONTENT_FOLDER = "post_content"
@@ -212,6 +224,24 @@ var (
 		"manga",
 		"all",
 	}
+	ACCEPTED_BOOKMARK_RESTRICT = []string{
+		"public",
+		"private",
+		"all",
+	}
+	ACCEPTED_RANKING_MODE = []string{
+		"daily", "weekly", "monthly",
+		"rookie", "original", "daily_ai",
+		"male", "female",
+		"daily_r18", "weekly_r18", "daily_r18_ai",
+		"male_r18", "female_r18", "r18g",
+	}
+	ACCEPTED_RANKING_CONTENT = []string{
+		"all",
+		"illust",
+		"manga",
+		"ugoira",
+	}
 
 	// For Kemono
 	KEMONO_IMG_SRC_TAG_REGEX     = regexp.MustCompile(`(?i)<img[^>]+src=(?:\\)?"(?P<imgSrc>[^">]+)(?:\\)?"[^>]*>`)
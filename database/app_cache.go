@@ -22,6 +22,11 @@ func InitAppDb() error {
 	if err != nil {
 		return err
 	}
+
+	if err := AppDb.Migrate(); err != nil {
+		CloseDb()
+		return err
+	}
 	return nil
 }
 
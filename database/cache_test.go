@@ -12,6 +12,24 @@ func resetBuckets() {
 	AppDb.DeleteBucket(GDRIVE_BUCKET)
 	AppDb.DeleteBucket(UGOIRA_BUCKET)
 	AppDb.DeleteBucket(KEMONO_CREATOR_BUCKET)
+	AppDb.DeleteBucket(WATERMARK_BUCKET)
+	AppDb.DeleteBucket(MANIFEST_BUCKET)
+	AppDb.DeleteBucket(SEARCH_DOC_BUCKET)
+	AppDb.DeleteBucket(SEARCH_INDEX_BUCKET)
+}
+
+// must returns a function that returns the value of the getter or fails the test on error,
+// e.g. must(GetAllCacheForAllPlatforms())(t).
+//
+// The test is given last as Go does not allow other arguments with the results of a multi-value call.
+func must[T any](val T, err error) func(t *testing.T) T {
+	return func(t *testing.T) T {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		return val
+	}
 }
 
 func initTestData(t *testing.T) {
@@ -113,7 +131,7 @@ func TestCachePost(t *testing.T) {
 	}
 
 	// Test getting the post key
-	val := getPostCache(key, constants.FANTIA)
+	val := AppDb.getPostCache(key, constants.FANTIA)
 	if val.IsZero() {
 		t.Errorf("Expected key to have a time value")
 	}
@@ -142,7 +160,7 @@ func TestGetAllCacheForPlatform(t *testing.T) {
 	initTestData(t)
 
 	// Test getting all cache for a platform
-	cache := GetAllCacheForPlatform(constants.FANTIA)
+	cache := must(GetAllCacheForPlatform(constants.FANTIA))(t)
 	if len(cache) != 2 {
 		t.Fatalf("Expected 2 cache entries for Fantia, got %d", len(cache))
 	}
@@ -152,7 +170,7 @@ func TestGetAllCacheForPlatform(t *testing.T) {
 		cache...,
 	)
 
-	cache = GetAllCacheForPlatform(constants.PIXIV)
+	cache = must(GetAllCacheForPlatform(constants.PIXIV))(t)
 	if len(cache) != 2 {
 		t.Fatalf("Expected 2 cache entries for Pixiv, got %d", len(cache))
 	}
@@ -162,7 +180,7 @@ func TestGetAllCacheForPlatform(t *testing.T) {
 		cache...,
 	)
 
-	cache = GetAllCacheForPlatform(constants.PIXIV_FANBOX)
+	cache = must(GetAllCacheForPlatform(constants.PIXIV_FANBOX))(t)
 	if len(cache) != 2 {
 		t.Fatalf("Expected 2 cache entries for Pixiv Fanbox, got %d", len(cache))
 	}
@@ -172,7 +190,7 @@ func TestGetAllCacheForPlatform(t *testing.T) {
 		cache...,
 	)
 
-	cache = GetAllCacheForPlatform(constants.KEMONO)
+	cache = must(GetAllCacheForPlatform(constants.KEMONO))(t)
 	if len(cache) != 2 {
 		t.Fatalf("Expected 2 cache entries for Kemono, got %d", len(cache))
 	}
@@ -193,7 +211,7 @@ func TestConcurrency(t *testing.T) {
 	}
 
 	// Test getting the post key
-	val := getPostCache(key, constants.FANTIA)
+	val := AppDb.getPostCache(key, constants.FANTIA)
 	if val.IsZero() {
 		t.Errorf("Expected key to have a time value")
 	}
@@ -203,7 +221,7 @@ func TestDeletion(t *testing.T) {
 	initTestData(t)
 
 	// Test getting all cache for all platforms
-	cache := GetAllCacheForAllPlatforms()
+	cache := must(GetAllCacheForAllPlatforms())(t)
 	if len(cache) != 8 {
 		t.Errorf("Expected 8 cache entries")
 	}
@@ -215,7 +233,7 @@ func TestDeletion(t *testing.T) {
 	}
 
 	// Test getting all cache for all platforms
-	cache = GetAllCacheForAllPlatforms()
+	cache = must(GetAllCacheForAllPlatforms())(t)
 	if len(cache) != 0 {
 		t.Errorf("Expected 0 cache entries")
 	}
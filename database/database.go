@@ -3,10 +3,12 @@ package database
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 	"time"
 
+	"github.com/KJHJason/Cultured-Downloader-Logic/cdlerrors"
 	"github.com/KJHJason/Cultured-Downloader-Logic/constants"
 	"github.com/KJHJason/Cultured-Downloader-Logic/logger"
 	bolt "go.etcd.io/bbolt"
@@ -18,12 +20,29 @@ const (
 
 type DbWrapper struct {
 	Db *bolt.DB
+
+	// ErrHandler reports the errors of the cache helpers of this database
+	// so that each session can handle its errors separately, HandleErr is used if nil.
+	ErrHandler func(err error, logMsg string)
+
+	// TTLs and post recheck of the caches, see SetCacheTTL and SetPostRecheckDays
+	policies cachePolicies
 }
 
-// HandleErr logs the error and exits the program
-// Override this function if you want to handle errors differently
+// HandleErr reports the errors of the cache helpers that cannot return an error like PostCacheExists.
+//
+// By default, the error is logged and the helper continues as if the entry was not cached.
+// Override this function if you want to handle errors differently, e.g. to show them in the GUI.
 var HandleErr = func(err error, logMsg string) {
-	logger.MainLogger.Fatalf("%s: %s", logMsg, err)
+	logger.LogError(fmt.Errorf("%s: %w", logMsg, err), logger.ERROR)
+}
+
+func (db *DbWrapper) handleErr(err error, logMsg string) {
+	if db.ErrHandler != nil {
+		db.ErrHandler(err, logMsg)
+		return
+	}
+	HandleErr(err, logMsg)
 }
 
 func (db *DbWrapper) Close() error {
@@ -123,7 +142,8 @@ func iterateOnPrefix(b *bolt.Bucket, prefix string, fn func(k, v []byte) error)
 	return nil
 }
 
-func (db *DbWrapper) Get(bucket, key string) []byte {
+// Returns nil if the key does not exist
+func (db *DbWrapper) Get(bucket, key string) ([]byte, error) {
 	var value []byte
 	err := db.Db.View(func(tx *bolt.Tx) error {
 		bucket := tx.Bucket([]byte(bucket))
@@ -135,12 +155,18 @@ func (db *DbWrapper) Get(bucket, key string) []byte {
 		return nil
 	})
 	if err != nil {
-		HandleErr(err, "Failed to get cache value") // will exit the program, hence no need to return
+		return nil, fmt.Errorf(
+			"error %d: failed to get %q from %s, more info => %w",
+			cdlerrors.OS_ERROR,
+			key,
+			bucket,
+			err,
+		)
 	}
-	return value
+	return value, nil
 }
 
-func (db *DbWrapper) GetKeyValueOnPrefix(bucket, prefix string) []*KeyValue {
+func (db *DbWrapper) GetKeyValueOnPrefix(bucket, prefix string) ([]*KeyValue, error) {
 	var cacheKeys []*KeyValue
 	err := db.Db.View(func(tx *bolt.Tx) error {
 		b := tx.Bucket([]byte(bucket))
@@ -160,13 +186,18 @@ func (db *DbWrapper) GetKeyValueOnPrefix(bucket, prefix string) []*KeyValue {
 		})
 	})
 	if err != nil {
-		HandleErr(err, "Failed to get cache on prefix") // will exit the program, hence no need to return
+		return nil, fmt.Errorf(
+			"error %d: failed to get %s entries on prefix %q, more info => %w",
+			cdlerrors.OS_ERROR,
+			bucket,
+			prefix,
+			err,
+		)
 	}
-
-	return cacheKeys
+	return cacheKeys, nil
 }
 
-func (db *DbWrapper) GetAllKeyValue(bucket string) []*KeyValue {
+func (db *DbWrapper) GetAllKeyValue(bucket string) ([]*KeyValue, error) {
 	var cacheKeys []*KeyValue
 	err := db.Db.View(func(tx *bolt.Tx) error {
 		b := tx.Bucket([]byte(bucket))
@@ -186,10 +217,14 @@ func (db *DbWrapper) GetAllKeyValue(bucket string) []*KeyValue {
 		})
 	})
 	if err != nil {
-		HandleErr(err, "Failed to get all cache keys") // will exit the program, hence no need to return
+		return nil, fmt.Errorf(
+			"error %d: failed to get all %s entries, more info => %w",
+			cdlerrors.OS_ERROR,
+			bucket,
+			err,
+		)
 	}
-
-	return cacheKeys
+	return cacheKeys, nil
 }
 
 func (db *DbWrapper) Set(bucket, key string, value []byte) error {
@@ -228,37 +263,41 @@ func (db *DbWrapper) SetTime(bucket, key string, value time.Time) error {
 }
 
 func (db *DbWrapper) GetJson(bucket, key string, v any) error {
-	err := json.Unmarshal(db.Get(bucket, key), v)
+	value, err := db.Get(bucket, key)
 	if err != nil {
 		return err
 	}
-	return nil
+	return json.Unmarshal(value, v)
 }
 
-func (db *DbWrapper) GetString(bucket, key string) string {
-	return string(db.Get(bucket, key))
+func (db *DbWrapper) GetString(bucket, key string) (string, error) {
+	value, err := db.Get(bucket, key)
+	return string(value), err
 }
 
-func (db *DbWrapper) GetInt64(bucket, key string) int64 {
-	value := db.Get(bucket, key)
-	if value == nil {
-		return -1
+// Returns -1 if the key does not exist
+func (db *DbWrapper) GetInt64(bucket, key string) (int64, error) {
+	value, err := db.Get(bucket, key)
+	if err != nil || value == nil {
+		return -1, err
 	}
-	return ParseBytesToInt64(value)
+	return ParseBytesToInt64(value), nil
 }
 
-func (db *DbWrapper) GetInt(bucket, key string) int {
-	value := db.Get(bucket, key)
-	if value == nil {
-		return -1
+// Returns -1 if the key does not exist
+func (db *DbWrapper) GetInt(bucket, key string) (int, error) {
+	value, err := db.Get(bucket, key)
+	if err != nil || value == nil {
+		return -1, err
 	}
-	return ParseBytesToInt(value)
+	return ParseBytesToInt(value), nil
 }
 
-func (db *DbWrapper) GetTime(bucket, key string) time.Time {
-	value := db.Get(bucket, key)
-	if value == nil {
-		return time.Time{}
+// Returns a zero time if the key does not exist
+func (db *DbWrapper) GetTime(bucket, key string) (time.Time, error) {
+	value, err := db.Get(bucket, key)
+	if err != nil || value == nil {
+		return time.Time{}, err
 	}
-	return ParseBytesToDateTime(value)
+	return ParseBytesToDateTime(value), nil
 }
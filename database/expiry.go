@@ -0,0 +1,196 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/KJHJason/Cultured-Downloader-Logic/cdlerrors"
+)
+
+// cachePolicies are the expiry settings of a database so that each session can have its own.
+type cachePolicies struct {
+	mu          sync.RWMutex
+	ttls        map[string]time.Duration // bucket -> TTL
+	postRecheck time.Duration
+}
+
+// SetCacheTTL sets how long the entries in the bucket of AppDb are valid for, see DbWrapper.SetCacheTTL.
+//
+// Note: the policies are reset when AppDb is closed.
+func SetCacheTTL(bucket string, ttl time.Duration) {
+	AppDb.SetCacheTTL(bucket, ttl)
+}
+
+// SetCacheTTL sets how long the entries in the bucket are valid for
+// where a TTL of 0 or less means that the entries never expire (default).
+//
+// Expired entries are treated as non-existent by the lookups like PostCacheExists
+// and can be removed with Prune by setting PruneOptions.Expired.
+func (db *DbWrapper) SetCacheTTL(bucket string, ttl time.Duration) {
+	db.policies.mu.Lock()
+	defer db.policies.mu.Unlock()
+	if ttl <= 0 {
+		delete(db.policies.ttls, bucket)
+		return
+	}
+	if db.policies.ttls == nil {
+		db.policies.ttls = make(map[string]time.Duration)
+	}
+	db.policies.ttls[bucket] = ttl
+}
+
+func GetCacheTTL(bucket string) time.Duration {
+	return AppDb.GetCacheTTL(bucket)
+}
+
+func (db *DbWrapper) GetCacheTTL(bucket string) time.Duration {
+	db.policies.mu.RLock()
+	defer db.policies.mu.RUnlock()
+	return db.policies.ttls[bucket]
+}
+
+// SetPostRecheckDays sets the post recheck of AppDb, see DbWrapper.SetPostRecheckDays.
+func SetPostRecheckDays(days int) {
+	AppDb.SetPostRecheckDays(days)
+}
+
+// SetPostRecheckDays makes PostCacheExists return false for posts cached more than the given number of days ago
+// so that edited posts will be checked again for new files. Use 0 to disable (default).
+//
+// Unlike the TTL, the entries are kept in the cache and will be updated after the post is downloaded again.
+func (db *DbWrapper) SetPostRecheckDays(days int) {
+	db.policies.mu.Lock()
+	defer db.policies.mu.Unlock()
+	db.policies.postRecheck = time.Duration(days) * 24 * time.Hour
+}
+
+// Returns true if the entry that was cached at the given time has expired based on the bucket's TTL
+func (db *DbWrapper) isCacheExpired(bucket string, cachedAt time.Time) bool {
+	ttl := db.GetCacheTTL(bucket)
+	return ttl > 0 && !cachedAt.IsZero() && time.Since(cachedAt) > ttl
+}
+
+func (db *DbWrapper) shouldRecheckPost(cachedAt time.Time) bool {
+	db.policies.mu.RLock()
+	recheck := db.policies.postRecheck
+	db.policies.mu.RUnlock()
+	return recheck > 0 && time.Since(cachedAt) > recheck
+}
+
+// getCachedAt returns when the value in the bucket was cached or a zero time if unknown.
+func getCachedAt(bucket string, val []byte) time.Time {
+	switch bucket {
+	case POST_BUCKET, GDRIVE_BUCKET, UGOIRA_BUCKET:
+		return ParseBytesToDateTime(val)
+	case KEMONO_CREATOR_BUCKET:
+		return parseKemonoCreatorCache(val).CachedAt
+	case WATERMARK_BUCKET:
+		var watermark CreatorWatermark
+		if err := json.Unmarshal(val, &watermark); err == nil {
+			return watermark.SyncedAt
+		}
+	case MANIFEST_BUCKET:
+		var manifest FileManifest
+		if err := json.Unmarshal(val, &manifest); err == nil {
+			return manifest.CompletedAt
+		}
+	}
+	return time.Time{}
+}
+
+type PruneOptions struct {
+	Bucket string
+
+	// Only removes entries with keys starting with the prefix.
+	// For the post cache, use Platform instead to remove the entries of a platform.
+	Prefix   string
+	Platform string
+
+	// Only removes entries cached before the given time or more than the given age ago.
+	// Entries without a known cache time are kept if either is set.
+	Before    time.Time
+	OlderThan time.Duration
+
+	// Only removes entries that have expired based on the bucket's TTL, see SetCacheTTL.
+	Expired bool
+}
+
+// Prune removes the entries in the bucket of AppDb that match all of the given options
+// and returns the number of entries removed.
+func Prune(options *PruneOptions) (int, error) {
+	return AppDb.Prune(options)
+}
+
+func (db *DbWrapper) Prune(options *PruneOptions) (int, error) {
+	if options.Bucket == "" {
+		return 0, fmt.Errorf(
+			"error %d: bucket is required to prune the cache",
+			cdlerrors.DEV_ERROR,
+		)
+	}
+
+	prefix := options.Prefix
+	if options.Platform != "" {
+		prefix = ParsePostKey(prefix, options.Platform)
+	}
+	before := options.Before
+	if options.OlderThan > 0 {
+		olderThan := time.Now().Add(-options.OlderThan)
+		if before.IsZero() || olderThan.Before(before) {
+			before = olderThan
+		}
+	}
+
+	var pruned int
+	err := db.DeleteKeyValueOnPrefixAndCond(options.Bucket, prefix, func(_, val []byte) bool {
+		if !before.IsZero() || options.Expired {
+			cachedAt := getCachedAt(options.Bucket, val)
+			if cachedAt.IsZero() {
+				return false
+			}
+			if !before.IsZero() && !cachedAt.Before(before) {
+				return false
+			}
+			if options.Expired && !db.isCacheExpired(options.Bucket, cachedAt) {
+				return false
+			}
+		}
+		pruned++
+		return true
+	})
+	if err != nil {
+		return 0, fmt.Errorf(
+			"error %d: failed to prune %s, more info => %w",
+			cdlerrors.OS_ERROR,
+			options.Bucket,
+			err,
+		)
+	}
+	return pruned, nil
+}
+
+// PruneExpired removes the expired entries of all the buckets of AppDb with a TTL.
+func PruneExpired() (int, error) {
+	return AppDb.PruneExpired()
+}
+
+func (db *DbWrapper) PruneExpired() (int, error) {
+	db.policies.mu.RLock()
+	buckets := make([]string, 0, len(db.policies.ttls))
+	for bucket := range db.policies.ttls {
+		buckets = append(buckets, bucket)
+	}
+	db.policies.mu.RUnlock()
+
+	var total int
+	for _, bucket := range buckets {
+		pruned, err := db.Prune(&PruneOptions{Bucket: bucket, Expired: true})
+		if err != nil {
+			return total, err
+		}
+		total += pruned
+	}
+	return total, nil
+}
@@ -0,0 +1,124 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/KJHJason/Cultured-Downloader-Logic/constants"
+)
+
+func TestCacheTTL(t *testing.T) {
+	initTestData(t)
+	defer SetCacheTTL(POST_BUCKET, 0)
+	defer SetCacheTTL(KEMONO_CREATOR_BUCKET, 0)
+
+	key := "https://fantia.jp/posts/123456"
+	AppDb.SetTime(POST_BUCKET, ParsePostKey(key, constants.FANTIA), time.Now().Add(-48*time.Hour))
+	if !PostCacheExists(key, constants.FANTIA) {
+		t.Errorf("Expected post to exist without a TTL")
+	}
+
+	SetCacheTTL(POST_BUCKET, 24*time.Hour)
+	if PostCacheExists(key, constants.FANTIA) {
+		t.Errorf("Expected post to have expired")
+	}
+	if !PostCacheExists("https://fantia.jp/posts/654321", constants.FANTIA) {
+		t.Errorf("Expected recently cached post to exist")
+	}
+
+	creatorKey := "https://kemono.su/fanbox/user/1234567"
+	if GetKemonoCreatorCache(creatorKey) != "Kemono Creator" {
+		t.Errorf("Expected Kemono creator name to be cached")
+	}
+	SetCacheTTL(KEMONO_CREATOR_BUCKET, time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if name := GetKemonoCreatorCache(creatorKey); name != "" {
+		t.Errorf("Expected Kemono creator name to have expired but got %q", name)
+	}
+
+	pruned, err := PruneExpired()
+	if err != nil {
+		t.Fatalf("Failed to prune expired entries: %v", err)
+	}
+	if pruned != 2 { // the old Fantia post and the Kemono creator
+		t.Errorf("Expected 2 expired entries to be pruned but got %d", pruned)
+	}
+}
+
+func TestPostRecheck(t *testing.T) {
+	initTestData(t)
+	defer SetPostRecheckDays(0)
+
+	key := "https://fantia.jp/posts/123456"
+	AppDb.SetTime(POST_BUCKET, ParsePostKey(key, constants.FANTIA), time.Now().Add(-72*time.Hour))
+	SetPostRecheckDays(2)
+	if PostCacheExists(key, constants.FANTIA) {
+		t.Errorf("Expected post to be rechecked")
+	}
+	if len(must(GetAllCacheForPlatform(constants.FANTIA))(t)) != 2 {
+		t.Errorf("Expected post to be kept in the cache")
+	}
+}
+
+func TestPrune(t *testing.T) {
+	initTestData(t)
+
+	pruned, err := Prune(&PruneOptions{Bucket: POST_BUCKET, Platform: constants.PIXIV})
+	if err != nil {
+		t.Fatalf("Failed to prune: %v", err)
+	}
+	if pruned != 2 || len(must(GetAllCacheForPlatform(constants.PIXIV))(t)) != 0 {
+		t.Errorf("Expected 2 Pixiv posts to be pruned but got %d", pruned)
+	}
+
+	AppDb.SetTime(POST_BUCKET, ParsePostKey("https://fantia.jp/posts/123456", constants.FANTIA), time.Now().Add(-48*time.Hour))
+	pruned, err = Prune(&PruneOptions{Bucket: POST_BUCKET, OlderThan: 24 * time.Hour})
+	if err != nil {
+		t.Fatalf("Failed to prune: %v", err)
+	}
+	if pruned != 1 || PostCacheExists("https://fantia.jp/posts/123456", constants.FANTIA) {
+		t.Errorf("Expected only the old Fantia post to be pruned but got %d", pruned)
+	}
+
+	pruned, err = Prune(&PruneOptions{Bucket: POST_BUCKET, Platform: constants.KEMONO, Prefix: "https://kemono.su/fanbox/user/1234567/post/1"})
+	if err != nil {
+		t.Fatalf("Failed to prune: %v", err)
+	}
+	if pruned != 1 {
+		t.Errorf("Expected 1 Kemono post to be pruned by prefix but got %d", pruned)
+	}
+
+	if _, err := Prune(&PruneOptions{}); err == nil {
+		t.Errorf("Expected an error without a bucket")
+	}
+}
+
+func TestCacheTTLSessionDb(t *testing.T) {
+	initTestData(t)
+
+	db, err := NewDb(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create db: %v", err)
+	}
+	defer db.Close()
+
+	key := "https://fantia.jp/posts/123456"
+	db.SetTime(POST_BUCKET, ParsePostKey(key, constants.FANTIA), time.Now().Add(-48*time.Hour))
+	AppDb.SetTime(POST_BUCKET, ParsePostKey(key, constants.FANTIA), time.Now().Add(-48*time.Hour))
+	db.SetCacheTTL(POST_BUCKET, 24*time.Hour)
+	if db.PostCacheExists(key, constants.FANTIA) {
+		t.Errorf("Expected post to have expired in the session's db")
+	}
+	if !PostCacheExists(key, constants.FANTIA) || GetCacheTTL(POST_BUCKET) != 0 {
+		t.Errorf("Expected the TTL of the session's db to not apply to the app's db")
+	}
+
+	pruned, err := db.PruneExpired()
+	if err != nil {
+		t.Fatalf("Failed to prune expired entries: %v", err)
+	}
+	if pruned != 1 || !PostCacheExists(key, constants.FANTIA) {
+		t.Errorf("Expected only the post in the session's db to be pruned but got %d", pruned)
+	}
+}
@@ -0,0 +1,408 @@
+package database
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/KJHJason/Cultured-Downloader-Logic/cdlerrors"
+	"github.com/KJHJason/Cultured-Downloader-Logic/constants"
+)
+
+// Version of the export format, increment this when the format of CacheEntry changes
+const CACHE_EXPORT_VERSION = 1
+
+type CacheExportFormat int
+
+const (
+	JSON_FORMAT   CacheExportFormat = iota // a single JSON object with all the entries
+	NDJSON_FORMAT                          // a header line followed by one entry per line
+)
+
+// Returns NDJSON_FORMAT for ".ndjson" and ".jsonl" files, otherwise JSON_FORMAT
+func GetCacheExportFormat(path string) CacheExportFormat {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".ndjson", ".jsonl":
+		return NDJSON_FORMAT
+	default:
+		return JSON_FORMAT
+	}
+}
+
+type MergeStrategy int
+
+const (
+	// Only adds entries that do not exist in the local cache
+	MERGE_UNION MergeStrategy = iota
+
+	// Adds new entries and overwrites local entries that are older than the imported entry
+	MERGE_NEWEST_WINS
+)
+
+// Buckets that can be exported and imported, values of these
+// buckets are either a datetime or a string (see CacheEntry).
+var exportableBuckets = []string{
+	POST_BUCKET,
+	GDRIVE_BUCKET,
+	UGOIRA_BUCKET,
+	KEMONO_CREATOR_BUCKET,
+	WATERMARK_BUCKET,
+}
+
+// Buckets where the value is the datetime of when the entry was cached
+var datetimeBuckets = []string{
+	POST_BUCKET,
+	GDRIVE_BUCKET,
+	UGOIRA_BUCKET,
+}
+
+// CacheEntry is the portable form of a key-value pair in the cache
+// as the raw values are not meant to be shared between machines.
+type CacheEntry struct {
+	Bucket   string    `json:"bucket"`
+	Key      string    `json:"key"`
+	Platform string    `json:"platform,omitempty"`
+	Datetime time.Time `json:"datetime"`        // when the entry was cached or synced, zero if the bucket does not store a datetime
+	Value    string    `json:"value,omitempty"` // for buckets that store a string like the Kemono creator's name
+}
+
+type cacheExportHeader struct {
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exported_at"`
+}
+
+type cacheExportJson struct {
+	cacheExportHeader
+	Entries []*CacheEntry `json:"entries"`
+}
+
+// CacheScope limits the entries to export or import.
+//
+// Zero values mean no limit. Note that entries without
+// a datetime are not limited by the date range.
+type CacheScope struct {
+	Buckets   []string
+	Platforms []string // e.g. constants.FANTIA, Google Drive entries do not belong to any platform
+	Since     time.Time
+	Until     time.Time
+}
+
+func (s *CacheScope) includes(entry *CacheEntry) bool {
+	if s == nil {
+		return true
+	}
+	if len(s.Buckets) > 0 && !slices.Contains(s.Buckets, entry.Bucket) {
+		return false
+	}
+	if len(s.Platforms) > 0 && !slices.Contains(s.Platforms, entry.Platform) {
+		return false
+	}
+	if entry.Datetime.IsZero() {
+		return true
+	}
+	if !s.Since.IsZero() && entry.Datetime.Before(s.Since) {
+		return false
+	}
+	if !s.Until.IsZero() && entry.Datetime.After(s.Until) {
+		return false
+	}
+	return true
+}
+
+// Returns the platform that the key of the bucket belongs to
+func getEntryPlatform(bucket, key string) string {
+	switch bucket {
+	case POST_BUCKET, WATERMARK_BUCKET:
+		_, platform := SeparatePostKey([]byte(key))
+		return platform
+	case UGOIRA_BUCKET:
+		return constants.PIXIV
+	case KEMONO_CREATOR_BUCKET:
+		return constants.KEMONO
+	default:
+		return ""
+	}
+}
+
+func newCacheEntry(kv *KeyValue) *CacheEntry {
+	entry := &CacheEntry{
+		Bucket:   kv.Bucket,
+		Key:      kv.KeyStr,
+		Platform: getEntryPlatform(kv.Bucket, kv.KeyStr),
+	}
+	entry.Datetime = getCachedAt(kv.Bucket, kv.Val)
+	switch kv.Bucket {
+	case KEMONO_CREATOR_BUCKET:
+		entry.Value = parseKemonoCreatorCache(kv.Val).Name
+	case WATERMARK_BUCKET:
+		entry.Value = kv.ValStr
+	}
+	return entry
+}
+
+// Returns the raw value to be stored in the bucket
+func (e *CacheEntry) getVal() []byte {
+	switch {
+	case slices.Contains(datetimeBuckets, e.Bucket):
+		return ParseDateTimeToBytes(e.Datetime)
+	case e.Bucket == KEMONO_CREATOR_BUCKET:
+		cachedAt := e.Datetime
+		if cachedAt.IsZero() {
+			cachedAt = time.Now()
+		}
+		val, _ := json.Marshal(KemonoCreatorCache{Name: e.Value, CachedAt: cachedAt})
+		return val
+	default:
+		return []byte(e.Value)
+	}
+}
+
+func (e *CacheEntry) validate() error {
+	if !slices.Contains(exportableBuckets, e.Bucket) {
+		return fmt.Errorf("unknown bucket %q", e.Bucket)
+	}
+	if e.Key == "" {
+		return errors.New("key cannot be empty")
+	}
+	if slices.Contains(datetimeBuckets, e.Bucket) && e.Datetime.IsZero() {
+		return fmt.Errorf("missing datetime for key %q", e.Key)
+	}
+	return nil
+}
+
+// GetCacheEntries returns the portable entries of all the exportable buckets within the scope.
+func (db *DbWrapper) GetCacheEntries(scope *CacheScope) ([]*CacheEntry, error) {
+	var entries []*CacheEntry
+	for _, bucket := range exportableBuckets {
+		if scope != nil && len(scope.Buckets) > 0 && !slices.Contains(scope.Buckets, bucket) {
+			continue
+		}
+		kvs, err := db.GetAllKeyValue(bucket)
+		if err != nil {
+			return nil, err
+		}
+		for _, kv := range kvs {
+			entry := newCacheEntry(kv)
+			if scope.includes(entry) {
+				entries = append(entries, entry)
+			}
+		}
+	}
+	return entries, nil
+}
+
+// ExportCache writes the cache entries within the scope to w in the given format.
+func (db *DbWrapper) ExportCache(w io.Writer, format CacheExportFormat, scope *CacheScope) error {
+	header := cacheExportHeader{
+		Version:    CACHE_EXPORT_VERSION,
+		ExportedAt: time.Now(),
+	}
+	entries, err := db.GetCacheEntries(scope)
+	if err != nil {
+		return err
+	}
+
+	if format == NDJSON_FORMAT {
+		encoder := json.NewEncoder(w)
+		err = encoder.Encode(header)
+		for _, entry := range entries {
+			if err != nil {
+				break
+			}
+			err = encoder.Encode(entry)
+		}
+	} else {
+		if entries == nil {
+			entries = make([]*CacheEntry, 0)
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "\t")
+		err = encoder.Encode(cacheExportJson{cacheExportHeader: header, Entries: entries})
+	}
+	if err != nil {
+		return fmt.Errorf(
+			"error %d: failed to export cache, more info => %w",
+			cdlerrors.JSON_ERROR,
+			err,
+		)
+	}
+	return nil
+}
+
+func checkExportVersion(version int) error {
+	if version < 1 || version > CACHE_EXPORT_VERSION {
+		return fmt.Errorf(
+			"error %d: unsupported cache export version %d, expected version %d or lower",
+			cdlerrors.INPUT_ERROR,
+			version,
+			CACHE_EXPORT_VERSION,
+		)
+	}
+	return nil
+}
+
+func newImportJsonErr(err error) error {
+	return fmt.Errorf(
+		"error %d: failed to parse cache export, more info => %w",
+		cdlerrors.JSON_ERROR,
+		err,
+	)
+}
+
+// Reads the entries from the exported cache
+func readCacheEntries(r io.Reader, format CacheExportFormat) ([]*CacheEntry, error) {
+	if format != NDJSON_FORMAT {
+		var export cacheExportJson
+		if err := json.NewDecoder(r).Decode(&export); err != nil {
+			return nil, newImportJsonErr(err)
+		}
+		if err := checkExportVersion(export.Version); err != nil {
+			return nil, err
+		}
+		return export.Entries, nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	var entries []*CacheEntry
+	hasHeader := false
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(strings.TrimSpace(string(line))) == 0 {
+			continue
+		}
+		if !hasHeader {
+			var header cacheExportHeader
+			if err := json.Unmarshal(line, &header); err != nil {
+				return nil, newImportJsonErr(err)
+			}
+			if err := checkExportVersion(header.Version); err != nil {
+				return nil, err
+			}
+			hasHeader = true
+			continue
+		}
+
+		var entry CacheEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return nil, newImportJsonErr(err)
+		}
+		entries = append(entries, &entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, newImportJsonErr(err)
+	}
+	if !hasHeader {
+		return nil, newImportJsonErr(errors.New("missing header line"))
+	}
+	return entries, nil
+}
+
+type ImportStats struct {
+	Added   int
+	Updated int
+	Skipped int // already exists locally, outside of the scope, or invalid
+}
+
+// ImportCache merges the exported cache from r into the database within a single transaction.
+func (db *DbWrapper) ImportCache(r io.Reader, format CacheExportFormat, strategy MergeStrategy, scope *CacheScope) (*ImportStats, error) {
+	entries, err := readCacheEntries(r, format)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &ImportStats{}
+	err = db.Db.Update(func(tx *bolt.Tx) error {
+		for _, entry := range entries {
+			if entry.validate() != nil {
+				stats.Skipped++
+				continue
+			}
+			entry.Platform = getEntryPlatform(entry.Bucket, entry.Key)
+			if !scope.includes(entry) {
+				stats.Skipped++
+				continue
+			}
+
+			b, err := tx.CreateBucketIfNotExists([]byte(entry.Bucket))
+			if err != nil {
+				return err
+			}
+
+			key := []byte(entry.Key)
+			localVal := b.Get(key)
+			if localVal != nil {
+				localEntry := newCacheEntry(&KeyValue{Bucket: entry.Bucket, Key: key, KeyStr: entry.Key, Val: localVal, ValStr: string(localVal)})
+				if strategy != MERGE_NEWEST_WINS || !entry.Datetime.After(localEntry.Datetime) {
+					stats.Skipped++
+					continue
+				}
+			}
+
+			if err := b.Put(key, entry.getVal()); err != nil {
+				return err
+			}
+			if localVal == nil {
+				stats.Added++
+			} else {
+				stats.Updated++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf(
+			"error %d: failed to import cache, more info => %w",
+			cdlerrors.OS_ERROR,
+			err,
+		)
+	}
+	return stats, nil
+}
+
+// ExportCacheToFile exports the cache to the file path where
+// the format is determined by the file extension, see GetCacheExportFormat.
+func (db *DbWrapper) ExportCacheToFile(path string, scope *CacheScope) error {
+	os.MkdirAll(filepath.Dir(path), constants.DEFAULT_PERMS)
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf(
+			"error %d: failed to create cache export file %s, more info => %w",
+			cdlerrors.OS_ERROR,
+			path,
+			err,
+		)
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	if err := db.ExportCache(writer, GetCacheExportFormat(path), scope); err != nil {
+		return err
+	}
+	return writer.Flush()
+}
+
+// ImportCacheFromFile imports the cache from the file path where
+// the format is determined by the file extension, see GetCacheExportFormat.
+func (db *DbWrapper) ImportCacheFromFile(path string, strategy MergeStrategy, scope *CacheScope) (*ImportStats, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"error %d: failed to open cache export file %s, more info => %w",
+			cdlerrors.OS_ERROR,
+			path,
+			err,
+		)
+	}
+	defer file.Close()
+	return db.ImportCache(bufio.NewReader(file), GetCacheExportFormat(path), strategy, scope)
+}
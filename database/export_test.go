@@ -0,0 +1,93 @@
+package database
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KJHJason/Cultured-Downloader-Logic/constants"
+)
+
+func exportTestCache(t *testing.T, format CacheExportFormat, scope *CacheScope) *bytes.Buffer {
+	var buf bytes.Buffer
+	if err := AppDb.ExportCache(&buf, format, scope); err != nil {
+		t.Fatalf("Failed to export cache: %v", err)
+	}
+	return &buf
+}
+
+func TestExportImportCache(t *testing.T) {
+	for _, format := range []CacheExportFormat{JSON_FORMAT, NDJSON_FORMAT} {
+		initTestData(t)
+		buf := exportTestCache(t, format, nil)
+
+		resetBuckets()
+		stats, err := AppDb.ImportCache(buf, format, MERGE_UNION, nil)
+		if err != nil {
+			t.Fatalf("Failed to import cache: %v", err)
+		}
+		if stats.Added != 11 || stats.Updated != 0 || stats.Skipped != 0 {
+			t.Errorf("Expected 11 added entries but got %+v", stats)
+		}
+
+		if !PostCacheExists("https://fantia.jp/posts/123456", constants.FANTIA) {
+			t.Errorf("Expected Fantia post to be imported")
+		}
+		if !GDriveCacheExists("https://drive.google.com/file/d/<file_id>/view?usp=drive_link") {
+			t.Errorf("Expected GDrive entry to be imported")
+		}
+		if !UgoiraCacheExists("https://www.pixiv.net/artworks/118849705") {
+			t.Errorf("Expected ugoira entry to be imported")
+		}
+		if name := GetKemonoCreatorCache("https://kemono.su/fanbox/user/1234567"); name != "Kemono Creator" {
+			t.Errorf("Expected Kemono creator name to be imported but got %q", name)
+		}
+	}
+}
+
+func TestExportCacheScope(t *testing.T) {
+	initTestData(t)
+
+	entries := must(AppDb.GetCacheEntries(&CacheScope{Platforms: []string{constants.PIXIV}}))(t)
+	if len(entries) != 3 { // 2 posts and 1 ugoira
+		t.Errorf("Expected 3 Pixiv entries but got %d", len(entries))
+	}
+
+	entries = must(AppDb.GetCacheEntries(&CacheScope{Buckets: []string{POST_BUCKET}, Since: time.Now().Add(time.Hour)}))(t)
+	if len(entries) != 0 {
+		t.Errorf("Expected no entries cached in the future but got %d", len(entries))
+	}
+}
+
+func TestImportCacheMerge(t *testing.T) {
+	initTestData(t)
+	key := ParsePostKey("https://fantia.jp/posts/123456", constants.FANTIA)
+	localTime := AppDb.getPostCache("https://fantia.jp/posts/123456", constants.FANTIA)
+	newerTime := localTime.Add(time.Hour).Truncate(time.Second)
+
+	export := `{"version":1}
+{"bucket":"post_cache","key":"` + key + `","datetime":"` + newerTime.Format(time.RFC3339Nano) + `"}
+{"bucket":"unknown","key":"abc","datetime":"` + newerTime.Format(time.RFC3339Nano) + `"}
+`
+	stats, err := AppDb.ImportCache(strings.NewReader(export), NDJSON_FORMAT, MERGE_UNION, nil)
+	if err != nil {
+		t.Fatalf("Failed to import cache: %v", err)
+	}
+	if stats.Skipped != 2 || !AppDb.getPostCache("https://fantia.jp/posts/123456", constants.FANTIA).Equal(localTime) {
+		t.Errorf("Expected union to keep the local entry but got %+v", stats)
+	}
+
+	stats, err = AppDb.ImportCache(strings.NewReader(export), NDJSON_FORMAT, MERGE_NEWEST_WINS, nil)
+	if err != nil {
+		t.Fatalf("Failed to import cache: %v", err)
+	}
+	if stats.Updated != 1 || !AppDb.getPostCache("https://fantia.jp/posts/123456", constants.FANTIA).Equal(newerTime) {
+		t.Errorf("Expected newest-wins to overwrite the local entry but got %+v", stats)
+	}
+
+	_, err = AppDb.ImportCache(strings.NewReader(`{"version":99,"entries":[]}`), JSON_FORMAT, MERGE_UNION, nil)
+	if err == nil {
+		t.Errorf("Expected an error for an unsupported export version")
+	}
+}
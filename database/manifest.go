@@ -0,0 +1,248 @@
+package database
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/KJHJason/Cultured-Downloader-Logic/cdlerrors"
+)
+
+// example of the Key-Value pairs in the database
+// |---------------------|-------------------|
+// | <absolute_filepath> | FileManifest JSON |
+// |---------------------|-------------------|
+
+const (
+	MANIFEST_BUCKET = "file_manifest"
+)
+
+// FileSource identifies the post that a downloaded file belongs to.
+type FileSource struct {
+	Site    string `json:"Site"`
+	Creator string `json:"Creator"` // creator ID which is prefixed with the service for Kemono, e.g. "fanbox/123"
+	PostKey string `json:"PostKey"` // <platform>|<post URL>, the same key as the post cache
+}
+
+// FileManifest is the record of a file that was downloaded successfully.
+type FileManifest struct {
+	FileSource
+	Url         string    `json:"Url"`
+	Path        string    `json:"Path"`
+	Size        int64     `json:"Size"`
+	Sha256      string    `json:"Sha256"`
+	Mime        string    `json:"Mime"`
+	CompletedAt time.Time `json:"CompletedAt"`
+}
+
+func getManifestKey(filePath string) string {
+	if absPath, err := filepath.Abs(filePath); err == nil {
+		return absPath
+	}
+	return filepath.Clean(filePath)
+}
+
+// HashFile returns the hex-encoded SHA-256 checksum and the size of the file.
+func HashFile(filePath string) (string, int64, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", 0, fmt.Errorf(
+			"error %d: failed to open file %s, more info => %w",
+			cdlerrors.OS_ERROR,
+			filePath,
+			err,
+		)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return "", 0, fmt.Errorf(
+			"error %d: failed to calculate SHA-256 checksum of %s, more info => %w",
+			cdlerrors.OS_ERROR,
+			filePath,
+			err,
+		)
+	}
+	return fmt.Sprintf("%x", hash.Sum(nil)), size, nil
+}
+
+// NewFileManifest hashes the downloaded file to create its manifest entry.
+func NewFileManifest(url, filePath, mime string, source *FileSource) (*FileManifest, error) {
+	checksum, size, err := HashFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &FileManifest{
+		Url:         url,
+		Path:        getManifestKey(filePath),
+		Size:        size,
+		Sha256:      checksum,
+		Mime:        mime,
+		CompletedAt: time.Now(),
+	}
+	if source != nil {
+		manifest.FileSource = *source
+	}
+	return manifest, nil
+}
+
+// SetFileManifests saves all the manifests in a single transaction
+// where existing manifests with the same path will be overwritten.
+func SetFileManifests(manifests []*FileManifest) error {
+	return AppDb.SetFileManifests(manifests)
+}
+
+func (db *DbWrapper) SetFileManifests(manifests []*FileManifest) error {
+	if len(manifests) == 0 {
+		return nil
+	}
+
+	err := db.Db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(MANIFEST_BUCKET))
+		if err != nil {
+			return err
+		}
+
+		for _, manifest := range manifests {
+			manifest.Path = getManifestKey(manifest.Path)
+			val, err := json.Marshal(manifest)
+			if err != nil {
+				return err
+			}
+			if err := b.Put([]byte(manifest.Path), val); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf(
+			"error %d: failed to save %d file manifests, more info => %w",
+			cdlerrors.OS_ERROR,
+			len(manifests),
+			err,
+		)
+	}
+	return nil
+}
+
+func FileManifestExists(filePath string) bool {
+	return AppDb.FileManifestExists(filePath)
+}
+
+func (db *DbWrapper) FileManifestExists(filePath string) bool {
+	val, err := db.Get(MANIFEST_BUCKET, getManifestKey(filePath))
+	if err != nil {
+		db.handleErr(err, "Failed to get file manifest")
+		return false
+	}
+	return val != nil
+}
+
+// Returns nil if there is no manifest for the file path
+func GetFileManifestByPath(filePath string) *FileManifest {
+	return AppDb.GetFileManifestByPath(filePath)
+}
+
+func (db *DbWrapper) GetFileManifestByPath(filePath string) *FileManifest {
+	var manifest FileManifest
+	if err := db.GetJson(MANIFEST_BUCKET, getManifestKey(filePath), &manifest); err != nil {
+		return nil
+	}
+	return &manifest
+}
+
+// Returns the manifests that satisfy the condition, or all manifests if cond is nil
+func (db *DbWrapper) getFileManifests(cond func(manifest *FileManifest) bool) ([]*FileManifest, error) {
+	kvs, err := db.GetAllKeyValue(MANIFEST_BUCKET)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifests []*FileManifest
+	for _, kv := range kvs {
+		var manifest FileManifest
+		if err := json.Unmarshal(kv.Val, &manifest); err != nil {
+			continue
+		}
+		if cond == nil || cond(&manifest) {
+			manifests = append(manifests, &manifest)
+		}
+	}
+	return manifests, nil
+}
+
+func GetAllFileManifests() ([]*FileManifest, error) {
+	return AppDb.GetAllFileManifests()
+}
+
+func (db *DbWrapper) GetAllFileManifests() ([]*FileManifest, error) {
+	return db.getFileManifests(nil)
+}
+
+// GetFileManifestsByCreator returns the manifests of the files downloaded from the creator on the site.
+func GetFileManifestsByCreator(site, creator string) ([]*FileManifest, error) {
+	return AppDb.GetFileManifestsByCreator(site, creator)
+}
+
+func (db *DbWrapper) GetFileManifestsByCreator(site, creator string) ([]*FileManifest, error) {
+	return db.getFileManifests(func(manifest *FileManifest) bool {
+		return manifest.Site == site && manifest.Creator == creator
+	})
+}
+
+// GetFileManifestsByPost returns the manifests of the files downloaded from the post.
+//
+// postKey should be the key of the post in the post cache, see ParsePostKey.
+func GetFileManifestsByPost(postKey string) ([]*FileManifest, error) {
+	return AppDb.GetFileManifestsByPost(postKey)
+}
+
+func (db *DbWrapper) GetFileManifestsByPost(postKey string) ([]*FileManifest, error) {
+	return db.getFileManifests(func(manifest *FileManifest) bool {
+		return manifest.PostKey == postKey
+	})
+}
+
+// GetFileManifestsInDir returns the manifests of the files in the directory and its subdirectories.
+func GetFileManifestsInDir(dirPath string) ([]*FileManifest, error) {
+	return AppDb.GetFileManifestsInDir(dirPath)
+}
+
+func (db *DbWrapper) GetFileManifestsInDir(dirPath string) ([]*FileManifest, error) {
+	prefix := getManifestKey(dirPath) + string(filepath.Separator)
+	kvs, err := db.GetKeyValueOnPrefix(MANIFEST_BUCKET, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifests []*FileManifest
+	for _, kv := range kvs {
+		var manifest FileManifest
+		if err := json.Unmarshal(kv.Val, &manifest); err == nil {
+			manifests = append(manifests, &manifest)
+		}
+	}
+	return manifests, nil
+}
+
+func DeleteFileManifest(filePath string) error {
+	return AppDb.DeleteFileManifest(filePath)
+}
+
+func (db *DbWrapper) DeleteFileManifest(filePath string) error {
+	return db.Delete(MANIFEST_BUCKET, getManifestKey(filePath))
+}
+
+func DeleteAllFileManifests() error {
+	return AppDb.DeleteBucket(MANIFEST_BUCKET)
+}
@@ -0,0 +1,110 @@
+package database
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/KJHJason/Cultured-Downloader-Logic/constants"
+)
+
+func TestFileManifest(t *testing.T) {
+	initTestData(t)
+
+	dirPath := t.TempDir()
+	source := &FileSource{
+		Site:    constants.KEMONO,
+		Creator: "fanbox/1234567",
+		PostKey: ParsePostKey(constants.KEMONO_API_URL+"/fanbox/user/1234567/post/7654321", constants.KEMONO),
+	}
+	var manifests []*FileManifest
+	for _, filename := range []string{"1.png", "2.png"} {
+		filePath := filepath.Join(dirPath, filename)
+		if err := os.WriteFile(filePath, []byte("hello"), 0644); err != nil {
+			t.Fatalf("Failed to write test file: %v", err)
+		}
+		manifest, err := NewFileManifest("https://kemono.su/data/"+filename, filePath, "image/png", source)
+		if err != nil {
+			t.Fatalf("Failed to create manifest: %v", err)
+		}
+		manifests = append(manifests, manifest)
+	}
+	if err := SetFileManifests(manifests); err != nil {
+		t.Fatalf("Failed to save manifests: %v", err)
+	}
+
+	manifest := GetFileManifestByPath(filepath.Join(dirPath, "1.png"))
+	if manifest == nil {
+		t.Fatalf("Expected manifest to exist")
+	}
+	expectedHash := "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	if manifest.Size != 5 || manifest.Sha256 != expectedHash || manifest.Creator != source.Creator {
+		t.Errorf("Unexpected manifest %+v", manifest)
+	}
+
+	if got := len(must(GetFileManifestsByCreator(constants.KEMONO, "fanbox/1234567"))(t)); got != 2 {
+		t.Errorf("Expected 2 manifests for the creator but got %d", got)
+	}
+	if got := len(must(GetFileManifestsByPost(source.PostKey))(t)); got != 2 {
+		t.Errorf("Expected 2 manifests for the post but got %d", got)
+	}
+	if got := len(must(GetFileManifestsInDir(dirPath))(t)); got != 2 {
+		t.Errorf("Expected 2 manifests in the directory but got %d", got)
+	}
+	if got := len(must(GetFileManifestsByCreator(constants.FANTIA, "fanbox/1234567"))(t)); got != 0 {
+		t.Errorf("Expected no manifests for another site but got %d", got)
+	}
+
+	if err := DeleteFileManifest(filepath.Join(dirPath, "1.png")); err != nil {
+		t.Fatalf("Failed to delete manifest: %v", err)
+	}
+	if FileManifestExists(filepath.Join(dirPath, "1.png")) {
+		t.Errorf("Expected manifest to be deleted")
+	}
+}
+
+func TestFileManifestSessionDb(t *testing.T) {
+	initTestData(t)
+
+	db, err := NewDb(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create db: %v", err)
+	}
+	defer db.Close()
+
+	dirPath := t.TempDir()
+	filePath := filepath.Join(dirPath, "1.png")
+	if err := os.WriteFile(filePath, []byte("hello"), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	source := &FileSource{
+		Site:    constants.FANTIA,
+		Creator: "1234",
+		PostKey: ParsePostKey(constants.FANTIA_POST_API_URL+"5678", constants.FANTIA),
+	}
+	manifest, err := NewFileManifest("https://fantia.jp/1.png", filePath, "image/png", source)
+	if err != nil {
+		t.Fatalf("Failed to create manifest: %v", err)
+	}
+	if err := db.SetFileManifests([]*FileManifest{manifest}); err != nil {
+		t.Fatalf("Failed to save manifests: %v", err)
+	}
+
+	if db.GetFileManifestByPath(filePath) == nil {
+		t.Errorf("Expected the manifest to be in the session's db")
+	}
+	if got := len(must(db.GetFileManifestsByCreator(constants.FANTIA, "1234"))(t)); got != 1 {
+		t.Errorf("Expected 1 manifest for the creator but got %d", got)
+	}
+	if got := len(must(db.GetFileManifestsByPost(source.PostKey))(t)); got != 1 {
+		t.Errorf("Expected 1 manifest for the post but got %d", got)
+	}
+	if got := len(must(db.GetFileManifestsInDir(dirPath))(t)); got != 1 {
+		t.Errorf("Expected 1 manifest in the directory but got %d", got)
+	}
+
+	// the manifests should not leak into the app's db
+	if GetFileManifestByPath(filePath) != nil {
+		t.Errorf("Expected the manifest to not be in the app's db")
+	}
+}
@@ -0,0 +1,216 @@
+package database
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/KJHJason/Cultured-Downloader-Logic/cdlerrors"
+)
+
+// example of the Key-Value pairs in the database
+// |----------------|---------------------------|
+// | schema_version | big-endian uint64 version |
+// |----------------|---------------------------|
+
+const (
+	META_BUCKET        = "app_meta"
+	SCHEMA_VERSION_KEY = "schema_version"
+)
+
+// migration upgrades the database from version-1 to version.
+//
+// Each migration runs in its own transaction together with the update
+// of the schema version so a failed migration will not be partially applied.
+type migration struct {
+	version     int
+	description string
+	migrate     func(tx *bolt.Tx) error
+}
+
+// Migrations must be ordered by version and new migrations should only be appended.
+var migrations = []migration{
+	{
+		version:     1,
+		description: "convert native-endian datetime values to big-endian",
+		migrate:     migrateNativeEndianDatetimes,
+	},
+	{
+		version:     2,
+		description: "store the cache time of Kemono creator names",
+		migrate:     migrateKemonoCreatorNames,
+	},
+}
+
+// Latest schema version of the database
+var CURRENT_SCHEMA_VERSION = migrations[len(migrations)-1].version
+
+// GetSchemaVersion returns the schema version of the database
+// where 0 means that the database was created before schema versioning.
+func (db *DbWrapper) GetSchemaVersion() (int, error) {
+	var version int
+	err := db.Db.View(func(tx *bolt.Tx) error {
+		version = getSchemaVersion(tx)
+		return nil
+	})
+	return version, err
+}
+
+func getSchemaVersion(tx *bolt.Tx) int {
+	b := tx.Bucket([]byte(META_BUCKET))
+	if b == nil {
+		return 0
+	}
+	version := ParseBytesToInt(b.Get([]byte(SCHEMA_VERSION_KEY)))
+	if version < 0 {
+		return 0
+	}
+	return version
+}
+
+func setSchemaVersion(tx *bolt.Tx, version int) error {
+	b, err := tx.CreateBucketIfNotExists([]byte(META_BUCKET))
+	if err != nil {
+		return err
+	}
+	return b.Put([]byte(SCHEMA_VERSION_KEY), ParseInt(version))
+}
+
+// Migrate runs the pending migrations in order until the database is at CURRENT_SCHEMA_VERSION.
+//
+// Returns an error if the database was created by a newer version of the program.
+func (db *DbWrapper) Migrate() error {
+	version, err := db.GetSchemaVersion()
+	if err != nil {
+		return fmt.Errorf(
+			"error %d: failed to get schema version, more info => %w",
+			cdlerrors.OS_ERROR,
+			err,
+		)
+	}
+	if version > CURRENT_SCHEMA_VERSION {
+		return fmt.Errorf(
+			"error %d: schema version %d is newer than the supported version %d, please update the program",
+			cdlerrors.DEV_ERROR,
+			version,
+			CURRENT_SCHEMA_VERSION,
+		)
+	}
+
+	for _, m := range migrations {
+		if m.version <= version {
+			continue
+		}
+
+		err := db.Db.Update(func(tx *bolt.Tx) error {
+			if err := m.migrate(tx); err != nil {
+				return err
+			}
+			return setSchemaVersion(tx, m.version)
+		})
+		if err != nil {
+			return fmt.Errorf(
+				"error %d: failed to migrate to schema version %d (%s), more info => %w",
+				cdlerrors.OS_ERROR,
+				m.version,
+				m.description,
+				err,
+			)
+		}
+	}
+	return nil
+}
+
+// Datetimes outside of this range are treated as
+// an incorrect decoding of the value when migrating.
+var (
+	minPlausibleDatetime = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	maxPlausibleDatetime = time.Date(3000, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+func isPlausibleDatetime(value []byte, order binary.ByteOrder) bool {
+	sec := int64(order.Uint64(value))
+	nSec := int64(order.Uint64(value[8:]))
+	if nSec < 0 || nSec >= int64(time.Second) {
+		return false
+	}
+	datetime := time.Unix(sec, nSec)
+	return datetime.After(minPlausibleDatetime) && datetime.Before(maxPlausibleDatetime)
+}
+
+// Before schema version 1, datetimes were encoded with binary.NativeEndian
+// which made the database file unportable across architectures.
+//
+// Values that already decode correctly as big-endian are left untouched
+// in case they were written before the migration could run.
+func migrateNativeEndianDatetimes(tx *bolt.Tx) error {
+	for _, bucket := range []string{POST_BUCKET, GDRIVE_BUCKET, UGOIRA_BUCKET} {
+		b := tx.Bucket([]byte(bucket))
+		if b == nil {
+			continue
+		}
+
+		converted := make(map[string][]byte)
+		err := b.ForEach(func(k, v []byte) error {
+			if len(v) != 16 || isPlausibleDatetime(v, binary.BigEndian) || !isPlausibleDatetime(v, binary.NativeEndian) {
+				return nil
+			}
+
+			newVal := make([]byte, 16)
+			binary.BigEndian.PutUint64(newVal, binary.NativeEndian.Uint64(v))
+			binary.BigEndian.PutUint64(newVal[8:], binary.NativeEndian.Uint64(v[8:]))
+			converted[string(k)] = newVal
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		// bbolt does not allow modifying the bucket while iterating over it
+		for k, v := range converted {
+			if err := b.Put([]byte(k), v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Before schema version 2, the Kemono creator names were stored as plain strings
+// which could not expire as the time they were cached was unknown.
+//
+// The names are treated as cached at the time of the migration.
+func migrateKemonoCreatorNames(tx *bolt.Tx) error {
+	b := tx.Bucket([]byte(KEMONO_CREATOR_BUCKET))
+	if b == nil {
+		return nil
+	}
+
+	now := time.Now()
+	converted := make(map[string][]byte)
+	err := b.ForEach(func(k, v []byte) error {
+		var cache KemonoCreatorCache
+		if json.Unmarshal(v, &cache) == nil {
+			return nil // already migrated
+		}
+		newVal, err := json.Marshal(KemonoCreatorCache{Name: string(v), CachedAt: now})
+		if err != nil {
+			return err
+		}
+		converted[string(k)] = newVal
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for k, v := range converted {
+		if err := b.Put([]byte(k), v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
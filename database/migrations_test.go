@@ -0,0 +1,86 @@
+package database
+
+import (
+	"encoding/binary"
+	"path/filepath"
+	"testing"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestMigrateNativeEndianDatetimes(t *testing.T) {
+	db, err := NewDb(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create db: %v", err)
+	}
+	defer db.Close()
+
+	// simulate a database created before schema versioning
+	datetime := time.Date(2024, 5, 12, 10, 30, 0, 123, time.UTC)
+	nativeVal := make([]byte, 16)
+	binary.NativeEndian.PutUint64(nativeVal, uint64(datetime.Unix()))
+	binary.NativeEndian.PutUint64(nativeVal[8:], uint64(datetime.Nanosecond()))
+	db.Set(POST_BUCKET, "fantia|123", nativeVal)
+	db.Set(GDRIVE_BUCKET, "https://drive.google.com/file/d/abc", ParseDateTimeToBytes(datetime))
+
+	if version, _ := db.GetSchemaVersion(); version != 0 {
+		t.Fatalf("Expected schema version 0 but got %d", version)
+	}
+	if err := db.Migrate(); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	if version, _ := db.GetSchemaVersion(); version != CURRENT_SCHEMA_VERSION {
+		t.Errorf("Expected schema version %d but got %d", CURRENT_SCHEMA_VERSION, version)
+	}
+
+	for _, bucket := range []string{POST_BUCKET, GDRIVE_BUCKET} {
+		for _, kv := range must(db.GetAllKeyValue(bucket))(t) {
+			if got := ParseBytesToDateTime(kv.Val); !got.Equal(datetime) {
+				t.Errorf("Expected %v for %s in %s but got %v", datetime, kv.KeyStr, bucket, got)
+			}
+		}
+	}
+
+	// running the migrations again should be a no-op
+	if err := db.Migrate(); err != nil {
+		t.Fatalf("Failed to migrate again: %v", err)
+	}
+}
+
+func TestMigrateNewerSchema(t *testing.T) {
+	db, err := NewDb(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create db: %v", err)
+	}
+	defer db.Close()
+
+	db.Db.Update(func(tx *bolt.Tx) error {
+		return setSchemaVersion(tx, CURRENT_SCHEMA_VERSION+1)
+	})
+	if err := db.Migrate(); err == nil {
+		t.Errorf("Expected an error for a newer schema version")
+	}
+}
+
+func TestMigrateKemonoCreatorNames(t *testing.T) {
+	db, err := NewDb(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create db: %v", err)
+	}
+	defer db.Close()
+
+	key := "https://kemono.su/fanbox/user/1234567"
+	db.Set(KEMONO_CREATOR_BUCKET, key, []byte("Kemono Creator"))
+	db.Db.Update(func(tx *bolt.Tx) error {
+		return setSchemaVersion(tx, 1)
+	})
+	if err := db.Migrate(); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	cache := parseKemonoCreatorCache(must(db.Get(KEMONO_CREATOR_BUCKET, key))(t))
+	if cache.Name != "Kemono Creator" || cache.CachedAt.IsZero() {
+		t.Errorf("Expected the creator name to be migrated but got %+v", cache)
+	}
+}
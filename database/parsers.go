@@ -7,7 +7,7 @@ import (
 
 func ParseInt64(value int64) []byte {
 	buf := make([]byte, 8)
-	binary.NativeEndian.PutUint64(buf, uint64(value))
+	binary.BigEndian.PutUint64(buf, uint64(value))
 	return buf
 }
 
@@ -19,7 +19,7 @@ func ParseBytesToInt64(value []byte) int64 {
 	if len(value) != 8 {
 		return -1
 	}
-	return int64(binary.NativeEndian.Uint64(value))
+	return int64(binary.BigEndian.Uint64(value))
 }
 
 func ParseBytesToInt(value []byte) int {
@@ -31,8 +31,8 @@ func ParseDateTimeToBytes(datetime time.Time) []byte {
 	nSec := datetime.Nanosecond()
 
 	buf := make([]byte, 16)
-	binary.NativeEndian.PutUint64(buf, uint64(sec))
-	binary.NativeEndian.PutUint64(buf[8:], uint64(nSec))
+	binary.BigEndian.PutUint64(buf, uint64(sec))
+	binary.BigEndian.PutUint64(buf[8:], uint64(nSec))
 	return buf
 }
 
@@ -40,7 +40,7 @@ func ParseBytesToDateTime(value []byte) time.Time {
 	if len(value) != 16 {
 		return time.Time{}
 	}
-	sec := int64(binary.NativeEndian.Uint64(value))
-	nSec := int64(binary.NativeEndian.Uint64(value[8:]))
+	sec := int64(binary.BigEndian.Uint64(value))
+	nSec := int64(binary.BigEndian.Uint64(value[8:]))
 	return time.Unix(sec, nSec)
 }
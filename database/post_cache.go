@@ -1,6 +1,7 @@
 package database
 
 import (
+	"encoding/json"
 	"fmt"
 	"sort"
 	"strings"
@@ -53,30 +54,102 @@ func SeparatePostKey(keyBytes []byte) (url string, platform string) {
 	return url, platform
 }
 
+// Note: the lookup and setter functions below report errors through the ErrHandler of the database or HandleErr
+// where the lookups will treat the entry as not cached so that it will be downloaded again.
+//
+// The package-level functions use AppDb while the DbWrapper methods can be used for other databases.
+
+// Returns true if the post is cached and has not expired or is due for a recheck, see SetCacheTTL and SetPostRecheckDays.
 func PostCacheExists(key, platform string) bool {
-	return len(AppDb.GetString(POST_BUCKET, ParsePostKey(key, platform))) > 0
+	return AppDb.PostCacheExists(key, platform)
+}
+
+func (db *DbWrapper) PostCacheExists(key, platform string) bool {
+	cachedAt := db.getPostCache(key, platform)
+	if cachedAt.IsZero() {
+		return false
+	}
+	return !db.isCacheExpired(POST_BUCKET, cachedAt) && !db.shouldRecheckPost(cachedAt)
+}
+
+func (db *DbWrapper) getPostCache(key, platform string) time.Time {
+	cachedAt, err := db.GetTime(POST_BUCKET, ParsePostKey(key, platform))
+	if err != nil {
+		db.handleErr(err, "Failed to get post cache")
+	}
+	return cachedAt
 }
 
-func getPostCache(key, platform string) time.Time {
-	return AppDb.GetTime(POST_BUCKET, ParsePostKey(key, platform))
+func (db *DbWrapper) timeCacheExists(bucket, key string) bool {
+	cachedAt, err := db.GetTime(bucket, key)
+	if err != nil {
+		db.handleErr(err, "Failed to get cache")
+		return false
+	}
+	return !cachedAt.IsZero() && !db.isCacheExpired(bucket, cachedAt)
 }
 
 func GDriveCacheExists(key string) bool {
-	return len(AppDb.GetString(GDRIVE_BUCKET, key)) > 0
+	return AppDb.GDriveCacheExists(key)
+}
+
+func (db *DbWrapper) GDriveCacheExists(key string) bool {
+	return db.timeCacheExists(GDRIVE_BUCKET, key)
 }
 
 func UgoiraCacheExists(key string) bool {
-	return len(AppDb.GetString(UGOIRA_BUCKET, key)) > 0
+	return AppDb.UgoiraCacheExists(key)
+}
+
+func (db *DbWrapper) UgoiraCacheExists(key string) bool {
+	return db.timeCacheExists(UGOIRA_BUCKET, key)
+}
+
+// KemonoCreatorCache is the value stored in the KEMONO_CREATOR_BUCKET since schema version 2.
+type KemonoCreatorCache struct {
+	Name     string    `json:"Name"`
+	CachedAt time.Time `json:"CachedAt"`
+}
+
+func parseKemonoCreatorCache(val []byte) *KemonoCreatorCache {
+	var cache KemonoCreatorCache
+	if err := json.Unmarshal(val, &cache); err != nil {
+		// plain creator name from before schema version 2
+		return &KemonoCreatorCache{Name: string(val)}
+	}
+	return &cache
 }
 
+// Returns the cached name of the Kemono creator or an empty string if it does not exist or has expired.
 func GetKemonoCreatorCache(key string) string {
-	return AppDb.GetString(KEMONO_CREATOR_BUCKET, key)
+	return AppDb.GetKemonoCreatorCache(key)
 }
 
-// Note: the setter functions below do not handle errors and will continue as usual
+func (db *DbWrapper) GetKemonoCreatorCache(key string) string {
+	val, err := db.Get(KEMONO_CREATOR_BUCKET, key)
+	if err != nil {
+		db.handleErr(err, "Failed to get Kemono creator cache")
+		return ""
+	}
+	if val == nil {
+		return ""
+	}
+
+	cache := parseKemonoCreatorCache(val)
+	if db.isCacheExpired(KEMONO_CREATOR_BUCKET, cache.CachedAt) {
+		return ""
+	}
+	return cache.Name
+}
 
 func CachePost(parsedKey string) {
-	AppDb.SetTime(POST_BUCKET, parsedKey, time.Now())
+	AppDb.CachePost(parsedKey)
+}
+
+func (db *DbWrapper) CachePost(parsedKey string) {
+	if err := db.SetTime(POST_BUCKET, parsedKey, time.Now()); err != nil {
+		db.handleErr(err, "Failed to cache post")
+	}
 }
 
 func batchCacheLogic(tx *bolt.Tx, bucketName string, key string) error {
@@ -88,31 +161,64 @@ func batchCacheLogic(tx *bolt.Tx, bucketName string, key string) error {
 }
 
 func CachePostViaBatch(parsedKey string) {
-	AppDb.Db.Batch(func(tx *bolt.Tx) error {
+	AppDb.CachePostViaBatch(parsedKey)
+}
+
+func (db *DbWrapper) CachePostViaBatch(parsedKey string) {
+	err := db.Db.Batch(func(tx *bolt.Tx) error {
 		return batchCacheLogic(tx, POST_BUCKET, parsedKey)
 	})
+	if err != nil {
+		db.handleErr(err, "Failed to cache post")
+	}
 }
 
 func CacheGDrive(key string) {
-	AppDb.Db.Batch(func(tx *bolt.Tx) error {
+	AppDb.CacheGDrive(key)
+}
+
+func (db *DbWrapper) CacheGDrive(key string) {
+	err := db.Db.Batch(func(tx *bolt.Tx) error {
 		return batchCacheLogic(tx, GDRIVE_BUCKET, key)
 	})
+	if err != nil {
+		db.handleErr(err, "Failed to cache GDrive file")
+	}
 }
 
 func CacheUgoira(key string) {
-	AppDb.Db.Batch(func(tx *bolt.Tx) error {
+	AppDb.CacheUgoira(key)
+}
+
+func (db *DbWrapper) CacheUgoira(key string) {
+	err := db.Db.Batch(func(tx *bolt.Tx) error {
 		return batchCacheLogic(tx, UGOIRA_BUCKET, key)
 	})
+	if err != nil {
+		db.handleErr(err, "Failed to cache ugoira")
+	}
 }
 
 func CacheKemonoCreatorName(key, creatorName string) {
-	AppDb.Db.Batch(func(tx *bolt.Tx) error {
+	AppDb.CacheKemonoCreatorName(key, creatorName)
+}
+
+func (db *DbWrapper) CacheKemonoCreatorName(key, creatorName string) {
+	val, err := json.Marshal(KemonoCreatorCache{Name: creatorName, CachedAt: time.Now()})
+	if err != nil {
+		db.handleErr(err, "Failed to marshal Kemono creator cache")
+		return
+	}
+	err = db.Db.Batch(func(tx *bolt.Tx) error {
 		b, err := tx.CreateBucketIfNotExists([]byte(KEMONO_CREATOR_BUCKET))
 		if err != nil {
 			return err
 		}
-		return b.Put([]byte(key), []byte(creatorName))
+		return b.Put([]byte(key), val)
 	})
+	if err != nil {
+		db.handleErr(err, "Failed to cache Kemono creator name")
+	}
 }
 
 type PostCache struct {
@@ -165,16 +271,7 @@ func sortPostCacheByDatetime(caches []*PostCache) {
 	})
 }
 
-func GetAllCacheForPlatform(platforms ...string) []*PostCache {
-	var caches []*KeyValue
-	for _, platform := range platforms {
-		caches = append(caches, AppDb.GetKeyValueOnPrefix(POST_BUCKET, platform)...)
-	}
-
-	if len(caches) == 0 {
-		return make([]*PostCache, 0)
-	}
-
+func toPostCaches(caches []*KeyValue) []*PostCache {
 	postCache := make([]*PostCache, 0, len(caches))
 	for _, cache := range caches {
 		url, platform := SeparatePostKey(cache.Key)
@@ -190,32 +287,35 @@ func GetAllCacheForPlatform(platforms ...string) []*PostCache {
 	return postCache
 }
 
-func GetAllCacheForAllPlatforms() []*PostCache {
-	postCache := AppDb.GetAllKeyValue(POST_BUCKET)
-	if len(postCache) == 0 {
-		return make([]*PostCache, 0)
+func GetAllCacheForPlatform(platforms ...string) ([]*PostCache, error) {
+	var caches []*KeyValue
+	for _, platform := range platforms {
+		platformCaches, err := AppDb.GetKeyValueOnPrefix(POST_BUCKET, platform)
+		if err != nil {
+			return nil, err
+		}
+		caches = append(caches, platformCaches...)
 	}
+	return toPostCaches(caches), nil
+}
 
-	allCache := make([]*PostCache, 0, len(postCache))
-	for _, cache := range postCache {
-		url, platform := SeparatePostKey(cache.Key)
-		allCache = append(allCache, &PostCache{
-			Url:      url,
-			Platform: platform,
-			Datetime: ParseBytesToDateTime(cache.Val),
-			CacheKey: string(cache.Key),
-			Bucket:   POST_BUCKET,
-		})
+func GetAllCacheForAllPlatforms() ([]*PostCache, error) {
+	return AppDb.GetAllCacheForAllPlatforms()
+}
+
+func (db *DbWrapper) GetAllCacheForAllPlatforms() ([]*PostCache, error) {
+	caches, err := db.GetAllKeyValue(POST_BUCKET)
+	if err != nil {
+		return nil, err
 	}
-	sortPostCacheByDatetime(allCache)
-	return allCache
+	return toPostCaches(caches), nil
 }
 
 func DeletePostCacheForAllPlatforms() error {
 	return AppDb.DeleteBucket(POST_BUCKET)
 }
 
-func GetAllGdriveCache() []*KeyValue {
+func GetAllGdriveCache() ([]*KeyValue, error) {
 	return AppDb.GetAllKeyValue(GDRIVE_BUCKET)
 }
 
@@ -223,7 +323,7 @@ func DeleteAllGdriveCache() error {
 	return AppDb.DeleteBucket(GDRIVE_BUCKET)
 }
 
-func GetAllUgoiraCache() []*KeyValue {
+func GetAllUgoiraCache() ([]*KeyValue, error) {
 	return AppDb.GetAllKeyValue(UGOIRA_BUCKET)
 }
 
@@ -231,7 +331,7 @@ func DeleteAllUgoiraCache() error {
 	return AppDb.DeleteBucket(UGOIRA_BUCKET)
 }
 
-func GetAllKemonoCreatorCache() []*KeyValue {
+func GetAllKemonoCreatorCache() ([]*KeyValue, error) {
 	return AppDb.GetAllKeyValue(KEMONO_CREATOR_BUCKET)
 }
 
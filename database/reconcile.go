@@ -0,0 +1,440 @@
+package database
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/KJHJason/Cultured-Downloader-Logic/cdlerrors"
+	"github.com/KJHJason/Cultured-Downloader-Logic/constants"
+	"github.com/KJHJason/Cultured-Downloader-Logic/iofuncs"
+	"github.com/KJHJason/Cultured-Downloader-Logic/logger"
+)
+
+var (
+	// Name of the ugoira zip file on Pixiv's servers, e.g. "118849705_ugoira1920x1080.zip"
+	ugoiraZipRegex = regexp.MustCompile(`^\d+_ugoira\d+x\d+\.zip$`)
+
+	fantiaPostIdRegex = regexp.MustCompile(`/(posts|products)/(\d+)`)
+	fanboxPostIdRegex = regexp.MustCompile(`(?:postId=|/posts/)(\d+)`)
+	pixivPostIdRegex  = regexp.MustCompile(`(?:/artworks/|illust_id=)(\d+)`)
+	pixivNovelIdRegex = regexp.MustCompile(`/novel/show\.php\?id=(\d+)`)
+	kemonoPostIdRegex = regexp.MustCompile(`/([^/]+)/user/([^/]+)/post/([^/?#]+)`)
+)
+
+// postRef identifies a post regardless of the URL format
+// since the cache keys usually use the API URL while the metadata uses the web URL.
+type postRef struct {
+	platform string
+	postId   string // prefixed with "products/" for Fantia products and "novel/" for Pixiv novels
+
+	// Kemono only
+	service   string
+	creatorId string
+}
+
+func parsePostRef(url, platform string) (*postRef, bool) {
+	ref := &postRef{platform: platform}
+	switch platform {
+	case constants.FANTIA:
+		matched := fantiaPostIdRegex.FindStringSubmatch(url)
+		if matched == nil {
+			return nil, false
+		}
+		ref.postId = matched[2]
+		if matched[1] == "products" {
+			ref.postId = "products/" + ref.postId
+		}
+	case constants.PIXIV_FANBOX:
+		matched := fanboxPostIdRegex.FindStringSubmatch(url)
+		if matched == nil {
+			return nil, false
+		}
+		ref.postId = matched[1]
+	case constants.PIXIV, constants.PIXIV_MOBILE:
+		ref.platform = constants.PIXIV
+		if matched := pixivNovelIdRegex.FindStringSubmatch(url); matched != nil {
+			ref.postId = "novel/" + matched[1]
+			break
+		}
+		matched := pixivPostIdRegex.FindStringSubmatch(url)
+		if matched == nil {
+			return nil, false
+		}
+		ref.postId = matched[1]
+	case constants.KEMONO:
+		matched := kemonoPostIdRegex.FindStringSubmatch(url)
+		if matched == nil {
+			return nil, false
+		}
+		ref.service, ref.creatorId, ref.postId = matched[1], matched[2], matched[3]
+	default:
+		return nil, false
+	}
+	return ref, true
+}
+
+// Returns the key of the post in the post cache
+func (r *postRef) key() string {
+	return ParsePostKey(r.cacheUrl(), r.platform)
+}
+
+// Returns the URL that the downloaders use for the post cache
+//
+// Note: the artworks downloaded with Pixiv's mobile API are cached under the URL of the mobile API
+// but the URL of the web page is returned for them to match the posts regardless of the API.
+func (r *postRef) cacheUrl() string {
+	switch r.platform {
+	case constants.FANTIA:
+		if productId, ok := strings.CutPrefix(r.postId, "products/"); ok {
+			return constants.FANTIA_PRODUCT_URL + productId
+		}
+		return constants.FANTIA_POST_API_URL + r.postId
+	case constants.PIXIV_FANBOX:
+		return fmt.Sprintf("%s/post.info?postId=%s", constants.PIXIV_FANBOX_API_URL, r.postId)
+	case constants.PIXIV:
+		if novelId, ok := strings.CutPrefix(r.postId, "novel/"); ok {
+			return fmt.Sprintf("%s/novel/show.php?id=%s", constants.PIXIV_URL, novelId)
+		}
+		return fmt.Sprintf("%s/artworks/%s", constants.PIXIV_URL, r.postId)
+	default: // constants.KEMONO
+		return fmt.Sprintf("%s/%s/user/%s/post/%s", constants.KEMONO_API_URL, r.service, r.creatorId, r.postId)
+	}
+}
+
+// Returns the post of the web URL in the post_metadata.json file or the search index
+func parsePostUrl(url string) (*postRef, bool) {
+	var platform string
+	switch {
+	case strings.Contains(url, "fanbox.cc"):
+		platform = constants.PIXIV_FANBOX
+	case strings.Contains(url, "fantia.jp"):
+		platform = constants.FANTIA
+	case strings.Contains(url, "pixiv.net"):
+		platform = constants.PIXIV
+	case strings.Contains(url, "kemono"):
+		platform = constants.KEMONO
+	}
+	return parsePostRef(url, platform)
+}
+
+// Returns the post in the post_metadata.json file
+func parseMetadataFile(filePath string) (*postRef, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var metadata struct {
+		Url     string `json:"url"`
+		PostUrl string `json:"post_url"` // Pixiv Fanbox
+	}
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return nil, err
+	}
+
+	url := metadata.Url
+	if url == "" {
+		url = metadata.PostUrl
+	}
+	ref, ok := parsePostUrl(url)
+	if !ok {
+		return nil, fmt.Errorf("unknown post URL %q", url)
+	}
+	return ref, nil
+}
+
+// Returns true if the folder has the zip file of an ugoira
+// which means that the unzipped folder in it was left behind by a failed or cancelled conversion.
+func hasUgoiraZip(dirPath string) bool {
+	entries, err := os.ReadDir(dirPath)
+	if err != nil {
+		return false
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() && ugoiraZipRegex.MatchString(entry.Name()) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the key of the post of the manifest entry in the same form as postRef.key
+func getManifestPostKey(manifest *FileManifest) string {
+	url, platform := SeparatePostKey([]byte(manifest.PostKey))
+	if ref, ok := parsePostRef(url, platform); ok {
+		return ref.key()
+	}
+	return manifest.PostKey
+}
+
+// Returns the search index entries by their post keys
+func (db *DbWrapper) getIndexedPosts() (map[string]*SearchDoc, error) {
+	kvs, err := db.GetAllKeyValue(SEARCH_DOC_BUCKET)
+	if err != nil {
+		return nil, err
+	}
+
+	indexed := make(map[string]*SearchDoc, len(kvs))
+	for _, kv := range kvs {
+		var doc SearchDoc
+		if err := json.Unmarshal(kv.Val, &doc); err != nil {
+			continue
+		}
+		if ref, ok := parsePostUrl(doc.Url); ok {
+			indexed[ref.key()] = &doc
+		}
+	}
+	return indexed, nil
+}
+
+// PostOnDisk is a post with a post_metadata.json file in the download directory.
+type PostOnDisk struct {
+	Platform     string
+	PostId       string
+	CacheKey     string
+	MetadataPath string
+}
+
+type ReconcileReport struct {
+	// Cached posts where all the files recorded in the download manifest no longer exist
+	// or, for posts without manifest entries, the folder of the post in the search index no longer exists
+	MissingPosts []*PostCache
+
+	// Manifest entries of files that no longer exist
+	MissingFiles []*FileManifest
+
+	// Manifest entries of files that are smaller than when they were downloaded, e.g. from an interrupted download
+	TruncatedFiles []*FileManifest
+
+	// Cached posts with truncated files which have to be downloaded again
+	IncompletePosts []*PostCache
+
+	// Posts that were downloaded but are not in the post cache, e.g. from another machine
+	UncachedPosts []*PostOnDisk
+
+	// Extracted ugoira frames left behind by cancelled or failed conversions
+	OrphanedUgoiraDirs []string
+
+	// Number of cached posts that have no metadata file, manifest entries, or search index entry to check against
+	UnverifiedPosts int
+
+	// Whether the cache and the orphaned files were repaired
+	Repaired bool
+
+	// Search index entries of the missing posts
+	staleSearchDocs []string
+}
+
+type ReconcileOptions struct {
+	DownloadDirPath string
+
+	// Removes the missing posts and files from the caches, caches the uncached posts,
+	// deletes the truncated files so that their posts will be downloaded again,
+	// and deletes the orphaned folders.
+	Repair bool
+}
+
+// Reconcile walks the download directory and compares it against the post cache and the download manifest.
+//
+// Note: this should not be called while downloading as the files of
+// the current downloads and ugoira conversions would be reported as truncated files and orphaned folders.
+func Reconcile(options *ReconcileOptions) (*ReconcileReport, error) {
+	return AppDb.Reconcile(options, &logger.MainLogger)
+}
+
+// Reconcile checks the download directory against the caches of the database,
+// see the package-level Reconcile. The files that cannot be read are logged to l.
+func (db *DbWrapper) Reconcile(options *ReconcileOptions, l *logger.Logger) (*ReconcileReport, error) {
+	if !iofuncs.DirPathExists(options.DownloadDirPath) {
+		return nil, fmt.Errorf(
+			"error %d: download directory %s does not exist",
+			cdlerrors.INPUT_ERROR,
+			options.DownloadDirPath,
+		)
+	}
+
+	report := &ReconcileReport{}
+	onDisk := make(map[string]*PostOnDisk)
+	err := filepath.WalkDir(options.DownloadDirPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// skip the files that cannot be read instead of stopping the walk
+			l.LogError(err, logger.ERROR)
+			return nil
+		}
+
+		if d.IsDir() {
+			if d.Name() == constants.UGOIRA_UNZIPPED_FOLDER && hasUgoiraZip(filepath.Dir(path)) {
+				report.OrphanedUgoiraDirs = append(report.OrphanedUgoiraDirs, path)
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if d.Name() == constants.METADATA_FILENAME {
+			ref, err := parseMetadataFile(path)
+			if err != nil {
+				l.LogError(
+					fmt.Errorf("error %d: failed to parse %s, more info => %w", cdlerrors.JSON_ERROR, path, err),
+					logger.ERROR,
+				)
+				return nil
+			}
+			onDisk[ref.key()] = &PostOnDisk{
+				Platform:     ref.platform,
+				PostId:       ref.postId,
+				CacheKey:     ParsePostKey(ref.cacheUrl(), ref.platform),
+				MetadataPath: path,
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf(
+			"error %d: failed to walk download directory %s, more info => %w",
+			cdlerrors.OS_ERROR,
+			options.DownloadDirPath,
+			err,
+		)
+	}
+
+	// post key -> whether any of the post's files still exist
+	manifests, err := db.GetAllFileManifests()
+	if err != nil {
+		return nil, err
+	}
+	hasExistingFile := make(map[string]bool)
+	hasTruncatedFile := make(map[string]bool)
+	for _, manifest := range manifests {
+		postKey := getManifestPostKey(manifest)
+		fileInfo, err := os.Stat(manifest.Path)
+		exists := err == nil
+		if !exists {
+			report.MissingFiles = append(report.MissingFiles, manifest)
+		} else if fileInfo.Size() < manifest.Size {
+			report.TruncatedFiles = append(report.TruncatedFiles, manifest)
+			hasTruncatedFile[postKey] = true
+		}
+		hasExistingFile[postKey] = hasExistingFile[postKey] || exists
+	}
+
+	indexed, err := db.getIndexedPosts()
+	if err != nil {
+		return nil, err
+	}
+
+	posts, err := db.GetAllCacheForAllPlatforms()
+	if err != nil {
+		return nil, err
+	}
+	cached := make(map[string]struct{})
+	for _, post := range posts {
+		ref, ok := parsePostRef(post.Url, post.Platform)
+		if !ok {
+			report.UnverifiedPosts++
+			continue
+		}
+
+		key := ref.key()
+		cached[key] = struct{}{}
+		if hasTruncatedFile[key] {
+			report.IncompletePosts = append(report.IncompletePosts, post)
+		}
+		if _, ok := onDisk[key]; ok {
+			continue
+		}
+
+		if exists, ok := hasExistingFile[key]; ok {
+			if !exists {
+				report.MissingPosts = append(report.MissingPosts, post)
+			}
+			continue
+		}
+
+		// the post was downloaded without a manifest, e.g. before the manifest was added,
+		// so the folder of its post_metadata.json in the search index is checked instead
+		doc, ok := indexed[key]
+		if !ok {
+			report.UnverifiedPosts++
+		} else if !iofuncs.DirPathExists(filepath.Dir(doc.Path)) {
+			report.MissingPosts = append(report.MissingPosts, post)
+			report.staleSearchDocs = append(report.staleSearchDocs, doc.Path)
+		}
+	}
+
+	for key, post := range onDisk {
+		if _, ok := cached[key]; !ok && !hasTruncatedFile[key] {
+			report.UncachedPosts = append(report.UncachedPosts, post)
+		}
+	}
+
+	if options.Repair {
+		if err := report.repair(db); err != nil {
+			return report, err
+		}
+		report.Repaired = true
+	}
+	return report, nil
+}
+
+func (r *ReconcileReport) repair(db *DbWrapper) error {
+	var errs []error
+	for _, post := range r.MissingPosts {
+		if err := db.Delete(POST_BUCKET, post.CacheKey); err != nil {
+			errs = append(errs, err)
+		}
+		if post.Platform == constants.PIXIV {
+			// the converted ugoira was in the same folder
+			if err := db.Delete(UGOIRA_BUCKET, post.Url); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	for _, manifest := range r.MissingFiles {
+		if err := db.DeleteFileManifest(manifest.Path); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, path := range r.staleSearchDocs {
+		if err := db.DeleteSearchDoc(path); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, manifest := range r.TruncatedFiles {
+		if err := os.Remove(manifest.Path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			errs = append(errs, err)
+			continue
+		}
+		if err := db.DeleteFileManifest(manifest.Path); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, post := range r.IncompletePosts {
+		if err := db.Delete(POST_BUCKET, post.CacheKey); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, post := range r.UncachedPosts {
+		db.CachePost(post.CacheKey)
+	}
+	for _, path := range r.OrphanedUgoiraDirs {
+		if err := os.RemoveAll(path); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf(
+			"error %d: failed to repair %d cache entries or files, more info => %w",
+			cdlerrors.OS_ERROR,
+			len(errs),
+			err,
+		)
+	}
+	return nil
+}
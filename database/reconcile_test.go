@@ -0,0 +1,243 @@
+package database
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/KJHJason/Cultured-Downloader-Logic/constants"
+	"github.com/KJHJason/Cultured-Downloader-Logic/logger"
+)
+
+func TestParsePostRef(t *testing.T) {
+	testCases := []struct {
+		url      string
+		platform string
+		expected string // key of the post in the post cache
+	}{
+		{constants.FANTIA_POST_API_URL + "123", constants.FANTIA, "fantia|" + constants.FANTIA_POST_API_URL + "123"},
+		{"https://fantia.jp/posts/123", constants.FANTIA, "fantia|" + constants.FANTIA_POST_API_URL + "123"},
+		{constants.FANTIA_PRODUCT_URL + "456", constants.FANTIA, "fantia|" + constants.FANTIA_PRODUCT_URL + "456"},
+		{"https://api.fanbox.cc/post.info?postId=789", constants.PIXIV_FANBOX, "fanbox|https://api.fanbox.cc/post.info?postId=789"},
+		{"https://www.fanbox.cc/@creator/posts/789", constants.PIXIV_FANBOX, "fanbox|https://api.fanbox.cc/post.info?postId=789"},
+		{"https://www.pixiv.net/artworks/118849705", constants.PIXIV, "pixiv|https://www.pixiv.net/artworks/118849705"},
+		{constants.PIXIV_MOBILE_ARTWORK_URL + "?illust_id=118849705", constants.PIXIV, "pixiv|https://www.pixiv.net/artworks/118849705"},
+		{"https://www.pixiv.net/novel/show.php?id=20000000", constants.PIXIV, "pixiv|https://www.pixiv.net/novel/show.php?id=20000000"},
+		{
+			"https://kemono.su/api/v1/fanbox/user/1234567/post/7654321",
+			constants.KEMONO,
+			"kemono|https://kemono.su/api/v1/fanbox/user/1234567/post/7654321",
+		},
+		{
+			"https://kemono.su/fanbox/user/1234567/post/7654321",
+			constants.KEMONO,
+			"kemono|https://kemono.su/api/v1/fanbox/user/1234567/post/7654321",
+		},
+	}
+	for _, tc := range testCases {
+		ref, ok := parsePostRef(tc.url, tc.platform)
+		if !ok {
+			t.Errorf("Failed to parse %s", tc.url)
+			continue
+		}
+		if ref.key() != tc.expected {
+			t.Errorf("Expected %s for %s but got %s", tc.expected, tc.url, ref.key())
+		}
+
+		// the cache URL should refer to the same post
+		if cacheRef, _ := parsePostRef(ref.cacheUrl(), tc.platform); cacheRef == nil || cacheRef.key() != tc.expected {
+			t.Errorf("Expected cache URL %s to refer to %s", ref.cacheUrl(), tc.expected)
+		}
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	initTestData(t)
+	dlDir := t.TempDir()
+
+	// downloaded on another machine
+	uncachedDir := filepath.Join(dlDir, "creator", "[118849707] artwork")
+	os.MkdirAll(uncachedDir, 0755)
+	os.WriteFile(
+		filepath.Join(uncachedDir, constants.METADATA_FILENAME),
+		[]byte(`{"url":"https://www.pixiv.net/artworks/118849707","title":"artwork","post_type":"illust"}`),
+		0644,
+	)
+
+	// cached post whose folder was deleted
+	missingFile := filepath.Join(dlDir, "deleted", "1.png")
+	SetFileManifests([]*FileManifest{{
+		FileSource: FileSource{Site: constants.FANTIA, PostKey: ParsePostKey(constants.FANTIA_POST_API_URL+"123456", constants.FANTIA)},
+		Path:       missingFile,
+	}})
+
+	// cached post without manifest entries whose folder was deleted
+	AppDb.IndexSearchDocs(&SearchDoc{
+		Path: filepath.Join(dlDir, "creator", "[118849706] deleted", constants.METADATA_FILENAME),
+		Site: constants.PIXIV,
+		Url:  "https://www.pixiv.net/artworks/118849706",
+	})
+
+	// cached post with an interrupted download
+	truncatedFile := filepath.Join(dlDir, "fantia", "video.mp4")
+	os.MkdirAll(filepath.Dir(truncatedFile), 0755)
+	os.WriteFile(truncatedFile, []byte("partial"), 0644)
+	SetFileManifests([]*FileManifest{{
+		FileSource: FileSource{Site: constants.FANTIA, PostKey: ParsePostKey(constants.FANTIA_POST_API_URL+"654321", constants.FANTIA)},
+		Path:       truncatedFile,
+		Size:       1024,
+	}})
+
+	// files and folders that were not created by the downloads
+	partFile := filepath.Join(dlDir, "creator", "video.mp4.part")
+	os.WriteFile(partFile, []byte("partial"), 0644)
+	userUnzippedDir := filepath.Join(dlDir, "creator", constants.UGOIRA_UNZIPPED_FOLDER)
+	os.MkdirAll(userUnzippedDir, 0755)
+
+	unzippedDir := filepath.Join(uncachedDir, constants.UGOIRA_UNZIPPED_FOLDER)
+	os.MkdirAll(unzippedDir, 0755)
+	os.WriteFile(filepath.Join(uncachedDir, "118849707_ugoira600x600.zip"), []byte("zip"), 0644)
+
+	report, err := Reconcile(&ReconcileOptions{DownloadDirPath: dlDir})
+	if err != nil {
+		t.Fatalf("Failed to reconcile: %v", err)
+	}
+	var missingUrls []string
+	for _, post := range report.MissingPosts {
+		missingUrls = append(missingUrls, post.Url)
+	}
+	slices.Sort(missingUrls)
+	if !slices.Equal(missingUrls, []string{"https://fantia.jp/posts/123456", "https://www.pixiv.net/artworks/118849706"}) {
+		t.Errorf("Expected the Fantia post and the Pixiv artwork to be missing but got %q", missingUrls)
+	}
+	if len(report.MissingFiles) != 1 {
+		t.Errorf("Expected 1 missing file but got %d", len(report.MissingFiles))
+	}
+	if len(report.TruncatedFiles) != 1 || len(report.IncompletePosts) != 1 || report.IncompletePosts[0].Url != "https://fantia.jp/posts/654321" {
+		t.Errorf("Expected the Fantia post to have a truncated file but got %+v and %+v", report.TruncatedFiles, report.IncompletePosts)
+	}
+	if len(report.UncachedPosts) != 1 || report.UncachedPosts[0].PostId != "118849707" {
+		t.Errorf("Expected the Pixiv artwork to be uncached but got %+v", report.UncachedPosts)
+	}
+	if !slices.Equal(report.OrphanedUgoiraDirs, []string{unzippedDir}) {
+		t.Errorf("Expected only the unzipped folder next to the ugoira zip file to be orphaned but got %q", report.OrphanedUgoiraDirs)
+	}
+	if report.UnverifiedPosts != 5 {
+		t.Errorf("Expected 5 unverified posts but got %d", report.UnverifiedPosts)
+	}
+
+	if _, err := Reconcile(&ReconcileOptions{DownloadDirPath: dlDir, Repair: true}); err != nil {
+		t.Fatalf("Failed to repair: %v", err)
+	}
+	for _, url := range []string{"https://fantia.jp/posts/123456", "https://fantia.jp/posts/654321"} {
+		if PostCacheExists(url, constants.FANTIA) {
+			t.Errorf("Expected %s to be removed from the cache", url)
+		}
+	}
+	if PostCacheExists("https://www.pixiv.net/artworks/118849706", constants.PIXIV) {
+		t.Errorf("Expected the missing artwork to be removed from the cache")
+	}
+	if !PostCacheExists("https://www.pixiv.net/artworks/118849707", constants.PIXIV) {
+		t.Errorf("Expected the uncached post to be cached")
+	}
+	if FileManifestExists(missingFile) || FileManifestExists(truncatedFile) {
+		t.Errorf("Expected the missing and truncated files to be removed from the manifest")
+	}
+	if _, err := os.Stat(truncatedFile); !os.IsNotExist(err) {
+		t.Errorf("Expected the truncated file to be deleted")
+	}
+	if _, err := os.Stat(unzippedDir); !os.IsNotExist(err) {
+		t.Errorf("Expected the unzipped ugoira folder to be deleted")
+	}
+	for _, path := range []string{partFile, userUnzippedDir} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("Expected %s to be kept: %v", path, err)
+		}
+	}
+}
+
+func TestReconcileSessionDb(t *testing.T) {
+	initTestData(t)
+
+	db, err := NewDb(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create db: %v", err)
+	}
+	defer db.Close()
+
+	// Kemono posts of different creators can have the same post ID
+	dlDir := t.TempDir()
+	existingFile := filepath.Join(dlDir, "fanbox", "1.png")
+	os.MkdirAll(filepath.Dir(existingFile), 0755)
+	os.WriteFile(existingFile, []byte("hello"), 0644)
+	existingUrl := constants.KEMONO_API_URL + "/fanbox/user/123/post/1"
+	missingUrl := constants.KEMONO_API_URL + "/patreon/user/456/post/1"
+	db.CachePost(ParsePostKey(existingUrl, constants.KEMONO))
+	db.CachePost(ParsePostKey(missingUrl, constants.KEMONO))
+	db.SetFileManifests([]*FileManifest{
+		{
+			FileSource: FileSource{Site: constants.KEMONO, PostKey: ParsePostKey(existingUrl, constants.KEMONO)},
+			Path:       existingFile,
+			Size:       5,
+		},
+		{
+			FileSource: FileSource{Site: constants.KEMONO, PostKey: ParsePostKey(missingUrl, constants.KEMONO)},
+			Path:       filepath.Join(dlDir, "patreon", "1.png"),
+		},
+	})
+
+	// cached with Pixiv's mobile API and recorded in the manifest under the URL of the mobile API
+	mobileUrl := constants.PIXIV_MOBILE_ARTWORK_URL + "?illust_id=118849705"
+	artworkFile := filepath.Join(dlDir, "pixiv", "118849705_p0.png")
+	os.MkdirAll(filepath.Dir(artworkFile), 0755)
+	os.WriteFile(artworkFile, []byte("hello"), 0644)
+	db.CachePost(ParsePostKey(mobileUrl, constants.PIXIV))
+	db.SetFileManifests([]*FileManifest{{
+		FileSource: FileSource{Site: constants.PIXIV, PostKey: ParsePostKey(mobileUrl, constants.PIXIV)},
+		Path:       artworkFile,
+		Size:       5,
+	}})
+
+	// novel with a metadata file
+	novelDir := filepath.Join(dlDir, "novel")
+	os.MkdirAll(novelDir, 0755)
+	os.WriteFile(
+		filepath.Join(novelDir, constants.METADATA_FILENAME),
+		[]byte(`{"url":"https://www.pixiv.net/novel/show.php?id=20000000","title":"novel"}`),
+		0644,
+	)
+	db.CachePost(ParsePostKey("https://www.pixiv.net/novel/show.php?id=20000000", constants.PIXIV))
+
+	// unknown post URL which should be logged to the given logger
+	os.MkdirAll(filepath.Join(dlDir, "unknown"), 0755)
+	os.WriteFile(filepath.Join(dlDir, "unknown", constants.METADATA_FILENAME), []byte(`{"url":"https://example.com"}`), 0644)
+
+	var logs bytes.Buffer
+	l := logger.NewLogger(&logs)
+	report, err := db.Reconcile(&ReconcileOptions{DownloadDirPath: dlDir}, &l)
+	if err != nil {
+		t.Fatalf("Failed to reconcile: %v", err)
+	}
+	if len(report.MissingPosts) != 1 || report.MissingPosts[0].Url != missingUrl {
+		t.Errorf("Expected only the Kemono post without files to be missing but got %+v", report.MissingPosts)
+	}
+	if len(report.UncachedPosts) != 0 || report.UnverifiedPosts != 0 {
+		t.Errorf("Expected the posts to match the cache but got %+v and %d unverified posts", report.UncachedPosts, report.UnverifiedPosts)
+	}
+	if !bytes.Contains(logs.Bytes(), []byte("example.com")) {
+		t.Errorf("Expected the unknown post URL to be logged to the given logger but got %q", logs.String())
+	}
+
+	// the caches of the app's db should not be used
+	if _, err := db.Reconcile(&ReconcileOptions{DownloadDirPath: dlDir, Repair: true}, &l); err != nil {
+		t.Fatalf("Failed to repair: %v", err)
+	}
+	if db.PostCacheExists(missingUrl, constants.KEMONO) || !db.PostCacheExists(existingUrl, constants.KEMONO) {
+		t.Errorf("Expected only the missing Kemono post to be removed from the cache")
+	}
+	if !PostCacheExists("https://fantia.jp/posts/123456", constants.FANTIA) {
+		t.Errorf("Expected the app's db to be untouched")
+	}
+}
@@ -0,0 +1,485 @@
+package database
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	bolt "go.etcd.io/bbolt"
+	"golang.org/x/text/unicode/norm"
+
+	"github.com/KJHJason/Cultured-Downloader-Logic/cdlerrors"
+)
+
+// example of the Key-Value pairs in the database
+// |--------------------------------------|----------------|
+// | <absolute_metadata_filepath>         | SearchDoc JSON |
+// |--------------------------------------|----------------|
+// | <field>\x00<term>\x00<absolute_path> | (empty)        |
+// |--------------------------------------|----------------|
+// Where the first is in the SEARCH_DOC_BUCKET and the second is in the SEARCH_INDEX_BUCKET.
+
+const (
+	SEARCH_DOC_BUCKET   = "search_doc"
+	SEARCH_INDEX_BUCKET = "search_index"
+
+	SEARCH_FIELD_TITLE   = "title"
+	SEARCH_FIELD_TEXT    = "text"
+	SEARCH_FIELD_CREATOR = "creator"
+	SEARCH_FIELD_TAG     = "tag"
+
+	searchKeyDelim = "\x00"
+)
+
+// Fields that the free text of a SearchQuery is matched against
+var searchTextFields = []string{
+	SEARCH_FIELD_TITLE,
+	SEARCH_FIELD_TEXT,
+	SEARCH_FIELD_CREATOR,
+	SEARCH_FIELD_TAG,
+}
+
+// SearchDoc is a downloaded post in the search index.
+type SearchDoc struct {
+	Path      string    `json:"Path"` // absolute path of the post_metadata.json file
+	Site      string    `json:"Site"`
+	Url       string    `json:"Url"`
+	Title     string    `json:"Title"`
+	Text      string    `json:"Text"`
+	Creator   string    `json:"Creator"`
+	CreatorId string    `json:"CreatorId"`
+	Tags      []string  `json:"Tags"`
+	Date      time.Time `json:"Date"`
+}
+
+func (d *SearchDoc) fieldValues() map[string][]string {
+	return map[string][]string{
+		SEARCH_FIELD_TITLE:   {d.Title},
+		SEARCH_FIELD_TEXT:    {d.Text},
+		SEARCH_FIELD_CREATOR: {d.Creator, d.CreatorId},
+		SEARCH_FIELD_TAG:     d.Tags,
+	}
+}
+
+// Returns the unique index keys of the document
+func (d *SearchDoc) indexKeys() [][]byte {
+	var keys [][]byte
+	for field, values := range d.fieldValues() {
+		seen := make(map[string]struct{})
+		for _, value := range values {
+			for _, term := range tokenize(value) {
+				if _, ok := seen[term]; ok {
+					continue
+				}
+				seen[term] = struct{}{}
+				keys = append(keys, []byte(field+searchKeyDelim+term+searchKeyDelim+d.Path))
+			}
+		}
+	}
+	return keys
+}
+
+// Han, Hiragana, Katakana, and Hangul are not separated by spaces
+// so they are indexed as bigrams, e.g. "差分あり" => "差分", "分あ", "あり", and "り".
+func isCjk(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) || r == 'ー'
+}
+
+// tokenize normalises the text and splits it into the words of alphabetic scripts
+// and the bigrams of CJK scripts where the last character of a CJK run is also a term
+// so that a single character query can be matched with a prefix search.
+func tokenize(text string) []string {
+	var tokens []string
+	var word, cjk []rune
+	flushWord := func() {
+		if len(word) > 0 {
+			tokens = append(tokens, string(word))
+			word = word[:0]
+		}
+	}
+	flushCjk := func() {
+		for i := 0; i+1 < len(cjk); i++ {
+			tokens = append(tokens, string(cjk[i:i+2]))
+		}
+		if len(cjk) > 0 {
+			tokens = append(tokens, string(cjk[len(cjk)-1]))
+			cjk = cjk[:0]
+		}
+	}
+
+	for _, r := range strings.ToLower(norm.NFKC.String(text)) {
+		switch {
+		case isCjk(r):
+			flushWord()
+			cjk = append(cjk, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r):
+			flushCjk()
+			word = append(word, r)
+		default:
+			flushWord()
+			flushCjk()
+		}
+	}
+	flushWord()
+	flushCjk()
+	return tokens
+}
+
+func putSearchDoc(docBucket, indexBucket *bolt.Bucket, doc *SearchDoc) error {
+	if err := deleteSearchDoc(docBucket, indexBucket, doc.Path); err != nil {
+		return err
+	}
+
+	val, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	if err := docBucket.Put([]byte(doc.Path), val); err != nil {
+		return err
+	}
+	for _, key := range doc.indexKeys() {
+		if err := indexBucket.Put(key, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func deleteSearchDoc(docBucket, indexBucket *bolt.Bucket, path string) error {
+	val := docBucket.Get([]byte(path))
+	if val == nil {
+		return nil
+	}
+
+	var doc SearchDoc
+	if err := json.Unmarshal(val, &doc); err != nil {
+		return err
+	}
+	for _, key := range doc.indexKeys() {
+		if err := indexBucket.Delete(key); err != nil {
+			return err
+		}
+	}
+	return docBucket.Delete([]byte(path))
+}
+
+// IndexSearchDocs adds the documents to the search index in a single transaction
+// where documents with the same path will be replaced.
+func (db *DbWrapper) IndexSearchDocs(docs ...*SearchDoc) error {
+	if len(docs) == 0 {
+		return nil
+	}
+
+	err := db.Db.Update(func(tx *bolt.Tx) error {
+		return putSearchDocs(tx, docs)
+	})
+	if err != nil {
+		return fmt.Errorf(
+			"error %d: failed to index %d posts for searching, more info => %w",
+			cdlerrors.OS_ERROR,
+			len(docs),
+			err,
+		)
+	}
+	return nil
+}
+
+func putSearchDocs(tx *bolt.Tx, docs []*SearchDoc) error {
+	docBucket, err := tx.CreateBucketIfNotExists([]byte(SEARCH_DOC_BUCKET))
+	if err != nil {
+		return err
+	}
+	indexBucket, err := tx.CreateBucketIfNotExists([]byte(SEARCH_INDEX_BUCKET))
+	if err != nil {
+		return err
+	}
+
+	for _, doc := range docs {
+		if err := putSearchDoc(docBucket, indexBucket, doc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReplaceSearchIndex clears the search index and adds the documents in a single transaction
+// so that the previous index is kept if the documents cannot be indexed.
+func (db *DbWrapper) ReplaceSearchIndex(docs ...*SearchDoc) error {
+	err := db.Db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range []string{SEARCH_INDEX_BUCKET, SEARCH_DOC_BUCKET} {
+			if tx.Bucket([]byte(bucket)) == nil {
+				continue
+			}
+			if err := tx.DeleteBucket([]byte(bucket)); err != nil {
+				return err
+			}
+		}
+		return putSearchDocs(tx, docs)
+	})
+	if err != nil {
+		return fmt.Errorf(
+			"error %d: failed to replace the search index with %d posts, more info => %w",
+			cdlerrors.OS_ERROR,
+			len(docs),
+			err,
+		)
+	}
+	return nil
+}
+
+// DeleteSearchDoc removes the document with the metadata file path from the search index.
+func (db *DbWrapper) DeleteSearchDoc(path string) error {
+	return db.Db.Update(func(tx *bolt.Tx) error {
+		docBucket := tx.Bucket([]byte(SEARCH_DOC_BUCKET))
+		indexBucket := tx.Bucket([]byte(SEARCH_INDEX_BUCKET))
+		if docBucket == nil || indexBucket == nil {
+			return nil
+		}
+		return deleteSearchDoc(docBucket, indexBucket, path)
+	})
+}
+
+// ClearSearchIndex removes all the documents from the search index.
+func (db *DbWrapper) ClearSearchIndex() error {
+	if err := db.DeleteBucket(SEARCH_INDEX_BUCKET); err != nil {
+		return err
+	}
+	return db.DeleteBucket(SEARCH_DOC_BUCKET)
+}
+
+// SearchQuery matches the documents that satisfy all of the given conditions.
+type SearchQuery struct {
+	// Free text where every term must be in the title, text, creator, or tags.
+	// Terms ending with "*" are prefix matches, e.g. "sketch*".
+	Text string
+
+	// Terms that must be in the respective fields
+	Title   string
+	Creator string
+	Tags    []string
+
+	Sites []string
+
+	// Only matches the posts dated within the range, where either can be zero for an open range.
+	// Posts without a date are excluded if either is set.
+	Since time.Time
+	Until time.Time
+
+	Limit int // 0 for no limit
+}
+
+// ParseSearchQuery parses a query like `差分 tag:オリジナル creator:someone site:fantia since:2024-03-01 until:2024-05-31`.
+//
+// The supported filters are title, creator, tag, site, since, and until where the dates are in the YYYY-MM-DD format.
+// Values with spaces can be quoted, e.g. `title:"my title"`, and words with an unknown filter are treated as free text.
+func ParseSearchQuery(query string) (*SearchQuery, error) {
+	q := &SearchQuery{}
+	var text []string
+	for _, word := range splitSearchQuery(query) {
+		field, value, ok := strings.Cut(word, ":")
+		if !ok || value == "" {
+			text = append(text, strings.Trim(word, `"`))
+			continue
+		}
+
+		value = strings.Trim(value, `"`)
+		switch strings.ToLower(field) {
+		case SEARCH_FIELD_TITLE:
+			q.Title = strings.TrimSpace(q.Title + " " + value)
+		case SEARCH_FIELD_CREATOR:
+			q.Creator = strings.TrimSpace(q.Creator + " " + value)
+		case SEARCH_FIELD_TAG:
+			q.Tags = append(q.Tags, value)
+		case "site":
+			q.Sites = append(q.Sites, strings.ToLower(value))
+		case "since", "until":
+			date, err := time.ParseInLocation(time.DateOnly, value, time.Local)
+			if err != nil {
+				return nil, fmt.Errorf(
+					"error %d: invalid date %q in search query, expected YYYY-MM-DD",
+					cdlerrors.INPUT_ERROR,
+					value,
+				)
+			}
+			if strings.EqualFold(field, "since") {
+				q.Since = date
+			} else {
+				q.Until = date.AddDate(0, 0, 1).Add(-time.Nanosecond) // inclusive of the whole day
+			}
+		default:
+			text = append(text, strings.Trim(word, `"`))
+		}
+	}
+	q.Text = strings.Join(text, " ")
+	return q, nil
+}
+
+// Splits the query on spaces except for the spaces in double quotes
+func splitSearchQuery(query string) []string {
+	var words []string
+	var word strings.Builder
+	inQuote := false
+	for _, r := range query {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+			word.WriteRune(r)
+		case unicode.IsSpace(r) && !inQuote:
+			if word.Len() > 0 {
+				words = append(words, word.String())
+				word.Reset()
+			}
+		default:
+			word.WriteRune(r)
+		}
+	}
+	if word.Len() > 0 {
+		words = append(words, word.String())
+	}
+	return words
+}
+
+type searchTerm struct {
+	fields []string
+	term   string
+	prefix bool
+}
+
+// Returns the terms of the query that must all be matched
+func (q *SearchQuery) terms() []searchTerm {
+	var terms []searchTerm
+	add := func(text string, fields ...string) {
+		for _, word := range strings.Fields(text) {
+			prefix := strings.HasSuffix(word, "*")
+			tokens := tokenize(word)
+			for i, token := range tokens {
+				// single characters of a CJK run are prefix matches of the bigrams
+				isLast := i == len(tokens)-1
+				isCjkChar := len([]rune(token)) == 1 && isCjk([]rune(token)[0])
+				terms = append(terms, searchTerm{
+					fields: fields,
+					term:   token,
+					prefix: (prefix && isLast) || isCjkChar,
+				})
+			}
+		}
+	}
+
+	add(q.Text, searchTextFields...)
+	add(q.Title, SEARCH_FIELD_TITLE)
+	add(q.Creator, SEARCH_FIELD_CREATOR)
+	for _, tag := range q.Tags {
+		add(tag, SEARCH_FIELD_TAG)
+	}
+	return terms
+}
+
+// Returns the paths of the documents that match the term in any of its fields
+func (t searchTerm) matches(indexBucket *bolt.Bucket) map[string]struct{} {
+	paths := make(map[string]struct{})
+	for _, field := range t.fields {
+		prefix := field + searchKeyDelim + t.term
+		if !t.prefix {
+			prefix += searchKeyDelim
+		}
+		iterateOnPrefix(indexBucket, prefix, func(k, _ []byte) error {
+			if idx := bytes.LastIndex(k, []byte(searchKeyDelim)); idx != -1 {
+				paths[string(k[idx+1:])] = struct{}{}
+			}
+			return nil
+		})
+	}
+	return paths
+}
+
+func (q *SearchQuery) includes(doc *SearchDoc) bool {
+	if len(q.Sites) > 0 && !slices.Contains(q.Sites, doc.Site) {
+		return false
+	}
+	if q.Since.IsZero() && q.Until.IsZero() {
+		return true
+	}
+	if doc.Date.IsZero() {
+		return false
+	}
+	if !q.Since.IsZero() && doc.Date.Before(q.Since) {
+		return false
+	}
+	return q.Until.IsZero() || !doc.Date.After(q.Until)
+}
+
+// Search returns the documents that match the query sorted from the newest to the oldest.
+func (db *DbWrapper) Search(q *SearchQuery) ([]*SearchDoc, error) {
+	var docs []*SearchDoc
+	err := db.Db.View(func(tx *bolt.Tx) error {
+		docBucket := tx.Bucket([]byte(SEARCH_DOC_BUCKET))
+		indexBucket := tx.Bucket([]byte(SEARCH_INDEX_BUCKET))
+		if docBucket == nil || indexBucket == nil {
+			return nil
+		}
+
+		var paths map[string]struct{}
+		for _, term := range q.terms() {
+			matched := term.matches(indexBucket)
+			if paths == nil {
+				paths = matched
+			} else {
+				for path := range paths {
+					if _, ok := matched[path]; !ok {
+						delete(paths, path)
+					}
+				}
+			}
+			if len(paths) == 0 {
+				return nil
+			}
+		}
+
+		addDoc := func(val []byte) error {
+			var doc SearchDoc
+			if err := json.Unmarshal(val, &doc); err != nil {
+				return err
+			}
+			if q.includes(&doc) {
+				docs = append(docs, &doc)
+			}
+			return nil
+		}
+		if paths == nil { // no terms, only filters
+			return docBucket.ForEach(func(_, v []byte) error {
+				return addDoc(v)
+			})
+		}
+		for path := range paths {
+			if val := docBucket.Get([]byte(path)); val != nil {
+				if err := addDoc(val); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf(
+			"error %d: failed to search the index, more info => %w",
+			cdlerrors.OS_ERROR,
+			err,
+		)
+	}
+
+	sort.Slice(docs, func(i, j int) bool {
+		if !docs[i].Date.Equal(docs[j].Date) {
+			return docs[i].Date.After(docs[j].Date)
+		}
+		return docs[i].Path < docs[j].Path
+	})
+	if q.Limit > 0 && len(docs) > q.Limit {
+		docs = docs[:q.Limit]
+	}
+	return docs, nil
+}
@@ -0,0 +1,125 @@
+package database
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/KJHJason/Cultured-Downloader-Logic/constants"
+)
+
+func TestTokenize(t *testing.T) {
+	tokens := tokenize("Hello, ＷＯＲＬＤ! 差分あり v2")
+	expected := []string{"hello", "world", "差分", "分あ", "あり", "り", "v2"}
+	if !slices.Equal(tokens, expected) {
+		t.Errorf("Expected %v but got %v", expected, tokens)
+	}
+}
+
+func TestParseSearchQuery(t *testing.T) {
+	q, err := ParseSearchQuery(`sketch tag:オリジナル title:"new year" site:Fantia since:2024-03-01 until:2024-05-31`)
+	if err != nil {
+		t.Fatalf("Failed to parse query: %v", err)
+	}
+	if q.Text != "sketch" || q.Title != "new year" || !slices.Equal(q.Tags, []string{"オリジナル"}) || !slices.Equal(q.Sites, []string{"fantia"}) {
+		t.Errorf("Unexpected query %+v", q)
+	}
+	if !q.Since.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local)) || !q.Until.Before(time.Date(2024, 6, 1, 0, 0, 0, 0, time.Local)) || q.Until.Day() != 31 {
+		t.Errorf("Unexpected date range %v - %v", q.Since, q.Until)
+	}
+
+	if _, err := ParseSearchQuery("since:yesterday"); err == nil {
+		t.Errorf("Expected an error for an invalid date")
+	}
+}
+
+func TestSearch(t *testing.T) {
+	initTestData(t)
+
+	docs := []*SearchDoc{
+		{
+			Path:    "/downloads/fantia/1/post_metadata.json",
+			Site:    constants.FANTIA,
+			Title:   "New Year Sketch",
+			Text:    "差分あり",
+			Creator: "Some Artist",
+			Tags:    []string{"オリジナル"},
+			Date:    time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			Path:    "/downloads/pixiv/2/post_metadata.json",
+			Site:    constants.PIXIV,
+			Title:   "Sketches",
+			Creator: "Another Artist",
+			Tags:    []string{"fanart"},
+			Date:    time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			Path:  "/downloads/fantia/3/post_metadata.json",
+			Site:  constants.FANTIA,
+			Title: "Undated Sketch",
+		},
+	}
+	if err := AppDb.IndexSearchDocs(docs...); err != nil {
+		t.Fatalf("Failed to index docs: %v", err)
+	}
+
+	search := func(query string) []string {
+		q, err := ParseSearchQuery(query)
+		if err != nil {
+			t.Fatalf("Failed to parse query %q: %v", query, err)
+		}
+		results, err := AppDb.Search(q)
+		if err != nil {
+			t.Fatalf("Failed to search %q: %v", query, err)
+		}
+		paths := make([]string, 0, len(results))
+		for _, doc := range results {
+			paths = append(paths, doc.Path)
+		}
+		return paths
+	}
+	testCases := []struct {
+		query    string
+		expected []string
+	}{
+		{"sketch", []string{docs[0].Path, docs[2].Path}},
+		{"sketch*", []string{docs[1].Path, docs[0].Path, docs[2].Path}},
+		{"差分", []string{docs[0].Path}},
+		{"差", []string{docs[0].Path}},
+		{"creator:artist", []string{docs[1].Path, docs[0].Path}},
+		{"artist tag:fanart", []string{docs[1].Path}},
+		{"sketch* site:fantia", []string{docs[0].Path, docs[2].Path}},
+		{"sketch* since:2024-03-01", []string{docs[1].Path}},
+		{"site:pixiv", []string{docs[1].Path}},
+		{"title:差分", nil},
+	}
+	for _, tc := range testCases {
+		if got := search(tc.query); !slices.Equal(got, tc.expected) {
+			t.Errorf("Query %q: expected %v but got %v", tc.query, tc.expected, got)
+		}
+	}
+
+	// re-indexing the same path replaces the old terms
+	docs[0].Title = "Spring"
+	if err := AppDb.IndexSearchDocs(docs[0]); err != nil {
+		t.Fatalf("Failed to re-index doc: %v", err)
+	}
+	if got := search("sketch"); !slices.Equal(got, []string{docs[2].Path}) {
+		t.Errorf("Expected the old title to be removed but got %v", got)
+	}
+
+	if err := AppDb.DeleteSearchDoc(docs[2].Path); err != nil {
+		t.Fatalf("Failed to delete doc: %v", err)
+	}
+	if got := search("sketch"); len(got) != 0 {
+		t.Errorf("Expected the deleted doc to be removed but got %v", got)
+	}
+
+	if err := AppDb.ReplaceSearchIndex(docs[2]); err != nil {
+		t.Fatalf("Failed to replace the search index: %v", err)
+	}
+	if got := search("sketch*"); !slices.Equal(got, []string{docs[2].Path}) {
+		t.Errorf("Expected only the new doc to be indexed after replacing the index but got %v", got)
+	}
+}
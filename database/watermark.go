@@ -0,0 +1,82 @@
+package database
+
+import (
+	"strconv"
+	"time"
+)
+
+// example of the Key-Value pairs in the database
+// |--------------------|------------------------|
+// | <platform>|<creator> | CreatorWatermark JSON |
+// |--------------------|------------------------|
+// Where <creator> is the creator ID which may be prefixed with the type of content, e.g. "posts/123".
+
+const (
+	WATERMARK_BUCKET = "sync_watermark"
+)
+
+// CreatorWatermark is the newest post of a creator that was
+// downloaded so that the next incremental sync can stop there.
+type CreatorWatermark struct {
+	PostId   string    `json:"PostId"`
+	Date     time.Time `json:"Date"`
+	SyncedAt time.Time `json:"SyncedAt"`
+}
+
+// IsNewer returns true if the given post is newer than the watermark.
+//
+// The post date is used if both dates are known and different,
+// otherwise, the post IDs are compared numerically.
+// If the post cannot be compared, it is assumed to be newer.
+func (w *CreatorWatermark) IsNewer(postId string, date time.Time) bool {
+	if postId == w.PostId {
+		return false
+	}
+	if !date.IsZero() && !w.Date.IsZero() && !date.Equal(w.Date) {
+		return date.After(w.Date)
+	}
+
+	id, err := strconv.ParseInt(postId, 10, 64)
+	if err != nil {
+		return true
+	}
+	watermarkId, err := strconv.ParseInt(w.PostId, 10, 64)
+	if err != nil {
+		return true
+	}
+	return id > watermarkId
+}
+
+// Returns nil if there is no watermark for the creator
+func GetCreatorWatermark(creatorKey, platform string) *CreatorWatermark {
+	return AppDb.GetCreatorWatermark(creatorKey, platform)
+}
+
+func (db *DbWrapper) GetCreatorWatermark(creatorKey, platform string) *CreatorWatermark {
+	var watermark CreatorWatermark
+	if err := db.GetJson(WATERMARK_BUCKET, ParsePostKey(creatorKey, platform), &watermark); err != nil {
+		return nil
+	}
+	return &watermark
+}
+
+func SetCreatorWatermark(creatorKey, platform string, watermark *CreatorWatermark) error {
+	return AppDb.SetCreatorWatermark(creatorKey, platform, watermark)
+}
+
+func (db *DbWrapper) SetCreatorWatermark(creatorKey, platform string, watermark *CreatorWatermark) error {
+	return db.SetJson(WATERMARK_BUCKET, ParsePostKey(creatorKey, platform), watermark)
+}
+
+// DeleteCreatorWatermark removes the watermark so that the next sync of the creator will be a full sync.
+func DeleteCreatorWatermark(creatorKey, platform string) error {
+	return AppDb.Delete(WATERMARK_BUCKET, ParsePostKey(creatorKey, platform))
+}
+
+func GetAllWatermarks() ([]*KeyValue, error) {
+	return AppDb.GetAllKeyValue(WATERMARK_BUCKET)
+}
+
+func DeleteAllWatermarks() error {
+	return AppDb.DeleteBucket(WATERMARK_BUCKET)
+}
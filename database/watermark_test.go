@@ -0,0 +1,62 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/KJHJason/Cultured-Downloader-Logic/constants"
+)
+
+func TestWatermarkIsNewer(t *testing.T) {
+	date := time.Date(2024, 5, 12, 0, 0, 0, 0, time.UTC)
+	watermark := &CreatorWatermark{PostId: "100", Date: date}
+
+	testCases := []struct {
+		postId   string
+		date     time.Time
+		expected bool
+	}{
+		{"100", date.Add(time.Hour), false}, // same post
+		{"99", date.Add(time.Hour), true},   // date takes precedence over the ID
+		{"101", date.Add(-time.Hour), false},
+		{"101", time.Time{}, true}, // falls back to the ID
+		{"99", time.Time{}, false},
+		{"101", date, true}, // same date, falls back to the ID
+		{"abc", time.Time{}, true},
+	}
+	for _, tc := range testCases {
+		if got := watermark.IsNewer(tc.postId, tc.date); got != tc.expected {
+			t.Errorf("Expected %v for post %s at %v but got %v", tc.expected, tc.postId, tc.date, got)
+		}
+	}
+}
+
+func TestCreatorWatermark(t *testing.T) {
+	if err := InitAppDb(); err != nil {
+		t.Fatalf("Failed to initialise cache db: %v", err)
+	}
+	DeleteAllWatermarks()
+
+	if GetCreatorWatermark("creator", constants.PIXIV_FANBOX) != nil {
+		t.Fatal("Expected no watermark for a new creator")
+	}
+
+	watermark := &CreatorWatermark{PostId: "123", Date: time.Unix(1700000000, 0), SyncedAt: time.Now()}
+	if err := SetCreatorWatermark("creator", constants.PIXIV_FANBOX, watermark); err != nil {
+		t.Fatalf("Failed to set watermark: %v", err)
+	}
+	got := GetCreatorWatermark("creator", constants.PIXIV_FANBOX)
+	if got == nil || got.PostId != "123" || !got.Date.Equal(watermark.Date) {
+		t.Fatalf("Expected %+v but got %+v", watermark, got)
+	}
+	if GetCreatorWatermark("creator", constants.FANTIA) != nil {
+		t.Error("Expected watermarks to be separated by platform")
+	}
+
+	if err := DeleteCreatorWatermark("creator", constants.PIXIV_FANBOX); err != nil {
+		t.Fatalf("Failed to delete watermark: %v", err)
+	}
+	if GetCreatorWatermark("creator", constants.PIXIV_FANBOX) != nil {
+		t.Error("Expected watermark to be deleted")
+	}
+}
@@ -60,7 +60,11 @@ func FantiaDownloadProcess(fantiaDl *fantia.FantiaDl, fantiaDlOptions *fantia.Fa
 				SupportRange:    constants.FANTIA_RANGE_SUPPORTED,
 				HeadReqTimeout:  constants.DEFAULT_HEAD_REQ_TIMEOUT,
 				Filters:         fantiaDlOptions.Base.Filters,
+				Sanitizer:       fantiaDlOptions.Base.Sanitizer,
 				ProgressBarInfo: fantiaDlOptions.Base.ProgressBarInfo,
+				Db:              fantiaDlOptions.Base.Session.GetDb(),
+				Logger:          fantiaDlOptions.Base.Session.GetLogger(),
+				Transports:      fantiaDlOptions.Base.Session.GetTransports(),
 			},
 			fantiaDlOptions.Base.Configs,
 		)
@@ -82,6 +86,7 @@ func FantiaDownloadProcess(fantiaDl *fantia.FantiaDl, fantiaDlOptions *fantia.Fa
 		downloadedPosts = true
 	}
 
+	errorSlice = commitSyncWatermarks(fantiaDlOptions.Base, fantiaDlOptions.CtxIsActive(), errorSlice)
 	notifier := fantiaDlOptions.GetNotifier()
 	if downloadedPosts {
 		notifier.Alert("Downloaded all posts from Fantia!")
@@ -0,0 +1,93 @@
+package filters
+
+import (
+	"errors"
+	"image"
+	"os"
+
+	// register the decoders used by image.DecodeConfig
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+// Note: Width, height, and aspect ratio filters are only applied to images.
+// A value of 0 means that there is no limit.
+type ImageDimensionFilters struct {
+	MinWidth  int
+	MaxWidth  int
+	MinHeight int
+	MaxHeight int
+
+	// Aspect ratio is width / height, e.g. 1.5 for a 3:2 landscape image.
+	// Set MinAspectRatio to 1 to only download landscape or square images.
+	MinAspectRatio float64
+	MaxAspectRatio float64
+}
+
+func (d *ImageDimensionFilters) validateArgs() error {
+	if d.MinWidth < 0 || d.MaxWidth < 0 || d.MinHeight < 0 || d.MaxHeight < 0 {
+		return errors.New("image width and height cannot be negative")
+	}
+	if d.MaxWidth != 0 && d.MinWidth > d.MaxWidth {
+		return errors.New("min image width cannot be greater than max image width")
+	}
+	if d.MaxHeight != 0 && d.MinHeight > d.MaxHeight {
+		return errors.New("min image height cannot be greater than max image height")
+	}
+	if d.MinAspectRatio < 0 || d.MaxAspectRatio < 0 {
+		return errors.New("image aspect ratio cannot be negative")
+	}
+	if d.MaxAspectRatio != 0 && d.MinAspectRatio > d.MaxAspectRatio {
+		return errors.New("min image aspect ratio cannot be greater than max image aspect ratio")
+	}
+	return nil
+}
+
+// HasImageDimensionFilter returns true if any of the image dimension filters are set.
+func (d *ImageDimensionFilters) HasImageDimensionFilter() bool {
+	return *d != ImageDimensionFilters{}
+}
+
+// IsImageDimensionValid returns false if the image is outside the dimension filters.
+//
+// Returns true if the width or height is unknown, i.e. 0 or less.
+func (d *ImageDimensionFilters) IsImageDimensionValid(width, height int) bool {
+	if width <= 0 || height <= 0 {
+		return true
+	}
+	if width < d.MinWidth || (d.MaxWidth != 0 && width > d.MaxWidth) {
+		return false
+	}
+	if height < d.MinHeight || (d.MaxHeight != 0 && height > d.MaxHeight) {
+		return false
+	}
+
+	aspectRatio := float64(width) / float64(height)
+	if aspectRatio < d.MinAspectRatio || (d.MaxAspectRatio != 0 && aspectRatio > d.MaxAspectRatio) {
+		return false
+	}
+	return true
+}
+
+// IsImageFileDimensionValid decodes the header of the image file to check its dimensions.
+//
+// Returns true if the file is not a JPEG, PNG, or GIF image
+// as the dimensions cannot be determined without other decoders.
+func (d *ImageDimensionFilters) IsImageFileDimensionValid(filePath string) bool {
+	if !d.HasImageDimensionFilter() {
+		return true
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return true
+	}
+	defer file.Close()
+
+	config, _, err := image.DecodeConfig(file)
+	if err != nil {
+		return true
+	}
+	return d.IsImageDimensionValid(config.Width, config.Height)
+}
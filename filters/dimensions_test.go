@@ -0,0 +1,72 @@
+package filters
+
+import (
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestImageDimensionFilters(t *testing.T) {
+	d := &ImageDimensionFilters{MinWidth: 1000, MinAspectRatio: 1}
+	if err := d.validateArgs(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	testCases := []struct {
+		width, height int
+		expected      bool
+	}{
+		{1920, 1080, true},
+		{1000, 1000, true},
+		{800, 600, false},   // too narrow
+		{1080, 1920, false}, // portrait
+		{0, 0, true},        // unknown dimensions
+	}
+	for _, tc := range testCases {
+		if got := d.IsImageDimensionValid(tc.width, tc.height); got != tc.expected {
+			t.Errorf("Expected %v for %dx%d but got %v", tc.expected, tc.width, tc.height, got)
+		}
+	}
+
+	if (&ImageDimensionFilters{}).HasImageDimensionFilter() {
+		t.Error("Expected no dimension filter by default")
+	}
+	for _, invalid := range []*ImageDimensionFilters{
+		{MinWidth: -1},
+		{MinHeight: 100, MaxHeight: 50},
+		{MinAspectRatio: 2, MaxAspectRatio: 1},
+	} {
+		if err := invalid.validateArgs(); err == nil {
+			t.Errorf("Expected error for %+v", invalid)
+		}
+	}
+}
+
+func TestImageFileDimensionValid(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "image.png")
+	file, err := os.Create(filePath)
+	if err != nil {
+		t.Fatalf("Error creating file: %v", err)
+	}
+	if err := png.Encode(file, image.NewRGBA(image.Rect(0, 0, 40, 20))); err != nil {
+		t.Fatalf("Error encoding image: %v", err)
+	}
+	file.Close()
+
+	if !(&ImageDimensionFilters{MaxAspectRatio: 2}).IsImageFileDimensionValid(filePath) {
+		t.Error("Expected image to be within the aspect ratio limit")
+	}
+	if (&ImageDimensionFilters{MinHeight: 30}).IsImageFileDimensionValid(filePath) {
+		t.Error("Expected image to be below the min height")
+	}
+
+	notImagePath := filepath.Join(t.TempDir(), "file.txt")
+	if err := os.WriteFile(notImagePath, []byte("not an image"), 0644); err != nil {
+		t.Fatalf("Error writing file: %v", err)
+	}
+	if !(&ImageDimensionFilters{MinHeight: 30}).IsImageFileDimensionValid(notImagePath) {
+		t.Error("Expected non-image files to be valid")
+	}
+}
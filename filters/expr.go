@@ -0,0 +1,698 @@
+package filters
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/KJHJason/Cultured-Downloader-Logic/cdlerrors"
+)
+
+// Fields that can be used in a filter expression.
+//
+// Post level fields: title, tag, fee, date, type
+// File level fields: name, ext, size, mime
+const (
+	EXPR_TITLE = "title"
+	EXPR_TAG   = "tag"
+	EXPR_FEE   = "fee"
+	EXPR_DATE  = "date"
+	EXPR_TYPE  = "type"
+	EXPR_NAME  = "name"
+	EXPR_EXT   = "ext"
+	EXPR_SIZE  = "size"
+	EXPR_MIME  = "mime"
+
+	UNKNOWN_FEE  = -1
+	UNKNOWN_SIZE = -1
+)
+
+type exprValueType int
+
+const (
+	exprStringType exprValueType = iota
+	exprStringListType
+	exprIntType
+	exprSizeType
+	exprDateType
+)
+
+var exprFieldTypes = map[string]exprValueType{
+	EXPR_TITLE: exprStringType,
+	EXPR_TAG:   exprStringListType,
+	EXPR_FEE:   exprIntType,
+	EXPR_DATE:  exprDateType,
+	EXPR_TYPE:  exprStringType,
+	EXPR_NAME:  exprStringType,
+	EXPR_EXT:   exprStringType,
+	EXPR_SIZE:  exprSizeType,
+	EXPR_MIME:  exprStringType,
+}
+
+// PostInfo contains the post details used to evaluate the post level fields of a filter expression.
+type PostInfo struct {
+	Title string
+	Tags  []string  // nil if unknown
+	Fee   int       // UNKNOWN_FEE if unknown
+	Date  time.Time // zero value if unknown
+	Type  string    // empty if unknown
+}
+
+// FileInfo contains the file details used to evaluate the file level fields of a filter expression.
+type FileInfo struct {
+	Name string
+	Ext  string // with the leading dot
+	Size int64  // UNKNOWN_SIZE if unknown
+	Mime string // empty if unknown
+}
+
+// Results of evaluating an expression.
+//
+// Since post and file details are evaluated at different times,
+// comparisons using fields that are not available yet are unknown.
+type exprResult int
+
+const (
+	exprFalse exprResult = iota
+	exprTrue
+	exprUnknown
+)
+
+func toExprResult(b bool) exprResult {
+	if b {
+		return exprTrue
+	}
+	return exprFalse
+}
+
+type exprNode interface {
+	eval(post *PostInfo, file *FileInfo) exprResult
+}
+
+type exprAnd struct{ left, right exprNode }
+type exprOr struct{ left, right exprNode }
+type exprNot struct{ node exprNode }
+
+func (n *exprAnd) eval(post *PostInfo, file *FileInfo) exprResult {
+	left := n.left.eval(post, file)
+	if left == exprFalse {
+		return exprFalse
+	}
+	right := n.right.eval(post, file)
+	if right == exprFalse {
+		return exprFalse
+	}
+	if left == exprUnknown || right == exprUnknown {
+		return exprUnknown
+	}
+	return exprTrue
+}
+
+func (n *exprOr) eval(post *PostInfo, file *FileInfo) exprResult {
+	left := n.left.eval(post, file)
+	if left == exprTrue {
+		return exprTrue
+	}
+	right := n.right.eval(post, file)
+	if right == exprTrue {
+		return exprTrue
+	}
+	if left == exprUnknown || right == exprUnknown {
+		return exprUnknown
+	}
+	return exprFalse
+}
+
+func (n *exprNot) eval(post *PostInfo, file *FileInfo) exprResult {
+	switch n.node.eval(post, file) {
+	case exprTrue:
+		return exprFalse
+	case exprFalse:
+		return exprTrue
+	default:
+		return exprUnknown
+	}
+}
+
+type exprComparison struct {
+	field string
+	op    string
+
+	strVals  []string // lowercased
+	regex    *regexp.Regexp
+	num      int64
+	date     time.Time
+	dateOnly bool // true if the date has no time
+}
+
+// Returns the string values of the field and whether the value is known
+func (c *exprComparison) getStrings(post *PostInfo, file *FileInfo) ([]string, bool) {
+	switch c.field {
+	case EXPR_TITLE:
+		if post == nil {
+			return nil, false
+		}
+		return []string{post.Title}, true
+	case EXPR_TYPE:
+		if post == nil || post.Type == "" {
+			return nil, false
+		}
+		return []string{post.Type}, true
+	case EXPR_TAG:
+		if post == nil || post.Tags == nil {
+			return nil, false
+		}
+		return post.Tags, true
+	case EXPR_NAME:
+		if file == nil {
+			return nil, false
+		}
+		return []string{file.Name}, true
+	case EXPR_EXT:
+		if file == nil {
+			return nil, false
+		}
+		return []string{file.Ext}, true
+	case EXPR_MIME:
+		if file == nil || file.Mime == "" {
+			return nil, false
+		}
+		mime, _, _ := strings.Cut(file.Mime, ";")
+		return []string{strings.TrimSpace(mime)}, true
+	default:
+		return nil, false
+	}
+}
+
+func compareNum(op string, a, b int64) bool {
+	switch op {
+	case "==":
+		return a == b
+	case "!=":
+		return a != b
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	default:
+		return false
+	}
+}
+
+func (c *exprComparison) eval(post *PostInfo, file *FileInfo) exprResult {
+	switch c.field {
+	case EXPR_FEE:
+		if post == nil || post.Fee == UNKNOWN_FEE {
+			return exprUnknown
+		}
+		return toExprResult(compareNum(c.op, int64(post.Fee), c.num))
+	case EXPR_SIZE:
+		if file == nil || file.Size == UNKNOWN_SIZE {
+			return exprUnknown
+		}
+		return toExprResult(compareNum(c.op, file.Size, c.num))
+	case EXPR_DATE:
+		if post == nil || post.Date.IsZero() {
+			return exprUnknown
+		}
+		// dates without a time are compared by the calendar day
+		// so that "date == 2024-01-31" matches any time on that day
+		postDate := post.Date
+		if c.dateOnly {
+			postDate = truncateToDay(postDate)
+		}
+		return toExprResult(compareNum(c.op, postDate.Unix(), c.date.Unix()))
+	}
+
+	values, ok := c.getStrings(post, file)
+	if !ok {
+		return exprUnknown
+	}
+
+	// For tags, the comparison is true if any of the tags matches.
+	matched := false
+	for _, value := range values {
+		if c.field == EXPR_EXT {
+			value = "." + strings.TrimPrefix(value, ".")
+		}
+		value = strings.ToLower(value)
+		switch c.op {
+		case "==", "!=", "has":
+			matched = value == c.strVals[0]
+		case "in":
+			for _, strVal := range c.strVals {
+				if value == strVal {
+					matched = true
+					break
+				}
+			}
+		case "~", "!~":
+			matched = c.regex.MatchString(value)
+		}
+		if matched {
+			break
+		}
+	}
+	if c.op == "!=" || c.op == "!~" {
+		matched = !matched
+	}
+	return toExprResult(matched)
+}
+
+// Expression is a parsed filter expression like
+// `(ext in [.psd, .clip] or size > 50MB) and not title ~ "sample"`.
+//
+// Supported operators:
+//   - and, or, not, and parentheses for grouping
+//   - ==, !=, <, <=, >, >= for fee, size and date
+//   - ==, !=, ~ (regex), !~, in [a, b] for title, type, name, ext and mime
+//   - has, ~, !~, in [a, b] for tag where it matches if any of the post's tags matches
+//
+// String comparisons are case-insensitive and dates without a time
+// like 2024-01-31 are compared by the calendar day in the local time zone.
+type Expression struct {
+	raw  string
+	root exprNode
+}
+
+func (e *Expression) String() string {
+	return e.raw
+}
+
+// IsPostValid returns false if the post details alone fails the expression.
+//
+// Comparisons using file level fields are ignored.
+func (e *Expression) IsPostValid(post *PostInfo) bool {
+	return e.root.eval(post, nil) != exprFalse
+}
+
+// IsFileValid returns false if the file fails the expression.
+//
+// post can be nil if the post details are not available.
+func (e *Expression) IsFileValid(post *PostInfo, file *FileInfo) bool {
+	return e.root.eval(post, file) != exprFalse
+}
+
+type exprToken struct {
+	value  string
+	quoted bool
+	pos    int
+}
+
+const exprSymbols = "()[],"
+
+func tokeniseExpr(expr string) ([]exprToken, error) {
+	var tokens []exprToken
+	runes := []rune(expr)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case strings.ContainsRune(exprSymbols, r):
+			tokens = append(tokens, exprToken{value: string(r), pos: i})
+			i++
+		case r == '"' || r == '\'':
+			var sb strings.Builder
+			start := i
+			i++
+			for i < len(runes) && runes[i] != r {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				sb.WriteRune(runes[i])
+				i++
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("unclosed quote at position %d", start)
+			}
+			i++ // skip the closing quote
+			tokens = append(tokens, exprToken{value: sb.String(), quoted: true, pos: start})
+		case strings.ContainsRune("=!<>~", r):
+			start := i
+			i++
+			if i < len(runes) && (runes[i] == '=' || (r == '!' && runes[i] == '~')) {
+				i++
+			}
+			tokens = append(tokens, exprToken{value: string(runes[start:i]), pos: start})
+		default:
+			start := i
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && !strings.ContainsRune(exprSymbols+"\"'=!<>~", runes[i]) {
+				i++
+			}
+			tokens = append(tokens, exprToken{value: string(runes[start:i]), pos: start})
+		}
+	}
+	return tokens, nil
+}
+
+type exprParser struct {
+	tokens []exprToken
+	pos    int
+}
+
+func (p *exprParser) peek() *exprToken {
+	if p.pos >= len(p.tokens) {
+		return nil
+	}
+	return &p.tokens[p.pos]
+}
+
+// Returns true if the next token is the given unquoted keyword or symbol
+func (p *exprParser) isNext(value string) bool {
+	token := p.peek()
+	return token != nil && !token.quoted && strings.EqualFold(token.value, value)
+}
+
+func (p *exprParser) next() (*exprToken, error) {
+	token := p.peek()
+	if token == nil {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	p.pos++
+	return token, nil
+}
+
+func (p *exprParser) expect(value string) error {
+	token, err := p.next()
+	if err != nil {
+		return fmt.Errorf("expected %q but reached the end of expression", value)
+	}
+	if token.quoted || token.value != value {
+		return fmt.Errorf("expected %q at position %d but got %q", value, token.pos, token.value)
+	}
+	return nil
+}
+
+func (p *exprParser) parseOr() (exprNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isNext("or") {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &exprOr{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseAnd() (exprNode, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.isNext("and") {
+		p.pos++
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &exprAnd{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseNot() (exprNode, error) {
+	if p.isNext("not") {
+		p.pos++
+		node, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &exprNot{node: node}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *exprParser) parsePrimary() (exprNode, error) {
+	if p.isNext("(") {
+		p.pos++
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return node, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *exprParser) parseList() ([]string, error) {
+	if err := p.expect("["); err != nil {
+		return nil, err
+	}
+
+	var values []string
+	for {
+		token, err := p.next()
+		if err != nil {
+			return nil, fmt.Errorf("unclosed list")
+		}
+		if !token.quoted && token.value == "]" {
+			break
+		}
+		if !token.quoted && token.value == "," {
+			continue
+		}
+		if !token.quoted && strings.ContainsAny(token.value, exprSymbols) {
+			return nil, fmt.Errorf("unexpected %q in list at position %d", token.value, token.pos)
+		}
+		values = append(values, token.value)
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("list cannot be empty")
+	}
+	return values, nil
+}
+
+var validExprOps = map[exprValueType][]string{
+	exprStringType:     {"==", "!=", "~", "!~", "in"},
+	exprStringListType: {"has", "~", "!~", "in"},
+	exprIntType:        {"==", "!=", "<", "<=", ">", ">="},
+	exprSizeType:       {"==", "!=", "<", "<=", ">", ">="},
+	exprDateType:       {"==", "!=", "<", "<=", ">", ">="},
+}
+
+func (p *exprParser) parseComparison() (exprNode, error) {
+	fieldToken, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	field := strings.ToLower(fieldToken.value)
+	if field == "tags" {
+		field = EXPR_TAG
+	}
+	fieldType, ok := exprFieldTypes[field]
+	if fieldToken.quoted || !ok {
+		return nil, fmt.Errorf("unknown field %q at position %d", fieldToken.value, fieldToken.pos)
+	}
+
+	opToken, err := p.next()
+	if err != nil {
+		return nil, fmt.Errorf("expected an operator after %q", fieldToken.value)
+	}
+	op := strings.ToLower(opToken.value)
+	isValidOp := false
+	for _, validOp := range validExprOps[fieldType] {
+		if op == validOp {
+			isValidOp = true
+			break
+		}
+	}
+	if opToken.quoted || !isValidOp {
+		return nil, fmt.Errorf(
+			"invalid operator %q for field %q at position %d, valid operators are %s",
+			opToken.value,
+			field,
+			opToken.pos,
+			strings.Join(validExprOps[fieldType], ", "),
+		)
+	}
+
+	comparison := &exprComparison{field: field, op: op}
+	if op == "in" {
+		values, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+		for _, value := range values {
+			comparison.strVals = append(comparison.strVals, normaliseExprStr(field, value))
+		}
+		return comparison, nil
+	}
+
+	valueToken, err := p.next()
+	if err != nil {
+		return nil, fmt.Errorf("expected a value after %q", opToken.value)
+	}
+	value := valueToken.value
+	if !valueToken.quoted && strings.ContainsAny(value, exprSymbols) {
+		return nil, fmt.Errorf("expected a value at position %d but got %q", valueToken.pos, value)
+	}
+
+	switch {
+	case op == "~" || op == "!~":
+		regex, err := regexp.Compile("(?i)" + value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex %q, more info => %w", value, err)
+		}
+		comparison.regex = regex
+	case fieldType == exprIntType:
+		num, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q for field %q", value, field)
+		}
+		comparison.num = num
+	case fieldType == exprSizeType:
+		// the unit may be separated from the number by whitespace like "1.5 GB"
+		if unitToken := p.peek(); !valueToken.quoted && unitToken != nil && !unitToken.quoted && isFileSizeUnit(unitToken.value) {
+			value += unitToken.value
+			p.pos++
+		}
+		size, err := ParseFileSize(value)
+		if err != nil {
+			return nil, err
+		}
+		comparison.num = size
+	case fieldType == exprDateType:
+		date, dateOnly, err := parseExprDate(value)
+		if err != nil {
+			return nil, err
+		}
+		comparison.date = date
+		comparison.dateOnly = dateOnly
+	default:
+		comparison.strVals = []string{normaliseExprStr(field, value)}
+	}
+	return comparison, nil
+}
+
+func normaliseExprStr(field, value string) string {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if field == EXPR_EXT {
+		value = "." + strings.TrimPrefix(value, ".")
+	}
+	return value
+}
+
+const exprDateLayout = "2006-01-02"
+
+// Returns the parsed date and whether the value has no time
+func parseExprDate(value string) (time.Time, bool, error) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", exprDateLayout} {
+		if date, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return date, layout == exprDateLayout, nil
+		}
+	}
+	return time.Time{}, false, fmt.Errorf("invalid date %q, expected a date like 2024-01-31", value)
+}
+
+// Returns the start of the calendar day of the date in the local time zone
+func truncateToDay(date time.Time) time.Time {
+	year, month, day := date.In(time.Local).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.Local)
+}
+
+var fileSizeUnits = []struct {
+	suffix     string
+	multiplier float64
+}{
+	// longer suffixes first so that "KB" is not matched as "B"
+	{"KIB", 1024},
+	{"MIB", 1024 * 1024},
+	{"GIB", 1024 * 1024 * 1024},
+	{"TIB", 1024 * 1024 * 1024 * 1024},
+	{"KB", 1024},
+	{"MB", 1024 * 1024},
+	{"GB", 1024 * 1024 * 1024},
+	{"TB", 1024 * 1024 * 1024 * 1024},
+	{"K", 1024},
+	{"M", 1024 * 1024},
+	{"G", 1024 * 1024 * 1024},
+	{"B", 1},
+}
+
+func isFileSizeUnit(value string) bool {
+	value = strings.ToUpper(value)
+	for _, unit := range fileSizeUnits {
+		if value == unit.suffix {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseFileSize converts a human-readable file size like "50MB" or "1.5 GB" to bytes.
+//
+// Units are in multiples of 1024 and a number without a unit is treated as bytes.
+func ParseFileSize(sizeStr string) (int64, error) {
+	str := strings.ToUpper(strings.TrimSpace(sizeStr))
+	multiplier := 1.0
+	for _, unit := range fileSizeUnits {
+		if strings.HasSuffix(str, unit.suffix) {
+			str = strings.TrimSpace(strings.TrimSuffix(str, unit.suffix))
+			multiplier = unit.multiplier
+			break
+		}
+	}
+
+	num, err := strconv.ParseFloat(str, 64)
+	if err != nil || num < 0 {
+		return 0, fmt.Errorf("invalid file size %q, expected a size like 50MB", sizeStr)
+	}
+	return int64(num * multiplier), nil
+}
+
+// ParseExpression parses a filter expression, see Expression for the syntax.
+func ParseExpression(expr string) (*Expression, error) {
+	newErr := func(err error) error {
+		return fmt.Errorf(
+			"error %d: invalid filter expression %q, %w",
+			cdlerrors.INPUT_ERROR,
+			expr,
+			err,
+		)
+	}
+
+	tokens, err := tokeniseExpr(expr)
+	if err != nil {
+		return nil, newErr(err)
+	}
+	if len(tokens) == 0 {
+		return nil, newErr(fmt.Errorf("expression cannot be empty"))
+	}
+
+	parser := &exprParser{tokens: tokens}
+	root, err := parser.parseOr()
+	if err != nil {
+		return nil, newErr(err)
+	}
+	if token := parser.peek(); token != nil {
+		return nil, newErr(fmt.Errorf("unexpected %q at position %d", token.value, token.pos))
+	}
+	return &Expression{raw: expr, root: root}, nil
+}
+
+// Helper to get the FileInfo of a file path
+func NewFileInfo(filePath string, size int64, mime string) *FileInfo {
+	return &FileInfo{
+		Name: filepath.Base(filePath),
+		Ext:  filepath.Ext(filePath),
+		Size: size,
+		Mime: mime,
+	}
+}
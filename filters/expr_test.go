@@ -0,0 +1,181 @@
+package filters
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseFileSize(t *testing.T) {
+	testCases := map[string]int64{
+		"100":    100,
+		"100B":   100,
+		"1KB":    1024,
+		"50MB":   50 * 1024 * 1024,
+		"1.5 GB": 1536 * 1024 * 1024,
+		"2gib":   2 * 1024 * 1024 * 1024,
+	}
+	for input, expected := range testCases {
+		got, err := ParseFileSize(input)
+		if err != nil {
+			t.Errorf("Unexpected error for %q: %v", input, err)
+			continue
+		}
+		if got != expected {
+			t.Errorf("Expected %d for %q but got %d", expected, input, got)
+		}
+	}
+
+	for _, input := range []string{"", "MB", "-1MB", "10XB"} {
+		if _, err := ParseFileSize(input); err == nil {
+			t.Errorf("Expected error for %q", input)
+		}
+	}
+}
+
+func TestExpressionEval(t *testing.T) {
+	post := &PostInfo{
+		Title: "Sample Artwork",
+		Tags:  []string{"R-18", "Original"},
+		Fee:   500,
+		Date:  time.Date(2024, 5, 12, 0, 0, 0, 0, time.Local),
+		Type:  "image",
+	}
+	psd := &FileInfo{Name: "layers.PSD", Ext: ".PSD", Size: 10 * 1024 * 1024, Mime: "image/vnd.adobe.photoshop"}
+	bigPng := &FileInfo{Name: "big.png", Ext: ".png", Size: 60 * 1024 * 1024, Mime: "image/png; charset=binary"}
+	smallPng := &FileInfo{Name: "small.png", Ext: ".png", Size: 1024, Mime: "image/png"}
+
+	testCases := []struct {
+		expr     string
+		file     *FileInfo
+		expected bool
+	}{
+		{`fee <= 500 and tag has "r-18"`, nil, true},
+		{`fee < 500`, nil, false},
+		{`tags in [original, fanart]`, nil, true},
+		{`not title ~ "sample"`, nil, false},
+		{`title !~ "^draft"`, nil, true},
+		{`date >= 2024-05-01 and date < 2024-06-01`, nil, true},
+		{`type == article`, nil, false},
+		{`ext in [.psd,.clip] or size > 50MB`, psd, true},
+		{`ext in [.psd,.clip] or size > 50MB`, bigPng, true},
+		{`ext in [.psd,.clip] or size > 50MB`, smallPng, false},
+		{`mime == image/png and name ~ "^small"`, smallPng, true},
+		{`mime == "image/png"`, bigPng, true},
+		{`ext == png`, smallPng, true},
+		{`(ext in [psd] or size > 50MB) and not title ~ "sample"`, psd, false},
+		{`NOT (fee > 1000 OR ext == .zip)`, smallPng, true},
+		{`size > 1.5 GB`, bigPng, false},
+		{`size < 1.5 gb and size > 50 MB`, bigPng, true},
+	}
+	for _, tc := range testCases {
+		expr, err := ParseExpression(tc.expr)
+		if err != nil {
+			t.Errorf("Unexpected error for %q: %v", tc.expr, err)
+			continue
+		}
+		var got bool
+		if tc.file == nil {
+			got = expr.IsPostValid(post)
+		} else {
+			got = expr.IsFileValid(post, tc.file)
+		}
+		if got != tc.expected {
+			t.Errorf("Expected %v for %q but got %v", tc.expected, tc.expr, got)
+		}
+	}
+}
+
+func TestExpressionDate(t *testing.T) {
+	post := &PostInfo{Date: time.Date(2024, 5, 12, 18, 30, 0, 0, time.Local)}
+	testCases := map[string]bool{
+		`date == 2024-05-12`:            true,
+		`date != 2024-05-12`:            false,
+		`date == 2024-05-13`:            false,
+		`date <= 2024-05-12`:            true,
+		`date < 2024-05-12`:             false,
+		`date > 2024-05-11`:             true,
+		`date == "2024-05-12T18:30:00"`: true,
+		`date == "2024-05-12T00:00:00"`: false,
+		`date > "2024-05-12T12:00:00"`:  true,
+	}
+	for input, expected := range testCases {
+		expr, err := ParseExpression(input)
+		if err != nil {
+			t.Errorf("Unexpected error for %q: %v", input, err)
+			continue
+		}
+		if got := expr.IsPostValid(post); got != expected {
+			t.Errorf("Expected %v for %q but got %v", expected, input, got)
+		}
+	}
+}
+
+func TestExpressionUnknownFields(t *testing.T) {
+	expr, err := ParseExpression(`(ext == .psd or size > 50MB) and fee <= 500`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// file details are not known at the post level
+	if !expr.IsPostValid(&PostInfo{Fee: 100}) {
+		t.Error("Expected post to pass as the file level fields are unknown")
+	}
+	if expr.IsPostValid(&PostInfo{Fee: 1000}) {
+		t.Error("Expected post to fail as the fee is too high")
+	}
+	if !expr.IsPostValid(&PostInfo{Fee: UNKNOWN_FEE}) {
+		t.Error("Expected post with an unknown fee to pass")
+	}
+
+	// post details are not known for files from external sources like Google Drive
+	if !expr.IsFileValid(nil, &FileInfo{Ext: ".psd", Size: UNKNOWN_SIZE}) {
+		t.Error("Expected file to pass as the fee is unknown")
+	}
+	if expr.IsFileValid(nil, &FileInfo{Ext: ".png", Size: 1024}) {
+		t.Error("Expected file to fail as it is neither a psd nor larger than 50MB")
+	}
+}
+
+func TestInvalidExpressions(t *testing.T) {
+	invalidExprs := []string{
+		"",
+		"   ",
+		"unknown == 1",
+		"fee ~ 500",
+		"size > abc",
+		"date > yesterday",
+		"tag == r-18",
+		"title ~ \"[\"",
+		"title == \"unclosed",
+		"(fee > 1",
+		"fee > 1)",
+		"fee > 1 and",
+		"ext in []",
+		"ext in [.psd",
+		"fee > 1 fee < 2",
+		"not",
+	}
+	for _, expr := range invalidExprs {
+		if _, err := ParseExpression(expr); err == nil {
+			t.Errorf("Expected error for expression %q", expr)
+		}
+	}
+}
+
+func TestFiltersExpression(t *testing.T) {
+	f := &Filters{MaxFileSize: NO_MAX_FILESIZE, Expression: "fee > 1000"}
+	if err := f.ValidateArgs(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if f.IsPostExprValid(&PostInfo{Fee: 500}) {
+		t.Error("Expected post to fail the expression")
+	}
+	if f.Copy().IsPostExprValid(&PostInfo{Fee: 500}) {
+		t.Error("Expected copied filters to keep the parsed expression")
+	}
+
+	f.Expression = "fee >"
+	if err := f.ValidateArgs(); err == nil {
+		t.Error("Expected error for invalid expression")
+	}
+}
@@ -7,8 +7,6 @@ import (
 	"strings"
 	"time"
 
-	"github.com/KJHJason/Cultured-Downloader-Logic/cdlerrors"
-	"github.com/KJHJason/Cultured-Downloader-Logic/logger"
 	"github.com/KJHJason/Cultured-Downloader-Logic/utils"
 )
 
@@ -16,6 +14,15 @@ const (
 	NO_MAX_FILESIZE = -1
 )
 
+// Controls whether posts marked as adult content (e.g. R-18) are downloaded
+type AdultContentFilter int
+
+const (
+	ADULT_INCLUDE AdultContentFilter = iota // download both adult and non-adult posts
+	ADULT_EXCLUDE                           // skip adult posts
+	ADULT_ONLY                              // only download adult posts
+)
+
 type filtersDateInfo struct {
 	hasStartDate bool
 	hasEndDate   bool
@@ -34,6 +41,25 @@ type Filters struct {
 	dateInfo  *filtersDateInfo
 
 	FileNameFilter *regexp.Regexp
+
+	// Post level filters based on the access and rating of the post.
+	//
+	// SkipRestricted skips posts or contents that the user cannot view, e.g. a higher tier on Fanbox.
+	// MaxFee is the highest plan fee in JPY to download from, 0 for no limit. Use FreeOnly for free posts only.
+	SkipRestricted bool               // Fantia, PixivFanbox
+	MaxFee         int                // Fantia, PixivFanbox
+	FreeOnly       bool               // Fantia, PixivFanbox
+	AdultContent   AdultContentFilter // Fantia, PixivFanbox, Pixiv
+
+	// Image dimension filters that uses the dimensions from the API if available.
+	// Otherwise, downloaded images are checked and deleted if they are outside the limits.
+	ImageDimensionFilters
+
+	// Boolean filter expression evaluated against the post and file details,
+	// e.g. `(ext in [.psd, .clip] or size > 50MB) and not title ~ "sample"`.
+	// See the Expression type for the supported syntax.
+	Expression string
+	expression *Expression
 }
 
 func (f *Filters) RemoveDuplicateFileExt() {
@@ -61,6 +87,14 @@ func (f *Filters) Copy() *Filters {
 		StartDate:      f.StartDate,
 		EndDate:        f.EndDate,
 		FileNameFilter: f.FileNameFilter,
+		SkipRestricted: f.SkipRestricted,
+		MaxFee:         f.MaxFee,
+		FreeOnly:       f.FreeOnly,
+		AdultContent:   f.AdultContent,
+
+		ImageDimensionFilters: f.ImageDimensionFilters,
+		Expression:            f.Expression,
+		expression:            f.expression,
 	}
 }
 
@@ -75,8 +109,8 @@ func (f *Filters) ValidateArgs() error {
 	}
 
 	now := time.Now()
-	hasStartDate := f.StartDate.IsZero()
-	hasEndDate := f.EndDate.IsZero()
+	hasStartDate := !f.StartDate.IsZero()
+	hasEndDate := !f.EndDate.IsZero()
 	if hasStartDate && hasEndDate && f.StartDate.After(f.EndDate) {
 		return errors.New("start date cannot be after end date")
 	}
@@ -92,17 +126,36 @@ func (f *Filters) ValidateArgs() error {
 	}
 
 	for idx, ext := range f.FileExt {
-		if !strings.HasSuffix(ext, ".") {
-			return errors.New("file extension must start with a period")
-		}
-
 		ext = strings.TrimSpace(ext)
 		if ext == "" {
 			return errors.New("file extension cannot be empty")
 		}
+		if !strings.HasPrefix(ext, ".") {
+			return errors.New("file extension must start with a period")
+		}
 		f.FileExt[idx] = ext
 	}
 
+	if f.MaxFee < 0 {
+		return errors.New("max fee cannot be negative")
+	}
+	if f.AdultContent < ADULT_INCLUDE || f.AdultContent > ADULT_ONLY {
+		return errors.New("invalid adult content filter")
+	}
+
+	if err := f.ImageDimensionFilters.validateArgs(); err != nil {
+		return err
+	}
+
+	f.expression = nil
+	if strings.TrimSpace(f.Expression) != "" {
+		expression, err := ParseExpression(f.Expression)
+		if err != nil {
+			return err
+		}
+		f.expression = expression
+	}
+
 	return nil
 }
 
@@ -132,11 +185,14 @@ func (f *Filters) IsFilePathExtValid(filePath string) bool {
 }
 
 func (f *Filters) IsPostDateValid(postDate time.Time) bool {
-	if f.dateInfo == nil {
-		logger.MainLogger.Fatalf(
-			"error %d: Filters struct was not initialised properly, did you forget to call ValidateArgs()?",
-			cdlerrors.DEV_ERROR,
-		)
+	dateInfo := f.dateInfo
+	if dateInfo == nil {
+		// ValidateArgs was not called, fallback to the dates as-is
+		// as they are only validated to report invalid user input.
+		dateInfo = &filtersDateInfo{
+			hasStartDate: !f.StartDate.IsZero(),
+			hasEndDate:   !f.EndDate.IsZero(),
+		}
 	}
 
 	if postDate.IsZero() {
@@ -150,13 +206,13 @@ func (f *Filters) IsPostDateValid(postDate time.Time) bool {
 
 	// Check if the user has provided a starting date.
 	// If provided, check if the post date is after the starting date
-	if f.dateInfo.hasStartDate && !postDate.After(f.StartDate) {
+	if dateInfo.hasStartDate && !postDate.After(f.StartDate) {
 		return false
 	}
 
 	// After checking the starting date, if the end date is not given, it is valid.
 	// Otherwise, check if the post date is before the given end date.
-	return !f.dateInfo.hasEndDate || postDate.Before(f.EndDate)
+	return !dateInfo.hasEndDate || postDate.Before(f.EndDate)
 }
 
 func (f *Filters) IsFileNameValid(fileName string) bool {
@@ -169,3 +225,52 @@ func (f *Filters) IsFileNameValid(fileName string) bool {
 func (f *Filters) IsFilePathFileNameValid(filePath string) bool {
 	return f.IsFileNameValid(filepath.Base(filePath))
 }
+
+// IsRestrictedPostValid returns false if the post or content
+// cannot be viewed by the user and SkipRestricted is set.
+func (f *Filters) IsRestrictedPostValid(isRestricted bool) bool {
+	return !(isRestricted && f.SkipRestricted)
+}
+
+// IsPostFeeValid returns false if the fee in JPY required
+// to view the post or content is above the user's limit.
+func (f *Filters) IsPostFeeValid(fee int) bool {
+	if f.FreeOnly {
+		return fee <= 0
+	}
+	return f.MaxFee == 0 || fee <= f.MaxFee
+}
+
+// IsAdultContentValid returns false if the post's
+// adult rating does not match the AdultContent filter.
+func (f *Filters) IsAdultContentValid(isAdult bool) bool {
+	switch f.AdultContent {
+	case ADULT_EXCLUDE:
+		return !isAdult
+	case ADULT_ONLY:
+		return isAdult
+	default:
+		return true
+	}
+}
+
+// IsPostExprValid returns false if the post fails the filter expression.
+//
+// Comparisons that require file details are ignored
+// and will be checked by IsFileExprValid when downloading.
+func (f *Filters) IsPostExprValid(post *PostInfo) bool {
+	if f.expression == nil {
+		return true
+	}
+	return f.expression.IsPostValid(post)
+}
+
+// IsFileExprValid returns false if the file fails the filter expression.
+//
+// post can be nil if the post details are not available.
+func (f *Filters) IsFileExprValid(post *PostInfo, file *FileInfo) bool {
+	if f.expression == nil {
+		return true
+	}
+	return f.expression.IsFileValid(post, file)
+}
@@ -0,0 +1,53 @@
+package filters
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPostAccessFilters(t *testing.T) {
+	f := &Filters{MaxFileSize: NO_MAX_FILESIZE, SkipRestricted: true, MaxFee: 500, AdultContent: ADULT_EXCLUDE}
+	if err := f.ValidateArgs(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if f.IsRestrictedPostValid(true) || !f.IsRestrictedPostValid(false) {
+		t.Error("Expected only restricted posts to be skipped")
+	}
+	if !f.IsPostFeeValid(0) || !f.IsPostFeeValid(500) || f.IsPostFeeValid(1000) {
+		t.Error("Expected posts above the max fee to be skipped")
+	}
+	if f.IsAdultContentValid(true) || !f.IsAdultContentValid(false) {
+		t.Error("Expected only adult posts to be skipped")
+	}
+
+	f.FreeOnly = true
+	f.AdultContent = ADULT_ONLY
+	if f.IsPostFeeValid(100) || !f.IsPostFeeValid(0) {
+		t.Error("Expected only free posts to be valid")
+	}
+	if !f.IsAdultContentValid(true) || f.IsAdultContentValid(false) {
+		t.Error("Expected only adult posts to be valid")
+	}
+
+	f = &Filters{MaxFileSize: NO_MAX_FILESIZE}
+	if !f.IsRestrictedPostValid(true) || !f.IsPostFeeValid(10000) || !f.IsAdultContentValid(true) {
+		t.Error("Expected no posts to be skipped by default")
+	}
+
+	for _, invalid := range []*Filters{
+		{MaxFileSize: NO_MAX_FILESIZE, MaxFee: -1},
+		{MaxFileSize: NO_MAX_FILESIZE, AdultContent: AdultContentFilter(5)},
+	} {
+		if err := invalid.ValidateArgs(); err == nil {
+			t.Errorf("Expected error for %+v", invalid)
+		}
+	}
+}
+
+func TestPostDateWithoutValidateArgs(t *testing.T) {
+	now := time.Now()
+	f := &Filters{StartDate: now.Add(-time.Hour)}
+	if !f.IsPostDateValid(now) || f.IsPostDateValid(now.Add(-2*time.Hour)) {
+		t.Error("Expected the start date to be used even if ValidateArgs() was not called")
+	}
+}
@@ -0,0 +1,325 @@
+package filters
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KJHJason/Cultured-Downloader-Logic/cdlerrors"
+	"github.com/KJHJason/Cultured-Downloader-Logic/constants"
+	"github.com/KJHJason/Cultured-Downloader-Logic/iofuncs"
+	"gopkg.in/yaml.v3"
+)
+
+// FilterProfile is the serialisable form of Filters that can be saved as JSON or YAML.
+//
+// Sizes use human-readable units like "50MB" and dates can be
+// absolute like "2024-01-31" or relative to now like "-30d".
+type FilterProfile struct {
+	Name string `json:"name" yaml:"name"`
+
+	MinFileSize string   `json:"min_file_size,omitempty" yaml:"min_file_size,omitempty"`
+	MaxFileSize string   `json:"max_file_size,omitempty" yaml:"max_file_size,omitempty"`
+	FileExt     []string `json:"file_ext,omitempty" yaml:"file_ext,omitempty"`
+
+	StartDate string `json:"start_date,omitempty" yaml:"start_date,omitempty"`
+	EndDate   string `json:"end_date,omitempty" yaml:"end_date,omitempty"`
+
+	FileNameRegex string `json:"file_name_regex,omitempty" yaml:"file_name_regex,omitempty"`
+
+	SkipRestricted bool   `json:"skip_restricted,omitempty" yaml:"skip_restricted,omitempty"`
+	MaxFee         int    `json:"max_fee,omitempty" yaml:"max_fee,omitempty"`
+	FreeOnly       bool   `json:"free_only,omitempty" yaml:"free_only,omitempty"`
+	AdultContent   string `json:"adult_content,omitempty" yaml:"adult_content,omitempty"` // "include", "exclude", or "only"
+
+	MinWidth       int     `json:"min_width,omitempty" yaml:"min_width,omitempty"`
+	MaxWidth       int     `json:"max_width,omitempty" yaml:"max_width,omitempty"`
+	MinHeight      int     `json:"min_height,omitempty" yaml:"min_height,omitempty"`
+	MaxHeight      int     `json:"max_height,omitempty" yaml:"max_height,omitempty"`
+	MinAspectRatio float64 `json:"min_aspect_ratio,omitempty" yaml:"min_aspect_ratio,omitempty"`
+	MaxAspectRatio float64 `json:"max_aspect_ratio,omitempty" yaml:"max_aspect_ratio,omitempty"`
+
+	Expression string `json:"expression,omitempty" yaml:"expression,omitempty"`
+}
+
+// Default path to save the user's filter profiles
+var FILTER_PROFILES_PATH = filepath.Join(iofuncs.APP_PATH, "filter_profiles.json")
+
+var adultContentFilterNames = map[string]AdultContentFilter{
+	"":        ADULT_INCLUDE,
+	"include": ADULT_INCLUDE,
+	"exclude": ADULT_EXCLUDE,
+	"only":    ADULT_ONLY,
+}
+
+var relativeDateRegex = regexp.MustCompile(`^-(\d+)([hdwmy])$`)
+
+// ParseProfileDate parses an absolute date like "2024-01-31"
+// or a date relative to now like "-12h", "-30d", "-2w", "-6m", or "-1y".
+func ParseProfileDate(dateStr string, now time.Time) (time.Time, error) {
+	dateStr = strings.ToLower(strings.TrimSpace(dateStr))
+	if matches := relativeDateRegex.FindStringSubmatch(dateStr); matches != nil {
+		num, err := strconv.Atoi(matches[1])
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid relative date %q", dateStr)
+		}
+		switch matches[2] {
+		case "h":
+			return now.Add(-time.Duration(num) * time.Hour), nil
+		case "d":
+			return now.AddDate(0, 0, -num), nil
+		case "w":
+			return now.AddDate(0, 0, -num*7), nil
+		case "m":
+			return now.AddDate(0, -num, 0), nil
+		default: // "y"
+			return now.AddDate(-num, 0, 0), nil
+		}
+	}
+	date, _, err := parseExprDate(dateStr)
+	return date, err
+}
+
+// ToFilters converts the profile to a validated Filters struct.
+//
+// Relative dates are resolved against now so the
+// profile should be converted again for every download.
+func (p *FilterProfile) ToFilters(now time.Time) (*Filters, error) {
+	newErr := func(err error) error {
+		return fmt.Errorf(
+			"error %d: invalid filter profile %q, %w",
+			cdlerrors.INPUT_ERROR,
+			p.Name,
+			err,
+		)
+	}
+
+	f := &Filters{
+		MaxFileSize:    NO_MAX_FILESIZE,
+		FileExt:        append([]string{}, p.FileExt...),
+		SkipRestricted: p.SkipRestricted,
+		MaxFee:         p.MaxFee,
+		FreeOnly:       p.FreeOnly,
+		ImageDimensionFilters: ImageDimensionFilters{
+			MinWidth:       p.MinWidth,
+			MaxWidth:       p.MaxWidth,
+			MinHeight:      p.MinHeight,
+			MaxHeight:      p.MaxHeight,
+			MinAspectRatio: p.MinAspectRatio,
+			MaxAspectRatio: p.MaxAspectRatio,
+		},
+		Expression: p.Expression,
+	}
+
+	var err error
+	if p.MinFileSize != "" {
+		if f.MinFileSize, err = ParseFileSize(p.MinFileSize); err != nil {
+			return nil, newErr(err)
+		}
+	}
+	if p.MaxFileSize != "" {
+		if f.MaxFileSize, err = ParseFileSize(p.MaxFileSize); err != nil {
+			return nil, newErr(err)
+		}
+	}
+	if p.StartDate != "" {
+		if f.StartDate, err = ParseProfileDate(p.StartDate, now); err != nil {
+			return nil, newErr(err)
+		}
+	}
+	if p.EndDate != "" {
+		if f.EndDate, err = ParseProfileDate(p.EndDate, now); err != nil {
+			return nil, newErr(err)
+		}
+	}
+	if p.FileNameRegex != "" {
+		if f.FileNameFilter, err = regexp.Compile(p.FileNameRegex); err != nil {
+			return nil, newErr(fmt.Errorf("invalid file name regex %q, more info => %w", p.FileNameRegex, err))
+		}
+	}
+
+	adultContent, ok := adultContentFilterNames[strings.ToLower(p.AdultContent)]
+	if !ok {
+		return nil, newErr(fmt.Errorf("invalid adult content filter %q, must be include, exclude, or only", p.AdultContent))
+	}
+	f.AdultContent = adultContent
+
+	if err := f.ValidateArgs(); err != nil {
+		return nil, newErr(err)
+	}
+	return f, nil
+}
+
+// FilterProfiles contains the user's named profiles and which profile to use for each site or creator.
+type FilterProfiles struct {
+	Profiles []*FilterProfile `json:"profiles" yaml:"profiles"`
+
+	// Maps a site like "fanbox" or a creator like "fanbox/<creator ID>" to a profile name.
+	// For Kemono, creators are in the form of "kemono/<service>/<creator ID>".
+	Assignments map[string]string `json:"assignments,omitempty" yaml:"assignments,omitempty"`
+
+	// Profile to use if there are no assignments for the site or creator
+	Default string `json:"default,omitempty" yaml:"default,omitempty"`
+}
+
+// Returns the profile with the given name or nil if it does not exist
+func (fp *FilterProfiles) GetProfile(name string) *FilterProfile {
+	for _, profile := range fp.Profiles {
+		if profile.Name == name {
+			return profile
+		}
+	}
+	return nil
+}
+
+// ValidateArgs checks that the profile names are unique, every profile is valid,
+// and all assignments refer to an existing profile.
+func (fp *FilterProfiles) ValidateArgs() error {
+	now := time.Now()
+	seenNames := make(map[string]struct{}, len(fp.Profiles))
+	for _, profile := range fp.Profiles {
+		if profile == nil || strings.TrimSpace(profile.Name) == "" {
+			return fmt.Errorf("error %d: filter profile name cannot be empty", cdlerrors.INPUT_ERROR)
+		}
+		if _, ok := seenNames[profile.Name]; ok {
+			return fmt.Errorf("error %d: duplicate filter profile name %q", cdlerrors.INPUT_ERROR, profile.Name)
+		}
+		seenNames[profile.Name] = struct{}{}
+
+		if _, err := profile.ToFilters(now); err != nil {
+			return err
+		}
+	}
+
+	if fp.Default != "" && fp.GetProfile(fp.Default) == nil {
+		return fmt.Errorf("error %d: default filter profile %q does not exist", cdlerrors.INPUT_ERROR, fp.Default)
+	}
+	for target, name := range fp.Assignments {
+		if fp.GetProfile(name) == nil {
+			return fmt.Errorf(
+				"error %d: filter profile %q assigned to %q does not exist",
+				cdlerrors.INPUT_ERROR,
+				name,
+				target,
+			)
+		}
+	}
+	return nil
+}
+
+// GetFilters returns the filters for the creator on the site
+// where the creator's assignment takes precedence over the site's assignment.
+//
+// creatorId can be empty to only look up the site's assignment.
+// Returns nil if there are no assignments and no default profile.
+func (fp *FilterProfiles) GetFilters(site, creatorId string) (*Filters, error) {
+	name := fp.Default
+	if siteProfile, ok := fp.Assignments[site]; ok {
+		name = siteProfile
+	}
+	if creatorId != "" {
+		if creatorProfile, ok := fp.Assignments[site+"/"+creatorId]; ok {
+			name = creatorProfile
+		}
+	}
+	if name == "" {
+		return nil, nil
+	}
+
+	profile := fp.GetProfile(name)
+	if profile == nil {
+		return nil, fmt.Errorf("error %d: filter profile %q does not exist", cdlerrors.INPUT_ERROR, name)
+	}
+	return profile.ToFilters(time.Now())
+}
+
+func isYamlPath(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// LoadFilterProfiles reads and validates the filter profiles from a JSON or YAML file
+// where the format is determined by the file extension.
+func LoadFilterProfiles(path string) (*FilterProfiles, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"error %d: failed to read filter profiles from %s, more info => %w",
+			cdlerrors.OS_ERROR,
+			path,
+			err,
+		)
+	}
+
+	var profiles FilterProfiles
+	if isYamlPath(path) {
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(&profiles)
+	} else {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&profiles)
+	}
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf(
+			"error %d: failed to parse filter profiles from %s, more info => %w",
+			cdlerrors.JSON_ERROR,
+			path,
+			err,
+		)
+	}
+
+	if err := profiles.ValidateArgs(); err != nil {
+		return nil, err
+	}
+	return &profiles, nil
+}
+
+// SaveFilterProfiles writes the filter profiles to a JSON or YAML file
+// where the format is determined by the file extension.
+func SaveFilterProfiles(path string, profiles *FilterProfiles) error {
+	if err := profiles.ValidateArgs(); err != nil {
+		return err
+	}
+
+	var data []byte
+	var err error
+	if isYamlPath(path) {
+		data, err = yaml.Marshal(profiles)
+	} else {
+		data, err = json.MarshalIndent(profiles, "", "\t")
+	}
+	if err != nil {
+		return fmt.Errorf(
+			"error %d: failed to marshal filter profiles, more info => %w",
+			cdlerrors.JSON_ERROR,
+			err,
+		)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), constants.DEFAULT_PERMS); err != nil {
+		return fmt.Errorf(
+			"error %d: failed to create directory for filter profiles, more info => %w",
+			cdlerrors.OS_ERROR,
+			err,
+		)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf(
+			"error %d: failed to write filter profiles to %s, more info => %w",
+			cdlerrors.OS_ERROR,
+			path,
+			err,
+		)
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package filters
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/KJHJason/Cultured-Downloader-Logic/constants"
+)
+
+func TestParseProfileDate(t *testing.T) {
+	now := time.Date(2024, 5, 31, 12, 0, 0, 0, time.UTC)
+	testCases := map[string]time.Time{
+		"-12h": now.Add(-12 * time.Hour),
+		"-30d": now.AddDate(0, 0, -30),
+		"-2w":  now.AddDate(0, 0, -14),
+		"-6m":  now.AddDate(0, -6, 0),
+		"-1y":  now.AddDate(-1, 0, 0),
+	}
+	for input, expected := range testCases {
+		got, err := ParseProfileDate(input, now)
+		if err != nil {
+			t.Errorf("Unexpected error for %q: %v", input, err)
+			continue
+		}
+		if !got.Equal(expected) {
+			t.Errorf("Expected %v for %q but got %v", expected, input, got)
+		}
+	}
+
+	if got, err := ParseProfileDate("2024-01-31", now); err != nil || got.Day() != 31 || got.Month() != time.January {
+		t.Errorf("Expected an absolute date but got %v (%v)", got, err)
+	}
+	for _, invalid := range []string{"30d", "-d", "-30x", "yesterday"} {
+		if _, err := ParseProfileDate(invalid, now); err == nil {
+			t.Errorf("Expected error for %q", invalid)
+		}
+	}
+}
+
+func TestFilterProfileToFilters(t *testing.T) {
+	now := time.Now()
+	profile := &FilterProfile{
+		Name:          "psd-only",
+		MinFileSize:   "1MB",
+		MaxFileSize:   "2GB",
+		FileExt:       []string{".psd", " .clip "},
+		StartDate:     "-30d",
+		FileNameRegex: "^(?!preview)",
+		AdultContent:  "exclude",
+	}
+	if _, err := profile.ToFilters(now); err == nil {
+		t.Error("Expected error for an invalid regex")
+	}
+
+	profile.FileNameRegex = "^layer"
+	f, err := profile.ToFilters(now)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if f.MinFileSize != 1024*1024 || f.MaxFileSize != 2*1024*1024*1024 {
+		t.Errorf("Expected sizes to be parsed but got %d and %d", f.MinFileSize, f.MaxFileSize)
+	}
+	if !f.IsFileExtValid(".clip") || f.IsFileExtValid(".png") {
+		t.Error("Expected only .psd and .clip to be valid")
+	}
+	if f.IsPostDateValid(now.AddDate(0, 0, -60)) || !f.IsPostDateValid(now.AddDate(0, 0, -1)) {
+		t.Error("Expected only posts from the last 30 days to be valid")
+	}
+	if f.AdultContent != ADULT_EXCLUDE {
+		t.Errorf("Expected adult content to be excluded but got %d", f.AdultContent)
+	}
+}
+
+func TestFilterProfilesAssignments(t *testing.T) {
+	profiles := &FilterProfiles{
+		Profiles: []*FilterProfile{
+			{Name: "psd-only", FileExt: []string{".psd"}},
+			{Name: "no-previews", FileNameRegex: "^[^p]"},
+		},
+		Assignments: map[string]string{
+			constants.PIXIV_FANBOX:               "no-previews",
+			constants.PIXIV_FANBOX + "/creator1": "psd-only",
+		},
+	}
+	if err := profiles.ValidateArgs(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	f, err := profiles.GetFilters(constants.PIXIV_FANBOX, "creator1")
+	if err != nil || f == nil || !f.IsFileExtValid(".psd") || f.IsFileExtValid(".png") {
+		t.Errorf("Expected the creator's profile to be used but got %+v (%v)", f, err)
+	}
+	f, err = profiles.GetFilters(constants.PIXIV_FANBOX, "creator2")
+	if err != nil || f == nil || f.FileNameFilter == nil {
+		t.Errorf("Expected the site's profile to be used but got %+v (%v)", f, err)
+	}
+	if f, err := profiles.GetFilters(constants.FANTIA, ""); f != nil || err != nil {
+		t.Errorf("Expected no filters without a default profile but got %+v (%v)", f, err)
+	}
+
+	profiles.Assignments[constants.FANTIA] = "missing"
+	if err := profiles.ValidateArgs(); err == nil {
+		t.Error("Expected error for an assignment to a missing profile")
+	}
+	delete(profiles.Assignments, constants.FANTIA)
+
+	profiles.Profiles = append(profiles.Profiles, &FilterProfile{Name: "psd-only"})
+	if err := profiles.ValidateArgs(); err == nil {
+		t.Error("Expected error for duplicate profile names")
+	}
+}
+
+func TestSaveAndLoadFilterProfiles(t *testing.T) {
+	profiles := &FilterProfiles{
+		Profiles: []*FilterProfile{
+			{Name: "psd-only", FileExt: []string{".psd"}, MaxFileSize: "50MB", StartDate: "-30d"},
+		},
+		Default: "psd-only",
+	}
+
+	dir := t.TempDir()
+	for _, filename := range []string{"profiles.json", "profiles.yaml"} {
+		path := filepath.Join(dir, filename)
+		if err := SaveFilterProfiles(path, profiles); err != nil {
+			t.Fatalf("Failed to save %s: %v", filename, err)
+		}
+		loaded, err := LoadFilterProfiles(path)
+		if err != nil {
+			t.Fatalf("Failed to load %s: %v", filename, err)
+		}
+		if loaded.Default != "psd-only" || len(loaded.Profiles) != 1 || loaded.Profiles[0].MaxFileSize != "50MB" {
+			t.Errorf("Expected the saved profiles from %s but got %+v", filename, loaded)
+		}
+	}
+
+	invalidPath := filepath.Join(dir, "invalid.yml")
+	if err := os.WriteFile(invalidPath, []byte("profiles:\n  - name: a\n    max_size: 50MB\n"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if _, err := LoadFilterProfiles(invalidPath); err == nil {
+		t.Error("Expected error for unknown fields")
+	}
+}
@@ -128,9 +128,11 @@ func (gdrive *GDrive) DownloadFile(ctx context.Context, fileInfo *GdriveFileToDl
 	}
 
 	dlReqInfo := &httpfuncs.DlRequestInfo{
-		Ctx:     ctx,
-		Url:     fileInfo.GetUrl(),
-		Filters: filters,
+		Ctx:      ctx,
+		Url:      fileInfo.GetUrl(),
+		PostInfo: fileInfo.PostInfo,
+		Filters:  filters,
+		Logger:   gdrive.getLogger(),
 	}
 	dlPartialInfo := httpfuncs.PartialDlInfo{
 		DownloadPartial:  true,
@@ -140,7 +142,7 @@ func (gdrive *GDrive) DownloadFile(ctx context.Context, fileInfo *GdriveFileToDl
 	return httpfuncs.DlToFile(res, dlReqInfo, filePath, dlPartialInfo, dlProgBar)
 }
 
-func filterDownloads(files []*GdriveFileToDl, filters *filters.Filters) []*GdriveFileToDl {
+func (gdrive *GDrive) filterDownloads(files []*GdriveFileToDl, defaultFilters *filters.Filters) []*GdriveFileToDl {
 	var notAllowedForDownload []*GdriveFileToDl
 	allowedForDownload := make([]*GdriveFileToDl, 0, len(files))
 	for _, file := range files {
@@ -149,10 +151,14 @@ func filterDownloads(files []*GdriveFileToDl, filters *filters.Filters) []*Gdriv
 			continue
 		}
 
-		if !filters.IsFileSizeInRange(file.Size) {
+		fileFilters := file.getFilters(defaultFilters)
+		if !fileFilters.IsFileSizeInRange(file.Size) {
 			continue
 		}
-		if !filters.IsFilePathFileNameValid(file.Name) || !filters.IsFileNameValid(file.Name) {
+		if !fileFilters.IsFilePathFileNameValid(file.Name) || !fileFilters.IsFileNameValid(file.Name) {
+			continue
+		}
+		if !fileFilters.IsFileExprValid(file.PostInfo, filters.NewFileInfo(file.Name, file.Size, file.MimeType)) {
 			continue
 		}
 		allowedForDownload = append(allowedForDownload, file)
@@ -166,7 +172,7 @@ func filterDownloads(files []*GdriveFileToDl, filters *filters.Filters) []*Gdriv
 				file.Name, file.Id, file.MimeType,
 			)
 		}
-		logger.LogError(errors.New(noticeMsg), logger.INFO)
+		gdrive.getLogger().LogError(errors.New(noticeMsg), logger.INFO)
 	}
 	return allowedForDownload
 }
@@ -212,7 +218,7 @@ func (gdrive *GDrive) processGdriveDlError(errTsSlice *threadsafe.Slice[*GdriveE
 
 // Downloads the multiple GDrive file in parallel using GDrive API v3
 func (gdrive *GDrive) DownloadMultipleFiles(files []*GdriveFileToDl, progBarInfo *progress.ProgressBarInfo, filters *filters.Filters) []error {
-	allowedForDownload := filterDownloads(files, filters)
+	allowedForDownload := gdrive.filterDownloads(files, filters)
 	dlLen := len(allowedForDownload)
 	if dlLen == 0 {
 		return nil
@@ -225,6 +231,7 @@ func (gdrive *GDrive) DownloadMultipleFiles(files []*GdriveFileToDl, progBarInfo
 	var wg sync.WaitGroup
 	queue := make(chan struct{}, maxConcurrency)
 	errTsSlice := threadsafe.NewSlice[*GdriveError]()
+	manifestTsSlice := threadsafe.NewSlice[*database.FileManifest]()
 
 	baseMsg := "Downloading GDrive files [%d/" + fmt.Sprintf("%d]...", dlLen)
 	prog := progBarInfo.MainProgressBar
@@ -255,7 +262,7 @@ func (gdrive *GDrive) DownloadMultipleFiles(files []*GdriveFileToDl, progBarInfo
 			var cacheKey string
 			if gdrive.useCacheDb {
 				cacheKey = file.GetUrl()
-				if database.GDriveCacheExists(cacheKey) {
+				if gdrive.getDb().GDriveCacheExists(cacheKey) {
 					prog.Increment()
 					return
 				}
@@ -265,7 +272,7 @@ func (gdrive *GDrive) DownloadMultipleFiles(files []*GdriveFileToDl, progBarInfo
 			filePath := filepath.Join(file.FilePath, file.Name)
 
 			queue <- struct{}{}
-			err := gdrive.DownloadFile(gdrive.ctx, file, filePath, progBarInfo, filters)
+			err := gdrive.DownloadFile(gdrive.ctx, file, filePath, progBarInfo, file.getFilters(filters))
 			hasErr := err != nil
 			if hasErr && !errors.Is(err, context.Canceled) {
 				err = fmt.Errorf(
@@ -282,7 +289,17 @@ func (gdrive *GDrive) DownloadMultipleFiles(files []*GdriveFileToDl, progBarInfo
 			}
 
 			if !hasErr && gdrive.useCacheDb {
-				database.CacheGDrive(cacheKey)
+				gdrive.getDb().CacheGDrive(cacheKey)
+			}
+
+			// the file may not exist if it was excluded by the filters
+			if !hasErr && file.Source != nil && gdrive.ctx.Err() == nil && iofuncs.PathExists(filePath) {
+				manifest, err := database.NewFileManifest(file.GetUrl(), filePath, file.MimeType, file.Source)
+				if err != nil {
+					gdrive.getLogger().LogError(err, logger.ERROR)
+				} else {
+					manifestTsSlice.Append(manifest)
+				}
 			}
 
 			prog.Increment()
@@ -291,6 +308,12 @@ func (gdrive *GDrive) DownloadMultipleFiles(files []*GdriveFileToDl, progBarInfo
 	wg.Wait()
 	close(queue)
 
+	if manifestTsSlice.LenUnsafe() > 0 {
+		if err := gdrive.getDb().SetFileManifests(manifestTsSlice.CopyItemsUnsafe()); err != nil {
+			gdrive.getLogger().LogError(err, logger.ERROR)
+		}
+	}
+
 	hasErr := false
 	if errTsSlice.LenUnsafe() > 0 {
 		hasErr = true
@@ -301,6 +324,10 @@ func (gdrive *GDrive) DownloadMultipleFiles(files []*GdriveFileToDl, progBarInfo
 
 // Uses regex to extract the file ID and the file type (type: file, folder) from the given URL
 func GetFileIdAndTypeFromUrl(url string) (string, string) {
+	return getFileIdAndTypeFromUrl(url, &logger.MainLogger)
+}
+
+func getFileIdAndTypeFromUrl(url string, l *logger.Logger) (string, string) {
 	matched := constants.GDRIVE_URL_REGEX.FindStringSubmatch(url)
 	if matched == nil {
 		return "", ""
@@ -318,7 +345,7 @@ func GetFileIdAndTypeFromUrl(url string) (string, string) {
 			cdlerrors.DEV_ERROR,
 			url,
 		)
-		logger.LogError(err, logger.ERROR)
+		l.LogError(err, logger.ERROR)
 		return "", ""
 	}
 	return matched[constants.GDRIVE_REGEX_ID_IDX], fileType
@@ -337,6 +364,9 @@ func (gdrive *GDrive) getGdriveFileInfo(gdriveId *GDriveToDl) ([]*GdriveFileToDl
 			}
 		}
 		fileInfo.FilePath = gdriveId.FilePath
+		fileInfo.PostInfo = gdriveId.PostInfo
+		fileInfo.Source = gdriveId.Source
+		fileInfo.Filters = gdriveId.Filters
 		return []*GdriveFileToDl{fileInfo}, nil
 	case "folder":
 		filesInfo, err := gdrive.GetNestedFolderContents(
@@ -352,6 +382,9 @@ func (gdrive *GDrive) getGdriveFileInfo(gdriveId *GDriveToDl) ([]*GdriveFileToDl
 		var gdriveFilesInfo []*GdriveFileToDl
 		for _, fileInfo := range filesInfo {
 			fileInfo.FilePath = gdriveId.FilePath
+			fileInfo.PostInfo = gdriveId.PostInfo
+			fileInfo.Source = gdriveId.Source
+			fileInfo.Filters = gdriveId.Filters
 			gdriveFilesInfo = append(gdriveFilesInfo, fileInfo)
 		}
 		return gdriveFilesInfo, nil
@@ -376,12 +409,15 @@ func (gdrive *GDrive) DownloadGdriveUrls(gdriveUrls []*httpfuncs.ToDownload, pro
 	// Retrieve the id from the url text
 	var gdriveIds []*GDriveToDl
 	for _, gdriveUrl := range gdriveUrls {
-		fileId, fileType := GetFileIdAndTypeFromUrl(gdriveUrl.Url)
+		fileId, fileType := getFileIdAndTypeFromUrl(gdriveUrl.Url, gdrive.getLogger())
 		if fileId != "" && fileType != "" {
 			gdriveIds = append(gdriveIds, &GDriveToDl{
 				Id:       fileId,
 				Type:     fileType,
 				FilePath: gdriveUrl.FilePath,
+				PostInfo: gdriveUrl.PostInfo,
+				Source:   gdriveUrl.Source,
+				Filters:  gdriveUrl.Filters,
 			})
 		}
 	}
@@ -6,6 +6,8 @@ import (
 
 	"github.com/KJHJason/Cultured-Downloader-Logic/cdlerrors"
 	"github.com/KJHJason/Cultured-Downloader-Logic/constants"
+	"github.com/KJHJason/Cultured-Downloader-Logic/database"
+	"github.com/KJHJason/Cultured-Downloader-Logic/logger"
 	"google.golang.org/api/drive/v3"
 	"google.golang.org/api/googleapi"
 	"google.golang.org/api/option"
@@ -17,6 +19,10 @@ type GDrive struct {
 	client             *drive.Service // Google Drive service client (if using service account credentials)
 	useCacheDb         bool
 	maxDownloadWorkers int // max concurrent workers for downloading files
+
+	// session resources set by SetDb and SetLogger, the defaults are used if nil
+	db     *database.DbWrapper
+	logger *logger.Logger
 }
 
 type CredsInputs struct {
@@ -79,6 +85,30 @@ func (gdrive *GDrive) Release() {
 	gdrive.cancel()
 }
 
+// SetDb sets the database to use for the GDrive cache and the download manifest instead of database.AppDb.
+func (gdrive *GDrive) SetDb(db *database.DbWrapper) {
+	gdrive.db = db
+}
+
+// SetLogger sets the logger to use instead of logger.MainLogger.
+func (gdrive *GDrive) SetLogger(l *logger.Logger) {
+	gdrive.logger = l
+}
+
+func (gdrive *GDrive) getDb() *database.DbWrapper {
+	if gdrive.db == nil {
+		return database.AppDb
+	}
+	return gdrive.db
+}
+
+func (gdrive *GDrive) getLogger() *logger.Logger {
+	if gdrive.logger == nil {
+		return &logger.MainLogger
+	}
+	return gdrive.logger
+}
+
 // getDefaultMaxConcurrency returns the default max concurrency if the given max concurrency is less than 1
 func getDefaultMaxConcurrency(maxConcurrency int, isAuthenticated bool) int {
 	if maxConcurrency > 1 {
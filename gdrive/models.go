@@ -4,12 +4,17 @@ import (
 	"fmt"
 
 	"github.com/KJHJason/Cultured-Downloader-Logic/constants"
+	"github.com/KJHJason/Cultured-Downloader-Logic/database"
+	"github.com/KJHJason/Cultured-Downloader-Logic/filters"
 )
 
 type GDriveToDl struct {
 	Id       string
 	Type     string
 	FilePath string
+	PostInfo *filters.PostInfo    // post details for the filter expression, can be nil
+	Source   *database.FileSource // for the download manifest, can be nil
+	Filters  *filters.Filters     // filters of the creator's filter profile, can be nil
 }
 
 type GdriveFileToDl struct {
@@ -19,12 +24,23 @@ type GdriveFileToDl struct {
 	MimeType    string
 	Md5Checksum string
 	FilePath    string
+	PostInfo    *filters.PostInfo    // post details for the filter expression, can be nil
+	Source      *database.FileSource // for the download manifest, can be nil
+	Filters     *filters.Filters     // filters of the creator's filter profile, can be nil
 }
 
 func (g GdriveFileToDl) GetUrl() string {
 	return fmt.Sprintf("%s/%s", constants.GDRIVE_FILE_API_URL, g.Id)
 }
 
+// Returns the filters of the creator's filter profile if set, otherwise the given filters.
+func (g GdriveFileToDl) getFilters(defaultFilters *filters.Filters) *filters.Filters {
+	if g.Filters != nil {
+		return g.Filters
+	}
+	return defaultFilters
+}
+
 type GdriveError struct {
 	Err      error
 	FilePath string
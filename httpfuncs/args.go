@@ -9,6 +9,7 @@ import (
 
 	"github.com/KJHJason/Cultured-Downloader-Logic/cdlerrors"
 	"github.com/KJHJason/Cultured-Downloader-Logic/constants"
+	"github.com/KJHJason/Cultured-Downloader-Logic/logger"
 )
 
 type RequestArgs struct {
@@ -30,6 +31,12 @@ type RequestArgs struct {
 	Http2 bool
 	Http3 bool
 
+	// Transports to reuse for the request, a new transport will be used for each request if nil.
+	Transports *Transports
+
+	// Logger to log the failed requests to, defaults to logger.MainLogger if nil.
+	Logger *logger.Logger
+
 	// Check status will check the status code of the response for 200 OK.
 	// If the status code is not 200 OK, it will retry several times and
 	// if the status code is still not 200 OK, it will return an error.
@@ -75,6 +82,13 @@ func (args *RequestArgs) validateHttp3Arg() error {
 	return nil
 }
 
+func (args *RequestArgs) getLogger() *logger.Logger {
+	if args.Logger == nil {
+		return &logger.MainLogger
+	}
+	return args.Logger
+}
+
 func (args *RequestArgs) getDefaultArgs() {
 	if args.RetryDelay == nil {
 		args.RetryDelay = &RetryDelay{
@@ -27,9 +27,11 @@ import (
 	"github.com/KJHJason/Cultured-Downloader-Logic/utils/threadsafe"
 )
 
-func getFullFilePath(res *http.Response, filePath string) (string, error) {
+func getFullFilePath(res *http.Response, filePath string, sanitizer *iofuncs.PathSanitizer) (string, error) {
 	// check if filepath already have a filename attached
-	if filepath.Ext(filePath) != "" {
+	// Note: paths ending with a separator are directories, see iofuncs.AsDirPath
+	isDirPath := strings.HasSuffix(filePath, string(filepath.Separator))
+	if !isDirPath && filepath.Ext(filePath) != "" {
 		filePathDir := filepath.Dir(filePath)
 		os.MkdirAll(filePathDir, 0755)
 		filePathWithoutExt := iofuncs.RemoveExtFromFilename(filePath)
@@ -47,7 +49,7 @@ func getFullFilePath(res *http.Response, filePath string) (string, error) {
 			res.Request.URL.String(),
 		)
 	}
-	filename = GetLastPartOfUrl(filename)
+	filename = sanitizer.CleanFilename(GetLastPartOfUrl(filename))
 	filenameWithoutExt := iofuncs.RemoveExtFromFilename(filename)
 	filePath = filepath.Join(
 		filePath,
@@ -108,6 +110,18 @@ type DlRequestInfo struct {
 	Ctx     context.Context
 	Url     string
 	Filters *filters.Filters
+
+	// Post details for the filter expression, can be nil
+	PostInfo *filters.PostInfo
+
+	Logger *logger.Logger // defaults to logger.MainLogger if nil
+}
+
+func (info *DlRequestInfo) getLogger() *logger.Logger {
+	if info.Logger == nil {
+		return &logger.MainLogger
+	}
+	return info.Logger
 }
 
 type PartialDlInfo struct {
@@ -167,6 +181,7 @@ func DlToFile(res *http.Response, dlRequestInfo *DlRequestInfo, filePath string,
 		fileFlags |= os.O_TRUNC
 	}
 
+	fileInfo := filters.NewFileInfo(filePath, partialDlInfo.ExpectedFileSize, res.Header.Get("Content-Type"))
 	filters := dlRequestInfo.Filters
 	if partialDlInfo.ExpectedFileSize != -1 {
 		if !filters.IsFileSizeInRange(partialDlInfo.ExpectedFileSize) {
@@ -178,6 +193,10 @@ func DlToFile(res *http.Response, dlRequestInfo *DlRequestInfo, filePath string,
 		return nil
 	}
 
+	if !filters.IsFileExprValid(dlRequestInfo.PostInfo, fileInfo) {
+		return nil
+	}
+
 	file, err := os.OpenFile(filePath, fileFlags, 0644)
 	if err != nil {
 		return fmt.Errorf(
@@ -222,17 +241,14 @@ func DlToFile(res *http.Response, dlRequestInfo *DlRequestInfo, filePath string,
 	progressTicker.Stop()
 	cancelDlInfoCtx()
 
-	if err == nil && hasDlProgBar {
-		(*dlProgBar).UpdatePercentage(100)
-		(*dlProgBar).Stop(false)
-	} else {
+	if err != nil {
 		if !partialDlInfo.DownloadPartial {
 			// Due to the checkIfCanSkipDl check before downloading,
 			// remove the file if the download process failed or was cancelled
 			// to prevent incomplete files from being kept when the server does not support range requests.
 			fileErr := os.Remove(filePath)
 			if fileErr != nil {
-				logger.LogError(
+				dlRequestInfo.getLogger().LogError(
 					fmt.Errorf(
 						"error %d: failed to remove file %s, more info => %w",
 						cdlerrors.OS_ERROR,
@@ -255,7 +271,7 @@ func DlToFile(res *http.Response, dlRequestInfo *DlRequestInfo, filePath string,
 		if hasDlProgBar {
 			(*dlProgBar).Stop(true)
 		}
-		logger.LogError(
+		dlRequestInfo.getLogger().LogError(
 			fmt.Errorf(
 				"failed to download %s due to %w",
 				dlRequestInfo.Url,
@@ -265,12 +281,37 @@ func DlToFile(res *http.Response, dlRequestInfo *DlRequestInfo, filePath string,
 		)
 		return err
 	}
+
+	// Fallback for images where the dimensions were not provided by the API
+	// which has to be checked before the download is shown as successful.
+	file.Close()
+	if !filters.IsImageFileDimensionValid(filePath) {
+		if err := os.Remove(filePath); err != nil {
+			if hasDlProgBar {
+				(*dlProgBar).Stop(true)
+			}
+			return fmt.Errorf(
+				"error %d: failed to remove image outside the dimension filters %s, more info => %w",
+				cdlerrors.OS_ERROR,
+				filePath,
+				err,
+			)
+		}
+	}
+
+	if hasDlProgBar {
+		(*dlProgBar).UpdatePercentage(100)
+		(*dlProgBar).Stop(false)
+	}
 	return nil
 }
 
 // DownloadUrl is used to download a file from a URL.
 // Note: If the file already exists, the download process will be skipped
-func downloadUrl(filePath string, queue chan struct{}, reqArgs *RequestArgs, overwriteExistingFile bool, dlOptions *DlOptions) error {
+//
+// Returns the manifest of the file if source is not nil and the file was downloaded or
+// already exists without a manifest, otherwise nil.
+func downloadUrl(filePath string, postInfo *filters.PostInfo, source *database.FileSource, postFilters *filters.Filters, queue chan struct{}, reqArgs *RequestArgs, overwriteExistingFile bool, dlOptions *DlOptions) (*database.FileManifest, error) {
 	queue <- struct{}{}
 
 	res, err := reqArgs.RequestHandler(reqArgs)
@@ -283,14 +324,14 @@ func downloadUrl(filePath string, queue chan struct{}, reqArgs *RequestArgs, ove
 				reqArgs.Url,
 			)
 		}
-		return err
+		return nil, err
 	}
 	defer res.Close()
 	fileReqContentLength := res.Resp.ContentLength
 
-	filePath, err = getFullFilePath(res.Resp, filePath)
+	filePath, err = getFullFilePath(res.Resp, filePath, dlOptions.GetSanitizer())
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	downloadedBytes, err := iofuncs.GetFileSize(filePath)
@@ -298,7 +339,7 @@ func downloadUrl(filePath string, queue chan struct{}, reqArgs *RequestArgs, ove
 		if errors.Is(err, fs.ErrNotExist) {
 			// if the error wasn't because the file does not exist,
 			// then log the error and continue with the download process
-			logger.LogError(err, logger.ERROR)
+			dlOptions.GetLogger().LogError(err, logger.ERROR)
 		}
 	}
 
@@ -336,11 +377,14 @@ func downloadUrl(filePath string, queue chan struct{}, reqArgs *RequestArgs, ove
 		forceOverwrite: overwriteExistingFile,
 		supportRange:   dlOptions.SupportRange,
 	}
-	if !checkIfCanSkipDl(skipDlArgsVal) {
+	skipDl := checkIfCanSkipDl(skipDlArgsVal)
+	if !skipDl {
 		dlReqInfo := &DlRequestInfo{
-			Ctx:     reqArgs.Context,
-			Url:     reqArgs.Url,
-			Filters: dlOptions.Filters,
+			Ctx:      reqArgs.Context,
+			Url:      reqArgs.Url,
+			Filters:  postFilters,
+			PostInfo: postInfo,
+			Logger:   dlOptions.GetLogger(),
 		}
 		dlPartialInfo := PartialDlInfo{
 			DownloadPartial:  downloadPartial,
@@ -355,7 +399,21 @@ func downloadUrl(filePath string, queue chan struct{}, reqArgs *RequestArgs, ove
 			dlProgBar.Stop(false)
 		}
 	}
-	return err
+	if err != nil || source == nil || reqArgs.Context.Err() != nil {
+		return nil, err
+	}
+
+	// the file may not exist if it was excluded by the filters
+	if !iofuncs.PathExists(filePath) || (skipDl && dlOptions.GetDb().FileManifestExists(filePath)) {
+		return nil, nil
+	}
+	manifest, err := database.NewFileManifest(reqArgs.Url, filePath, res.Resp.Header.Get("Content-Type"), source)
+	if err != nil {
+		// the file was still downloaded successfully
+		dlOptions.GetLogger().LogError(err, logger.ERROR)
+		return nil, nil
+	}
+	return manifest, nil
 }
 
 type cacheEl struct {
@@ -364,6 +422,33 @@ type cacheEl struct {
 	cacheFn  func(key string) // Note: no need to use batch call as the update is done sequentially
 }
 
+// Adds a suffix like " (1)" to file paths that are used by more than one URL
+// so that files with the same name in a post, e.g. two attachments named "image.png",
+// will not overwrite each other.
+//
+// The paths are compared case-insensitively as the file system may be case-insensitive.
+func suffixDuplicateFilePaths(urlInfoSlice []*ToDownload, sanitizer *iofuncs.PathSanitizer) {
+	seenPaths := make(map[string]string) // lowercased file path -> url
+	isTaken := func(path string) bool {
+		_, ok := seenPaths[strings.ToLower(path)]
+		return ok
+	}
+	for _, urlInfo := range urlInfoSlice {
+		filePath := urlInfo.FilePath
+		if strings.HasSuffix(filePath, string(filepath.Separator)) || filepath.Ext(filePath) == "" {
+			continue // file name will be determined when downloading
+		}
+
+		key := strings.ToLower(filePath)
+		if seenUrl, ok := seenPaths[key]; !ok || seenUrl == urlInfo.Url {
+			seenPaths[key] = urlInfo.Url
+			continue
+		}
+		urlInfo.FilePath = sanitizer.GetUniquePath(filePath, isTaken)
+		seenPaths[strings.ToLower(urlInfo.FilePath)] = urlInfo.Url
+	}
+}
+
 // DownloadUrls is used to download multiple files from URLs concurrently
 //
 // Note: If the file already exists, the download process will be skipped
@@ -375,11 +460,13 @@ func DownloadUrlsWithHandler(urlInfoSlice []*ToDownload, dlOptions *DlOptions, c
 	if urlsLen < dlOptions.MaxConcurrency {
 		dlOptions.MaxConcurrency = urlsLen
 	}
+	suffixDuplicateFilePaths(urlInfoSlice, dlOptions.GetSanitizer())
 
 	var wg sync.WaitGroup
 	queue := make(chan struct{}, dlOptions.MaxConcurrency)
 	errTsSlice := threadsafe.NewSlice[error]()
 	cacheTsSlice := threadsafe.NewSliceWithCapacity[*cacheEl](urlsLen)
+	manifestTsSlice := threadsafe.NewSlice[*database.FileManifest]()
 
 	baseMsg := "Downloading files [%d/" + fmt.Sprintf("%d]...", urlsLen)
 	progress := dlOptions.ProgressBarInfo.MainProgressBar
@@ -407,8 +494,11 @@ func DownloadUrlsWithHandler(urlInfoSlice []*ToDownload, dlOptions *DlOptions, c
 				wg.Done()
 				<-queue
 			}()
-			err := downloadUrl(
+			manifest, err := downloadUrl(
 				urlInfo.FilePath,
+				urlInfo.PostInfo,
+				urlInfo.Source,
+				urlInfo.GetFilters(dlOptions),
 				queue,
 				&RequestArgs{
 					Method:         "GET",
@@ -422,6 +512,8 @@ func DownloadUrlsWithHandler(urlInfoSlice []*ToDownload, dlOptions *DlOptions, c
 					UserAgent:      config.UserAgent,
 					RequestHandler: reqHandler,
 					Context:        dlOptions.Context,
+					Transports:     dlOptions.Transports,
+					Logger:         dlOptions.GetLogger(),
 				},
 				config.OverwriteFiles,
 				dlOptions,
@@ -430,6 +522,9 @@ func DownloadUrlsWithHandler(urlInfoSlice []*ToDownload, dlOptions *DlOptions, c
 			if hasErr {
 				errTsSlice.Append(err)
 			}
+			if manifest != nil {
+				manifestTsSlice.Append(manifest)
+			}
 			if urlInfo.CacheKey != "" {
 				cacheTsSlice.Append(&cacheEl{
 					hasErr:   hasErr,
@@ -474,17 +569,23 @@ func DownloadUrlsWithHandler(urlInfoSlice []*ToDownload, dlOptions *DlOptions, c
 			}
 			if el.cacheFn != nil {
 				el.cacheFn(cacheKey)
-			} else { // default to database.CachePost
-				database.CachePost(cacheKey)
+			} else { // default to DbWrapper.CachePost
+				dlOptions.GetDb().CachePost(cacheKey)
 			}
 		}
 	}
 
+	if manifestTsSlice.LenUnsafe() > 0 {
+		if err := dlOptions.GetDb().SetFileManifests(manifestTsSlice.CopyItemsUnsafe()); err != nil {
+			dlOptions.GetLogger().LogError(err, logger.ERROR)
+		}
+	}
+
 	hasErr := false
 	if errTsSlice.LenUnsafe() > 0 {
 		hasErr = true
 		var hasCancelled bool
-		if hasCancelled, errorSlice = logger.LogSliceErrors(logger.ERROR, errTsSlice); hasCancelled {
+		if hasCancelled, errorSlice = dlOptions.GetLogger().LogSliceErrors(logger.ERROR, errTsSlice); hasCancelled {
 			progress.StopInterrupt("Stopped downloading files (incomplete downloads will be resumed later or be deleted)...")
 			return true, errorSlice
 		}
@@ -6,7 +6,10 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/KJHJason/Cultured-Downloader-Logic/database"
 	"github.com/KJHJason/Cultured-Downloader-Logic/filters"
+	"github.com/KJHJason/Cultured-Downloader-Logic/iofuncs"
+	"github.com/KJHJason/Cultured-Downloader-Logic/logger"
 	"github.com/KJHJason/Cultured-Downloader-Logic/progress"
 )
 
@@ -70,6 +73,50 @@ type ToDownload struct {
 	CacheFn  func(key string)
 	Url      string
 	FilePath string
+
+	// Post details for the filter expression, can be nil
+	PostInfo *filters.PostInfo
+
+	// Post that the file belongs to for the download manifest, can be nil to not record the file
+	Source *database.FileSource
+
+	// Filters of the creator's filter profile, DlOptions.Filters is used if nil
+	Filters *filters.Filters
+}
+
+// SetPostInfo sets the post details used by the filter expression on all the given downloads.
+func SetPostInfo(toDownload []*ToDownload, postInfo *filters.PostInfo) {
+	for _, dl := range toDownload {
+		if dl != nil {
+			dl.PostInfo = postInfo
+		}
+	}
+}
+
+// SetSource sets the post that the given downloads belong to for the download manifest.
+func SetSource(toDownload []*ToDownload, source *database.FileSource) {
+	for _, dl := range toDownload {
+		if dl != nil {
+			dl.Source = source
+		}
+	}
+}
+
+// GetFilters returns the filters of the creator's filter profile if set, otherwise the filters of the download options.
+func (dl *ToDownload) GetFilters(dlOptions *DlOptions) *filters.Filters {
+	if dl.Filters != nil {
+		return dl.Filters
+	}
+	return dlOptions.Filters
+}
+
+// SetFilters sets the filters of the creator's filter profile on all the given downloads.
+func SetFilters(toDownload []*ToDownload, creatorFilters *filters.Filters) {
+	for _, dl := range toDownload {
+		if dl != nil {
+			dl.Filters = creatorFilters
+		}
+	}
 }
 
 type CaptchaHandler struct {
@@ -127,9 +174,39 @@ type DlOptions struct {
 
 	Filters *filters.Filters
 
+	// Sanitizer cleans the file names from the URLs and the suffixed duplicate file paths,
+	// defaults to iofuncs.DefaultPathSanitizer if nil
+	Sanitizer *iofuncs.PathSanitizer
+
 	ProgressBarInfo *progress.ProgressBarInfo
 
 	CaptchaHandler CaptchaHandler
+
+	// Session resources, the defaults are used if nil
+	Db         *database.DbWrapper // defaults to database.AppDb
+	Logger     *logger.Logger      // defaults to logger.MainLogger
+	Transports *Transports
+}
+
+func (o *DlOptions) GetDb() *database.DbWrapper {
+	if o.Db == nil {
+		return database.AppDb
+	}
+	return o.Db
+}
+
+func (o *DlOptions) GetSanitizer() *iofuncs.PathSanitizer {
+	if o.Sanitizer == nil {
+		return iofuncs.DefaultPathSanitizer
+	}
+	return o.Sanitizer
+}
+
+func (o *DlOptions) GetLogger() *logger.Logger {
+	if o.Logger == nil {
+		return &logger.MainLogger
+	}
+	return o.Logger
 }
 
 type GithubApiRes struct {
@@ -11,20 +11,41 @@ import (
 
 	"github.com/KJHJason/Cultured-Downloader-Logic/cdlerrors"
 	"github.com/KJHJason/Cultured-Downloader-Logic/constants"
-	"github.com/KJHJason/Cultured-Downloader-Logic/logger"
 	"github.com/quic-go/quic-go/http3"
 )
 
+// Transports are the HTTP/2 and HTTP/3 transports that are reused by the requests of a session
+// so that the connections are pooled and not shared with the requests of other sessions.
+type Transports struct {
+	Http2 http.RoundTripper
+	Http3 http.RoundTripper
+}
+
+func NewTransports() *Transports {
+	return &Transports{
+		Http2: &http.Transport{},
+		Http3: &http3.RoundTripper{},
+	}
+}
+
 func GetHttp2Client(reqArgs *RequestArgs) *http.Client {
+	var transport http.RoundTripper = &http.Transport{}
+	if reqArgs.Transports != nil && reqArgs.Transports.Http2 != nil {
+		transport = reqArgs.Transports.Http2
+	}
 	return &http.Client{
-		Transport: &http.Transport{},
+		Transport: transport,
 		Timeout:   time.Duration(reqArgs.Timeout) * time.Second,
 	}
 }
 
 func GetHttp3Client(reqArgs *RequestArgs) *http.Client {
+	var transport http.RoundTripper = &http3.RoundTripper{}
+	if reqArgs.Transports != nil && reqArgs.Transports.Http3 != nil {
+		transport = reqArgs.Transports.Http3
+	}
 	return &http.Client{
-		Transport: &http3.RoundTripper{},
+		Transport: transport,
 		Timeout:   time.Duration(reqArgs.Timeout) * time.Second,
 	}
 }
@@ -93,7 +114,7 @@ func Http2FallbackLogic(isUsingHttp3 *bool, failedHttp3Req *int, retryCount *int
 		// if the request failed and is not using HTTP/3
 		*retryCount++
 	}
-	logger.MainLogger.Errorf(
+	reqArgs.getLogger().Errorf(
 		"error %d: request to %s failed, more info => %v",
 		cdlerrors.CONNECTION_ERROR,
 		reqArgs.Url,
@@ -88,24 +88,21 @@ func RemoveExtFromFilename(filename string) string {
 	return strings.TrimSuffix(filename, filepath.Ext(filename))
 }
 
-// Used in CleanPathName to remove illegal characters in a path name
-func removeIllegalRuneInPath(r rune) rune {
-	if strings.ContainsRune("<>:\"/\\|?*\n\r\t", r) {
-		return '-'
-	} else if r == '.' {
-		return ','
-	}
-	return r
-}
-
 // Removes any illegal characters in a path name
 // to prevent any error with file I/O using the path name
+//
+// Uses the DefaultPathSanitizer, use a PathSanitizer for a different policy.
 func CleanPathName(pathName string) string {
-	pathName = strings.TrimSpace(pathName)
-	if len(pathName) > 255 {
-		pathName = pathName[:255]
+	return DefaultPathSanitizer.Clean(pathName)
+}
+
+// AsDirPath adds a trailing path separator to the path so that the downloader
+// will not mistake a directory name like "[123] v1.0" as a file with an extension.
+func AsDirPath(dirPath string) string {
+	if strings.HasSuffix(dirPath, string(filepath.Separator)) {
+		return dirPath
 	}
-	return strings.Map(removeIllegalRuneInPath, pathName)
+	return dirPath + string(filepath.Separator)
 }
 
 // Returns a directory path for a post, artwork, etc.
@@ -119,5 +116,5 @@ func GetPostFolder(downloadPath, creatorName, postId, postTitle string) string {
 		creatorName,
 		fmt.Sprintf("[%s] %s", postId, postTitle),
 	)
-	return postFolderPath
+	return AsDirPath(postFolderPath)
 }
@@ -0,0 +1,270 @@
+package iofuncs
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/KJHJason/Cultured-Downloader-Logic/cdlerrors"
+	"golang.org/x/text/unicode/norm"
+	"golang.org/x/text/width"
+)
+
+type SanitizePolicy int
+
+const (
+	// Only "/", NUL and control characters are replaced.
+	POSIX_SANITIZE SanitizePolicy = iota
+
+	// Also replaces characters that are illegal on Windows,
+	// removes trailing dots and spaces, and renames reserved names like "CON" or "NUL".
+	WINDOWS_SANITIZE
+
+	// Same as WINDOWS_SANITIZE but also removes private use characters
+	// which some SMB clients use to map illegal characters (e.g. macOS).
+	SMB_SANITIZE
+)
+
+const (
+	// Most file systems limit a file or directory name to 255 bytes.
+	MAX_PATH_NAME_BYTES = 255
+
+	// Extensions longer than this are treated as part of the file name when truncating.
+	maxExtBytes = 16
+)
+
+var windowsReservedNames = []string{
+	"CON", "PRN", "AUX", "NUL",
+	"COM1", "COM2", "COM3", "COM4", "COM5", "COM6", "COM7", "COM8", "COM9",
+	"LPT1", "LPT2", "LPT3", "LPT4", "LPT5", "LPT6", "LPT7", "LPT8", "LPT9",
+}
+
+// PathSanitizer cleans file and directory names so that they can be safely written to disk.
+//
+// All names are normalised to Unicode NFC so that the same name
+// will not be written twice to NAS shares that do not normalise names.
+type PathSanitizer struct {
+	Policy SanitizePolicy
+
+	// Maximum length of a name in bytes, defaults to MAX_PATH_NAME_BYTES if 0.
+	MaxBytes int
+
+	// If true, full-width characters are converted to their half-width form and
+	// accents are removed from Latin characters, e.g. "Ｃａｆé" -> "Cafe".
+	// Other characters like Japanese are kept as they are.
+	Transliterate bool
+
+	// Used to replace illegal characters, defaults to "-" if empty.
+	Replacement string
+}
+
+// Used by CleanPathName and path templates when no PathSanitizer is given.
+var DefaultPathSanitizer = &PathSanitizer{Policy: WINDOWS_SANITIZE}
+
+func (s *PathSanitizer) ValidateArgs() error {
+	if s.Policy < POSIX_SANITIZE || s.Policy > SMB_SANITIZE {
+		return fmt.Errorf(
+			"error %d: invalid path sanitize policy %d",
+			cdlerrors.INPUT_ERROR,
+			s.Policy,
+		)
+	}
+
+	if s.MaxBytes == 0 {
+		s.MaxBytes = MAX_PATH_NAME_BYTES
+	} else if s.MaxBytes < 16 || s.MaxBytes > MAX_PATH_NAME_BYTES {
+		return fmt.Errorf(
+			"error %d: max bytes for path names must be between 16 and %d, got %d",
+			cdlerrors.INPUT_ERROR,
+			MAX_PATH_NAME_BYTES,
+			s.MaxBytes,
+		)
+	}
+
+	if s.Replacement == "" {
+		s.Replacement = "-"
+	} else if strings.ContainsFunc(s.Replacement, s.isIllegalRune) || strings.Contains(s.Replacement, ".") {
+		return fmt.Errorf(
+			"error %d: replacement %q for illegal characters cannot contain illegal characters or dots",
+			cdlerrors.INPUT_ERROR,
+			s.Replacement,
+		)
+	}
+	return nil
+}
+
+func (s *PathSanitizer) getMaxBytes() int {
+	if s.MaxBytes <= 0 {
+		return MAX_PATH_NAME_BYTES
+	}
+	return s.MaxBytes
+}
+
+func (s *PathSanitizer) getReplacement() string {
+	if s.Replacement == "" {
+		return "-"
+	}
+	return s.Replacement
+}
+
+func (s *PathSanitizer) isIllegalRune(r rune) bool {
+	if r == '/' || unicode.IsControl(r) {
+		return true
+	}
+	if s.Policy == POSIX_SANITIZE {
+		return false
+	}
+	if strings.ContainsRune("<>:\"\\|?*", r) {
+		return true
+	}
+	return s.Policy == SMB_SANITIZE && unicode.Is(unicode.Co, r)
+}
+
+func (s *PathSanitizer) replaceIllegalRunes(name string) string {
+	var sb strings.Builder
+	sb.Grow(len(name))
+	for _, r := range name {
+		if s.isIllegalRune(r) {
+			sb.WriteString(s.getReplacement())
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// Converts full-width characters to half-width and removes accents from Latin characters
+func transliterate(name string) string {
+	name = width.Fold.String(name)
+	decomposed := norm.NFD.String(name)
+
+	var sb strings.Builder
+	sb.Grow(len(decomposed))
+	for _, r := range decomposed {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// TruncateToBytes cuts the string to at most maxBytes without splitting a multi-byte character.
+func TruncateToBytes(str string, maxBytes int) string {
+	if len(str) <= maxBytes {
+		return str
+	}
+	if maxBytes <= 0 {
+		return ""
+	}
+
+	cutIdx := 0
+	for idx, r := range str {
+		if idx+utf8.RuneLen(r) > maxBytes {
+			break
+		}
+		cutIdx = idx + utf8.RuneLen(r)
+	}
+	return str[:cutIdx]
+}
+
+func isWindowsReservedName(name string) bool {
+	baseName, _, _ := strings.Cut(name, ".")
+	baseName = strings.ToUpper(strings.TrimSpace(baseName))
+	for _, reserved := range windowsReservedNames {
+		if baseName == reserved {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *PathSanitizer) trimEnd(name string) string {
+	if s.Policy == POSIX_SANITIZE {
+		return name
+	}
+	return strings.TrimRight(name, ". ")
+}
+
+// Applies the policy to the name without truncating it
+func (s *PathSanitizer) clean(name string) string {
+	name = norm.NFC.String(strings.ToValidUTF8(name, s.getReplacement()))
+	if s.Transliterate {
+		name = norm.NFC.String(transliterate(name))
+	}
+
+	name = strings.TrimSpace(s.replaceIllegalRunes(name))
+	name = s.trimEnd(name)
+	if s.Policy != POSIX_SANITIZE && isWindowsReservedName(name) {
+		baseName, ext, hasExt := strings.Cut(name, ".")
+		name = baseName + "_"
+		if hasExt {
+			name += "." + ext
+		}
+	}
+	return name
+}
+
+// Returns a placeholder if the name is empty or only consists of dots
+func fixEmptyName(name string) string {
+	if strings.Trim(name, ". ") == "" {
+		return "_"
+	}
+	return name
+}
+
+// Clean sanitises a directory name or any name where the dots should not be treated as a file extension.
+func (s *PathSanitizer) Clean(name string) string {
+	name = s.clean(name)
+	name = TruncateToBytes(name, s.getMaxBytes())
+	return fixEmptyName(strings.TrimSpace(s.trimEnd(name)))
+}
+
+// CleanFilename is the same as Clean but keeps the file extension when the name has to be truncated.
+func (s *PathSanitizer) CleanFilename(filename string) string {
+	filename = s.clean(filename)
+	maxBytes := s.getMaxBytes()
+	if len(filename) <= maxBytes {
+		return fixEmptyName(filename)
+	}
+
+	ext := filepath.Ext(filename)
+	if len(ext) > maxExtBytes || strings.ContainsRune(ext, ' ') {
+		ext = ""
+	}
+	stem := strings.TrimSuffix(filename, ext)
+	stem = TruncateToBytes(stem, maxBytes-len(ext))
+	stem = strings.TrimSpace(s.trimEnd(stem))
+	return fixEmptyName(stem) + ext
+}
+
+// GetUniquePath returns the given path if isTaken returns false for it.
+// Otherwise, a suffix like " (1)" is added before the file extension until an unused path is found.
+//
+// If isTaken is nil, PathExists will be used.
+func (s *PathSanitizer) GetUniquePath(path string, isTaken func(string) bool) string {
+	if isTaken == nil {
+		isTaken = PathExists
+	}
+	if !isTaken(path) {
+		return path
+	}
+
+	dir := filepath.Dir(path)
+	filename := filepath.Base(path)
+	ext := filepath.Ext(filename)
+	if len(ext) > maxExtBytes {
+		ext = ""
+	}
+	stem := strings.TrimSuffix(filename, ext)
+	for i := 1; ; i++ {
+		suffix := fmt.Sprintf(" (%d)", i)
+		newStem := TruncateToBytes(stem, s.getMaxBytes()-len(suffix)-len(ext))
+		newPath := filepath.Join(dir, newStem+suffix+ext)
+		if !isTaken(newPath) {
+			return newPath
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package iofuncs
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncateToBytes(t *testing.T) {
+	str := "あいうえお" // 3 bytes each
+	for maxBytes := 0; maxBytes <= len(str)+1; maxBytes++ {
+		truncated := TruncateToBytes(str, maxBytes)
+		if len(truncated) > maxBytes {
+			t.Errorf("Expected at most %d bytes but got %d", maxBytes, len(truncated))
+		}
+		if !utf8.ValidString(truncated) {
+			t.Errorf("Truncated string %q is not valid UTF-8", truncated)
+		}
+	}
+	if got := TruncateToBytes(str, 7); got != "あい" {
+		t.Errorf("Expected %q but got %q", "あい", got)
+	}
+}
+
+func TestSanitizePolicies(t *testing.T) {
+	testCases := []struct {
+		policy   SanitizePolicy
+		input    string
+		expected string
+	}{
+		{POSIX_SANITIZE, "a/b\nc", "a-b-c"},
+		{POSIX_SANITIZE, "what?:*", "what?:*"},
+		{POSIX_SANITIZE, "trailing. ", "trailing."},
+		{WINDOWS_SANITIZE, "what?:*", "what---"},
+		{WINDOWS_SANITIZE, "v1.0 release", "v1.0 release"},
+		{WINDOWS_SANITIZE, "trailing. . ", "trailing"},
+		{WINDOWS_SANITIZE, "CON", "CON_"},
+		{WINDOWS_SANITIZE, "nul.txt", "nul_.txt"},
+		{WINDOWS_SANITIZE, "CONSOLE", "CONSOLE"},
+		{WINDOWS_SANITIZE, "..", "_"},
+		{WINDOWS_SANITIZE, "", "_"},
+		{SMB_SANITIZE, "ab", "a-b"},
+		{WINDOWS_SANITIZE, "ab", "ab"},
+	}
+	for _, tc := range testCases {
+		s := &PathSanitizer{Policy: tc.policy}
+		if got := s.Clean(tc.input); got != tc.expected {
+			t.Errorf("Policy %d: expected %q for %q but got %q", tc.policy, tc.expected, tc.input, got)
+		}
+	}
+}
+
+func TestSanitizeNormalisation(t *testing.T) {
+	nfd := "ガ" // KA + combining voiced mark
+	s := &PathSanitizer{Policy: POSIX_SANITIZE}
+	if got := s.Clean(nfd); got != "ガ" {
+		t.Errorf("Expected NFC %q but got %q", "ガ", got)
+	}
+
+	s.Transliterate = true
+	if got := s.Clean("Ｃａｆé ガ"); got != "Cafe カ" {
+		t.Errorf("Expected %q but got %q", "Cafe カ", got)
+	}
+}
+
+func TestCleanFilenameKeepsExt(t *testing.T) {
+	s := &PathSanitizer{Policy: WINDOWS_SANITIZE, MaxBytes: 20}
+	got := s.CleanFilename(strings.Repeat("あ", 20) + ".png")
+	if !strings.HasSuffix(got, ".png") {
+		t.Errorf("Expected extension to be kept but got %q", got)
+	}
+	if len(got) > 20 || !utf8.ValidString(got) {
+		t.Errorf("Expected a valid name of at most 20 bytes but got %q", got)
+	}
+
+	got = s.Clean(strings.Repeat("a", 30) + ".png")
+	if len(got) != 20 {
+		t.Errorf("Expected directory name to be cut at 20 bytes but got %q", got)
+	}
+}
+
+func TestGetUniquePath(t *testing.T) {
+	taken := map[string]bool{
+		filepath.Join("dir", "image.png"):     true,
+		filepath.Join("dir", "image (1).png"): true,
+	}
+	isTaken := func(path string) bool {
+		return taken[path]
+	}
+
+	s := &PathSanitizer{}
+	if got := s.GetUniquePath(filepath.Join("dir", "other.png"), isTaken); got != filepath.Join("dir", "other.png") {
+		t.Errorf("Expected unused path to be returned as is but got %q", got)
+	}
+	if got := s.GetUniquePath(filepath.Join("dir", "image.png"), isTaken); got != filepath.Join("dir", "image (2).png") {
+		t.Errorf("Expected %q but got %q", filepath.Join("dir", "image (2).png"), got)
+	}
+}
+
+func TestValidateSanitizerArgs(t *testing.T) {
+	invalid := []*PathSanitizer{
+		{Policy: SanitizePolicy(10)},
+		{MaxBytes: 1000},
+		{Policy: WINDOWS_SANITIZE, Replacement: "?"},
+		{Replacement: "."},
+	}
+	for _, s := range invalid {
+		if err := s.ValidateArgs(); err == nil {
+			t.Errorf("Expected error for %+v", s)
+		}
+	}
+
+	s := &PathSanitizer{}
+	if err := s.ValidateArgs(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if s.MaxBytes != MAX_PATH_NAME_BYTES || s.Replacement != "-" {
+		t.Errorf("Expected defaults to be set but got %+v", s)
+	}
+}
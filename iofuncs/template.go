@@ -0,0 +1,266 @@
+package iofuncs
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KJHJason/Cultured-Downloader-Logic/cdlerrors"
+)
+
+// Fields that can be used in a path template, e.g. "{creator_name}/[{post_id}] {title}".
+//
+// Not every field is available for every platform or template kind.
+const (
+	TMPL_SITE         = "site"
+	TMPL_CREATOR_NAME = "creator_name"
+	TMPL_CREATOR_ID   = "creator_id"
+	TMPL_POST_ID      = "post_id"
+	TMPL_TITLE        = "title"
+	TMPL_DATE         = "date"    // accepts a Go time layout, e.g. {date:2006-01}
+	TMPL_SERVICE      = "service" // Kemono only
+	TMPL_INDEX        = "index"   // accepts a zero-padded width, e.g. {index:03}
+	TMPL_FILENAME     = "filename"
+	TMPL_EXT          = "ext"
+	TMPL_SUBFOLDER    = "subfolder" // e.g. "images" or "attachments", empty for files in the post folder
+
+	DEFAULT_TMPL_DATE_LAYOUT = "2006-01-02"
+)
+
+// The subfolder field has to be a directory on its own in a template
+const subfolderTmplSegment = "{" + TMPL_SUBFOLDER + "}"
+
+// Characters that cannot be used in the literal text of a template
+// as they are illegal in path names on some operating systems.
+const illegalTmplLiteralChars = "<>:\"\\|?*\n\r\t"
+
+// PathTemplateInfo contains the values used to fill in a PathTemplate.
+type PathTemplateInfo struct {
+	Site        string
+	CreatorName string
+	CreatorId   string
+	PostId      string
+	Title       string
+	Service     string
+	Date        time.Time
+
+	// File level values which are only used in file name templates
+	Index     int
+	Filename  string // without the file extension
+	Ext       string // without the leading dot
+	Subfolder string // subfolder of the file in the post folder, empty if none
+}
+
+type tmplPart struct {
+	literal string
+	field   string
+	format  string
+}
+
+// PathTemplate is a parsed path or file name template.
+type PathTemplate struct {
+	raw   string
+	parts []tmplPart
+}
+
+// String returns the raw template string
+func (t *PathTemplate) String() string {
+	return t.raw
+}
+
+// HasField returns true if the template uses the given field
+func (t *PathTemplate) HasField(field string) bool {
+	for _, part := range t.parts {
+		if part.field == field {
+			return true
+		}
+	}
+	return false
+}
+
+func validateTmplFormat(field, format string) error {
+	switch field {
+	case TMPL_DATE:
+		if strings.ContainsAny(format, illegalTmplLiteralChars+"/") {
+			return errors.New("date layout contains illegal characters")
+		}
+	case TMPL_INDEX:
+		if _, err := strconv.Atoi(format); err != nil {
+			return errors.New("index format must be a number like \"03\"")
+		}
+	default:
+		return fmt.Errorf("field %q does not accept a format", field)
+	}
+	return nil
+}
+
+// ParsePathTemplate parses a template string like "{creator_name}/[{post_id}] {title}".
+//
+// Only the fields in allowedFields can be used in the template.
+// Use "/" to separate the directories in the template.
+func ParsePathTemplate(tmpl string, allowedFields []string) (*PathTemplate, error) {
+	tmpl = strings.TrimSpace(tmpl)
+	newErr := func(msg string) error {
+		return fmt.Errorf(
+			"error %d: invalid path template %q, %s",
+			cdlerrors.INPUT_ERROR,
+			tmpl,
+			msg,
+		)
+	}
+	if tmpl == "" {
+		return nil, newErr("template cannot be empty")
+	}
+
+	var parts []tmplPart
+	var literal strings.Builder
+	for i := 0; i < len(tmpl); i++ {
+		c := tmpl[i]
+		switch c {
+		case '}':
+			return nil, newErr(fmt.Sprintf("unexpected '}' at position %d", i))
+		case '{':
+			end := strings.IndexByte(tmpl[i:], '}')
+			if end == -1 {
+				return nil, newErr(fmt.Sprintf("unclosed '{' at position %d", i))
+			}
+			if literal.Len() > 0 {
+				parts = append(parts, tmplPart{literal: literal.String()})
+				literal.Reset()
+			}
+
+			field, format, _ := strings.Cut(tmpl[i+1:i+end], ":")
+			if !slices.Contains(allowedFields, field) {
+				return nil, newErr(
+					fmt.Sprintf("unknown field %q, allowed fields are %s", field, strings.Join(allowedFields, ", ")),
+				)
+			}
+			if format != "" {
+				if err := validateTmplFormat(field, format); err != nil {
+					return nil, newErr(err.Error())
+				}
+			}
+			parts = append(parts, tmplPart{field: field, format: format})
+			i += end
+		default:
+			if strings.IndexByte(illegalTmplLiteralChars, c) != -1 {
+				return nil, newErr(fmt.Sprintf("illegal character %q at position %d", c, i))
+			}
+			literal.WriteByte(c)
+		}
+	}
+	if literal.Len() > 0 {
+		parts = append(parts, tmplPart{literal: literal.String()})
+	}
+
+	segments := strings.Split(tmpl, "/")
+	for i, segment := range segments {
+		if strings.TrimSpace(segment) == "" || segment == "." || segment == ".." {
+			return nil, newErr("template cannot contain empty, \".\" or \"..\" directories")
+		}
+		if strings.Contains(segment, "{"+TMPL_SUBFOLDER) && (segment != subfolderTmplSegment || i == len(segments)-1) {
+			return nil, newErr(fmt.Sprintf("{%s} must be a directory on its own like \"{%s}/{filename}.{ext}\"", TMPL_SUBFOLDER, TMPL_SUBFOLDER))
+		}
+	}
+	return &PathTemplate{raw: tmpl, parts: parts}, nil
+}
+
+func (t *PathTemplate) fieldValue(part tmplPart, info *PathTemplateInfo, sanitizer *PathSanitizer) string {
+	switch part.field {
+	case TMPL_SITE:
+		return sanitizer.Clean(info.Site)
+	case TMPL_CREATOR_NAME:
+		return sanitizer.Clean(info.CreatorName)
+	case TMPL_CREATOR_ID:
+		return sanitizer.Clean(info.CreatorId)
+	case TMPL_POST_ID:
+		return sanitizer.Clean(info.PostId)
+	case TMPL_TITLE:
+		return sanitizer.Clean(info.Title)
+	case TMPL_SERVICE:
+		return sanitizer.Clean(info.Service)
+	case TMPL_DATE:
+		layout := part.format
+		if layout == "" {
+			layout = DEFAULT_TMPL_DATE_LAYOUT
+		}
+		return sanitizer.Clean(info.Date.Format(layout))
+	case TMPL_INDEX:
+		if part.format == "" {
+			return strconv.Itoa(info.Index)
+		}
+		width, _ := strconv.Atoi(part.format)
+		return fmt.Sprintf("%0*d", width, info.Index)
+	case TMPL_FILENAME:
+		return sanitizer.Clean(info.Filename)
+	case TMPL_EXT:
+		return strings.ToLower(sanitizer.Clean(strings.TrimPrefix(info.Ext, ".")))
+	case TMPL_SUBFOLDER:
+		return strings.Join(getSubfolderDirs(info, sanitizer), "/")
+	default:
+		return ""
+	}
+}
+
+// Execute fills in the template using the given info and
+// returns the resulting relative path using the OS path separator.
+//
+// The values and the resulting names are cleaned using the sanitizer or
+// DefaultPathSanitizer if nil. The last name is treated as a file name if isFile is true.
+func (t *PathTemplate) Execute(info *PathTemplateInfo, sanitizer *PathSanitizer, isFile bool) string {
+	if sanitizer == nil {
+		sanitizer = DefaultPathSanitizer
+	}
+
+	var sb strings.Builder
+	for _, part := range t.parts {
+		if part.field == "" {
+			sb.WriteString(part.literal)
+			continue
+		}
+		sb.WriteString(t.fieldValue(part, info, sanitizer))
+	}
+
+	// Only the subfolder can contain "/" after being cleaned
+	// and it is a directory on its own so that the segments can be
+	// matched to the template's segments to skip an empty subfolder.
+	subfolderDirs := getSubfolderDirs(info, sanitizer)
+	tmplSegments := strings.Split(t.raw, "/")
+	segments := strings.Split(sb.String(), "/")
+	lastIdx := len(tmplSegments) - 1
+	cleanedSegments := make([]string, 0, len(segments))
+	segmentIdx := 0
+	for i, tmplSegment := range tmplSegments {
+		if tmplSegment == subfolderTmplSegment {
+			cleanedSegments = append(cleanedSegments, subfolderDirs...)
+			segmentIdx += max(len(subfolderDirs), 1)
+			continue
+		}
+
+		// Clean again as the combined name can exceed the length limit
+		// or end with a dot or space due to the literal text in the template.
+		segment := segments[segmentIdx]
+		segmentIdx++
+		if isFile && i == lastIdx {
+			cleanedSegments = append(cleanedSegments, sanitizer.CleanFilename(segment))
+		} else {
+			cleanedSegments = append(cleanedSegments, sanitizer.Clean(segment))
+		}
+	}
+	return filepath.Join(cleanedSegments...)
+}
+
+// Returns the cleaned directories of the subfolder which can be nested like "blog_contents/1"
+func getSubfolderDirs(info *PathTemplateInfo, sanitizer *PathSanitizer) []string {
+	var dirs []string
+	for _, dir := range strings.Split(filepath.ToSlash(info.Subfolder), "/") {
+		if dir != "" {
+			dirs = append(dirs, sanitizer.Clean(dir))
+		}
+	}
+	return dirs
+}
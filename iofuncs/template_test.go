@@ -0,0 +1,98 @@
+package iofuncs
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var testTmplFields = []string{
+	TMPL_SITE,
+	TMPL_CREATOR_NAME,
+	TMPL_CREATOR_ID,
+	TMPL_POST_ID,
+	TMPL_TITLE,
+	TMPL_DATE,
+	TMPL_INDEX,
+	TMPL_FILENAME,
+	TMPL_EXT,
+	TMPL_SUBFOLDER,
+}
+
+func TestDefaultPathTemplate(t *testing.T) {
+	tmpl, err := ParsePathTemplate("{creator_name}/[{post_id}] {title}", testTmplFields)
+	if err != nil {
+		t.Fatalf("Error parsing template: %v", err)
+	}
+
+	info := &PathTemplateInfo{
+		CreatorName: "Creator: Name",
+		PostId:      "123",
+		Title:       "Title v1.0?",
+	}
+	expected := GetPostFolder("dl", info.CreatorName, info.PostId, info.Title)
+	if got := AsDirPath(filepath.Join("dl", tmpl.Execute(info, nil, false))); got != expected {
+		t.Errorf("Expected %q but got %q", expected, got)
+	}
+}
+
+func TestPathTemplateFormats(t *testing.T) {
+	tmpl, err := ParsePathTemplate("{site}/{date:2006-01}/{post_id} {title}/{index:03}.{ext}", testTmplFields)
+	if err != nil {
+		t.Fatalf("Error parsing template: %v", err)
+	}
+
+	info := &PathTemplateInfo{
+		Site:   "fanbox",
+		PostId: "1",
+		Title:  "a/b",
+		Date:   time.Date(2024, 5, 12, 0, 0, 0, 0, time.UTC),
+		Index:  7,
+		Ext:    ".PNG",
+	}
+	expected := filepath.Join("fanbox", "2024-05", "1 a-b", "007.png")
+	if got := tmpl.Execute(info, nil, true); got != expected {
+		t.Errorf("Expected %q but got %q", expected, got)
+	}
+}
+
+func TestInvalidPathTemplates(t *testing.T) {
+	invalidTmpls := []string{
+		"",
+		"{unknown}",
+		"{title",
+		"title}",
+		"{title:03}",
+		"{index:abc}",
+		"{creator_name}//{post_id}",
+		"../{post_id}",
+		"{post_id}?",
+		"{subfolder}_{filename}.{ext}",
+		"{filename}.{ext}/{subfolder}",
+	}
+	for _, tmpl := range invalidTmpls {
+		if _, err := ParsePathTemplate(tmpl, testTmplFields); err == nil {
+			t.Errorf("Expected error for template %q", tmpl)
+		}
+	}
+}
+
+func TestPathTemplateSubfolder(t *testing.T) {
+	tmpl, err := ParsePathTemplate("{subfolder}/{index:02}.{ext}", testTmplFields)
+	if err != nil {
+		t.Fatalf("Error parsing template: %v", err)
+	}
+
+	testCases := map[string]string{
+		"":                   "01.png",
+		"images":             filepath.Join("images", "01.png"),
+		"blog_contents/3":    filepath.Join("blog_contents", "3", "01.png"),
+		"paid:content?/../x": filepath.Join("paid-content-", "_", "x", "01.png"),
+	}
+	for subfolder, expected := range testCases {
+		info := &PathTemplateInfo{Index: 1, Ext: ".png", Subfolder: subfolder}
+		if got := tmpl.Execute(info, nil, true); got != expected {
+			t.Errorf("Expected %q for the subfolder %q but got %q", expected, subfolder, got)
+		}
+	}
+}
@@ -30,7 +30,7 @@ func KemonoDownloadProcess(kemonoDl *kemono.KemonoDl, dlOptions *kemono.KemonoDl
 		favToDl, favGdriveLinks, err := kemono.GetFavourites(dlOptions)
 		hasErr := (err != nil)
 		if hasErr {
-			cancel := logger.LogErrors(logger.ERROR, err...)
+			cancel := dlOptions.Base.Session.GetLogger().LogErrors(logger.ERROR, err...)
 			if cancel {
 				return nil
 			}
@@ -79,7 +79,11 @@ func KemonoDownloadProcess(kemonoDl *kemono.KemonoDl, dlOptions *kemono.KemonoDl
 					Max: constants.KEMONO_RETRY_MAX_DELAY,
 				},
 				Filters:         dlOptions.Base.Filters,
+				Sanitizer:       dlOptions.Base.Sanitizer,
 				ProgressBarInfo: dlOptions.Base.ProgressBarInfo,
+				Db:              dlOptions.Base.Session.GetDb(),
+				Logger:          dlOptions.Base.Session.GetLogger(),
+				Transports:      dlOptions.Base.Session.GetTransports(),
 			},
 			dlOptions.Base.Configs,
 		)
@@ -102,6 +106,7 @@ func KemonoDownloadProcess(kemonoDl *kemono.KemonoDl, dlOptions *kemono.KemonoDl
 		}
 	}
 
+	errSlice = commitSyncWatermarks(dlOptions.Base, dlOptions.CtxIsActive(), errSlice)
 	notifier := dlOptions.Base.Notifier
 	if downloadedPosts {
 		notifier.Alert("Downloaded all posts from Kemono!")
@@ -0,0 +1,245 @@
+package cdlogic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/KJHJason/Cultured-Downloader-Logic/api"
+	"github.com/KJHJason/Cultured-Downloader-Logic/api/kemono"
+	"github.com/KJHJason/Cultured-Downloader-Logic/configs"
+	"github.com/KJHJason/Cultured-Downloader-Logic/constants"
+	"github.com/KJHJason/Cultured-Downloader-Logic/database"
+	"github.com/KJHJason/Cultured-Downloader-Logic/filters"
+	"github.com/KJHJason/Cultured-Downloader-Logic/httpfuncs"
+	"github.com/KJHJason/Cultured-Downloader-Logic/logger"
+	"github.com/KJHJason/Cultured-Downloader-Logic/progress"
+)
+
+const (
+	testKemonoService   = "fanbox"
+	testKemonoCreatorId = "123"
+)
+
+type testNotifier struct{}
+
+func (n testNotifier) Alert(msg string) {}
+func (n testNotifier) Release()         {}
+
+type errReader struct{}
+
+func (r errReader) Read(p []byte) (int, error) {
+	return 0, errors.New("connection reset")
+}
+
+// testKemonoTransport serves the creator's posts and their attachments
+// and records the requests made by the download process.
+type testKemonoTransport struct {
+	mu        sync.Mutex
+	postIds   []string // newest first
+	failFiles bool
+	requests  []string
+}
+
+func (tr *testKemonoTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	tr.requests = append(tr.requests, req.URL.RequestURI())
+
+	var body io.Reader
+	switch req.URL.Path {
+	case fmt.Sprintf("/api/v1/%s/user/%s", testKemonoService, testKemonoCreatorId):
+		posts := []*kemono.MainKemonoJson{}
+		if req.URL.Query().Get("o") == "0" {
+			for _, postId := range tr.postIds {
+				post := &kemono.MainKemonoJson{
+					Id:        postId,
+					Published: "2024-05-12T10:00:0" + postId,
+					Service:   testKemonoService,
+					Title:     "Post " + postId,
+					User:      testKemonoCreatorId,
+				}
+				post.Attachments = append(post.Attachments, struct {
+					Name string `json:"name"`
+					Path string `json:"path"`
+				}{Name: postId + ".png", Path: "/data/" + postId + ".png"})
+				posts = append(posts, post)
+			}
+		}
+		jsonBytes, _ := json.Marshal(posts)
+		body = strings.NewReader(string(jsonBytes))
+	case fmt.Sprintf("/%s/user/%s", testKemonoService, testKemonoCreatorId):
+		body = strings.NewReader(`<span itemprop="name">creator</span>`)
+	default:
+		if tr.failFiles {
+			body = errReader{}
+		} else {
+			body = strings.NewReader("attachment")
+		}
+	}
+	return &http.Response{
+		StatusCode:    http.StatusOK,
+		Status:        "200 OK",
+		Header:        make(http.Header),
+		Body:          io.NopCloser(body),
+		ContentLength: -1,
+		Request:       req,
+	}, nil
+}
+
+// Returns the requests made since the last call.
+func (tr *testKemonoTransport) popRequests() []string {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	requests := tr.requests
+	tr.requests = nil
+	return requests
+}
+
+func runTestKemonoSync(t *testing.T, db *database.DbWrapper, dlDirPath string, transport *testKemonoTransport, profiles *filters.FilterProfiles) []error {
+	l := logger.NewLogger(io.Discard)
+	session := api.NewSession(db, &l)
+	session.Transports = &httpfuncs.Transports{Http2: transport, Http3: transport}
+
+	dlOptions := &kemono.KemonoDlOptions{
+		Base: &api.BaseDl{
+			Notifier:        testNotifier{},
+			Session:         session,
+			DlAttachments:   true,
+			UseCacheDb:      true,
+			IncrementalSync: true,
+			DownloadDirPath: dlDirPath,
+			Filters:         &filters.Filters{},
+			FilterProfiles:  profiles,
+			Configs:         &configs.Config{UserAgent: "test"},
+			ProgressBarInfo: &progress.ProgressBarInfo{MainProgressBar: &progress.DummyProgBar{}},
+		},
+	}
+	dlOptions.SetContext(context.Background())
+	if err := dlOptions.Base.ValidatePathTemplates(constants.KEMONO); err != nil {
+		t.Fatalf("Failed to validate the path templates: %v", err)
+	}
+	if err := dlOptions.Base.ValidateFilterProfiles(); err != nil {
+		t.Fatalf("Failed to validate the filter profiles: %v", err)
+	}
+
+	kemonoDl := &kemono.KemonoDl{
+		CreatorsToDl: []*kemono.KemonoCreatorToDl{
+			{Service: testKemonoService, CreatorId: testKemonoCreatorId},
+		},
+	}
+	return KemonoDownloadProcess(kemonoDl, dlOptions, false)
+}
+
+func newTestDb(t *testing.T) *database.DbWrapper {
+	db, err := database.NewDb(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := db.Migrate(); err != nil {
+		t.Fatalf("Failed to migrate db: %v", err)
+	}
+	return db
+}
+
+// Returns the API pages and the attachments that were requested.
+func splitTestKemonoRequests(requests []string) (pages []string, files []string) {
+	for _, request := range requests {
+		if strings.HasPrefix(request, "/api/") {
+			pages = append(pages, request)
+		} else if strings.HasPrefix(request, "/data/") {
+			files = append(files, request)
+		}
+	}
+	slices.Sort(files)
+	return pages, files
+}
+
+func TestKemonoIncrementalSync(t *testing.T) {
+	db := newTestDb(t)
+	dlDirPath := t.TempDir()
+	transport := &testKemonoTransport{postIds: []string{"2", "1"}}
+	if errs := runTestKemonoSync(t, db, dlDirPath, transport, nil); len(errs) > 0 {
+		t.Fatalf("Failed to download the creator's posts: %v", errs)
+	}
+	pages, files := splitTestKemonoRequests(transport.popRequests())
+	if len(pages) != 2 || !slices.Equal(files, []string{"/data/1.png", "/data/2.png"}) {
+		t.Fatalf("Expected all the pages and attachments to be requested in the first sync but got %q and %q", pages, files)
+	}
+
+	watermark := db.GetCreatorWatermark(testKemonoService+"/"+testKemonoCreatorId, constants.KEMONO)
+	if watermark == nil || watermark.PostId != "2" {
+		t.Fatalf("Expected the newest post to be saved as the watermark but got %+v", watermark)
+	}
+
+	transport.postIds = []string{"3", "2", "1"}
+	if errs := runTestKemonoSync(t, db, dlDirPath, transport, nil); len(errs) > 0 {
+		t.Fatalf("Failed to download the creator's posts: %v", errs)
+	}
+	pages, files = splitTestKemonoRequests(transport.popRequests())
+	if len(pages) != 1 || !slices.Equal(files, []string{"/data/3.png"}) {
+		t.Errorf("Expected the second sync to stop at the watermark but got %q and %q", pages, files)
+	}
+	if watermark := db.GetCreatorWatermark(testKemonoService+"/"+testKemonoCreatorId, constants.KEMONO); watermark == nil || watermark.PostId != "3" {
+		t.Errorf("Expected the watermark to be moved to the new post but got %+v", watermark)
+	}
+}
+
+func TestKemonoIncrementalSyncFailedDownload(t *testing.T) {
+	db := newTestDb(t)
+	dlDirPath := t.TempDir()
+	transport := &testKemonoTransport{postIds: []string{"2", "1"}, failFiles: true}
+	if errs := runTestKemonoSync(t, db, dlDirPath, transport, nil); len(errs) == 0 {
+		t.Fatal("Expected the failed downloads to be returned")
+	}
+	if watermark := db.GetCreatorWatermark(testKemonoService+"/"+testKemonoCreatorId, constants.KEMONO); watermark != nil {
+		t.Fatalf("Expected no watermark to be saved after failed downloads but got %+v", watermark)
+	}
+	transport.popRequests()
+
+	transport.failFiles = false
+	if errs := runTestKemonoSync(t, db, dlDirPath, transport, nil); len(errs) > 0 {
+		t.Fatalf("Failed to download the creator's posts: %v", errs)
+	}
+	pages, files := splitTestKemonoRequests(transport.popRequests())
+	if len(pages) != 2 || !slices.Equal(files, []string{"/data/1.png", "/data/2.png"}) {
+		t.Errorf("Expected the failed posts to be downloaded again but got %q and %q", pages, files)
+	}
+}
+
+func TestKemonoFilterProfiles(t *testing.T) {
+	profiles := &filters.FilterProfiles{
+		Profiles: []*filters.FilterProfile{
+			{Name: "psd-only", FileExt: []string{".psd"}},
+			{Name: "png-only", FileExt: []string{".png"}},
+		},
+		Assignments: map[string]string{
+			constants.KEMONO: "psd-only",
+		},
+	}
+	transport := &testKemonoTransport{postIds: []string{"1"}}
+	if errs := runTestKemonoSync(t, newTestDb(t), t.TempDir(), transport, profiles); len(errs) > 0 {
+		t.Fatalf("Failed to download the creator's posts: %v", errs)
+	}
+	if _, files := splitTestKemonoRequests(transport.popRequests()); len(files) > 0 {
+		t.Fatalf("Expected the attachments to be excluded by the site's profile but got %q", files)
+	}
+
+	// the creator's profile takes precedence over the site's profile
+	profiles.Assignments[constants.KEMONO+"/"+testKemonoService+"/"+testKemonoCreatorId] = "png-only"
+	if errs := runTestKemonoSync(t, newTestDb(t), t.TempDir(), transport, profiles); len(errs) > 0 {
+		t.Fatalf("Failed to download the creator's posts: %v", errs)
+	}
+	if _, files := splitTestKemonoRequests(transport.popRequests()); !slices.Equal(files, []string{"/data/1.png"}) {
+		t.Errorf("Expected the attachments to be downloaded with the creator's profile but got %q", files)
+	}
+}
@@ -120,17 +120,36 @@ func DeleteEmptyAndOldLogs() error {
 
 // Thread-safe logging function that logs to "cultured_downloader.log" in the logs directory
 func LogError(err error, level int) {
-	if err == nil {
-		return
-	}
-
-	MainLogger.LogBasedOnLvl(level, err.Error()+LOG_SUFFIX)
+	MainLogger.LogError(err, level)
 }
 
 // Uses the thread-safe LogError() function to log multiple errors
 //
 // Also returns if any errors were due to context.Canceled which is caused by Ctrl + C.
 func LogErrors(level int, errs ...error) bool {
+	return MainLogger.LogErrors(level, errs...)
+}
+
+// Uses the thread-safe LogError() function to log a slice of errors
+//
+// Note that the thread-safe slice will be cleared after logging using `ClearUnsafe`.
+//
+// Also returns if any errors were due to context.Canceled which is caused by Ctrl + C.
+func LogSliceErrors(level int, tsErrSlice *threadsafe.Slice[error]) (bool, []error) {
+	return MainLogger.LogSliceErrors(level, tsErrSlice)
+}
+
+// Thread-safe logging function that logs the error to the logger's output
+func (l Logger) LogError(err error, level int) {
+	if err == nil {
+		return
+	}
+
+	l.LogBasedOnLvl(level, err.Error()+LOG_SUFFIX)
+}
+
+// Same as LogErrors but logs to the logger's output
+func (l Logger) LogErrors(level int, errs ...error) bool {
 	var hasCanceled bool
 	for _, err := range errs {
 		if errors.Is(err, context.Canceled) {
@@ -139,17 +158,13 @@ func LogErrors(level int, errs ...error) bool {
 			}
 			continue
 		}
-		LogError(err, level)
+		l.LogError(err, level)
 	}
 	return hasCanceled
 }
 
-// Uses the thread-safe LogError() function to log a slice of errors
-//
-// Note that the thread-safe slice will be cleared after logging using `ClearUnsafe`.
-//
-// Also returns if any errors were due to context.Canceled which is caused by Ctrl + C.
-func LogSliceErrors(level int, tsErrSlice *threadsafe.Slice[error]) (bool, []error) {
+// Same as LogSliceErrors but logs to the logger's output
+func (l Logger) LogSliceErrors(level int, tsErrSlice *threadsafe.Slice[error]) (bool, []error) {
 	var hasCanceled bool
 
 	errSlice := make([]error, 0, tsErrSlice.Len())
@@ -162,7 +177,7 @@ func LogSliceErrors(level int, tsErrSlice *threadsafe.Slice[error]) (bool, []err
 			}
 			continue
 		}
-		LogError(err, level)
+		l.LogError(err, level)
 		errSlice = append(errSlice, err)
 	}
 	tsErrSlice.ClearUnsafe()
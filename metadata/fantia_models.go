@@ -10,6 +10,9 @@ type FantiaPost struct {
 	Title                string    `json:"title"`
 	PostComment          string    `json:"post_comment"`
 	EmbeddedPostComments []string  `json:"embedded_post_comments"`
+	Creator              string    `json:"creator,omitempty"`
+	CreatorId            string    `json:"creator_id,omitempty"`
+	Tags                 []string  `json:"tags,omitempty"`
 }
 
 type FantiaProductPricing struct {
@@ -23,4 +26,5 @@ type FantiaProduct struct {
 	Description string               `json:"description"`
 	Images      []string             `json:"images"`
 	Pricing     FantiaProductPricing `json:"Pricing"`
+	Creator     string               `json:"creator,omitempty"`
 }
@@ -14,4 +14,6 @@ type KemonoPost struct {
 	Content      string                    `json:"content"`
 	PublishedUTC string                    `json:"published_utc"`
 	EmbedContent KemonoPostEmbeddedContent `json:"embed_content"`
+	Creator      string                    `json:"creator,omitempty"`
+	CreatorId    string                    `json:"creator_id,omitempty"`
 }
@@ -7,12 +7,30 @@ import (
 
 	"github.com/KJHJason/Cultured-Downloader-Logic/cdlerrors"
 	"github.com/KJHJason/Cultured-Downloader-Logic/constants"
+	"github.com/KJHJason/Cultured-Downloader-Logic/database"
 	"github.com/KJHJason/Cultured-Downloader-Logic/iofuncs"
 	"github.com/KJHJason/Cultured-Downloader-Logic/logger"
 )
 
 type MetadataTypes interface {
-	FantiaPost | FantiaProduct | KemonoPost | PixivFanboxPost | PixivPost
+	FantiaPost | FantiaProduct | KemonoPost | PixivFanboxPost | PixivPost | PixivNovel
+	searchDoc() *database.SearchDoc
+}
+
+// WriteOptions are the optional settings of WriteMetadataWithOptions.
+type WriteOptions struct {
+	// If not nil, the metadata will also be added to
+	// the search index even if the file already exists.
+	SearchIndex *database.DbWrapper
+
+	Logger *logger.Logger // defaults to logger.MainLogger if nil
+}
+
+func (o *WriteOptions) getLogger() *logger.Logger {
+	if o.Logger == nil {
+		return &logger.MainLogger
+	}
+	return o.Logger
 }
 
 // Marshal the metadata into a JSON file
@@ -20,15 +38,26 @@ type MetadataTypes interface {
 //
 // If the file already exists, do nothing.
 func WriteMetadata[T MetadataTypes](metadata T, dirPath string) error {
-	filePath := filepath.Join(dirPath, "post_metadata.json")
+	return WriteMetadataWithOptions(metadata, dirPath, nil)
+}
+
+// WriteMetadataWithOptions is the same as WriteMetadata
+// but with the optional settings, options can be nil.
+func WriteMetadataWithOptions[T MetadataTypes](metadata T, dirPath string, options *WriteOptions) error {
+	if options == nil {
+		options = &WriteOptions{}
+	}
+
+	filePath := filepath.Join(dirPath, constants.METADATA_FILENAME)
 	if iofuncs.PathExists(filePath) {
+		indexMetadata(options, metadata, filePath)
 		return nil
 	}
 
 	os.MkdirAll(dirPath, constants.DEFAULT_PERMS)
 	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY, constants.DEFAULT_PERMS)
 	if err != nil {
-		logger.MainLogger.Errorf(
+		options.getLogger().Errorf(
 			"error %d: error opening file for writing metadata %q => %v",
 			cdlerrors.OS_ERROR, filePath, err,
 		)
@@ -38,7 +67,7 @@ func WriteMetadata[T MetadataTypes](metadata T, dirPath string) error {
 
 	metadataBytes, err := json.Marshal(metadata)
 	if err != nil {
-		logger.MainLogger.Errorf(
+		options.getLogger().Errorf(
 			"error %d: error marshalling metadata json => %v",
 			cdlerrors.JSON_ERROR, err,
 		)
@@ -47,12 +76,13 @@ func WriteMetadata[T MetadataTypes](metadata T, dirPath string) error {
 
 	_, err = file.Write(metadataBytes)
 	if err != nil {
-		logger.MainLogger.Errorf(
+		options.getLogger().Errorf(
 			"error %d: error writing metadata to file %q => %v",
 			cdlerrors.OS_ERROR, filePath, err,
 		)
 		return err
 	}
-	logger.MainLogger.Infof("Metadata written to %q", filePath)
+	options.getLogger().Infof("Metadata written to %q", filePath)
+	indexMetadata(options, metadata, filePath)
 	return nil
 }
@@ -1,7 +1,72 @@
 package metadata
 
+import (
+	"time"
+)
+
+// PixivPost is the metadata of a Pixiv artwork from either the web or the mobile API.
 type PixivPost struct {
-	Url   string `json:"url"`
+	Url       string `json:"url"`
+	Title     string `json:"title"`
+	Type      string `json:"post_type"` // "Illustration", "Manga", or "Ugoira"
+	Creator   string `json:"creator,omitempty"`
+	CreatorId string `json:"creator_id,omitempty"`
+	Caption   string `json:"caption,omitempty"` // HTML
+
+	Tags []string `json:"tags,omitempty"`
+	// Tag -> English translation of the tag if there is one
+	TagTranslations map[string]string `json:"tag_translations,omitempty"`
+
+	CreatedAt  time.Time `json:"created_at,omitempty"`
+	UploadedAt time.Time `json:"uploaded_at,omitempty"` // same as CreatedAt for the mobile API
+
+	PageCount int `json:"page_count,omitempty"`
+	Width     int `json:"width,omitempty"` // dimensions of the first page
+	Height    int `json:"height,omitempty"`
+
+	Views     int `json:"views"`
+	Bookmarks int `json:"bookmarks"`
+	Likes     int `json:"likes,omitempty"` // not returned by the mobile API
+
+	Series *PixivSeries `json:"series,omitempty"`
+
+	AiType    int `json:"ai_type"`    // 0: unknown, 1: not AI-generated, 2: AI-generated
+	XRestrict int `json:"x_restrict"` // 0: all ages, 1: R-18, 2: R-18G
+
+	// Frames of the ugoira in order with the delay in milliseconds
+	UgoiraFrames []PixivUgoiraFrame `json:"ugoira_frames,omitempty"`
+
+	// Positions of the artwork in the downloaded rankings
+	Rankings []PixivRanking `json:"rankings,omitempty"`
+}
+
+type PixivSeries struct {
+	Id    string `json:"id"`
 	Title string `json:"title"`
-	Type  string `json:"post_type"`
+	Order int    `json:"order,omitempty"` // not returned by the mobile API
+}
+
+type PixivUgoiraFrame struct {
+	File  string `json:"file"`
+	Delay int64  `json:"delay"`
+}
+
+type PixivRanking struct {
+	Mode    string `json:"mode"`
+	Content string `json:"content"`
+	Date    string `json:"date,omitempty"` // YYYY-MM-DD, empty if unknown
+	Rank    int    `json:"rank"`
+}
+
+type PixivNovel struct {
+	Url         string    `json:"url"`
+	Title       string    `json:"title"`
+	Description string    `json:"description,omitempty"`
+	Creator     string    `json:"creator,omitempty"`
+	CreatorId   string    `json:"creator_id,omitempty"`
+	Tags        []string  `json:"tags,omitempty"`
+	UploadedAt  time.Time `json:"uploaded_at,omitempty"`
+	SeriesId    string    `json:"series_id,omitempty"`
+	SeriesTitle string    `json:"series_title,omitempty"`
+	SeriesOrder int       `json:"series_order,omitempty"`
 }
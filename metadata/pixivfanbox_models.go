@@ -12,4 +12,8 @@ type PixivFanboxPost struct {
 	RestrictedFromUser bool      `json:"restricted_from_user"`
 	PostType           string    `json:"post_type"`
 	PlanFee            int       `json:"plan_fee"`
+	Creator            string    `json:"creator,omitempty"`
+	CreatorId          string    `json:"creator_id,omitempty"`
+	Tags               []string  `json:"tags,omitempty"`
+	Text               string    `json:"text,omitempty"` // text content of the post
 }
@@ -0,0 +1,264 @@
+package metadata
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/KJHJason/Cultured-Downloader-Logic/cdlerrors"
+	"github.com/KJHJason/Cultured-Downloader-Logic/constants"
+	"github.com/KJHJason/Cultured-Downloader-Logic/database"
+	"github.com/KJHJason/Cultured-Downloader-Logic/logger"
+)
+
+var htmlTagRegex = regexp.MustCompile(`<[^>]*>`)
+
+// Removes the HTML tags from the content, e.g. Kemono's post content
+func stripHtml(content string) string {
+	return strings.TrimSpace(html.UnescapeString(htmlTagRegex.ReplaceAllString(content, " ")))
+}
+
+func joinText(texts ...string) string {
+	nonEmpty := make([]string, 0, len(texts))
+	for _, text := range texts {
+		if text != "" {
+			nonEmpty = append(nonEmpty, text)
+		}
+	}
+	return strings.Join(nonEmpty, "\n")
+}
+
+func (p FantiaPost) searchDoc() *database.SearchDoc {
+	return &database.SearchDoc{
+		Site:      constants.FANTIA,
+		Url:       p.Url,
+		Title:     p.Title,
+		Text:      joinText(append([]string{p.PostComment}, p.EmbeddedPostComments...)...),
+		Creator:   p.Creator,
+		CreatorId: p.CreatorId,
+		Tags:      p.Tags,
+		Date:      p.PostedAt,
+	}
+}
+
+func (p FantiaProduct) searchDoc() *database.SearchDoc {
+	return &database.SearchDoc{
+		Site:    constants.FANTIA,
+		Url:     p.Url,
+		Title:   p.Name,
+		Text:    p.Description,
+		Creator: p.Creator,
+	}
+}
+
+func (p KemonoPost) searchDoc() *database.SearchDoc {
+	var date time.Time
+	if published, err := time.Parse(constants.KEMONO_PUBLISHED_DATE_LAYOUT, p.PublishedUTC); err == nil {
+		date = published.In(constants.KEMONO_DATETIME_OFFSET)
+	}
+	return &database.SearchDoc{
+		Site:      constants.KEMONO,
+		Url:       p.Url,
+		Title:     p.Title,
+		Text:      joinText(stripHtml(p.Content), p.EmbedContent.Subject, p.EmbedContent.Description),
+		Creator:   p.Creator,
+		CreatorId: p.CreatorId,
+		Date:      date,
+	}
+}
+
+func (p PixivFanboxPost) searchDoc() *database.SearchDoc {
+	return &database.SearchDoc{
+		Site:      constants.PIXIV_FANBOX,
+		Url:       p.PostUrl,
+		Title:     p.Title,
+		Text:      p.Text,
+		Creator:   p.Creator,
+		CreatorId: p.CreatorId,
+		Tags:      p.Tags,
+		Date:      p.PublishedAt,
+	}
+}
+
+func (p PixivPost) searchDoc() *database.SearchDoc {
+	// the translated tags are searchable as well
+	tags := append([]string{}, p.Tags...)
+	for _, tag := range p.Tags {
+		if translation := p.TagTranslations[tag]; translation != "" {
+			tags = append(tags, translation)
+		}
+	}
+
+	var seriesTitle string
+	if p.Series != nil {
+		seriesTitle = p.Series.Title
+	}
+	return &database.SearchDoc{
+		Site:      constants.PIXIV,
+		Url:       p.Url,
+		Title:     p.Title,
+		Text:      joinText(seriesTitle, stripHtml(p.Caption)),
+		Creator:   p.Creator,
+		CreatorId: p.CreatorId,
+		Tags:      tags,
+		Date:      p.UploadedAt,
+	}
+}
+
+func (p PixivNovel) searchDoc() *database.SearchDoc {
+	return &database.SearchDoc{
+		Site:      constants.PIXIV,
+		Url:       p.Url,
+		Title:     p.Title,
+		Text:      joinText(p.SeriesTitle, p.Description),
+		Creator:   p.Creator,
+		CreatorId: p.CreatorId,
+		Tags:      p.Tags,
+		Date:      p.UploadedAt,
+	}
+}
+
+// Adds the metadata file to the search index if the search index of the options is not nil.
+func indexMetadata[T MetadataTypes](options *WriteOptions, metadata T, filePath string) {
+	searchIndex := options.SearchIndex
+	if searchIndex == nil {
+		return
+	}
+
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		absPath = filePath
+	}
+	doc := metadata.searchDoc()
+	doc.Path = absPath
+	if err := searchIndex.IndexSearchDocs(doc); err != nil {
+		options.getLogger().Errorf("error indexing metadata %q => %v", filePath, err)
+	}
+}
+
+func parseMetadata[T MetadataTypes](data []byte) (*database.SearchDoc, error) {
+	var metadata T
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return nil, err
+	}
+	return metadata.searchDoc(), nil
+}
+
+// ParseMetadataFile reads a post_metadata.json file written by WriteMetadata
+// and returns its search document where the type is detected from the post URL.
+func ParseMetadataFile(filePath string) (*database.SearchDoc, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"error %d: failed to read metadata file %q, more info => %w",
+			cdlerrors.OS_ERROR,
+			filePath,
+			err,
+		)
+	}
+
+	var probe struct {
+		Url     string `json:"url"`
+		PostUrl string `json:"post_url"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, fmt.Errorf(
+			"error %d: failed to parse metadata file %q, more info => %w",
+			cdlerrors.JSON_ERROR,
+			filePath,
+			err,
+		)
+	}
+
+	postUrl := probe.Url
+	if postUrl == "" {
+		postUrl = probe.PostUrl
+	}
+	parsedUrl, err := url.Parse(postUrl)
+	if err != nil {
+		parsedUrl = &url.URL{}
+	}
+	host := parsedUrl.Hostname()
+
+	var doc *database.SearchDoc
+	switch {
+	case strings.HasSuffix(host, "fantia.jp") && strings.HasPrefix(parsedUrl.Path, "/products/"):
+		doc, err = parseMetadata[FantiaProduct](data)
+	case strings.HasSuffix(host, "fantia.jp"):
+		doc, err = parseMetadata[FantiaPost](data)
+	case strings.HasSuffix(host, "fanbox.cc"):
+		doc, err = parseMetadata[PixivFanboxPost](data)
+	case strings.HasSuffix(host, "pixiv.net") && strings.HasPrefix(parsedUrl.Path, "/novel/"):
+		doc, err = parseMetadata[PixivNovel](data)
+	case strings.HasSuffix(host, "pixiv.net"):
+		doc, err = parseMetadata[PixivPost](data)
+	case strings.Contains(host, "kemono"):
+		doc, err = parseMetadata[KemonoPost](data)
+	default:
+		return nil, fmt.Errorf(
+			"error %d: unknown post URL %q in metadata file %q",
+			cdlerrors.UNEXPECTED_ERROR,
+			postUrl,
+			filePath,
+		)
+	}
+	if err != nil {
+		return nil, fmt.Errorf(
+			"error %d: failed to parse metadata file %q, more info => %w",
+			cdlerrors.JSON_ERROR,
+			filePath,
+			err,
+		)
+	}
+
+	if absPath, err := filepath.Abs(filePath); err == nil {
+		filePath = absPath
+	}
+	doc.Path = filePath
+	return doc, nil
+}
+
+// RebuildSearchIndex clears the search index and re-indexes all the
+// post_metadata.json files in the directory and its subdirectories.
+//
+// Files that cannot be parsed are logged to l and skipped.
+// Returns the number of indexed posts.
+func RebuildSearchIndex(db *database.DbWrapper, dirPath string, l *logger.Logger) (int, error) {
+	var docs []*database.SearchDoc
+	err := filepath.WalkDir(dirPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || d.Name() != constants.METADATA_FILENAME {
+			return nil
+		}
+
+		doc, err := ParseMetadataFile(path)
+		if err != nil {
+			l.LogError(err, logger.ERROR)
+			return nil
+		}
+		docs = append(docs, doc)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf(
+			"error %d: failed to walk %q for metadata files, more info => %w",
+			cdlerrors.OS_ERROR,
+			dirPath,
+			err,
+		)
+	}
+
+	if err := db.ReplaceSearchIndex(docs...); err != nil {
+		return 0, err
+	}
+	return len(docs), nil
+}
@@ -0,0 +1,145 @@
+package metadata
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KJHJason/Cultured-Downloader-Logic/constants"
+	"github.com/KJHJason/Cultured-Downloader-Logic/database"
+	"github.com/KJHJason/Cultured-Downloader-Logic/logger"
+)
+
+func TestWriteAndRebuildSearchIndex(t *testing.T) {
+	dirPath := t.TempDir()
+	db, err := database.NewDb(filepath.Join(t.TempDir(), "search.db"))
+	if err != nil {
+		t.Fatalf("Failed to open db: %v", err)
+	}
+	defer db.Close()
+
+	kemonoPost := KemonoPost{
+		PostId:       "7654321",
+		Url:          "https://kemono.su/fanbox/user/1234567/post/7654321",
+		Title:        "Monthly Rewards",
+		Service:      "fanbox",
+		Content:      "<p>Thanks for the <b>support</b> &amp; see you</p>",
+		PublishedUTC: "2024-05-24T15:00:00",
+		Creator:      "Kemono Creator",
+		CreatorId:    "1234567",
+	}
+	if err := WriteMetadataWithOptions(kemonoPost, filepath.Join(dirPath, "kemono"), &WriteOptions{SearchIndex: db}); err != nil {
+		t.Fatalf("Failed to write metadata: %v", err)
+	}
+	productPost := FantiaProduct{
+		Url:     constants.FANTIA_PRODUCT_URL + "123",
+		Name:    "Art Book",
+		Creator: "Fantia Creator",
+	}
+	if err := WriteMetadata(productPost, filepath.Join(dirPath, "product")); err != nil {
+		t.Fatalf("Failed to write metadata: %v", err)
+	}
+
+	search := func(query string) []*database.SearchDoc {
+		q, err := database.ParseSearchQuery(query)
+		if err != nil {
+			t.Fatalf("Failed to parse query %q: %v", query, err)
+		}
+		docs, err := db.Search(q)
+		if err != nil {
+			t.Fatalf("Failed to search %q: %v", query, err)
+		}
+		return docs
+	}
+
+	docs := search("support")
+	if len(docs) != 1 || docs[0].Site != constants.KEMONO || docs[0].Creator != "Kemono Creator" {
+		t.Fatalf("Expected the Kemono post to be indexed but got %+v", docs)
+	}
+	if !docs[0].Date.Equal(time.Date(2024, 5, 24, 15, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected published date %v", docs[0].Date)
+	}
+	if len(search("title:art")) != 0 {
+		t.Errorf("Expected the product to not be indexed without a search index")
+	}
+
+	invalidDir := filepath.Join(dirPath, "invalid")
+	os.MkdirAll(invalidDir, 0755)
+	os.WriteFile(filepath.Join(invalidDir, constants.METADATA_FILENAME), []byte("{"), 0644)
+
+	var logs bytes.Buffer
+	l := logger.NewLogger(&logs)
+	count, err := RebuildSearchIndex(db, dirPath, &l)
+	if err != nil {
+		t.Fatalf("Failed to rebuild the search index: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 indexed posts but got %d", count)
+	}
+	if !strings.Contains(logs.String(), invalidDir) {
+		t.Errorf("Expected the invalid metadata file to be logged to the given logger but got %q", logs.String())
+	}
+	docs = search("creator:fantia")
+	if len(docs) != 1 || docs[0].Title != "Art Book" || docs[0].Site != constants.FANTIA {
+		t.Errorf("Expected the product to be indexed after rebuilding but got %+v", docs)
+	}
+	if len(search("support")) != 1 {
+		t.Errorf("Expected the Kemono post to still be indexed after rebuilding")
+	}
+}
+
+func TestPixivPostSearchDoc(t *testing.T) {
+	post := PixivPost{
+		Url:             "https://www.pixiv.net/artworks/123",
+		Title:           "Sunset",
+		Caption:         "Drawn for the <a href=\"https://example.com\">event</a><br />Thanks &amp; enjoy",
+		Tags:            []string{"夕焼け", "オリジナル"},
+		TagTranslations: map[string]string{"夕焼け": "sunset"},
+		Series:          &PixivSeries{Id: "1", Title: "Skies", Order: 2},
+	}
+	doc := post.searchDoc()
+	if len(doc.Tags) != 3 || doc.Tags[2] != "sunset" {
+		t.Errorf("Expected the translated tag to be searchable but got %q", doc.Tags)
+	}
+	if !strings.HasPrefix(doc.Text, "Skies\n") || !strings.Contains(doc.Text, "Thanks & enjoy") || strings.Contains(doc.Text, "<") {
+		t.Errorf("Expected the series title and the caption without HTML but got %q", doc.Text)
+	}
+	if len(post.Tags) != 2 {
+		t.Errorf("Expected the tags of the post to be unchanged but got %q", post.Tags)
+	}
+}
+
+func TestFanboxPostTextIndexed(t *testing.T) {
+	db, err := database.NewDb(filepath.Join(t.TempDir(), "search.db"))
+	if err != nil {
+		t.Fatalf("Failed to open db: %v", err)
+	}
+	defer db.Close()
+
+	fanboxPost := PixivFanboxPost{
+		PostUrl:     "https://api.fanbox.cc/post.info?postId=123",
+		Title:       "May Rewards",
+		PublishedAt: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+		Creator:     "Fanbox Creator",
+		CreatorId:   "creator",
+		Text:        "The password for the high resolution files is below",
+	}
+	if err := WriteMetadataWithOptions(fanboxPost, t.TempDir(), &WriteOptions{SearchIndex: db}); err != nil {
+		t.Fatalf("Failed to write metadata: %v", err)
+	}
+
+	q, err := database.ParseSearchQuery("resolution")
+	if err != nil {
+		t.Fatalf("Failed to parse query: %v", err)
+	}
+	docs, err := db.Search(q)
+	if err != nil {
+		t.Fatalf("Failed to search: %v", err)
+	}
+	if len(docs) != 1 || docs[0].Site != constants.PIXIV_FANBOX {
+		t.Errorf("Expected the Fanbox post to be found by its text but got %+v", docs)
+	}
+}
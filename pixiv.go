@@ -5,6 +5,7 @@ import (
 
 	"github.com/KJHJason/Cultured-Downloader-Logic/api/pixiv"
 	pixivcommon "github.com/KJHJason/Cultured-Downloader-Logic/api/pixiv/common"
+	"github.com/KJHJason/Cultured-Downloader-Logic/api/pixiv/manga"
 	pixivmobile "github.com/KJHJason/Cultured-Downloader-Logic/api/pixiv/mobile"
 	"github.com/KJHJason/Cultured-Downloader-Logic/api/pixiv/ugoira"
 	pixivweb "github.com/KJHJason/Cultured-Downloader-Logic/api/pixiv/web"
@@ -14,8 +15,17 @@ import (
 	"github.com/KJHJason/Cultured-Downloader-Logic/utils"
 )
 
-func alertUser(artworksToDl []*httpfuncs.ToDownload, ugoiraToDl []*ugoira.Ugoira, notifier notify.Notifier) {
-	if len(artworksToDl) > 0 || len(ugoiraToDl) > 0 {
+// Exports the downloaded chapters of the manga series as CBZ files
+func exportMangaSeriesCbz(seriesSlice []*manga.Series) []error {
+	var errSlice []error
+	for _, series := range seriesSlice {
+		errSlice = append(errSlice, series.ExportCbz()...)
+	}
+	return errSlice
+}
+
+func alertUser(artworksToDl []*httpfuncs.ToDownload, ugoiraToDl []*ugoira.Ugoira, hasNovels bool, notifier notify.Notifier) {
+	if len(artworksToDl) > 0 || len(ugoiraToDl) > 0 || hasNovels {
 		notifier.Alert("Finished downloading artworks from Pixiv!")
 	} else {
 		notifier.Alert("No artworks to download from Pixiv!")
@@ -93,6 +103,54 @@ func PixivWebDownloadProcess(pixivDl *pixiv.PixivDl, pixivDlOptions *pixivweb.Pi
 		}
 	}
 
+	if len(pixivDl.Rankings) > 0 && pixivDlOptions.CtxIsActive() {
+		artworkIdsSlice, err := pixivweb.GetMultipleRankings(
+			pixivDl.Rankings,
+			pixivDlOptions,
+		)
+		if len(err) > 0 {
+			errSlice = append(errSlice, err...)
+		}
+		pixivDl.ArtworkIds = append(pixivDl.ArtworkIds, artworkIdsSlice...)
+	}
+
+	if pixivDl.FollowFeed != nil && pixivDlOptions.CtxIsActive() {
+		artworkIds, err, hasCancelled := pixivweb.GetFollowFeed(pixivDl.FollowFeed, pixivDlOptions)
+		if len(err) > 0 {
+			errSlice = append(errSlice, err...)
+		}
+		if hasCancelled {
+			return errSlice
+		}
+		pixivDl.ArtworkIds = append(pixivDl.ArtworkIds, artworkIds...)
+	}
+
+	if len(pixivDl.Bookmarks) > 0 && pixivDlOptions.CtxIsActive() {
+		artworkIdsSlice, err := pixivweb.GetMultipleBookmarks(
+			pixivDl.Bookmarks,
+			pixivDlOptions,
+		)
+		if len(err) > 0 {
+			errSlice = append(errSlice, err...)
+		}
+		pixivDl.ArtworkIds = append(pixivDl.ArtworkIds, artworkIdsSlice...)
+	}
+
+	var mangaSeries []*manga.Series
+	if len(pixivDl.MangaSeriesIds) > 0 && pixivDlOptions.CtxIsActive() {
+		seriesSlice, err := pixivweb.GetMultipleMangaSeries(
+			pixivDl.MangaSeriesIds,
+			pixivDlOptions,
+		)
+		if len(err) > 0 {
+			errSlice = append(errSlice, err...)
+		}
+		mangaSeries = seriesSlice
+		for _, series := range seriesSlice {
+			pixivDl.ArtworkIds = append(pixivDl.ArtworkIds, series.ArtworkIds()...)
+		}
+	}
+
 	if len(pixivDl.ArtworkIds) > 0 && pixivDlOptions.CtxIsActive() {
 		pixivDl.ArtworkIds = utils.RemoveDuplicatesFromSlice(pixivDl.ArtworkIds)
 		artworkSlice, ugoiraSlice, err := pixivweb.GetMultipleArtworkDetails(
@@ -107,6 +165,57 @@ func PixivWebDownloadProcess(pixivDl *pixiv.PixivDl, pixivDlOptions *pixivweb.Pi
 		}
 	}
 
+	var novels []*pixivweb.Novel
+	var novelSeries []*pixivweb.NovelSeries
+	if len(pixivDl.NovelSeriesIds) > 0 && pixivDlOptions.CtxIsActive() {
+		seriesSlice, err := pixivweb.GetMultipleNovelSeries(
+			pixivDl.NovelSeriesIds,
+			pixivDlOptions,
+		)
+		if len(err) > 0 {
+			errSlice = append(errSlice, err...)
+		}
+		novelSeries = seriesSlice
+	}
+
+	if len(pixivDl.NovelArtistIds) > 0 && pixivDlOptions.CtxIsActive() {
+		novelIdsSlice, err := pixivweb.GetMultipleArtistsNovels(
+			pixivDl.NovelArtistIds,
+			pixivDl.NovelArtistPageNums,
+			pixivDlOptions,
+		)
+		if len(err) > 0 {
+			errSlice = append(errSlice, err...)
+		}
+		pixivDl.NovelIds = append(pixivDl.NovelIds, novelIdsSlice...)
+	}
+
+	if len(pixivDl.NovelIds) > 0 && pixivDlOptions.CtxIsActive() {
+		// skip the novels that were already retrieved from the series
+		novelIds := make([]string, 0, len(pixivDl.NovelIds))
+		seriesNovelIds := make(map[string]struct{})
+		for _, series := range novelSeries {
+			for _, n := range series.Novels {
+				seriesNovelIds[n.Id] = struct{}{}
+			}
+		}
+		for _, novelId := range utils.RemoveDuplicatesFromSlice(pixivDl.NovelIds) {
+			if _, ok := seriesNovelIds[novelId]; !ok {
+				novelIds = append(novelIds, novelId)
+			}
+		}
+
+		if len(novelIds) > 0 {
+			novelSlice, err := pixivweb.GetMultipleNovels(novelIds, pixivDlOptions)
+			if len(err) > 0 {
+				errSlice = append(errSlice, err...)
+			}
+			novels = novelSlice
+		}
+	}
+	// the covers and the embedded images of the novels are downloaded with the artworks
+	artworksToDl = append(artworksToDl, pixivweb.GetNovelsToDownload(novels, novelSeries)...)
+
 	captchaHandler := pixivDlOptions.GetCaptchaHandler()
 	if len(artworksToDl) > 0 && pixivDlOptions.CtxIsActive() {
 		httpfuncs.DownloadUrls(
@@ -120,7 +229,11 @@ func PixivWebDownloadProcess(pixivDl *pixiv.PixivDl, pixivDlOptions *pixivweb.Pi
 				HeadReqTimeout:  constants.DEFAULT_HEAD_REQ_TIMEOUT,
 				SupportRange:    constants.PIXIV_RANGE_SUPPORTED,
 				Filters:         pixivDlOptions.Base.Filters,
+				Sanitizer:       pixivDlOptions.Base.Sanitizer,
 				ProgressBarInfo: pixivDlOptions.Base.ProgressBarInfo,
+				Db:              pixivDlOptions.Base.Session.GetDb(),
+				Logger:          pixivDlOptions.Base.Session.GetLogger(),
+				Transports:      pixivDlOptions.Base.Session.GetTransports(),
 				CaptchaHandler:  captchaHandler,
 			},
 			pixivDlOptions.Base.Configs,
@@ -131,9 +244,11 @@ func PixivWebDownloadProcess(pixivDl *pixiv.PixivDl, pixivDlOptions *pixivweb.Pi
 			UseMobileApi:   false,
 			ToDownload:     ugoiraToDl,
 			Filters:        pixivDlOptions.Base.Filters,
+			Sanitizer:      pixivDlOptions.Base.Sanitizer,
 			CaptchaHandler: captchaHandler,
 			Cookies:        pixivDlOptions.Base.SessionCookies,
 			MainProgBar:    pixivDlOptions.Base.MainProgBar(),
+			Session:        pixivDlOptions.Base.Session,
 		}
 		ugoiraArgs.SetContext(pixivDlOptions.GetContext())
 		err := ugoira.DownloadMultipleUgoira(
@@ -148,13 +263,30 @@ func PixivWebDownloadProcess(pixivDl *pixiv.PixivDl, pixivDlOptions *pixivweb.Pi
 		}
 	}
 
-	alertUser(artworksToDl, ugoiraToDl, pixivDlOptions.Base.Notifier)
+	if pixivDl.MangaSeriesCbz && len(mangaSeries) > 0 && pixivDlOptions.CtxIsActive() {
+		if err := exportMangaSeriesCbz(mangaSeries); len(err) > 0 {
+			errSlice = append(errSlice, err...)
+		}
+	}
+
+	hasNovels := len(novels) > 0 || len(novelSeries) > 0
+	if hasNovels && pixivDlOptions.CtxIsActive() {
+		if err := pixivweb.ExportNovels(novels, novelSeries, pixivDlOptions); len(err) > 0 {
+			errSlice = append(errSlice, err...)
+		}
+	}
+
+	errSlice = commitSyncWatermarks(pixivDlOptions.Base, pixivDlOptions.CtxIsActive(), errSlice)
+	alertUser(artworksToDl, ugoiraToDl, hasNovels, pixivDlOptions.Base.Notifier)
 	return errSlice
 }
 
 // Start the download process for Pixiv
 func PixivMobileDownloadProcess(pixivDl *pixiv.PixivDl, pixivMobile *pixivmobile.PixivMobile, pixivUgoiraOptions *ugoira.UgoiraOptions, catchInterrupt bool) []error {
 	defer pixivMobile.CancelCtx()
+	if err := pixivDl.ValidateForMobileApi(); err != nil {
+		return []error{err}
+	}
 	var errSlice []error
 	var ugoiraToDl []*ugoira.Ugoira
 	var artworksToDl []*httpfuncs.ToDownload
@@ -177,7 +309,47 @@ func PixivMobileDownloadProcess(pixivDl *pixiv.PixivDl, pixivMobile *pixivmobile
 		}
 	}
 
+	if len(pixivDl.Rankings) > 0 && pixivMobile.CtxIsActive() {
+		artworkIds, err := pixivMobile.GetMultipleRankings(pixivDl.Rankings)
+		if len(err) > 0 {
+			errSlice = append(errSlice, err...)
+		}
+		pixivDl.ArtworkIds = append(pixivDl.ArtworkIds, artworkIds...)
+	}
+
+	if pixivDl.FollowFeed != nil && pixivMobile.CtxIsActive() {
+		artworkIds, err, hasCancelled := pixivMobile.GetFollowFeed(pixivDl.FollowFeed)
+		if len(err) > 0 {
+			errSlice = append(errSlice, err...)
+		}
+		if hasCancelled {
+			return errSlice
+		}
+		pixivDl.ArtworkIds = append(pixivDl.ArtworkIds, artworkIds...)
+	}
+
+	if len(pixivDl.Bookmarks) > 0 && pixivMobile.CtxIsActive() {
+		artworkIds, err := pixivMobile.GetMultipleBookmarks(pixivDl.Bookmarks)
+		if len(err) > 0 {
+			errSlice = append(errSlice, err...)
+		}
+		pixivDl.ArtworkIds = append(pixivDl.ArtworkIds, artworkIds...)
+	}
+
+	var mangaSeries []*manga.Series
+	if len(pixivDl.MangaSeriesIds) > 0 && pixivMobile.CtxIsActive() {
+		seriesSlice, err := pixivMobile.GetMultipleMangaSeries(pixivDl.MangaSeriesIds)
+		if len(err) > 0 {
+			errSlice = append(errSlice, err...)
+		}
+		mangaSeries = seriesSlice
+		for _, series := range seriesSlice {
+			pixivDl.ArtworkIds = append(pixivDl.ArtworkIds, series.ArtworkIds()...)
+		}
+	}
+
 	if len(pixivDl.ArtworkIds) > 0 && pixivMobile.CtxIsActive() {
+		pixivDl.ArtworkIds = utils.RemoveDuplicatesFromSlice(pixivDl.ArtworkIds)
 		artworkSlice, ugoiraSlice, err := pixivMobile.GetMultipleArtworkDetails(pixivDl.ArtworkIds)
 		if len(err) > 0 {
 			errSlice = append(errSlice, err...)
@@ -247,7 +419,11 @@ func PixivMobileDownloadProcess(pixivDl *pixiv.PixivDl, pixivMobile *pixivmobile
 				HeadReqTimeout:  constants.DEFAULT_HEAD_REQ_TIMEOUT,
 				SupportRange:    constants.PIXIV_RANGE_SUPPORTED,
 				Filters:         pixivMobile.Base.Filters,
+				Sanitizer:       pixivMobile.Base.Sanitizer,
 				ProgressBarInfo: pixivMobile.Base.ProgressBarInfo,
+				Db:              pixivMobile.Base.Session.GetDb(),
+				Logger:          pixivMobile.Base.Session.GetLogger(),
+				Transports:      pixivMobile.Base.Session.GetTransports(),
 				CaptchaHandler:  captchaHandler,
 			},
 			pixivMobile.Base.Configs,
@@ -265,9 +441,11 @@ func PixivMobileDownloadProcess(pixivDl *pixiv.PixivDl, pixivMobile *pixivmobile
 			UseMobileApi:   true,
 			ToDownload:     ugoiraToDl,
 			Filters:        pixivMobile.Base.Filters,
+			Sanitizer:      pixivMobile.Base.Sanitizer,
 			CaptchaHandler: captchaHandler,
 			Cookies:        nil,
 			MainProgBar:    pixivMobile.Base.MainProgBar(),
+			Session:        pixivMobile.Base.Session,
 		}
 		ugoiraArgs.SetContext(pixivMobile.GetContext())
 		err := ugoira.DownloadMultipleUgoira(
@@ -282,6 +460,13 @@ func PixivMobileDownloadProcess(pixivDl *pixiv.PixivDl, pixivMobile *pixivmobile
 		}
 	}
 
-	alertUser(artworksToDl, ugoiraToDl, pixivMobile.Base.Notifier)
+	if pixivDl.MangaSeriesCbz && len(mangaSeries) > 0 && pixivMobile.CtxIsActive() {
+		if err := exportMangaSeriesCbz(mangaSeries); len(err) > 0 {
+			errSlice = append(errSlice, err...)
+		}
+	}
+
+	errSlice = commitSyncWatermarks(pixivMobile.Base, pixivMobile.CtxIsActive(), errSlice)
+	alertUser(artworksToDl, ugoiraToDl, false, pixivMobile.Base.Notifier)
 	return errSlice
 }
@@ -53,7 +53,11 @@ func PixivFanboxDownloadProcess(pixivFanboxDl *pixivfanbox.PixivFanboxDl, pixivF
 				HeadReqTimeout:  constants.DEFAULT_HEAD_REQ_TIMEOUT,
 				SupportRange:    constants.PIXIV_FANBOX_RANGE_SUPPORTED,
 				Filters:         pixivFanboxDlOptions.Base.Filters,
+				Sanitizer:       pixivFanboxDlOptions.Base.Sanitizer,
 				ProgressBarInfo: pixivFanboxDlOptions.Base.ProgressBarInfo,
+				Db:              pixivFanboxDlOptions.Base.Session.GetDb(),
+				Logger:          pixivFanboxDlOptions.Base.Session.GetLogger(),
+				Transports:      pixivFanboxDlOptions.Base.Session.GetTransports(),
 				CaptchaHandler:  pixivFanboxDlOptions.GetCaptchaHandler(),
 			},
 			pixivFanboxDlOptions.Base.Configs,
@@ -77,6 +81,7 @@ func PixivFanboxDownloadProcess(pixivFanboxDl *pixivfanbox.PixivFanboxDl, pixivF
 		}
 	}
 
+	errSlice = commitSyncWatermarks(pixivFanboxDlOptions.Base, pixivFanboxDlOptions.CtxIsActive(), errSlice)
 	notifier := pixivFanboxDlOptions.Base.Notifier
 	if downloadedPosts {
 		notifier.Alert("Downloaded all posts from Pixiv Fanbox!")
@@ -110,7 +110,7 @@ func ValidateStrArgs(str string, slice, errMsgs []string) (string, error) {
 }
 
 // Validates if the slice of strings contains only numbers
-// Otherwise, os.Exit(1) is called after printing error messages for the user to read
+// Otherwise, the error of the first invalid ID is returned
 func ValidateIds(args []string) error {
 	for _, id := range args {
 		err := ValidateId(id)
@@ -0,0 +1,21 @@
+package cdlogic
+
+import (
+	"github.com/KJHJason/Cultured-Downloader-Logic/api"
+	"github.com/KJHJason/Cultured-Downloader-Logic/logger"
+)
+
+// Saves the incremental sync watermarks of the download process
+// only if all the posts were downloaded successfully and the process was not cancelled
+// so that the posts that failed to download will not be skipped in the next sync.
+func commitSyncWatermarks(base *api.BaseDl, ctxIsActive bool, errSlice []error) []error {
+	if len(errSlice) > 0 || !ctxIsActive {
+		return errSlice
+	}
+
+	if err := base.CommitSyncWatermarks(); err != nil {
+		base.Session.GetLogger().LogError(err, logger.ERROR)
+		errSlice = append(errSlice, err)
+	}
+	return errSlice
+}